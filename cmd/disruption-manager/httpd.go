@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/format"
@@ -188,7 +189,56 @@ func (s *httpServer) statusHandler(w http.ResponseWriter, req *http.Request) {
 		htmlWriter.WriteHtml(writer)
 	}
 	fmt.Fprintln(writer, "</h3>")
+	s.writeSchedules(writer)
 	fmt.Fprintln(writer, "<hr>")
 	html.WriteFooter(writer)
 	fmt.Fprintln(writer, "</body>")
 }
+
+func (s *httpServer) writeSchedules(writer io.Writer) {
+	scheduleList := s.disruptionManager.getScheduleList()
+	if len(scheduleList) < 1 {
+		return
+	}
+	fmt.Fprintln(writer, "<hr>")
+	fmt.Fprintln(writer, "<b>Maintenance windows:</b><br>")
+	fmt.Fprintln(writer, `<table border="1">`)
+	tw, _ := html.NewTableWriter(writer, true,
+		"Group", "Source", "State", "Next Open", "Windows", "Blackouts")
+	now := time.Now()
+	for _, schedule := range scheduleList {
+		var background, nextOpen string
+		switch schedule.State {
+		case "blackout":
+			background = "red"
+		case "closed":
+			background = "grey"
+		}
+		if schedule.Error != "" {
+			background = "red"
+			schedule.State = "error: " + schedule.Error
+		}
+		if schedule.State == "open" {
+			nextOpen = "now"
+		} else if !schedule.NextOpen.IsZero() {
+			nextOpen = format.Duration(schedule.NextOpen.Sub(now))
+		}
+		var blackouts []string
+		for _, blackout := range schedule.Blackouts {
+			text := blackout.Start.Format(format.TimeFormatSeconds) + " - " +
+				blackout.End.Format(format.TimeFormatSeconds)
+			if blackout.Reason != "" {
+				text += " (" + blackout.Reason + ")"
+			}
+			blackouts = append(blackouts, text)
+		}
+		tw.WriteRow("", background,
+			schedule.Identifier,
+			schedule.Source,
+			schedule.State,
+			nextOpen,
+			strings.Join(schedule.Windows, "<br>"),
+			strings.Join(blackouts, "<br>"))
+	}
+	tw.Close()
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/configwatch"
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
 	"github.com/Cloud-Foundations/Dominator/lib/flags/loadflags"
 	"github.com/Cloud-Foundations/Dominator/lib/log/serverlogger"
@@ -15,6 +16,8 @@ import (
 )
 
 var (
+	maintenanceWindowsFile = flag.String("maintenanceWindowsFile", "",
+		"Name of file or URL containing maintenance windows for groups")
 	maximumPermittedDuration = flag.Duration("maximumPermittedDuration",
 		time.Hour,
 		"Maximum time disruption will be permitted after last request")
@@ -46,6 +49,14 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Unable to create Disruption Manager: %s\n", err)
 	}
+	if *maintenanceWindowsFile != "" {
+		schedulesChannel, err := configwatch.Watch(*maintenanceWindowsFile,
+			time.Minute, decodeSchedulesConfig, logger)
+		if err != nil {
+			logger.Fatalf("Unable to watch maintenance windows: %s\n", err)
+		}
+		go dm.watchSchedules(schedulesChannel)
+	}
 	err = setupserver.SetupTlsWithParams(setupserver.Params{Logger: logger})
 	if err != nil {
 		logger.Fatalln(err)
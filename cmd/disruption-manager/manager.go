@@ -32,18 +32,22 @@ const (
 type disruptionManager struct {
 	logger              log.DebugLogger
 	maxDuration         time.Duration
+	now                 func() time.Time
 	stateFilename       string
 	recalculateNotifier chan<- struct{}
 	writeNotifier       chan<- struct{}
 	mutex               sync.Mutex                // Protect everything below.
 	exportable          *groupListType            // nil if invalid.
 	groups              map[string]*groupInfoType // Key: group identifier.
+	schedules           map[string]*scheduleType  // Key: group identifier.
 }
 
 type groupInfoType struct {
 	maxPermitted uint64
 	permitted    map[string]time.Time     // K: hostname, V: last request time.
 	requested    map[string]time.Time     // K: hostname, V: last request time.
+	schedule     *scheduleType            // nil: always open.
+	scheduleTags map[string]tags.Tags     // K: hostname.
 	waiting      map[string]*waitDataType // K: hostname.
 }
 
@@ -129,6 +133,13 @@ func sortWaitInfos(list []waitInfoType) {
 func newDisruptionManager(stateFilename string,
 	maximumPermittedDuration time.Duration,
 	logger log.DebugLogger) (*disruptionManager, error) {
+	return newDisruptionManagerWithClock(stateFilename,
+		maximumPermittedDuration, time.Now, logger)
+}
+
+func newDisruptionManagerWithClock(stateFilename string,
+	maximumPermittedDuration time.Duration, now func() time.Time,
+	logger log.DebugLogger) (*disruptionManager, error) {
 	recalculateNotifier := make(chan struct{}, 1)
 	writeNotifier := make(chan struct{}, 1)
 	var groupList groupListType
@@ -137,6 +148,7 @@ func newDisruptionManager(stateFilename string,
 		groups:              make(map[string]*groupInfoType),
 		logger:              logger,
 		maxDuration:         maximumPermittedDuration,
+		now:                 now,
 		stateFilename:       stateFilename,
 		recalculateNotifier: recalculateNotifier,
 		writeNotifier:       writeNotifier,
@@ -201,7 +213,7 @@ func (dm *disruptionManager) cancel(machine mdb.Machine) (
 				groupText, dm.logger)
 			logMessage = fmt.Sprintf("%s: permitted->denied/waiting (%s)",
 				machine.Hostname, groupText)
-		} else {
+		} else if group.isOpen(dm.now()) {
 			// Move one host from Requested -> Permitted if possible.
 			for hostname, lastRequest := range group.requested {
 				group.permitted[hostname] = lastRequest
@@ -215,6 +227,9 @@ func (dm *disruptionManager) cancel(machine mdb.Machine) (
 				logMessage = fmt.Sprintf("%s: permitted->denied (%s)",
 					machine.Hostname, groupText)
 			}
+		} else {
+			logMessage = fmt.Sprintf("%s: permitted->denied (%s)",
+				machine.Hostname, groupText)
 		}
 		delete(group.permitted, machine.Hostname)
 	}
@@ -244,6 +259,18 @@ func (dm *disruptionManager) check(machine mdb.Machine) (
 	if !previouslyRequested {
 		return sub_proto.DisruptionStateDenied, "", nil
 	}
+	switch group.schedule.getState(dm.now()) {
+	case windowStateBlackout:
+		// Consistent with request(): requests are dropped during a blackout.
+		invalidate = true
+		delete(group.requested, machine.Hostname)
+		return sub_proto.DisruptionStateDenied,
+			fmt.Sprintf("%s: requested->denied/blackout (%s)",
+				machine.Hostname, groupText),
+			nil
+	case windowStateClosed:
+		return sub_proto.DisruptionStateRequested, "", nil
+	}
 	if !group.canPermit(machine.Tags) {
 		return sub_proto.DisruptionStateRequested, "", nil
 	}
@@ -268,8 +295,10 @@ func (dm *disruptionManager) getGroup(machine mdb.Machine) (
 	group := dm.groups[groupIdentifier]
 	if group == nil {
 		group = newGroup()
+		group.schedule = dm.schedules[groupIdentifier]
 		dm.groups[groupIdentifier] = group
 	}
+	dm.updateSchedule(group, groupIdentifier, machine)
 	return group, makeGroupText(groupIdentifier)
 }
 
@@ -345,7 +374,8 @@ func (dm *disruptionManager) recalculateOnce() []string {
 	defer func() {
 		dm.unlockAndInvalidate(invalidate)
 	}()
-	expireBefore := time.Now().Add(-dm.maxDuration)
+	now := dm.now()
+	expireBefore := now.Add(-dm.maxDuration)
 	var logLines []string
 	for groupIdentifier, group := range dm.groups {
 		groupText := makeGroupText(groupIdentifier)
@@ -364,13 +394,14 @@ func (dm *disruptionManager) recalculateOnce() []string {
 						hostname, groupText))
 			}
 		}
+		isOpen := group.isOpen(now)
 		for hostname, lastRequestTime := range group.requested {
 			if lastRequestTime.Before(expireBefore) {
 				invalidate = true
 				delete(group.requested, hostname)
 				dm.logger.Printf("%s: requested/expired->denied (%s)\n",
 					hostname, groupText)
-			} else if group.canPermit(nil) {
+			} else if isOpen && group.canPermit(nil) {
 				invalidate = true
 				group.permitted[hostname] = lastRequestTime
 				delete(group.requested, hostname)
@@ -388,13 +419,34 @@ func (dm *disruptionManager) request(machine mdb.Machine) (
 	dm.mutex.Lock()
 	defer dm.unlockAndInvalidate(true)
 	group, groupText := dm.getGroup(machine)
+	now := dm.now()
 	if _, ok := group.permitted[machine.Hostname]; ok {
-		group.permitted[machine.Hostname] = time.Now()
+		group.permitted[machine.Hostname] = now
 		return sub_proto.DisruptionStatePermitted, "", nil
 	}
 	var logMessage string
+	switch group.schedule.getState(now) {
+	case windowStateBlackout:
+		if _, ok := group.requested[machine.Hostname]; ok {
+			delete(group.requested, machine.Hostname)
+			logMessage = fmt.Sprintf("%s: requested->denied/blackout (%s)",
+				machine.Hostname, groupText)
+		} else {
+			logMessage = fmt.Sprintf("%s: denied/blackout (%s)",
+				machine.Hostname, groupText)
+		}
+		return sub_proto.DisruptionStateDenied, logMessage, nil
+	case windowStateClosed:
+		if _, ok := group.requested[machine.Hostname]; !ok {
+			logMessage = fmt.Sprintf(
+				"%s: denied->requested, outside window (%s)",
+				machine.Hostname, groupText)
+		}
+		group.requested[machine.Hostname] = now
+		return sub_proto.DisruptionStateRequested, logMessage, nil
+	}
 	if group.canPermit(machine.Tags) {
-		group.permitted[machine.Hostname] = time.Now()
+		group.permitted[machine.Hostname] = now
 		if _, ok := group.requested[machine.Hostname]; ok {
 			logMessage = fmt.Sprintf("%s: requested->permitted (%s)",
 				machine.Hostname, groupText)
@@ -409,7 +461,7 @@ func (dm *disruptionManager) request(machine mdb.Machine) (
 		logMessage = fmt.Sprintf("%s: denied->requested (%s)",
 			machine.Hostname, groupText)
 	}
-	group.requested[machine.Hostname] = time.Now()
+	group.requested[machine.Hostname] = now
 	return sub_proto.DisruptionStateRequested, logMessage, nil
 }
 
@@ -453,11 +505,17 @@ func (group *groupInfoType) canPermit(tgs tags.Tags) bool {
 	return uint64(len(group.permitted)+len(group.waiting)) < maximum
 }
 
+// isOpen returns true if the maintenance window for the group is open.
+func (group *groupInfoType) isOpen(t time.Time) bool {
+	return group.schedule.getState(t) == windowStateOpen
+}
+
 func newGroup() *groupInfoType {
 	return &groupInfoType{
 		maxPermitted: 1,
 		permitted:    make(map[string]time.Time),
 		requested:    make(map[string]time.Time),
+		scheduleTags: make(map[string]tags.Tags),
 		waiting:      make(map[string]*waitDataType),
 	}
 }
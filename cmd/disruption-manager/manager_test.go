@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -39,9 +41,67 @@ var (
 		},
 	}
 
+	machine5 = mdb.Machine{
+		Hostname: "testhost-5",
+		Tags: tags.Tags{
+			"DisruptionManagerGroupIdentifier":    "Weekend",
+			"DisruptionManagerMaintenanceWindows": "0 2 * * Sat,Sun 4h",
+		},
+	}
+	machine6 = mdb.Machine{
+		Hostname: "testhost-6",
+		Tags: tags.Tags{
+			"DisruptionManagerGroupIdentifier": "Frozen",
+			"DisruptionManagerBlackouts": "2026-10-01T00:00:00Z/" +
+				"2026-11-01T00:00:00Z",
+		},
+	}
+	machine7 = mdb.Machine{
+		Hostname: "testhost-7",
+		Tags:     tags.Tags{"DisruptionManagerGroupIdentifier": "Nightly"},
+	}
+
 	ok = []byte("ok")
 )
 
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (clock *fakeClock) advance(duration time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.now = clock.now.Add(duration)
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	return clock.now
+}
+
+func checkState(t *testing.T, name string, expected proto.DisruptionState,
+	state proto.DisruptionState, err error) {
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != expected {
+		t.Fatalf("%s state: %s != %s", name, state, expected)
+	}
+}
+
+func makeClockedManager(t *testing.T) (*disruptionManager, *fakeClock) {
+	// Friday 16 October 2026, 12:00 UTC.
+	clock := &fakeClock{now: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	dm, err := newDisruptionManagerWithClock("", 24*time.Hour, clock.Now,
+		testlogger.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dm, clock
+}
+
 func okHandler(w http.ResponseWriter, req *http.Request) {
 	w.Write(ok)
 }
@@ -230,3 +290,234 @@ func TestReadinessTimeout(t *testing.T) {
 			format.Duration(timeTaken))
 	}
 }
+
+func TestBlackout(t *testing.T) {
+	dm, _ := makeClockedManager(t)
+	state, _, err := dm.request(machine6)
+	checkState(t, "blackout request", proto.DisruptionStateDenied, state, err)
+	state, _, err = dm.check(machine6)
+	checkState(t, "blackout check", proto.DisruptionStateDenied, state, err)
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	dm, clock := makeClockedManager(t)
+	state, _, err := dm.request(machine5)
+	checkState(t, "outside window request", proto.DisruptionStateRequested,
+		state, err)
+	state, _, err = dm.check(machine5)
+	checkState(t, "outside window check", proto.DisruptionStateRequested,
+		state, err)
+	clock.advance(14*time.Hour + 30*time.Minute) // Saturday 02:30.
+	state, _, err = dm.check(machine5)
+	checkState(t, "inside window check", proto.DisruptionStatePermitted,
+		state, err)
+	clock.advance(4 * time.Hour) // Saturday 06:30.
+	state, _, err = dm.request(machine5)
+	checkState(t, "permitted after window request",
+		proto.DisruptionStatePermitted, state, err)
+	state, _, err = dm.cancel(machine5)
+	checkState(t, "cancel", proto.DisruptionStateDenied, state, err)
+	state, _, err = dm.request(machine5)
+	checkState(t, "after window request", proto.DisruptionStateRequested,
+		state, err)
+}
+
+func TestMaintenanceWindowsConfig(t *testing.T) {
+	dm, clock := makeClockedManager(t)
+	schedules, err := decodeSchedulesConfig(strings.NewReader(`{
+	"Nightly": {"Windows": ["CRON_TZ=UTC 0 22 * * * 1h"]}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm.setSchedules(schedules.(map[string]*scheduleType))
+	state, _, err := dm.request(machine7)
+	checkState(t, "outside window request", proto.DisruptionStateRequested,
+		state, err)
+	clock.advance(10*time.Hour + 15*time.Minute) // Friday 22:15.
+	state, _, err = dm.request(machine7)
+	checkState(t, "inside window request", proto.DisruptionStatePermitted,
+		state, err)
+	if _, err := decodeSchedulesConfig(strings.NewReader(`{
+	"Nightly": {"Windows": ["0 25 * * * 1h"]}
+}`)); err == nil {
+		t.Fatal("invalid window not rejected")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for _, spec := range []string{
+		"0 2 * * Sat",
+		"0 2 * * Sat 1s",
+		"60 2 * * * 1h",
+		"0 2 * * Foo 1h",
+		"CRON_TZ=Nowhere/Special 0 2 * * * 1h",
+	} {
+		if _, err := parseWindow(spec); err == nil {
+			t.Errorf("invalid window: \"%s\" not rejected", spec)
+		}
+	}
+	window, err := parseWindow("*/15 9-17 1,15 * Mon-Fri 10m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Day of month and day of week are both restricted, so either matches.
+	for _, test := range []struct {
+		time     time.Time
+		contains bool
+	}{
+		{time.Date(2026, 10, 16, 9, 5, 0, 0, time.UTC), true},   // Friday.
+		{time.Date(2026, 10, 16, 9, 10, 0, 0, time.UTC), false}, // Friday.
+		{time.Date(2026, 10, 16, 18, 5, 0, 0, time.UTC), false}, // Friday.
+		{time.Date(2026, 11, 1, 9, 50, 0, 0, time.UTC), true},   // Sunday 1st.
+		{time.Date(2026, 11, 8, 9, 50, 0, 0, time.UTC), false},  // Sunday.
+	} {
+		if contains := window.contains(test.time); contains != test.contains {
+			t.Errorf("%s: contains=%v, expected %v",
+				test.time, contains, test.contains)
+		}
+	}
+}
+
+func TestBlackoutAfterRequest(t *testing.T) {
+	dm, clock := makeClockedManager(t)
+	machine := mdb.Machine{
+		Hostname: "testhost-8",
+		Tags: tags.Tags{
+			"DisruptionManagerGroupIdentifier":    "Sunday",
+			"DisruptionManagerMaintenanceWindows": "0 2 * * Sun 4h",
+			"DisruptionManagerBlackouts": "2026-10-17T00:00:00Z/" +
+				"2026-10-18T00:00:00Z",
+		},
+	}
+	state, _, err := dm.request(machine)
+	checkState(t, "outside window request", proto.DisruptionStateRequested,
+		state, err)
+	clock.advance(13 * time.Hour) // Saturday 01:00.
+	state, _, err = dm.check(machine)
+	checkState(t, "blackout check", proto.DisruptionStateDenied, state, err)
+	state, _, err = dm.request(machine)
+	checkState(t, "blackout request", proto.DisruptionStateDenied, state, err)
+	clock.advance(25 * time.Hour) // Sunday 02:00.
+	state, _, err = dm.request(machine)
+	checkState(t, "inside window request", proto.DisruptionStatePermitted,
+		state, err)
+}
+
+func TestConflictingScheduleTags(t *testing.T) {
+	dm, _ := makeClockedManager(t)
+	makeMachine := func(hostname, windows string) mdb.Machine {
+		machine := mdb.Machine{
+			Hostname: hostname,
+			Tags:     tags.Tags{"DisruptionManagerGroupIdentifier": "Mixed"},
+		}
+		if windows != "" {
+			machine.Tags["DisruptionManagerMaintenanceWindows"] = windows
+		}
+		return machine
+	}
+	machineA := makeMachine("testhost-a", "0 12 * * * 1h")
+	machineB := makeMachine("testhost-b", "0 2 * * * 1h")
+	machineC := makeMachine("testhost-c", "")
+	state, _, err := dm.request(machineA)
+	checkState(t, "A request", proto.DisruptionStatePermitted, state, err)
+	state, _, err = dm.cancel(machineA)
+	checkState(t, "A cancel", proto.DisruptionStateDenied, state, err)
+	// A machine without schedule tags follows the group schedule.
+	state, _, err = dm.request(machineC)
+	checkState(t, "C request", proto.DisruptionStatePermitted, state, err)
+	state, _, err = dm.cancel(machineC)
+	checkState(t, "C cancel", proto.DisruptionStateDenied, state, err)
+	state, _, err = dm.request(machineB)
+	checkState(t, "B conflicting request", proto.DisruptionStateRequested,
+		state, err)
+	state, _, err = dm.request(machineA)
+	checkState(t, "A conflicting request", proto.DisruptionStateRequested,
+		state, err)
+	scheduleList := dm.getScheduleList()
+	if len(scheduleList) != 1 ||
+		!strings.Contains(scheduleList[0].Error, "conflicting") {
+		t.Fatalf("conflict not reported: %v", scheduleList)
+	}
+	machineB = makeMachine("testhost-b", "0 12 * * * 1h")
+	state, _, err = dm.check(machineB)
+	checkState(t, "B check", proto.DisruptionStatePermitted, state, err)
+}
+
+func TestNextOpen(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) // Friday.
+	saturday := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		name     string
+		config   scheduleConfigType
+		expected time.Time
+	}{
+		{"always open", scheduleConfigType{}, now},
+		{
+			name:     "open window",
+			config:   scheduleConfigType{Windows: []string{"0 11 * * * 2h"}},
+			expected: now,
+		},
+		{
+			name:     "next window",
+			config:   scheduleConfigType{Windows: []string{"0 2 * * Sat 4h"}},
+			expected: saturday.Add(2 * time.Hour),
+		},
+		{
+			name: "earliest window",
+			config: scheduleConfigType{Windows: []string{
+				"0 2 * * Sat 4h",
+				"30 22 * * * 1h",
+			}},
+			expected: now.Add(10*time.Hour + 30*time.Minute),
+		},
+		{
+			name: "blackout end",
+			config: scheduleConfigType{
+				Blackouts: []blackoutType{{Start: now, End: saturday}},
+			},
+			expected: saturday,
+		},
+		{
+			name: "window during blackout",
+			config: scheduleConfigType{
+				Blackouts: []blackoutType{
+					{Start: now, End: saturday.Add(3 * time.Hour)},
+				},
+				Windows: []string{"0 2 * * Sat 4h"},
+			},
+			expected: saturday.Add(3 * time.Hour),
+		},
+		{
+			name: "window after blackout",
+			config: scheduleConfigType{
+				Blackouts: []blackoutType{
+					{Start: now, End: saturday.Add(12 * time.Hour)},
+				},
+				Windows: []string{"0 2 * * Sat,Sun 4h"},
+			},
+			expected: saturday.Add(26 * time.Hour),
+		},
+		{
+			name: "long blackout",
+			config: scheduleConfigType{
+				Blackouts: []blackoutType{
+					{Start: now, End: now.Add(30 * 24 * time.Hour)},
+				},
+			},
+		},
+		{
+			name:   "window too far away",
+			config: scheduleConfigType{Windows: []string{"0 2 1 1 * 1h"}},
+		},
+	} {
+		schedule, err := makeSchedule(test.config, "test")
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if nextOpen := schedule.nextOpen(now); !nextOpen.Equal(test.expected) {
+			t.Errorf("%s: expected: %s, got: %s",
+				test.name, test.expected, nextOpen)
+		}
+	}
+}
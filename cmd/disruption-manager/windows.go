@@ -0,0 +1,545 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+)
+
+const (
+	tagBlackouts          = "DisruptionManagerBlackouts"
+	tagMaintenanceWindows = "DisruptionManagerMaintenanceWindows"
+
+	maximumWindowDuration = 7 * 24 * time.Hour
+)
+
+const (
+	windowStateOpen = iota
+	windowStateClosed
+	windowStateBlackout
+)
+
+var (
+	dayNames = map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+	monthNames = map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	windowStateToText = map[uint]string{
+		windowStateOpen:     "open",
+		windowStateClosed:   "closed",
+		windowStateBlackout: "blackout",
+	}
+)
+
+type blackoutType struct {
+	Start  time.Time
+	End    time.Time
+	Reason string `json:",omitempty"`
+}
+
+// scheduleConfigType is the configuration for a group, read from the
+// maintenance windows configuration file.
+type scheduleConfigType struct {
+	Blackouts []blackoutType `json:",omitempty"`
+	Windows   []string       `json:",omitempty"`
+}
+
+// scheduleType contains the maintenance windows and blackout periods for a
+// group. A nil *scheduleType is always open.
+type scheduleType struct {
+	blackouts []blackoutType
+	err       error // If not nil, the schedule is always closed.
+	source    string
+	windows   []*windowType
+}
+
+type scheduleInfoType struct {
+	Identifier string
+	Blackouts  []blackoutType
+	Error      string
+	NextOpen   time.Time
+	Source     string
+	State      string
+	Windows    []string
+}
+
+// windowType is a cron-like schedule for the start of a window and the
+// duration of the window. The specification is of the form:
+//
+//	[CRON_TZ=zone] minute hour day-of-month month day-of-week duration
+type windowType struct {
+	daysOfMonth   uint64
+	daysOfWeek    uint64
+	domRestricted bool
+	dowRestricted bool
+	duration      time.Duration
+	hours         uint64
+	location      *time.Location
+	minutes       uint64
+	months        uint64
+	spec          string
+}
+
+// decodeSchedulesConfig decodes a JSON-encoded map of group identifiers to
+// schedule configurations.
+func decodeSchedulesConfig(reader io.Reader) (interface{}, error) {
+	var config map[string]scheduleConfigType
+	if err := json.Read(reader, &config); err != nil {
+		return nil, err
+	}
+	schedules := make(map[string]*scheduleType, len(config))
+	for groupIdentifier, groupConfig := range config {
+		schedule, err := makeSchedule(groupConfig, "config")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s",
+				makeGroupText(groupIdentifier), err)
+		}
+		schedules[groupIdentifier] = schedule
+	}
+	return schedules, nil
+}
+
+func makeSchedule(config scheduleConfigType, source string) (
+	*scheduleType, error) {
+	schedule := &scheduleType{blackouts: config.Blackouts, source: source}
+	for _, blackout := range config.Blackouts {
+		if !blackout.End.After(blackout.Start) {
+			return nil, fmt.Errorf("blackout end: %s not after start: %s",
+				blackout.End, blackout.Start)
+		}
+	}
+	for _, spec := range config.Windows {
+		window, err := parseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		schedule.windows = append(schedule.windows, window)
+	}
+	return schedule, nil
+}
+
+// makeScheduleFromTags returns the schedule specified by the tags, or nil if
+// there are no schedule tags.
+func makeScheduleFromTags(tgs tags.Tags) *scheduleType {
+	windowsValue, haveWindows := tgs[tagMaintenanceWindows]
+	blackoutsValue, haveBlackouts := tgs[tagBlackouts]
+	if !haveWindows && !haveBlackouts {
+		return nil
+	}
+	var config scheduleConfigType
+	if haveWindows {
+		for _, spec := range strings.Split(windowsValue, ";") {
+			if spec = strings.TrimSpace(spec); spec != "" {
+				config.Windows = append(config.Windows, spec)
+			}
+		}
+	}
+	if haveBlackouts {
+		blackouts, err := parseBlackouts(blackoutsValue)
+		if err != nil {
+			return &scheduleType{err: err, source: "tags"}
+		}
+		config.Blackouts = blackouts
+	}
+	schedule, err := makeSchedule(config, "tags")
+	if err != nil {
+		return &scheduleType{err: err, source: "tags"}
+	}
+	return schedule
+}
+
+// parseBlackouts parses a comma separated list of start/end time pairs, in
+// RFC 3339 format.
+func parseBlackouts(value string) ([]blackoutType, error) {
+	var blackouts []blackoutType
+	for _, interval := range strings.Split(value, ",") {
+		interval = strings.TrimSpace(interval)
+		if interval == "" {
+			continue
+		}
+		splitInterval := strings.Split(interval, "/")
+		if len(splitInterval) != 2 {
+			return nil, fmt.Errorf("invalid blackout: %s", interval)
+		}
+		start, err := time.Parse(time.RFC3339, splitInterval[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339, splitInterval[1])
+		if err != nil {
+			return nil, err
+		}
+		blackouts = append(blackouts, blackoutType{Start: start, End: end})
+	}
+	return blackouts, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps,
+// returning a bitmask of the selected values and true if the field is not "*".
+func parseCronField(field string, min, max uint,
+	names map[string]uint) (uint64, bool, error) {
+	if field == "*" {
+		return 1<<(max+1) - 1<<min, false, nil
+	}
+	var mask uint64
+	for _, item := range strings.Split(field, ",") {
+		step := uint(1)
+		if splitItem := strings.Split(item, "/"); len(splitItem) == 2 {
+			value, err := strconv.ParseUint(splitItem[1], 10, 8)
+			if err != nil || value < 1 {
+				return 0, false, fmt.Errorf("invalid step: %s", item)
+			}
+			item = splitItem[0]
+			step = uint(value)
+		} else if len(splitItem) > 2 {
+			return 0, false, fmt.Errorf("invalid field: %s", item)
+		}
+		first, last := min, max
+		if item != "*" {
+			splitItem := strings.Split(item, "-")
+			if len(splitItem) > 2 {
+				return 0, false, fmt.Errorf("invalid range: %s", item)
+			}
+			var err error
+			if first, err = parseCronValue(splitItem[0], names); err != nil {
+				return 0, false, err
+			}
+			last = first
+			if len(splitItem) == 2 {
+				last, err = parseCronValue(splitItem[1], names)
+				if err != nil {
+					return 0, false, err
+				}
+			}
+		}
+		if names != nil && max == 6 && last == 7 {
+			// Sunday may be specified as 7.
+			if first == 7 {
+				first, last = 0, 0
+			} else {
+				last = 6
+				mask |= 1
+			}
+		}
+		if first < min || last > max || first > last {
+			return 0, false, fmt.Errorf("value out of range: %s", item)
+		}
+		for value := first; value <= last; value += step {
+			mask |= 1 << value
+		}
+	}
+	return mask, true, nil
+}
+
+func parseCronValue(value string, names map[string]uint) (uint, error) {
+	if number, ok := names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+	number, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %s", value)
+	}
+	return uint(number), nil
+}
+
+func parseWindow(spec string) (*windowType, error) {
+	window := &windowType{location: time.UTC, spec: spec}
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "CRON_TZ=") {
+		location, err := time.LoadLocation(fields[0][8:])
+		if err != nil {
+			return nil, err
+		}
+		window.location = location
+		fields = fields[1:]
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf(
+			"window: \"%s\" does not have 5 schedule fields and a duration",
+			spec)
+	}
+	var err error
+	if window.minutes, _, err = parseCronField(fields[0], 0, 59,
+		nil); err != nil {
+		return nil, err
+	}
+	if window.hours, _, err = parseCronField(fields[1], 0, 23,
+		nil); err != nil {
+		return nil, err
+	}
+	if window.daysOfMonth, window.domRestricted, err = parseCronField(
+		fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if window.months, _, err = parseCronField(fields[3], 1, 12,
+		monthNames); err != nil {
+		return nil, err
+	}
+	if window.daysOfWeek, window.dowRestricted, err = parseCronField(
+		fields[4], 0, 6, dayNames); err != nil {
+		return nil, err
+	}
+	if window.duration, err = time.ParseDuration(fields[5]); err != nil {
+		return nil, err
+	}
+	if window.duration < time.Minute {
+		return nil, errors.New("window duration must be at least 1m")
+	}
+	if window.duration > maximumWindowDuration {
+		return nil, fmt.Errorf("window duration exceeds: %s",
+			maximumWindowDuration)
+	}
+	return window, nil
+}
+
+func (dm *disruptionManager) getScheduleList() []scheduleInfoType {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+	now := dm.now()
+	var list []scheduleInfoType
+	for groupIdentifier, group := range dm.groups {
+		if group.schedule == nil {
+			continue
+		}
+		list = append(list, group.schedule.makeInfo(groupIdentifier, now))
+	}
+	for groupIdentifier, schedule := range dm.schedules {
+		if _, ok := dm.groups[groupIdentifier]; !ok {
+			list = append(list, schedule.makeInfo(groupIdentifier, now))
+		}
+	}
+	sort.SliceStable(list, func(left, right int) bool {
+		return list[left].Identifier < list[right].Identifier
+	})
+	return list
+}
+
+// setSchedules sets the schedules from the configuration file. Schedules
+// specified with tags take precedence.
+func (dm *disruptionManager) setSchedules(schedules map[string]*scheduleType) {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+	dm.schedules = schedules
+	for groupIdentifier, group := range dm.groups {
+		if len(group.scheduleTags) < 1 {
+			group.schedule = schedules[groupIdentifier]
+		}
+	}
+	sendNotification(dm.recalculateNotifier)
+}
+
+func (dm *disruptionManager) watchSchedules(
+	schedulesChannel <-chan interface{}) {
+	for schedules := range schedulesChannel {
+		dm.setSchedules(schedules.(map[string]*scheduleType))
+		dm.logger.Println("Loaded maintenance windows")
+	}
+}
+
+// getScheduleTags returns the schedule tags, or nil if there are none.
+func getScheduleTags(tgs tags.Tags) tags.Tags {
+	var scheduleTags tags.Tags
+	for _, key := range []string{tagBlackouts, tagMaintenanceWindows} {
+		if value, ok := tgs[key]; ok {
+			if scheduleTags == nil {
+				scheduleTags = make(tags.Tags, 2)
+			}
+			scheduleTags[key] = value
+		}
+	}
+	return scheduleTags
+}
+
+// makeGroupSchedule returns the schedule for the group. If any machines in the
+// group have schedule tags, they must all have the same schedule tags, else the
+// group is closed. If no machines have schedule tags, the schedule from the
+// configuration file is used. The lock must be held.
+func (dm *disruptionManager) makeGroupSchedule(group *groupInfoType,
+	groupIdentifier string) *scheduleType {
+	if len(group.scheduleTags) < 1 {
+		return dm.schedules[groupIdentifier]
+	}
+	hostnames := make([]string, 0, len(group.scheduleTags))
+	for hostname := range group.scheduleTags {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	scheduleTags := group.scheduleTags[hostnames[0]]
+	for _, hostname := range hostnames[1:] {
+		if !group.scheduleTags[hostname].Equal(scheduleTags) {
+			return &scheduleType{
+				err: fmt.Errorf("conflicting schedule tags for: %s and %s",
+					hostnames[0], hostname),
+				source: "tags",
+			}
+		}
+	}
+	return makeScheduleFromTags(scheduleTags)
+}
+
+// updateSchedule records the schedule tags for the machine and updates the
+// schedule for the group if they have changed. The lock must be held.
+func (dm *disruptionManager) updateSchedule(group *groupInfoType,
+	groupIdentifier string, machine mdb.Machine) {
+	scheduleTags := getScheduleTags(machine.Tags)
+	if scheduleTags.Equal(group.scheduleTags[machine.Hostname]) {
+		return
+	}
+	if scheduleTags == nil {
+		delete(group.scheduleTags, machine.Hostname)
+	} else {
+		group.scheduleTags[machine.Hostname] = scheduleTags
+	}
+	group.schedule = dm.makeGroupSchedule(group, groupIdentifier)
+}
+
+// blackoutEnd returns the latest end time of the blackouts which contain t, or
+// the zero time if t is not within a blackout.
+func (schedule *scheduleType) blackoutEnd(t time.Time) time.Time {
+	var end time.Time
+	for _, blackout := range schedule.blackouts {
+		if !t.Before(blackout.Start) && t.Before(blackout.End) &&
+			blackout.End.After(end) {
+			end = blackout.End
+		}
+	}
+	return end
+}
+
+func (schedule *scheduleType) getState(t time.Time) uint {
+	if schedule == nil {
+		return windowStateOpen
+	}
+	if schedule.err != nil {
+		return windowStateClosed
+	}
+	if !schedule.blackoutEnd(t).IsZero() {
+		return windowStateBlackout
+	}
+	if schedule.inWindow(t) {
+		return windowStateOpen
+	}
+	return windowStateClosed
+}
+
+// inWindow returns true if t is within a window or there are no windows.
+func (schedule *scheduleType) inWindow(t time.Time) bool {
+	if len(schedule.windows) < 1 {
+		return true
+	}
+	for _, window := range schedule.windows {
+		if window.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (schedule *scheduleType) makeInfo(groupIdentifier string,
+	now time.Time) scheduleInfoType {
+	info := scheduleInfoType{
+		Identifier: groupIdentifier,
+		Blackouts:  schedule.blackouts,
+		Source:     schedule.source,
+		State:      windowStateToText[schedule.getState(now)],
+	}
+	if schedule.err != nil {
+		info.Error = schedule.err.Error()
+	}
+	for _, window := range schedule.windows {
+		info.Windows = append(info.Windows, window.spec)
+	}
+	info.NextOpen = schedule.nextOpen(now)
+	return info
+}
+
+// nextOpen returns the next time (at or after t) at which the schedule is
+// open, or the zero time if the schedule is not open within the next week.
+// The schedule can only open at the end of a blackout or the start of a
+// window, so only those times are checked.
+func (schedule *scheduleType) nextOpen(t time.Time) time.Time {
+	if schedule == nil {
+		return t
+	}
+	if schedule.err != nil {
+		return time.Time{}
+	}
+	stopTime := t.Add(maximumWindowDuration)
+	for t.Before(stopTime) {
+		if end := schedule.blackoutEnd(t); !end.IsZero() {
+			t = end
+			continue
+		}
+		if schedule.inWindow(t) {
+			return t
+		}
+		var nextStart time.Time
+		for _, window := range schedule.windows {
+			start := window.nextStart(t.Add(time.Nanosecond), stopTime)
+			if !start.IsZero() &&
+				(nextStart.IsZero() || start.Before(nextStart)) {
+				nextStart = start
+			}
+		}
+		if nextStart.IsZero() {
+			break
+		}
+		t = nextStart
+	}
+	return time.Time{}
+}
+
+// contains returns true if t is within a window which started at most the
+// window duration before t.
+func (window *windowType) contains(t time.Time) bool {
+	return !window.nextStart(t.Add(-window.duration).Add(time.Nanosecond),
+		t).IsZero()
+}
+
+// matchesDay returns true if the day of t matches, using cron semantics.
+func (window *windowType) matchesDay(t time.Time) bool {
+	domMatch := window.daysOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := window.daysOfWeek&(1<<uint(t.Weekday())) != 0
+	if window.domRestricted && window.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// nextStart returns the first time a window starts at or after t (rounded up
+// to the minute) and not after stopTime, or the zero time if there is none.
+// Rather than checking every minute, non-matching months, days and hours are
+// skipped.
+func (window *windowType) nextStart(t, stopTime time.Time) time.Time {
+	t = t.In(window.location)
+	if truncated := t.Truncate(time.Minute); !truncated.Equal(t) {
+		t = truncated.Add(time.Minute)
+	}
+	for !t.After(stopTime) {
+		year, month, day := t.Date()
+		if window.months&(1<<uint(month)) == 0 {
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, window.location)
+		} else if !window.matchesDay(t) {
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, window.location)
+		} else if window.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0,
+				window.location)
+		} else if window.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+		} else {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+
+	domclient "github.com/Cloud-Foundations/Dominator/dom/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func abortDefaultImageRolloutSubcommand(args []string,
+	logger log.DebugLogger) error {
+	if err := domclient.AbortDefaultImageRollout(getClient()); err != nil {
+		return fmt.Errorf("error aborting default image rollout: %s", err)
+	}
+	return nil
+}
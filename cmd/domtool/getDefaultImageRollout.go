@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	domclient "github.com/Cloud-Foundations/Dominator/dom/client"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+)
+
+func getDefaultImageRolloutSubcommand(args []string,
+	logger log.DebugLogger) error {
+	if err := getDefaultImageRollout(getClient()); err != nil {
+		return fmt.Errorf("error getting default image rollout: %s", err)
+	}
+	return nil
+}
+
+func getDefaultImageRollout(client *srpc.Client) error {
+	rollout, err := domclient.GetDefaultImageRollout(client)
+	if err != nil {
+		return err
+	}
+	if rollout != nil {
+		json.WriteWithIndent(os.Stdout, "    ", rollout)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	domclient "github.com/Cloud-Foundations/Dominator/dom/client"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func getPlannedUpdateSubcommand(args []string, logger log.DebugLogger) error {
+	var imageName string
+	if len(args) > 1 {
+		imageName = args[1]
+	}
+	if err := getPlannedUpdate(getClient(), args[0], imageName); err != nil {
+		return fmt.Errorf("error getting planned update: %s", err)
+	}
+	return nil
+}
+
+func getPlannedUpdate(client *srpc.Client, hostname, imageName string) error {
+	update, err := domclient.GetPlannedUpdate(client,
+		dominator.GetPlannedUpdateRequest{
+			Hostname:  hostname,
+			ImageName: imageName,
+		})
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		return json.WriteWithIndent(os.Stdout, "    ", update)
+	}
+	writePlannedUpdate(os.Stdout, update)
+	return nil
+}
+
+func writePlannedUpdate(writer io.Writer, update *dominator.PlannedUpdate) {
+	fmt.Fprintf(writer, "Sub: %s, image: %s -> %s\n",
+		update.Hostname, update.LastSuccessfulImageName, update.ImageName)
+	if update.UpdatesDisabled {
+		fmt.Fprintln(writer, "Updates are disabled")
+	}
+	if update.Reboot {
+		fmt.Fprintln(writer, "Update is disruptive: will reboot")
+	} else if update.Disruptive {
+		fmt.Fprintln(writer, "Update is disruptive")
+	}
+	if len(update.FilesAdded) < 1 && len(update.FilesChanged) < 1 &&
+		len(update.FilesRemoved) < 1 {
+		fmt.Fprintln(writer, "No changes")
+	}
+	for _, name := range update.FilesAdded {
+		fmt.Fprintf(writer, "+ %s\n", name)
+	}
+	for _, change := range update.FilesChanged {
+		var what string
+		if change.Data {
+			what = "data"
+		}
+		if change.Metadata {
+			if what != "" {
+				what += ","
+			}
+			what += "metadata"
+		}
+		fmt.Fprintf(writer, "M %s (%s)\n", change.Name, what)
+	}
+	for _, name := range update.FilesRemoved {
+		fmt.Fprintf(writer, "- %s\n", name)
+	}
+	for _, name := range update.MissingComputedFiles {
+		fmt.Fprintf(writer, "Missing computed file: %s\n", name)
+	}
+	writeTriggers(writer, "stop", update.TriggersToStop)
+	writeTriggers(writer, "start", update.TriggersToStart)
+}
+
+func writeTriggers(writer io.Writer, action string,
+	triggerList []triggers.Trigger) {
+	for _, trigger := range triggerList {
+		var flags string
+		if trigger.DoReboot {
+			flags += " (reboot)"
+		}
+		if trigger.HighImpact {
+			flags += " (high impact)"
+		}
+		fmt.Fprintf(writer, "Trigger: %s %s%s\n",
+			action, trigger.Service, flags)
+	}
+}
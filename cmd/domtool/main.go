@@ -25,6 +25,8 @@ var (
 		"Hostname of dominator")
 	domPortNum = flag.Uint("domPortNum", constants.DominatorPortNumber,
 		"Port number of dominator")
+	jsonOutput = flag.Bool("json", false,
+		"If true, write output in JSON format")
 	locationsToMatch  flagutil.StringList
 	mdbServerHostname = flag.String("mdbServerHostname", "",
 		"Hostname of MDB server (default same as domHostname)")
@@ -36,8 +38,20 @@ var (
 		"Network speed as percentage of capacity")
 	pauseDuration = flag.Duration("pauseDuration", time.Hour,
 		"Duration to pause updates for sub")
-	scanExcludeList  flagutil.StringList = constants.ScanExcludeList
-	scanSpeedPercent                     = flag.Uint("scanSpeedPercent",
+	rollBackOnFailure = flag.Bool("rollBackOnFailure", false,
+		"If true, roll back a default image rollout on failure, else pause")
+	rolloutMaximumFailures = flag.Uint("rolloutMaximumFailures", 0,
+		"Maximum number of failed subs before stopping a rollout")
+	rolloutMinimumSyncedPercent = flag.Uint("rolloutMinimumSyncedPercent",
+		100, "Percentage of subs in a stage which must be synced to advance")
+	rolloutPercentages = flagutil.UintList{1, 10, 50}
+	rolloutPlanFile    = flag.String("rolloutPlanFile", "",
+		"Name of JSON file containing default image rollout plan")
+	rolloutSoakTime = flag.Duration("rolloutSoakTime", time.Hour,
+		"Time to wait after each rollout stage is synced")
+	rolloutTagsToMatch tags.MatchTags
+	scanExcludeList    flagutil.StringList = constants.ScanExcludeList
+	scanSpeedPercent                       = flag.Uint("scanSpeedPercent",
 		constants.DefaultScanSpeedPercent,
 		"Scan speed as percentage of capacity")
 	statusesToMatch flagutil.StringList
@@ -53,6 +67,10 @@ var (
 func init() {
 	flag.Var(&locationsToMatch, "locationsToMatch",
 		"Sub locations to match when listing")
+	flag.Var(&rolloutPercentages, "rolloutPercentages",
+		"Comma separated list of percentages of subs for each rollout stage")
+	flag.Var(&rolloutTagsToMatch, "rolloutTagsToMatch",
+		"Tags which subs must match to be included in a rollout stage")
 	flag.Var(&scanExcludeList, "scanExcludeList",
 		"Comma separated list of patterns to exclude from scanning")
 	flag.Var(&statusesToMatch, "statusesToMatch",
@@ -70,6 +88,8 @@ func printUsage() {
 }
 
 var subcommands = []commands.Command{
+	{"abort-default-image-rollout", "", 0, 0,
+		abortDefaultImageRolloutSubcommand},
 	{"clear-safety-shutoff", "sub", 1, 1, clearSafetyShutoffSubcommand},
 	{"configure-subs", "", 0, 0, configureSubsSubcommand},
 	{"disable-updates", "reason", 1, 1, disableUpdatesSubcommand},
@@ -80,15 +100,24 @@ var subcommands = []commands.Command{
 	{"fast-update", "sub", 1, 1, fastUpdateSubcommand},
 	{"force-disruptive-update", "sub", 1, 1, forceDisruptiveUpdateSubcommand},
 	{"get-default-image", "", 0, 0, getDefaultImageSubcommand},
+	{"get-default-image-rollout", "", 0, 0,
+		getDefaultImageRolloutSubcommand},
 	{"get-info-for-subs", "", 0, 0, getInfoForSubsSubcommand},
 	{"get-machine-from-mdb", "sub", 1, 1, getMachineMdbSubcommand},
 	{"get-mdb", "", 0, 0, getMdbSubcommand},
 	{"get-mdb-updates", "", 0, 0, getMdbUpdatesSubcommand},
+	{"get-planned-update", "sub [image]", 1, 2, getPlannedUpdateSubcommand},
 	{"get-subs-configuration", "", 0, 0, getSubsConfigurationSubcommand},
 	{"list-subs", "", 0, 0, listSubsSubcommand},
+	{"pause-default-image-rollout", "reason", 1, 1,
+		pauseDefaultImageRolloutSubcommand},
 	{"pause-sub-updates", "sub reason", 2, 2, pauseSubUpdatesSubcommand},
+	{"resume-default-image-rollout", "", 0, 0,
+		resumeDefaultImageRolloutSubcommand},
 	{"resume-sub-updates", "sub", 1, 1, resumeSubUpdatesSubcommand},
 	{"set-default-image", "", 1, 1, setDefaultImageSubcommand},
+	{"start-default-image-rollout", "image", 1, 1,
+		startDefaultImageRolloutSubcommand},
 }
 
 func getClient() *srpc.Client {
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	domclient "github.com/Cloud-Foundations/Dominator/dom/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func pauseDefaultImageRolloutSubcommand(args []string,
+	logger log.DebugLogger) error {
+	err := domclient.PauseDefaultImageRollout(getClient(), args[0])
+	if err != nil {
+		return fmt.Errorf("error pausing default image rollout: %s", err)
+	}
+	return nil
+}
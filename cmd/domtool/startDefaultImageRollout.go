@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	domclient "github.com/Cloud-Foundations/Dominator/dom/client"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func startDefaultImageRolloutSubcommand(args []string,
+	logger log.DebugLogger) error {
+	if err := startDefaultImageRollout(getClient(), args[0]); err != nil {
+		return fmt.Errorf("error starting default image rollout: %s", err)
+	}
+	return nil
+}
+
+func startDefaultImageRollout(client *srpc.Client, imageName string) error {
+	var plan dominator.DefaultImageRolloutPlan
+	if *rolloutPlanFile != "" {
+		if err := json.ReadFromFile(*rolloutPlanFile, &plan); err != nil {
+			return err
+		}
+	} else {
+		plan.MaximumFailures = *rolloutMaximumFailures
+		plan.MinimumSyncedPercent = *rolloutMinimumSyncedPercent
+		plan.RollBackOnFailure = *rollBackOnFailure
+		for _, percent := range rolloutPercentages {
+			plan.Stages = append(plan.Stages,
+				dominator.DefaultImageRolloutStage{
+					Percent:     percent,
+					SoakTime:    *rolloutSoakTime,
+					TagsToMatch: rolloutTagsToMatch,
+				})
+		}
+	}
+	plan.ImageName = imageName
+	return domclient.StartDefaultImageRollout(client, plan)
+}
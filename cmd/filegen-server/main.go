@@ -13,6 +13,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/flags/loadflags"
 	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
 	"github.com/Cloud-Foundations/Dominator/lib/log/serverlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb/mdbd"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc/setupserver"
 	"github.com/Cloud-Foundations/tricorder/go/tricorder"
@@ -21,6 +22,13 @@ import (
 var (
 	configFile = flag.String("configFile", "/var/lib/filegen-server/config",
 		"Name of file containing the configuration")
+	imageServerHostname = flag.String("imageServerHostname", "",
+		"Hostname of image server for context templates (default none)")
+	imageServerPortNum = flag.Uint("imageServerPortNum",
+		constants.ImageServerPortNumber,
+		"Port number of image server")
+	mdbFile = flag.String("mdbFile", "",
+		"File to read MDB data from for context templates (default machines which requested data)")
 	permitInsecureMode = flag.Bool("permitInsecureMode", false,
 		"If true, run in insecure mode. This gives remote access to all")
 	portNum = flag.Uint("portNum", constants.BasicFileGenServerPortNumber,
@@ -57,6 +65,18 @@ func main() {
 		}
 	}
 	manager := filegen.New(logger)
+	if *imageServerHostname != "" {
+		manager.SetImageServerAddress(fmt.Sprintf("%s:%d",
+			*imageServerHostname, *imageServerPortNum))
+	}
+	if *mdbFile != "" {
+		mdbChannel := mdbd.StartMdbDaemon(*mdbFile, logger)
+		go func() {
+			for mdb := range mdbChannel {
+				manager.SetMdb(mdb)
+			}
+		}()
+	}
 	if *configFile != "" {
 		if err := util.LoadConfiguration(manager, *configFile); err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path"
+	"strings"
 
 	"github.com/Cloud-Foundations/Dominator/lib/filegen"
 )
@@ -32,7 +33,16 @@ func registerSourceDirectory(manager *filegen.Manager, baseDir string,
 			}
 		} else if fi.Mode().IsRegular() {
 			manager.RegisterFileForPath(filename, pathname)
+			registerValidators(manager, filename)
 		}
 	}
 	return nil
 }
+
+// registerValidators registers validators for a pathname based on the filename
+// extension.
+func registerValidators(manager *filegen.Manager, pathname string) {
+	if strings.HasSuffix(pathname, ".json") {
+		manager.RegisterValidatorForPath(pathname, filegen.NewJsonValidator())
+	}
+}
@@ -33,13 +33,17 @@ var (
 	checkTopology = flag.Bool("checkTopology", false,
 		"If true, perform a one-time check, write to stdout and exit")
 	ipmiPasswordFile = flag.String("ipmiPasswordFile", "",
-		"Name of password file used to authenticate for IPMI requests")
+		"Name of password file used to authenticate for IPMI/Redfish requests")
 	ipmiUsername = flag.String("ipmiUsername", "",
-		"Name of user to authenticate as when making IPMI requests")
+		"Name of user to authenticate as when making IPMI/Redfish requests")
 	topologyCheckInterval = flag.Duration("topologyCheckInterval",
 		time.Minute, "Configuration check interval")
 	portNum = flag.Uint("portNum", constants.FleetManagerPortNumber,
 		"Port number to allocate and listen on for HTTP/RPC")
+	powerDriver = flag.String("powerDriver", "ipmi",
+		"Default power driver for machine BMCs (ipmi or redfish)")
+	redfishInsecure = flag.Bool("redfishInsecure", false,
+		"If true, do not verify the certificates of Redfish BMCs")
 	stateDir = flag.String("stateDir", "/var/lib/fleet-manager",
 		"Name of state directory")
 	topologyDir = flag.String("topologyDir", "",
@@ -112,6 +116,8 @@ func main() {
 		IpmiPasswordFile: *ipmiPasswordFile,
 		IpmiUsername:     *ipmiUsername,
 		Logger:           logger,
+		PowerDriver:      *powerDriver,
+		RedfishInsecure:  *redfishInsecure,
 		Storer:           storer,
 	})
 	if err != nil {
@@ -30,6 +30,18 @@ func addSubnet(subnetId, ipGateway, ipMask string, nameservers []string,
 		IpMask:            net.ParseIP(ipMask),
 		DomainNameServers: nsIPs,
 	}
+	if *ipv6Prefix != "" {
+		subnet.Ipv6Prefix = net.ParseIP(*ipv6Prefix)
+		if subnet.Ipv6Prefix == nil {
+			return fmt.Errorf("invalid IPv6 prefix: %s", *ipv6Prefix)
+		}
+	}
+	if *ipv6Gateway != "" {
+		subnet.Ipv6Gateway = net.ParseIP(*ipv6Gateway)
+		if subnet.Ipv6Gateway == nil {
+			return fmt.Errorf("invalid IPv6 gateway: %s", *ipv6Gateway)
+		}
+	}
 	subnet.Shrink()
 	request := proto.UpdateSubnetsRequest{Add: []proto.Subnet{subnet}}
 	var reply proto.UpdateSubnetsResponse
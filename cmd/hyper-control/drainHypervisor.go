@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	fm_client "github.com/Cloud-Foundations/Dominator/fleetmanager/client"
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func drainHypervisorSubcommand(args []string, logger log.DebugLogger) error {
+	err := drainHypervisor(logger)
+	if err != nil {
+		return fmt.Errorf("error draining Hypervisor: %s", err)
+	}
+	return nil
+}
+
+func drainHypervisor(logger log.DebugLogger) error {
+	if *hypervisorHostname == "" {
+		return errors.New("hypervisorHostname not specified")
+	}
+	client, err := dialFleetManager()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = fm_client.DrainHypervisor(client, fm_proto.DrainHypervisorRequest{
+		Hostname:       *hypervisorHostname,
+		MaxConcurrency: *maxConcurrentMigrations,
+		MaxRetries:     *maxMigrationRetries,
+	})
+	if err != nil {
+		return err
+	}
+	conn, err := client.Call("FleetManager.GetUpdates")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	request := fm_proto.GetUpdatesRequest{
+		IgnoreMissingLocalTags: *ignoreMissingLocalTags,
+	}
+	if err := conn.Encode(request); err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	var lastMigrated, lastMigrating, lastFailed int
+	for {
+		var update fm_proto.Update
+		if err := conn.Decode(&update); err != nil {
+			return err
+		}
+		if err := errors.New(update.Error); err != nil {
+			return err
+		}
+		for _, status := range update.DrainStatuses {
+			if status.Hostname != *hypervisorHostname {
+				continue
+			}
+			if len(status.MigratedVMs) != lastMigrated ||
+				len(status.MigratingVMs) != lastMigrating ||
+				len(status.FailedVMs) != lastFailed {
+				lastMigrated = len(status.MigratedVMs)
+				lastMigrating = len(status.MigratingVMs)
+				lastFailed = len(status.FailedVMs)
+				logger.Printf(
+					"%d of %d VMs migrated, %d migrating, %d failed\n",
+					lastMigrated, status.NumVMs, lastMigrating, lastFailed)
+			}
+			if !status.Finished {
+				continue
+			}
+			if err := errors.New(status.Error); err != nil {
+				return err
+			}
+			for ipAddr, err := range status.FailedVMs {
+				logger.Printf("failed to migrate VM: %s: %s\n", ipAddr, err)
+			}
+			if len(status.FailedVMs) > 0 {
+				return fmt.Errorf("failed to migrate %d VMs",
+					len(status.FailedVMs))
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	fmclient "github.com/Cloud-Foundations/Dominator/fleetmanager/client"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func getMachineHealthSubcommand(args []string, logger log.DebugLogger) error {
+	err := getMachineHealth(args[0], logger)
+	if err != nil {
+		return fmt.Errorf("error getting machine health: %s", err)
+	}
+	return nil
+}
+
+func getMachineHealth(hostname string, logger log.DebugLogger) error {
+	client, err := dialFleetManager()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	health, err := fmclient.GetMachineHealth(client, hostname)
+	if err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "    ", health)
+}
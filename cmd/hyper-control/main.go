@@ -23,7 +23,9 @@ import (
 var (
 	externalLeaseHostnames flagutil.StringList
 	externalLeaseAddresses proto.AddressList
-	emailBodyFilename      = flag.String("emailBodyFilename", "",
+	bootToPXE              = flag.Bool("bootToPXE", false,
+		"If true, boot from the network once for power-cycle-machine")
+	emailBodyFilename = flag.String("emailBodyFilename", "",
 		"Filename containing body of email message to send (default is to read from stdin")
 	emailDomain = flag.String("emailDomain", "",
 		"Email domain to sent notifications to")
@@ -36,6 +38,8 @@ var (
 	fleetManagerPortNum = flag.Uint("fleetManagerPortNum",
 		constants.FleetManagerPortNumber,
 		"Port number of Fleet Resource Manager")
+	forcePower = flag.Bool("forcePower", false,
+		"If true, power off or cycle machine even if VM state is unknown")
 	hypervisorHostname = flag.String("hypervisorHostname", "",
 		"Hostname of hypervisor")
 	hypervisorPortNum = flag.Uint("hypervisorPortNum",
@@ -52,12 +56,20 @@ var (
 		"Name of default image stream for building bootable installer ISO")
 	installerPortNum = flag.Uint("installerPortNum",
 		constants.InstallerPortNumber, "Port number of installer")
+	ipv6Gateway = flag.String("ipv6Gateway", "",
+		"IPv6 gateway for add-subnet")
+	ipv6Prefix = flag.String("ipv6Prefix", "",
+		"IPv6 /64 prefix for add-subnet (enables DHCPv6 and RAs)")
 	location = flag.String("location", "",
 		"Location to search for hypervisors")
 	lockTimeout = flag.Duration("lockTimeout", 15*time.Second,
 		"Time to hold the lock")
 	offerTimeout = flag.Duration("offerTimeout", time.Minute+time.Second,
 		"How long to offer DHCP OFFERs and ACKs")
+	maxConcurrentMigrations = flag.Uint("maxConcurrentMigrations", 0,
+		"Maximum number of concurrent VM migrations when draining (default 2)")
+	maxMigrationRetries = flag.Uint("maxMigrationRetries", 1,
+		"Maximum number of retries per VM migration when draining")
 	maxUpdates = flag.Uint64("maxUpdates", 0,
 		"Maximum number of updates to receive (default infinite)")
 	memory              = flagutil.Size(4 << 30)
@@ -118,9 +130,11 @@ var subcommands = []commands.Command{
 	{"change-tags", "", 0, 0, changeTagsSubcommand},
 	{"connect-to-vm-manager", "IPaddr", 1, 1, connectToVmManagerSubcommand},
 	{"disable-hypervisor", "", 0, 0, disableHypervisorSubcommand},
+	{"drain-hypervisor", "", 0, 0, drainHypervisorSubcommand},
 	{"enable-hypervisor", "", 0, 0, enableHypervisorSubcommand},
 	{"get-capacity", "", 0, 0, getCapacitySubcommand},
 	{"get-identity-provider", "", 0, 0, getIdentityProviderSubcommand},
+	{"get-machine-health", "hostname", 1, 1, getMachineHealthSubcommand},
 	{"get-machine-info", "hostname", 1, 1, getMachineInfoSubcommand},
 	{"get-public-key", "", 0, 0, getPublicKeySubcommand},
 	{"get-updates", "", 0, 0, getUpdatesSubcommand},
@@ -135,7 +149,9 @@ var subcommands = []commands.Command{
 	{"netboot-machine", "MACaddr IPaddr [hostname]", 2, 3,
 		netbootMachineSubcommand},
 	{"netboot-vm", "", 0, 0, netbootVmSubcommand},
+	{"power-cycle-machine", "hostname", 1, 1, powerCycleMachineSubcommand},
 	{"power-off", "", 0, 0, powerOffSubcommand},
+	{"power-off-machine", "hostname", 1, 1, powerOffMachineSubcommand},
 	{"power-on", "", 0, 0, powerOnSubcommand},
 	{"register-external-leases", "", 0, 0, registerExternalLeasesSubcommand},
 	{"reinstall", "", 0, 0, reinstallSubcommand},
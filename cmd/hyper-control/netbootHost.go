@@ -132,8 +132,15 @@ func getNetworkEntries(
 	return networkEntries
 }
 
-func getStorageLayout() (installer_proto.StorageLayout, error) {
+// getStorageLayout returns the storage layout from the file specified by the
+// -storageLayoutFilename option, else from the machine information, else the
+// default layout.
+func getStorageLayout(info fm_proto.GetMachineInfoResponse) (
+	installer_proto.StorageLayout, error) {
 	if *storageLayoutFilename == "" {
+		if info.Machine.StorageLayout != nil {
+			return *info.Machine.StorageLayout, nil
+		}
 		return makeDefaultStorageLayout(), nil
 	}
 	var val installer_proto.StorageLayout
@@ -196,7 +203,7 @@ func makeConfigFiles(info fm_proto.GetMachineInfoResponse, imageName string,
 		fmt.Fprintf(buffer, "nameserver %s\n", nameserver)
 	}
 	filesMap["resolv.conf"] = buffer.Bytes()
-	if layout, err := getStorageLayout(); err != nil {
+	if layout, err := getStorageLayout(info); err != nil {
 		return nil, err
 	} else {
 		if data, err := json.MarshalIndent(layout, "", "    "); err != nil {
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	fmclient "github.com/Cloud-Foundations/Dominator/fleetmanager/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func powerCycleMachineSubcommand(args []string,
+	logger log.DebugLogger) error {
+	err := powerCycleMachine(args[0], logger)
+	if err != nil {
+		return fmt.Errorf("error power cycling machine: %s", err)
+	}
+	return nil
+}
+
+func powerCycleMachine(hostname string, logger log.DebugLogger) error {
+	client, err := dialFleetManager()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return fmclient.PowerCycleMachine(client, proto.PowerCycleMachineRequest{
+		BootToPXE: *bootToPXE,
+		Force:     *forcePower,
+		Hostname:  hostname,
+	})
+}
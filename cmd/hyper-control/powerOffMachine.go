@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	fmclient "github.com/Cloud-Foundations/Dominator/fleetmanager/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func powerOffMachineSubcommand(args []string, logger log.DebugLogger) error {
+	err := powerOffMachine(args[0], logger)
+	if err != nil {
+		return fmt.Errorf("error powering off machine: %s", err)
+	}
+	return nil
+}
+
+func powerOffMachine(hostname string, logger log.DebugLogger) error {
+	client, err := dialFleetManager()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return fmclient.PowerOffMachine(client, proto.PowerOffMachineRequest{
+		Force:    *forcePower,
+		Hostname: hostname,
+	})
+}
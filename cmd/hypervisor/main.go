@@ -16,6 +16,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/hypervisor/rpcd"
 	"github.com/Cloud-Foundations/Dominator/hypervisor/tftpbootd"
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/flags/commands"
 	"github.com/Cloud-Foundations/Dominator/lib/flags/loadflags"
 	"github.com/Cloud-Foundations/Dominator/lib/flagutil"
@@ -23,6 +24,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/log/serverlogger"
 	"github.com/Cloud-Foundations/Dominator/lib/net"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/serverutil"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc/setupserver"
 	"github.com/Cloud-Foundations/tricorder/go/tricorder"
 )
@@ -33,6 +35,8 @@ const (
 )
 
 var (
+	cloudInitMetadata = flag.Bool("cloudInitMetadata", false,
+		"If true, serve cloud-init compatible (EC2 and OpenStack) metadata")
 	dhcpServerOnBridgesOnly = flag.Bool("dhcpServerOnBridgesOnly", false,
 		"If true, run the DHCP server on bridge interfaces only")
 	identityProvider = flag.String("identityProvider", "",
@@ -42,6 +46,8 @@ var (
 	imageServerPortNum = flag.Uint("imageServerPortNum",
 		constants.ImageServerPortNumber,
 		"Port number of image server")
+	imageSigningKeysFile = flag.String("imageSigningKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign images (default do not verify)")
 	lockCheckInterval = flag.Duration("lockCheckInterval", 2*time.Second,
 		"Interval between checks for lock timeouts")
 	lockLogTimeout = flag.Duration("lockLogTimeout", 5*time.Second,
@@ -50,9 +56,13 @@ var (
 		"Name of boot image passed via DHCP option")
 	objectCacheDirectory = flag.String("objectCacheDirectory", "",
 		"Directory to store object cache (default first volume directory parent)")
-	objectCacheSize = flagutil.Size(10 << 30)
-	portNum         = flag.Uint("portNum", constants.HypervisorPortNumber,
+	objectCacheSize       = flagutil.Size(10 << 30)
+	ownerSshKeysDirectory = flag.String("ownerSshKeysDirectory", "",
+		"Directory containing SSH authorized_keys files for VM owners")
+	portNum = flag.Uint("portNum", constants.HypervisorPortNumber,
 		"Port number to allocate and listen on for HTTP/RPC")
+	rateLimitsFile = flag.String("rateLimitsFile", "",
+		"Name of JSON file containing per-user method rate limits and quotas")
 	showVGA  = flag.Bool("showVGA", false, "If true, show VGA console")
 	stateDir = flag.String("stateDir", "/var/lib/hypervisor",
 		"Name of state directory")
@@ -178,26 +188,46 @@ func run() {
 	}
 	imageServerAddress := fmt.Sprintf("%s:%d",
 		*imageServerHostname, *imageServerPortNum)
+	var imageSignatureVerifier *dsse.Verifier
+	if *imageSigningKeysFile != "" {
+		imageSignatureVerifier, err = dsse.LoadVerifier(*imageSigningKeysFile)
+		if err != nil {
+			logger.Fatalf("Cannot load image signing keys: %s\n", err)
+		}
+	}
+	var rateLimiter *serverutil.RateLimiter
+	if *rateLimitsFile != "" {
+		config, err := serverutil.LoadRateLimiterConfig(*rateLimitsFile)
+		if err != nil {
+			logger.Fatalf("Cannot load rate limits: %s\n", err)
+		}
+		rateLimiter, err = serverutil.NewRateLimiter("Hypervisor", config)
+		if err != nil {
+			logger.Fatalf("Cannot create rate limiter: %s\n", err)
+		}
+	}
 	tftpbootServer, err := tftpbootd.New(imageServerAddress,
 		*tftpbootImageStream, logger)
 	if err != nil {
 		logger.Fatalf("Cannot start tftpboot server: %s\n", err)
 	}
 	managerObj, err := manager.New(manager.StartOptions{
-		BridgeMap:            bridgeMap,
-		DhcpServer:           dhcpServer,
-		IdentityProvider:     *identityProvider,
-		ImageServerAddress:   imageServerAddress,
-		LockCheckInterval:    *lockCheckInterval,
-		LockLogTimeout:       *lockLogTimeout,
-		Logger:               logger,
-		ObjectCacheDirectory: *objectCacheDirectory,
-		ObjectCacheBytes:     uint64(objectCacheSize),
-		ShowVgaConsole:       *showVGA,
-		StateDir:             *stateDir,
-		Username:             *username,
-		VlanIdToBridge:       vlanIdToBridge,
-		VolumeDirectories:    volumeDirectories,
+		BridgeMap:              bridgeMap,
+		DhcpServer:             dhcpServer,
+		IdentityProvider:       *identityProvider,
+		ImageServerAddress:     imageServerAddress,
+		ImageSignatureVerifier: imageSignatureVerifier,
+		LockCheckInterval:      *lockCheckInterval,
+		LockLogTimeout:         *lockLogTimeout,
+		Logger:                 logger,
+		ObjectCacheDirectory:   *objectCacheDirectory,
+		ObjectCacheBytes:       uint64(objectCacheSize),
+		RateLimiter:            rateLimiter,
+		ShowVgaConsole:         *showVGA,
+		StateDir:               *stateDir,
+		Username:               *username,
+		VlanIdToBridge:         vlanIdToBridge,
+		VolumeDirectories:      volumeDirectories,
 	})
 	if err != nil {
 		logger.Fatalf("Cannot start hypervisor: %s\n", err)
@@ -218,7 +248,12 @@ func run() {
 		httpd.AddHtmlWriter(rpcHtmlWriter)
 	}
 	httpd.AddHtmlWriter(logger)
-	err = metadatad.StartServer(*portNum, bridges, managerObj, logger)
+	err = metadatad.StartServerWithOptions(*portNum, bridges, managerObj,
+		metadatad.Options{
+			CloudInit:             *cloudInitMetadata,
+			OwnerSshKeysDirectory: *ownerSshKeysDirectory,
+		},
+		logger)
 	if err != nil {
 		logger.Fatalf("Cannot start metadata server: %s\n", err)
 	}
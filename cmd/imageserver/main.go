@@ -3,16 +3,22 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/imageserver/httpd"
 	imageserverRpcd "github.com/Cloud-Foundations/Dominator/imageserver/rpcd"
 	"github.com/Cloud-Foundations/Dominator/imageserver/scanner"
+	"github.com/Cloud-Foundations/Dominator/imageserver/vulnerabilities"
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
 	"github.com/Cloud-Foundations/Dominator/lib/flags/loadflags"
+	"github.com/Cloud-Foundations/Dominator/lib/flagutil"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/log/serverlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
 	"github.com/Cloud-Foundations/Dominator/lib/objectserver/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/s3"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc/setupserver"
 	objectserverRpcd "github.com/Cloud-Foundations/Dominator/objectserver/rpcd"
@@ -22,6 +28,10 @@ import (
 )
 
 var (
+	advisoryDirectory = flag.String("advisoryDirectory", "",
+		"Directory of OSV JSON advisories to match image packages against")
+	advisoryScanInterval = flag.Duration("advisoryScanInterval", time.Hour,
+		"Interval between reloading advisories and rescanning images")
 	allowPublicAddObjects = flag.Bool("allowPublicAddObjects", false,
 		"If true, allow all users to call AddObjects method")
 	allowPublicCheckObjects = flag.Bool("allowPublicCheckObjects", false,
@@ -46,14 +56,77 @@ var (
 	maximumExpirationDurationPrivileged = flag.Duration(
 		"maximumExpirationDurationPrivileged", 730*time.Hour,
 		"Maximum expiration time for privileged users")
-	objectDir = flag.String("objectDir", "/var/lib/objectserver",
+	objectCompression filesystem.Compression
+	objectDir         = flag.String("objectDir", "/var/lib/objectserver",
 		"Name of image server data directory.")
+	objectS3Bucket = flag.String("objectS3Bucket", "",
+		"If specified, store objects and images in this S3-compatible bucket")
+	objectS3CacheSize = flagutil.Size(10 << 30)
+	objectS3Endpoint  = flag.String("objectS3Endpoint", "",
+		"Endpoint URL for an S3-compatible service (e.g. MinIO)")
+	objectS3Prefix = flag.String("objectS3Prefix", "",
+		"Prefix for keys in the S3-compatible bucket")
+	objectS3Region = flag.String("objectS3Region", "",
+		"Region of the S3-compatible bucket")
+	objectS3UsePathStyle = flag.Bool("objectS3UsePathStyle", false,
+		"If true, use path-style addressing for the S3-compatible bucket")
 	permitInsecureMode = flag.Bool("permitInsecureMode", false,
 		"If true, run in insecure mode. This gives remote access to all")
 	portNum = flag.Uint("portNum", constants.ImageServerPortNumber,
 		"Port number to allocate and listen on for HTTP/RPC")
 )
 
+func init() {
+	flag.Var(&objectCompression, "objectCompression",
+		"Compression for new objects: none, gzip or zstd")
+	flag.Var(&objectS3CacheSize, "objectS3CacheSize",
+		"Maximum size of local cache (in objectDir) of objects in the bucket")
+}
+
+type objectServerType interface {
+	objectserver.FullObjectServer
+	objectserver.StashingObjectServer
+	WriteHtml(writer io.Writer)
+}
+
+// makeObjectServer returns the object server and, if objects are stored in a
+// bucket, a mirror for the image files.
+func makeObjectServer(logger log.DebugLogger) (
+	objectServerType, scanner.FileMirror, error) {
+	if *objectS3Bucket == "" {
+		objSrv, err := filesystem.NewObjectServerWithConfigAndParams(
+			filesystem.Config{
+				BaseDirectory:     *objectDir,
+				Compression:       objectCompression,
+				LockCheckInterval: *lockCheckInterval,
+				LockLogTimeout:    *lockLogTimeout,
+			},
+			filesystem.Params{
+				Logger: logger,
+			})
+		return objSrv, nil, err
+	}
+	config := s3.Config{
+		Bucket:         *objectS3Bucket,
+		CacheDirectory: *objectDir,
+		CacheSize:      uint64(objectS3CacheSize),
+		Endpoint:       *objectS3Endpoint,
+		Prefix:         *objectS3Prefix,
+		Region:         *objectS3Region,
+		UsePathStyle:   *objectS3UsePathStyle,
+	}
+	params := s3.Params{Logger: logger}
+	objSrv, err := s3.NewObjectServer(config, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileMirror, err := s3.NewFileMirror(config, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return objSrv, fileMirror, nil
+}
+
 func main() {
 	if os.Geteuid() == 0 {
 		fmt.Fprintln(os.Stderr, "Do not run the Image Server as root")
@@ -75,15 +148,7 @@ func main() {
 			logger.Fatalln(err)
 		}
 	}
-	objSrv, err := filesystem.NewObjectServerWithConfigAndParams(
-		filesystem.Config{
-			BaseDirectory:     *objectDir,
-			LockCheckInterval: *lockCheckInterval,
-			LockLogTimeout:    *lockLogTimeout,
-		},
-		filesystem.Params{
-			Logger: logger,
-		})
+	objSrv, fileMirror, err := makeObjectServer(logger)
 	if err != nil {
 		logger.Fatalf("Cannot create ObjectServer: %s\n", err)
 	}
@@ -102,6 +167,7 @@ func main() {
 			ReplicationMaster:                   imageServerAddress,
 		},
 		scanner.Params{
+			FileMirror:   fileMirror,
 			Logger:       logger,
 			ObjectServer: objSrv,
 		})
@@ -111,8 +177,23 @@ func main() {
 	tricorder.RegisterMetric("/image-count",
 		func() uint { return imdb.CountImages() },
 		units.None, "number of images")
+	var vulnerabilityScanner *vulnerabilities.Scanner
+	if *advisoryDirectory != "" {
+		vulnerabilityScanner, err = vulnerabilities.New(
+			vulnerabilities.Config{
+				AdvisoryDirectory: *advisoryDirectory,
+				ScanInterval:      *advisoryScanInterval,
+			},
+			vulnerabilities.Params{
+				ImageDataBase: imdb,
+				Logger:        logger,
+			})
+		if err != nil {
+			logger.Fatalf("Cannot load advisories: %s\n", err)
+		}
+	}
 	imgSrvRpcHtmlWriter, err := imageserverRpcd.Setup(imdb, imageServerAddress,
-		objSrv, logger)
+		objSrv, vulnerabilityScanner, logger)
 	if err != nil {
 		logger.Fatalln(err)
 	}
@@ -131,10 +212,14 @@ func main() {
 	httpd.AddHtmlWriter(imgSrvRpcHtmlWriter)
 	httpd.AddHtmlWriter(objSrv)
 	httpd.AddHtmlWriter(objSrvRpcHtmlWriter)
+	if vulnerabilityScanner != nil {
+		httpd.AddHtmlWriter(vulnerabilityScanner)
+	}
 	httpd.AddHtmlWriter(logger)
 	healthserver.SetReady()
 	logger.Printf("Service ready, opening listener on port: %d\n", *portNum)
-	if err = httpd.StartServer(*portNum, imdb, objSrv, false); err != nil {
+	if err = httpd.StartServer(*portNum, imdb, objSrv,
+		vulnerabilityScanner, false); err != nil {
 		logger.Fatalf("Unable to create http server: %s\n", err)
 	}
 }
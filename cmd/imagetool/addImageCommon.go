@@ -15,6 +15,7 @@ import (
 
 	"github.com/Cloud-Foundations/Dominator/imageserver/client"
 	"github.com/Cloud-Foundations/Dominator/lib/concurrent"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem/scanner"
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem/untar"
@@ -22,6 +23,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/format"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/mbr"
 	objectclient "github.com/Cloud-Foundations/Dominator/lib/objectserver/client"
@@ -48,6 +50,15 @@ func addImage(imageSClient *srpc.Client, name string, img *image.Image,
 	if err := img.VerifyRequiredPaths(requiredPaths); err != nil {
 		return err
 	}
+	if *imageSigningKeyFile != "" {
+		signer, err := dsse.LoadSigner(*imageSigningKeyFile)
+		if err != nil {
+			return err
+		}
+		if img.Signature, err = signature.Sign(name, img, signer); err != nil {
+			return err
+		}
+	}
 	startTime := time.Now()
 	if err := client.AddImage(imageSClient, name, img); err != nil {
 		return errors.New("remote error: " + err.Error())
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	imgclient "github.com/Cloud-Foundations/Dominator/imageserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func diffImageSbomsSubcommand(args []string, logger log.DebugLogger) error {
+	if err := diffImageSboms(args[0], args[1], os.Stdout); err != nil {
+		return fmt.Errorf("error diffing image SBOMs: %s", err)
+	}
+	return nil
+}
+
+func diffImageSboms(leftName, rightName string, writer io.Writer) error {
+	left, err := getImageInventory(leftName)
+	if err != nil {
+		return err
+	}
+	right, err := getImageInventory(rightName)
+	if err != nil {
+		return err
+	}
+	if left.OperatingSystem != right.OperatingSystem {
+		fmt.Fprintf(writer, "Operating system: %s %s -> %s %s\n",
+			left.OperatingSystem.Id, left.OperatingSystem.VersionId,
+			right.OperatingSystem.Id, right.OperatingSystem.VersionId)
+	}
+	leftVersions := getComponentVersions(left.Components)
+	rightVersions := getComponentVersions(right.Components)
+	var added, changed, removed []string
+	var nameWidth, versionWidth int
+	for name, leftVersion := range leftVersions {
+		if rightVersion, ok := rightVersions[name]; !ok {
+			removed = append(removed, name)
+		} else if rightVersion != leftVersion {
+			changed = append(changed, name)
+		} else {
+			continue
+		}
+		if len(name) > nameWidth {
+			nameWidth = len(name)
+		}
+		if len(leftVersion) > versionWidth {
+			versionWidth = len(leftVersion)
+		}
+	}
+	for name := range rightVersions {
+		if _, ok := leftVersions[name]; !ok {
+			added = append(added, name)
+			if len(name) > nameWidth {
+				nameWidth = len(name)
+			}
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	for _, name := range changed {
+		fmt.Fprintf(writer, "~ %-*s %-*s -> %s\n", nameWidth, name,
+			versionWidth, leftVersions[name], rightVersions[name])
+	}
+	for _, name := range added {
+		fmt.Fprintf(writer, "+ %-*s %s\n", nameWidth, name, rightVersions[name])
+	}
+	for _, name := range removed {
+		fmt.Fprintf(writer, "- %-*s %s\n", nameWidth, name, leftVersions[name])
+	}
+	return nil
+}
+
+// getComponentVersions returns a table of versions, keyed by component name.
+// Multiple versions of a component (i.e. multi-arch packages) are joined.
+func getComponentVersions(components []sbom.Component) map[string]string {
+	versions := make(map[string][]string, len(components))
+	for _, component := range components {
+		versions[component.Name] = append(versions[component.Name],
+			component.Version)
+	}
+	table := make(map[string]string, len(versions))
+	for name, versionList := range versions {
+		sort.Strings(versionList)
+		table[name] = strings.Join(versionList, ",")
+	}
+	return table
+}
+
+func getImageInventory(imageName string) (*sbom.Inventory, error) {
+	imageSClient, _ := getClients()
+	data, err := imgclient.GetImageSbom(imageSClient, imageName,
+		sbom.FormatCycloneDx)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := sbom.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(inventory.Components) < 1 {
+		return nil, fmt.Errorf("no package data for image: %s", imageName)
+	}
+	return inventory, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	imgclient "github.com/Cloud-Foundations/Dominator/imageserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func getImageSbomSubcommand(args []string, logger log.DebugLogger) error {
+	var outFileName string
+	if len(args) > 1 {
+		outFileName = args[1]
+	}
+	if err := getImageSbom(args[0], outFileName); err != nil {
+		return fmt.Errorf("error getting image SBOM: %s", err)
+	}
+	return nil
+}
+
+func getImageSbom(imageName, outFileName string) error {
+	imageSClient, _ := getClients()
+	data, err := imgclient.GetImageSbom(imageSClient, imageName, *sbomFormat)
+	if err != nil {
+		return err
+	}
+	if outFileName == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return fsutil.CopyToFile(outFileName, fsutil.PublicFilePerms,
+		bytes.NewReader(data), uint64(len(data)))
+}
@@ -630,6 +630,11 @@ func listFileGenerators(address string, logger log.Logger) ([]string, error) {
 	defer client.Close()
 	err = client.RequestReply("FileGenerator.ListGenerators", request, &reply)
 	if err == nil {
+		for _, alert := range reply.Alerts {
+			logger.Printf("%s: %s: invalid data for %d machines: %s\n",
+				address, alert.Pathname, alert.NumFailingMachines,
+				alert.LastError)
+		}
 		return reply.Pathnames, nil
 	} else {
 		logger.Printf("%s: error listing generators: %s\n", address, err)
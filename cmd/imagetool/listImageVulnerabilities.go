@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	imgclient "github.com/Cloud-Foundations/Dominator/imageserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func listImageVulnerabilitiesSubcommand(args []string,
+	logger log.DebugLogger) error {
+	if err := listImageVulnerabilities(args[0]); err != nil {
+		return fmt.Errorf("error listing image vulnerabilities: %s", err)
+	}
+	return nil
+}
+
+func listImageVulnerabilities(imageName string) error {
+	imageSClient, _ := getClients()
+	findings, imageExists, err := imgclient.ListImageVulnerabilities(
+		imageSClient, imageName)
+	if err != nil {
+		return err
+	}
+	if !imageExists {
+		return errors.New("image not found")
+	}
+	for _, finding := range findings {
+		fixedVersion := finding.FixedVersion
+		if fixedVersion == "" {
+			fixedVersion = "(not fixed)"
+		}
+		fmt.Printf("%-8s %s %s %s fixed: %s",
+			finding.Severity, finding.AdvisoryId, finding.PackageName,
+			finding.PackageVersion, fixedVersion)
+		if len(finding.Aliases) > 0 {
+			fmt.Printf(" (%s)", strings.Join(finding.Aliases, ", "))
+		}
+		fmt.Println()
+	}
+	return nil
+}
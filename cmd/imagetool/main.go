@@ -62,6 +62,10 @@ var (
 	imageServerPortNum = flag.Uint("imageServerPortNum",
 		constants.ImageServerPortNumber,
 		"Port number of image server")
+	imageSigningKeyFile = flag.String("imageSigningKeyFile", "",
+		"Name of file containing PEM private key to sign added images")
+	imageSigningKeysFile = flag.String("imageSigningKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign images")
 	makeBootable = flag.Bool("makeBootable", true,
 		"If true, make raw image bootable by installing GRUB")
 	masterImageServerHostname = flag.String("masterImageServerHostname", "",
@@ -77,6 +81,8 @@ var (
 		"Interval between object uploads (for debugging)")
 	overlayDirectory = flag.String("overlayDirectory", "",
 		"Directory tree of files to overlay on top of the image when making raw image")
+	provenanceKeysFile = flag.String("provenanceKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign build provenance")
 	releaseNotes = flag.String("releaseNotes", "",
 		"Filename or URL containing release notes")
 	requiredPaths = flagutil.StringToRuneMap(constants.RequiredPaths)
@@ -86,6 +92,8 @@ var (
 		"power of 2 to round up raw image size")
 	runTriggers = flag.Bool("runTriggers", false,
 		"If true, run image triggers when patching /")
+	sbomFormat = flag.String("sbomFormat", "cyclonedx",
+		"SBOM format for get-image-sbom (cyclonedx or spdx)")
 	scanExcludeList flagutil.StringList = constants.ScanExcludeList
 	skipFields                          = flag.String("skipFields", "",
 		"Fields to skip when showing or diffing images")
@@ -164,6 +172,8 @@ var subcommands = []commands.Command{
 		diffFileInImagesSubcommand},
 	{"diff-filters", "           tool left right", 3, 3,
 		diffFilterInImagesSubcommand},
+	{"diff-image-sboms", "       left right", 2, 2,
+		diffImageSbomsSubcommand},
 	{"diff-package-lists", "     tool left right", 3, 3,
 		diffImagePackageListsSubcommand},
 	{"diff-triggers", "          tool left right", 3, 3,
@@ -178,11 +188,15 @@ var subcommands = []commands.Command{
 	{"get-file-in-image", "      name imageFile [outfile]", 2, 3,
 		getFileInImageSubcommand},
 	{"get-image-expiration", "   name", 1, 1, getImageExpirationSubcommand},
+	{"get-image-sbom", "         name [outfile]", 1, 2,
+		getImageSbomSubcommand},
 	{"get-image-updates", "", 0, 0, getImageUpdatesSubcommand},
 	{"get-package-list", "       name [outfile]", 1, 2,
 		getImagePackageListSubcommand},
 	{"get-replication-master", "", 0, 0, getReplicationMasterSubcommand},
 	{"list", "", 0, 0, listImagesSubcommand},
+	{"list-image-vulnerabilities", "name", 1, 1,
+		listImageVulnerabilitiesSubcommand},
 	{"list-mdb", "", 0, 0, listMdbImagesSubcommand},
 	{"list-not-in-mdb", "", 0, 0, listImagesNotInMdbSubcommand},
 	{"listdirs", "", 0, 0, listDirectoriesSubcommand},
@@ -215,6 +229,9 @@ var subcommands = []commands.Command{
 	{"test-download-speed", "    name", 1, 1, testDownloadSpeedSubcommand},
 	{"trace-inode-history", "    name inodePath", 2, 2,
 		traceInodeHistorySubcommand},
+	{"verify-image-signature", " name", 1, 1,
+		verifyImageSignatureSubcommand},
+	{"verify-provenance", "      name", 1, 1, verifyProvenanceSubcommand},
 	{"wait", "                   name", 1, 1, waitImageSubcommand},
 }
 
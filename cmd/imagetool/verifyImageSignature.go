@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func verifyImageSignatureSubcommand(args []string,
+	logger log.DebugLogger) error {
+	if err := verifyImageSignature(args[0], logger); err != nil {
+		return fmt.Errorf("error verifying image signature: %s", err)
+	}
+	return nil
+}
+
+func verifyImageSignature(imageName string, logger log.DebugLogger) error {
+	if *imageSigningKeysFile == "" {
+		return errors.New("no imageSigningKeysFile specified")
+	}
+	verifier, err := dsse.LoadVerifier(*imageSigningKeysFile)
+	if err != nil {
+		return err
+	}
+	imageClient, _ := getClients()
+	img, err := getImage(imageClient, imageName)
+	if err != nil {
+		return err
+	}
+	keyId, err := signature.Verify(imageName, img, verifier)
+	if err != nil {
+		return err
+	}
+	logger.Printf("image signed by key: %s\n", keyId)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/image/provenance"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func verifyProvenanceSubcommand(args []string, logger log.DebugLogger) error {
+	if err := verifyProvenance(args[0], logger); err != nil {
+		return fmt.Errorf("error verifying provenance: %s", err)
+	}
+	return nil
+}
+
+func verifyProvenance(imageName string, logger log.DebugLogger) error {
+	if *provenanceKeysFile == "" {
+		return errors.New("no provenanceKeysFile specified")
+	}
+	verifier, err := dsse.LoadVerifier(*provenanceKeysFile)
+	if err != nil {
+		return err
+	}
+	imageClient, objectClient := getClients()
+	img, err := getImage(imageClient, imageName)
+	if err != nil {
+		return err
+	}
+	if img.Provenance == nil || img.Provenance.Object == nil {
+		return errors.New("image has no build provenance")
+	}
+	size, reader, err := objectClient.GetObject(*img.Provenance.Object)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return err
+	}
+	statement, keyId, err := provenance.Verify(data, verifier, imageName, img)
+	if err != nil {
+		return err
+	}
+	logger.Printf("provenance signed by key: %s\n", keyId)
+	return json.WriteWithIndent(os.Stdout, "    ", statement)
+}
@@ -38,6 +38,8 @@ var (
 	imageServerPortNum = flag.Uint("imageServerPortNum",
 		constants.ImageServerPortNumber,
 		"Port number of image server")
+	imageSigningKeyFile = flag.String("imageSigningKeyFile", "",
+		"Name of file containing PEM private key to sign images")
 	imageRebuildInterval = flag.Duration("imageRebuildInterval", time.Hour,
 		"time between automatic rebuilds of images")
 	maximumExpirationDuration = flag.Duration("maximumExpirationDuration",
@@ -53,6 +55,8 @@ var (
 	presentationImageServerHostname = flag.String(
 		"presentationImageServerHostname", "",
 		"Hostname of image server for links presentation")
+	provenanceKeyFile = flag.String("provenanceKeyFile", "",
+		"Name of file containing PEM private key to sign build provenance")
 	slaveDriverConfigurationFile = flag.String("slaveDriverConfigurationFile",
 		"", "Name of configuration file for slave builders")
 	stateDir = flag.String("stateDir", "/var/lib/imaginator",
@@ -121,10 +125,12 @@ func main() {
 			ImageRebuildInterval: *imageRebuildInterval,
 			ImageServerAddress: fmt.Sprintf("%s:%d",
 				*imageServerHostname, *imageServerPortNum),
+			ImageSigningKeyFile:                 *imageSigningKeyFile,
 			MaximumExpirationDuration:           *maximumExpirationDuration,
 			MaximumExpirationDurationPrivileged: *maximumExpirationDurationPrivileged,
 			MinimumExpirationDuration:           *minimumExpirationDuration,
 			PresentationImageServerAddress:      presentationImageServerAddress,
+			ProvenanceKeyFile:                   *provenanceKeyFile,
 			StateDirectory:                      *stateDir,
 			VariablesFile:                       *variablesFile,
 		},
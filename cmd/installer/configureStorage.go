@@ -25,11 +25,13 @@ import (
 	imageclient "github.com/Cloud-Foundations/Dominator/imageserver/client"
 	"github.com/Cloud-Foundations/Dominator/lib/concurrent"
 	"github.com/Cloud-Foundations/Dominator/lib/cpusharer"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem/util"
 	"github.com/Cloud-Foundations/Dominator/lib/format"
 	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
 	"github.com/Cloud-Foundations/Dominator/lib/json"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/mbr"
@@ -50,6 +52,7 @@ type driveType struct {
 	discarded   bool
 	devpath     string
 	mbr         *mbr.Mbr
+	mirror      *driveType // Mirror (RAID 1) for the boot drive.
 	name        string
 	size        uint64 // Bytes
 }
@@ -74,24 +77,16 @@ func checkIsEfi() bool {
 }
 
 func closeEncryptedVolumes(logger log.DebugLogger) error {
-	if file, err := os.Open("/dev/mapper"); err != nil {
+	if names, err := listEncryptedVolumes(); err != nil {
 		return err
 	} else {
-		defer file.Close()
-		if names, err := file.Readdirnames(-1); err != nil {
-			return err
-		} else {
-			for _, name := range names {
-				if name == "control" {
-					continue
-				}
-				err := run("cryptsetup", *tmpRoot, logger, "close", name)
-				if err != nil {
-					return err
-				}
+		for _, name := range names {
+			err := run("cryptsetup", *tmpRoot, logger, "close", name)
+			if err != nil {
+				return err
 			}
-			return nil
 		}
+		return nil
 	}
 }
 
@@ -99,15 +94,14 @@ func configureBootDrive(cpuSharer cpusharer.CpuSharer, drive *driveType,
 	layout installer_proto.StorageLayout, rootPartition, bootPartition int,
 	img *image.Image, objGetter objectserver.ObjectsGetter,
 	bootInfo *util.BootInfoType, logger log.DebugLogger) error {
-	startTime := time.Now()
-	if run("blkdiscard", "", logger, drive.devpath) == nil {
-		drive.discarded = true
-		logger.Printf("discarded %s in %s\n",
-			drive.devpath, format.Duration(time.Since(startTime)))
-	} else { // Erase old partition.
-		if err := eraseStart(drive.devpath, logger); err != nil {
+	if err := drive.discardOrErase(logger); err != nil {
+		return err
+	}
+	if drive.mirror != nil {
+		if err := drive.mirror.discardOrErase(logger); err != nil {
 			return err
 		}
+		drive.discarded = drive.discarded && drive.mirror.discarded
 	}
 	isEfi := checkIsEfi()
 	args := []string{"-s", "-a", "optimal", drive.devpath}
@@ -143,12 +137,36 @@ func configureBootDrive(cpuSharer cpusharer.CpuSharer, drive *driveType,
 	if err := run("parted", *tmpRoot, logger, args...); err != nil {
 		return err
 	}
+	if drive.mirror != nil {
+		args[3] = drive.mirror.devpath
+		if err := run("parted", *tmpRoot, logger, args...); err != nil {
+			return err
+		}
+		// Use metadata at the end of each partition, so that the firmware and
+		// bootloader can read the file-systems.
+		for index := 1; index <= len(layout.BootDriveLayout)+1; index++ {
+			_, err := createRaidArray(bootMirrorName(index), 1, "1.0",
+				[]*driveType{
+					{
+						devpath:   partitionName(drive.devpath, index),
+						discarded: drive.discarded,
+					},
+					{
+						devpath:   partitionName(drive.mirror.devpath, index),
+						discarded: drive.discarded,
+					},
+				}, logger)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	// Prepare all file-systems concurrently, make them serially.
 	concurrentState := concurrent.NewState(uint(
 		len(layout.BootDriveLayout) + 1))
 	var mkfsMutex sync.Mutex
 	for index, partition := range layout.BootDriveLayout {
-		device := partitionName(drive.devpath, index+1)
+		device := drive.partitionDevice(index + 1)
 		partition := partition
 		err := concurrentState.GoRun(func() error {
 			return drive.makeFileSystem(cpuSharer, device, partition.MountPoint,
@@ -159,7 +177,7 @@ func configureBootDrive(cpuSharer cpusharer.CpuSharer, drive *driveType,
 		}
 	}
 	concurrentState.GoRun(func() error {
-		device := partitionName(drive.devpath, len(layout.BootDriveLayout)+1)
+		device := drive.partitionDevice(len(layout.BootDriveLayout) + 1)
 		return drive.makeFileSystem(cpuSharer, device,
 			layout.ExtraMountPointsBasename+"0",
 			installer_proto.FileSystemTypeExt4, layout.Encrypt, &mkfsMutex,
@@ -171,7 +189,7 @@ func configureBootDrive(cpuSharer cpusharer.CpuSharer, drive *driveType,
 	// Mount all file-systems, except the /boot and data file-systems, so that
 	// the image can create directories in them. First do the root partition,
 	// which might not be first in the list.
-	err := mount(partitionName(drive.devpath, rootPartition), *mountPoint,
+	err := mount(drive.partitionDevice(rootPartition), *mountPoint,
 		layout.BootDriveLayout[rootPartition-1].FileSystemType.String(), logger)
 	if err != nil {
 		return err
@@ -180,7 +198,7 @@ func configureBootDrive(cpuSharer cpusharer.CpuSharer, drive *driveType,
 		if index+1 == rootPartition || index+1 == bootPartition {
 			continue
 		}
-		device := partitionName(drive.devpath, index+1)
+		device := drive.partitionDevice(index + 1)
 		err := mount(remapDevice(device, partition.MountPoint, layout.Encrypt),
 			filepath.Join(*mountPoint, partition.MountPoint),
 			partition.FileSystemType.String(), logger)
@@ -192,7 +210,7 @@ func configureBootDrive(cpuSharer cpusharer.CpuSharer, drive *driveType,
 	if bootPartition != rootPartition {
 		bootP = bootPartition
 	}
-	return installRoot(drive.devpath, layout, img.FileSystem, objGetter,
+	return installRoot(drive, layout, img.FileSystem, objGetter,
 		bootInfo, bootP, logger)
 }
 
@@ -220,7 +238,10 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 		"storage-layout.json"),
 		&layout)
 	if err != nil {
-		return nil, err
+		if !os.IsNotExist(err) || config.Machine.StorageLayout == nil {
+			return nil, err
+		}
+		layout = *config.Machine.StorageLayout
 	}
 	isEfi := checkIsEfi()
 	if isEfi {
@@ -267,6 +288,13 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 	if err != nil {
 		return nil, err
 	}
+	volumeDrives, err := checkStorageLayout(layout, len(drives))
+	if err != nil {
+		return nil, err
+	}
+	if layout.MirrorBootDrive {
+		drives[0].mirror = drives[1]
+	}
 	rootDevice := partitionName(drives[0].devpath, rootPartition)
 	var randomKey []byte
 	if layout.Encrypt {
@@ -354,7 +382,7 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 	// Configure all drives concurrently, making file-systems.
 	// Use concurrent package because of it's reaping cabability.
 	// Use cpusharer package to limit CPU intensive operations.
-	concurrentState := concurrent.NewState(uint(len(drives)))
+	concurrentState := concurrent.NewState(uint(len(drives) + 1))
 	cpuSharer := cpusharer.NewFifoCpuSharer()
 	err = concurrentState.GoRun(func() error {
 		return configureBootDrive(cpuSharer, drives[0], layout, rootPartition,
@@ -366,6 +394,9 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 	for index, drive := range drives[1:] {
 		drive := drive
 		index := index + 1
+		if _, ok := volumeDrives[uint(index)]; ok {
+			continue
+		}
 		err := concurrentState.GoRun(func() error {
 			return configureDataDrive(cpuSharer, drive, index, layout, logger)
 		})
@@ -373,6 +404,15 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 			break
 		}
 	}
+	var volumes []volumeType
+	concurrentState.GoRun(func() error {
+		err := prepareVolumeDrives(drives, layout, logger)
+		if err != nil {
+			return err
+		}
+		volumes, err = configureVolumes(cpuSharer, drives, layout, logger)
+		return err
+	})
 	if err := concurrentState.Reap(); err != nil {
 		return nil, err
 	}
@@ -382,7 +422,7 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 	// Write the root file-system entry first.
 	bootCheckCount := uint(1)
 	{
-		device := partitionName(drives[0].devpath, rootPartition)
+		device := drives[0].partitionDevice(rootPartition)
 		partition := layout.BootDriveLayout[rootPartition-1]
 		err = drives[0].writeDeviceEntries(device, partition.MountPoint,
 			partition.FileSystemType, fsTab, cryptTab, bootCheckCount)
@@ -395,7 +435,7 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 			continue
 		}
 		bootCheckCount++
-		device := partitionName(drives[0].devpath, index+1)
+		device := drives[0].partitionDevice(index + 1)
 		err = drives[0].writeDeviceEntries(device, partition.MountPoint,
 			partition.FileSystemType, fsTab, cryptTab, bootCheckCount)
 		if err != nil {
@@ -408,8 +448,9 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 		var device string
 		if index == 0 { // The boot device is partitioned.
 			checkCount = uint(len(layout.BootDriveLayout) + 1)
-			device = partitionName(drives[0].devpath,
-				len(layout.BootDriveLayout)+1)
+			device = drives[0].partitionDevice(len(layout.BootDriveLayout) + 1)
+		} else if _, ok := volumeDrives[uint(index)]; ok {
+			continue
 		} else { // Extra drives are used whole.
 			device = drive.devpath
 		}
@@ -421,6 +462,14 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 			return nil, err
 		}
 	}
+	// Make table entries for RAID arrays and logical volumes.
+	for _, volume := range volumes {
+		err = volume.drive.writeDeviceEntries(volume.drive.devpath,
+			volume.mountPoint, volume.fileSystemType, fsTab, cryptTab, 2)
+		if err != nil {
+			return nil, err
+		}
+	}
 	logger.Printf("Writing /etc/fstab:\n%s", string(fsTab.Bytes()))
 	err = ioutil.WriteFile(filepath.Join(*mountPoint, "etc", "fstab"),
 		fsTab.Bytes(), fsutil.PublicFilePerms)
@@ -450,6 +499,11 @@ func configureStorage(config fm_proto.GetMachineInfoResponse,
 			}
 		}
 	}
+	if layout.MirrorBootDrive || len(layout.RaidArrays) > 0 {
+		if err := writeMdadmConfig(logger); err != nil {
+			return nil, err
+		}
+	}
 	logdir := filepath.Join(*mountPoint, "var", "log", "installer")
 	if err := os.MkdirAll(logdir, fsutil.DirPerms); err != nil {
 		return nil, err
@@ -529,6 +583,9 @@ func getImageFromClient(client *srpc.Client, imageName string,
 	if img != nil {
 		logger.Debugf(0, "got image: %s in %s\n",
 			imageName, format.Duration(time.Since(startTime)))
+		if err := verifyImageSignature(imageName, img, logger); err != nil {
+			return "", nil, err
+		}
 		return imageName, img, nil
 	}
 	streamName := imageName
@@ -562,6 +619,9 @@ func getImageFromClient(client *srpc.Client, imageName string,
 	} else {
 		logger.Debugf(0, "got image: %s in %s\n",
 			imageName, format.Duration(time.Since(startTime)))
+		if err := verifyImageSignature(imageName, img, logger); err != nil {
+			return "", nil, err
+		}
 		return imageName, img, nil
 	}
 }
@@ -585,7 +645,7 @@ func getRandomKey(numBytes uint, logger log.DebugLogger) ([]byte, error) {
 	}
 }
 
-func installRoot(device string, layout installer_proto.StorageLayout,
+func installRoot(drive *driveType, layout installer_proto.StorageLayout,
 	fileSystem *filesystem.FileSystem, objGetter objectserver.ObjectsGetter,
 	bootInfo *util.BootInfoType, bootPartition int,
 	logger log.DebugLogger) error {
@@ -604,7 +664,7 @@ func installRoot(device string, layout installer_proto.StorageLayout,
 		// This ensures that the bootloader has the files it needs and that the
 		// root file-system is fully up-to-date with the image.
 		partition := layout.BootDriveLayout[bootPartition-1]
-		err := mount(partitionName(device, bootPartition), "/tmpboot",
+		err := mount(drive.partitionDevice(bootPartition), "/tmpboot",
 			partition.FileSystemType.String(), logger)
 		if err != nil {
 			return err
@@ -625,7 +685,7 @@ func installRoot(device string, layout installer_proto.StorageLayout,
 			return fmt.Errorf("error unmounting: %s: %s", "/tmpboot", err)
 		}
 		logger.Debugln(0, "unmounted /tmpboot")
-		err = mount(partitionName(device, bootPartition),
+		err = mount(drive.partitionDevice(bootPartition),
 			filepath.Join(*mountPoint, partition.MountPoint),
 			partition.FileSystemType.String(), logger)
 		if err != nil {
@@ -654,8 +714,15 @@ func installRoot(device string, layout installer_proto.StorageLayout,
 		waiter.Lock()
 		waiter.Unlock()
 	}()
-	return util.MakeBootable(fileSystem, device, "rootfs", *mountPoint, "",
-		true, logger)
+	err = util.MakeBootable(fileSystem, drive.devpath, "rootfs", *mountPoint,
+		"", true, logger)
+	if err != nil || drive.mirror == nil {
+		return err
+	}
+	// Install the bootloader on the mirror as well, so that the machine can
+	// boot from either drive.
+	return util.MakeBootable(fileSystem, drive.mirror.devpath, "rootfs",
+		*mountPoint, "", true, logger)
 }
 
 func installTmpRoot(fileSystem *filesystem.FileSystem,
@@ -747,6 +814,53 @@ func listDrives(logger log.DebugLogger) ([]*driveType, error) {
 	return drives, nil
 }
 
+// listEncryptedVolumes returns the names of the device-mapper devices which
+// are encrypted volumes. Other devices (such as LVM logical volumes) are
+// skipped.
+func listEncryptedVolumes() ([]string, error) {
+	basedir := filepath.Join(*sysfsDirectory, "block")
+	file, err := os.Open(basedir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := file.Readdirnames(-1)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	var volumes []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, "dm-") {
+			continue
+		}
+		dirname := filepath.Join(basedir, name, "dm")
+		uuid, err := readString(filepath.Join(dirname, "uuid"), false)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(uuid, "CRYPT-") {
+			continue
+		}
+		name, err := readString(filepath.Join(dirname, "name"), false)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, name)
+	}
+	return volumes, nil
+}
+
+// makeLabel returns the file-system label for a mount point.
+func makeLabel(target string) string {
+	switch target {
+	case "/":
+		return "rootfs"
+	case "/boot":
+		return "bootfs"
+	}
+	return target
+}
+
 func mount(source string, target string, fstype string,
 	logger log.DebugLogger) error {
 	if *dryRun {
@@ -883,6 +997,25 @@ func unmountStorage(logger log.DebugLogger) error {
 	return nil
 }
 
+// verifyImageSignature will verify the signature of an image, if the
+// -imageSigningKeysFile option was specified.
+func verifyImageSignature(imageName string, img *image.Image,
+	logger log.DebugLogger) error {
+	if *imageSigningKeysFile == "" || img == nil {
+		return nil
+	}
+	verifier, err := dsse.LoadVerifier(*imageSigningKeysFile)
+	if err != nil {
+		return err
+	}
+	keyId, err := signature.Verify(imageName, img, verifier)
+	if err != nil {
+		return fmt.Errorf("image: %s bad signature: %s", imageName, err)
+	}
+	logger.Printf("image: %s signed by key: %s\n", imageName, keyId)
+	return nil
+}
+
 func (drive driveType) String() string {
 	return drive.name
 }
@@ -919,6 +1052,19 @@ func (drive driveType) cryptSetup(cpuSharer cpusharer.CpuSharer, device string,
 	return nil
 }
 
+// discardOrErase will discard the drive if possible, else the start of the
+// drive is erased.
+func (drive *driveType) discardOrErase(logger log.DebugLogger) error {
+	startTime := time.Now()
+	if run("blkdiscard", "", logger, drive.devpath) == nil {
+		drive.discarded = true
+		logger.Printf("discarded %s in %s\n",
+			drive.devpath, format.Duration(time.Since(startTime)))
+		return nil
+	}
+	return eraseStart(drive.devpath, logger)
+}
+
 func (drive driveType) makeFileSystem(cpuSharer cpusharer.CpuSharer,
 	device, target string, fstype installer_proto.FileSystemType, encrypt bool,
 	mkfsMutex *sync.Mutex, bytesPerInode uint, logger log.DebugLogger) error {
@@ -933,13 +1079,9 @@ func (drive driveType) makeFileSystem(cpuSharer cpusharer.CpuSharer,
 			device, numIterations, numOpened,
 			format.Duration(time.Since(startTime)))
 	}
-	label := target
+	label := makeLabel(target)
 	erase := !drive.discarded
-	if label == "/" {
-		label = "rootfs"
-	} else if label == "/boot" {
-		label = "bootfs"
-	} else if encrypt {
+	if encrypt && target != "/" && target != "/boot" {
 		if err := drive.cryptSetup(cpuSharer, device, logger); err != nil {
 			return err
 		}
@@ -968,6 +1110,8 @@ func (drive driveType) makeFileSystem(cpuSharer cpusharer.CpuSharer,
 	case installer_proto.FileSystemTypeVfat:
 		err = run("mkfs.vfat", *tmpRoot, logger, "--codepage=437",
 			"-n", label, device)
+	case installer_proto.FileSystemTypeXfs:
+		err = run("mkfs.xfs", *tmpRoot, logger, "-f", "-L", label, device)
 	default:
 		return fmt.Errorf("unsupported file-system type: %d (%s)",
 			fstype, fstype)
@@ -986,12 +1130,8 @@ func (drive driveType) makeFileSystem(cpuSharer cpusharer.CpuSharer,
 func (drive driveType) writeDeviceEntries(device, target string,
 	fstype installer_proto.FileSystemType,
 	fsTab, cryptTab io.Writer, checkOrder uint) error {
-	label := target
-	if label == "/" {
-		label = "rootfs"
-	} else if label == "/boot" {
-		label = "bootfs"
-	} else {
+	label := makeLabel(target)
+	if target != "/" && target != "/boot" {
 		var options string
 		if drive.discarded {
 			options = "discard"
@@ -0,0 +1,397 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/concurrent"
+	"github.com/Cloud-Foundations/Dominator/lib/cpusharer"
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	installer_proto "github.com/Cloud-Foundations/Dominator/proto/installer"
+)
+
+const (
+	bootMirrorPrefix  = "bootdrive"
+	mdDirectory       = "/dev/md"
+	xfsMaxLabelLength = 12
+)
+
+type volumeType struct {
+	drive          *driveType // Pseudo drive for a RAID array or LV.
+	fileSystemType installer_proto.FileSystemType
+	mountPoint     string
+}
+
+func bootMirrorName(partitionNumber int) string {
+	return bootMirrorPrefix + strconv.FormatInt(int64(partitionNumber), 10)
+}
+
+// checkFileSystem checks that a file-system can be made for the mount point.
+func checkFileSystem(mountPoint string,
+	fstype installer_proto.FileSystemType) error {
+	if _, err := fstype.MarshalText(); err != nil {
+		return fmt.Errorf("%s: unsupported file-system type: %d",
+			mountPoint, fstype)
+	}
+	if fstype == installer_proto.FileSystemTypeXfs &&
+		len(makeLabel(mountPoint)) > xfsMaxLabelLength {
+		return fmt.Errorf("%s: label too long for XFS", mountPoint)
+	}
+	return nil
+}
+
+// checkStorageLayout checks the RAID and LVM configuration in the layout
+// against the number of selected drives. It returns the set of drive indices
+// which are used by the boot drive mirror, RAID arrays and volume groups.
+// These drives are not used for data file-systems.
+func checkStorageLayout(layout installer_proto.StorageLayout,
+	numDrives int) (map[uint]struct{}, error) {
+	usedDrives := make(map[uint]struct{})
+	useDrive := func(index uint) error {
+		if index < 1 || index >= uint(numDrives) {
+			return fmt.Errorf("invalid drive index: %d", index)
+		}
+		if _, ok := usedDrives[index]; ok {
+			return fmt.Errorf("drive index: %d used multiple times", index)
+		}
+		usedDrives[index] = struct{}{}
+		return nil
+	}
+	if layout.MirrorBootDrive {
+		if err := useDrive(1); err != nil {
+			return nil, fmt.Errorf("cannot mirror boot drive: %s", err)
+		}
+	}
+	for _, partition := range layout.BootDriveLayout {
+		err := checkFileSystem(partition.MountPoint, partition.FileSystemType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	mountPoints := make(map[string]struct{})
+	addMountPoint := func(mountPoint string,
+		fstype installer_proto.FileSystemType) error {
+		if !strings.HasPrefix(mountPoint, "/") || mountPoint == "/" ||
+			mountPoint == "/boot" {
+			return fmt.Errorf("invalid mount point: \"%s\"", mountPoint)
+		}
+		if _, ok := mountPoints[mountPoint]; ok {
+			return fmt.Errorf("duplicate mount point: %s", mountPoint)
+		}
+		mountPoints[mountPoint] = struct{}{}
+		return checkFileSystem(mountPoint, fstype)
+	}
+	raidArrays := make(map[string]bool) // Value: true if used by a VG.
+	for _, array := range layout.RaidArrays {
+		if array.Name == "" || strings.Contains(array.Name, "/") ||
+			strings.HasPrefix(array.Name, bootMirrorPrefix) {
+			return nil, fmt.Errorf("invalid RAID array name: \"%s\"",
+				array.Name)
+		}
+		if _, ok := raidArrays[array.Name]; ok {
+			return nil, fmt.Errorf("duplicate RAID array: %s", array.Name)
+		}
+		raidArrays[array.Name] = false
+		minDrives := 2
+		switch array.Level {
+		case 0, 1:
+		case 10:
+			minDrives = 4
+		default:
+			return nil, fmt.Errorf("RAID array: %s: unsupported level: %d",
+				array.Name, array.Level)
+		}
+		if len(array.Drives) < minDrives {
+			return nil, fmt.Errorf("RAID array: %s: level %d needs %d drives",
+				array.Name, array.Level, minDrives)
+		}
+		for _, index := range array.Drives {
+			if err := useDrive(index); err != nil {
+				return nil, fmt.Errorf("RAID array: %s: %s", array.Name, err)
+			}
+		}
+		if array.MountPoint != "" {
+			err := addMountPoint(array.MountPoint, array.FileSystemType)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	volumeGroups := make(map[string]struct{})
+	logicalVolumes := make(map[string]struct{})
+	for _, group := range layout.VolumeGroups {
+		if group.Name == "" || strings.Contains(group.Name, "/") {
+			return nil, fmt.Errorf("invalid volume group name: \"%s\"",
+				group.Name)
+		}
+		if _, ok := volumeGroups[group.Name]; ok {
+			return nil, fmt.Errorf("duplicate volume group: %s", group.Name)
+		}
+		volumeGroups[group.Name] = struct{}{}
+		if len(group.Drives)+len(group.RaidArrays) < 1 {
+			return nil, fmt.Errorf("volume group: %s: no devices", group.Name)
+		}
+		for _, index := range group.Drives {
+			if err := useDrive(index); err != nil {
+				return nil, fmt.Errorf("volume group: %s: %s", group.Name, err)
+			}
+		}
+		for _, name := range group.RaidArrays {
+			if used, ok := raidArrays[name]; !ok {
+				return nil, fmt.Errorf(
+					"volume group: %s: unknown RAID array: %s",
+					group.Name, name)
+			} else if used {
+				return nil, fmt.Errorf("RAID array: %s used multiple times",
+					name)
+			}
+			raidArrays[name] = true
+		}
+		if len(group.LogicalVolumes) < 1 {
+			return nil, fmt.Errorf("volume group: %s: no logical volumes",
+				group.Name)
+		}
+		for index, volume := range group.LogicalVolumes {
+			// Logical volume names must be unique across groups, since the
+			// encrypted volume name is the logical volume name.
+			if volume.Name == "" || strings.Contains(volume.Name, "/") {
+				return nil, fmt.Errorf("invalid logical volume name: \"%s\"",
+					volume.Name)
+			}
+			if _, ok := logicalVolumes[volume.Name]; ok {
+				return nil, fmt.Errorf("duplicate logical volume: %s",
+					volume.Name)
+			}
+			logicalVolumes[volume.Name] = struct{}{}
+			if volume.SizeBytes < 1 && index+1 < len(group.LogicalVolumes) {
+				return nil, fmt.Errorf(
+					"logical volume: %s: only the last may use remaining space",
+					volume.Name)
+			}
+			if volume.MountPoint == "" {
+				return nil, fmt.Errorf("logical volume: %s: no mount point",
+					volume.Name)
+			}
+			err := addMountPoint(volume.MountPoint, volume.FileSystemType)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, array := range layout.RaidArrays {
+		if array.MountPoint == "" && !raidArrays[array.Name] {
+			return nil, fmt.Errorf("RAID array: %s is not used", array.Name)
+		} else if array.MountPoint != "" && raidArrays[array.Name] {
+			return nil, fmt.Errorf(
+				"RAID array: %s has a mount point and is used by LVM",
+				array.Name)
+		}
+	}
+	return usedDrives, nil
+}
+
+// configureVolumes creates the RAID arrays and LVM volume groups in the layout
+// and makes file-systems for those which have mount points.
+func configureVolumes(cpuSharer cpusharer.CpuSharer, drives []*driveType,
+	layout installer_proto.StorageLayout,
+	logger log.DebugLogger) ([]volumeType, error) {
+	if len(layout.RaidArrays) < 1 && len(layout.VolumeGroups) < 1 {
+		return nil, nil
+	}
+	startTime := time.Now()
+	var volumes []volumeType
+	raidArrays := make(map[string]*driveType, len(layout.RaidArrays))
+	for _, array := range layout.RaidArrays {
+		members := make([]*driveType, 0, len(array.Drives))
+		for _, index := range array.Drives {
+			members = append(members, drives[index])
+		}
+		raidDrive, err := createRaidArray(array.Name, array.Level, "1.2",
+			members, logger)
+		if err != nil {
+			return nil, err
+		}
+		raidArrays[array.Name] = raidDrive
+		if array.MountPoint != "" {
+			volumes = append(volumes, volumeType{
+				drive:          raidDrive,
+				fileSystemType: array.FileSystemType,
+				mountPoint:     array.MountPoint,
+			})
+		}
+	}
+	for _, group := range layout.VolumeGroups {
+		members := make([]*driveType, 0,
+			len(group.Drives)+len(group.RaidArrays))
+		for _, index := range group.Drives {
+			members = append(members, drives[index])
+		}
+		for _, name := range group.RaidArrays {
+			members = append(members, raidArrays[name])
+		}
+		groupVolumes, err := createVolumeGroup(group, members, logger)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, groupVolumes...)
+	}
+	concurrentState := concurrent.NewState(uint(len(volumes)))
+	for _, volume := range volumes {
+		volume := volume
+		err := concurrentState.GoRun(func() error {
+			return volume.drive.makeFileSystem(cpuSharer, volume.drive.devpath,
+				volume.mountPoint, volume.fileSystemType, layout.Encrypt, nil,
+				0, logger)
+		})
+		if err != nil {
+			break
+		}
+	}
+	if err := concurrentState.Reap(); err != nil {
+		return nil, err
+	}
+	logger.Printf("configured %d RAID arrays and %d volume groups in %s\n",
+		len(layout.RaidArrays), len(layout.VolumeGroups),
+		time.Since(startTime))
+	return volumes, nil
+}
+
+// createRaidArray creates a RAID array from the member devices. A pseudo drive
+// for the array is returned.
+func createRaidArray(name string, level uint, metadata string,
+	members []*driveType, logger log.DebugLogger) (*driveType, error) {
+	raidDrive := &driveType{
+		discarded: true,
+		devpath:   filepath.Join(mdDirectory, name),
+		name:      name,
+	}
+	args := []string{"--create", raidDrive.devpath, "--run",
+		"--level=" + strconv.FormatUint(uint64(level), 10),
+		"--metadata=" + metadata,
+		"--raid-devices=" + strconv.Itoa(len(members)),
+	}
+	for _, member := range members {
+		if !*dryRun {
+			_, _, err := fsutil.WaitForBlockAvailable(member.devpath,
+				5*time.Second)
+			if err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, member.devpath)
+		if !member.discarded {
+			raidDrive.discarded = false
+		}
+	}
+	if err := run("mdadm", *tmpRoot, logger, args...); err != nil {
+		return nil, err
+	}
+	logger.Printf("created RAID %d array: %s from: %d devices\n",
+		level, raidDrive.devpath, len(members))
+	return raidDrive, nil
+}
+
+// createVolumeGroup creates an LVM volume group from the member devices and
+// creates the logical volumes in it. The logical volumes are returned.
+func createVolumeGroup(group installer_proto.VolumeGroup,
+	members []*driveType, logger log.DebugLogger) ([]volumeType, error) {
+	discarded := true
+	devices := make([]string, 0, len(members))
+	for _, member := range members {
+		devices = append(devices, member.devpath)
+		if !member.discarded {
+			discarded = false
+		}
+	}
+	args := append([]string{"--yes"}, devices...)
+	if err := run("pvcreate", *tmpRoot, logger, args...); err != nil {
+		return nil, err
+	}
+	args = append([]string{group.Name}, devices...)
+	if err := run("vgcreate", *tmpRoot, logger, args...); err != nil {
+		return nil, err
+	}
+	volumes := make([]volumeType, 0, len(group.LogicalVolumes))
+	for _, volume := range group.LogicalVolumes {
+		args := []string{"--yes", "--name", volume.Name}
+		if volume.SizeBytes > 0 {
+			args = append(args, "--size",
+				strconv.FormatUint(volume.SizeBytes, 10)+"b")
+		} else {
+			args = append(args, "--extents", "100%FREE")
+		}
+		args = append(args, group.Name)
+		if err := run("lvcreate", *tmpRoot, logger, args...); err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volumeType{
+			drive: &driveType{
+				discarded: discarded,
+				devpath:   filepath.Join("/dev", group.Name, volume.Name),
+				name:      volume.Name,
+			},
+			fileSystemType: volume.FileSystemType,
+			mountPoint:     volume.MountPoint,
+		})
+	}
+	logger.Printf("created volume group: %s with %d logical volumes\n",
+		group.Name, len(volumes))
+	return volumes, nil
+}
+
+// prepareVolumeDrives discards or erases the drives used for RAID arrays and
+// volume groups.
+func prepareVolumeDrives(drives []*driveType,
+	layout installer_proto.StorageLayout, logger log.DebugLogger) error {
+	var indices []uint
+	for _, array := range layout.RaidArrays {
+		indices = append(indices, array.Drives...)
+	}
+	for _, group := range layout.VolumeGroups {
+		indices = append(indices, group.Drives...)
+	}
+	for _, index := range indices {
+		if err := drives[index].discardOrErase(logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMdadmConfig writes the configuration for the RAID arrays into the
+// installed OS, so that they are assembled with the same names at boot.
+func writeMdadmConfig(logger log.DebugLogger) error {
+	if *dryRun {
+		logger.Debugln(0, "dry run: skipping writing mdadm configuration")
+		return nil
+	}
+	output, err := runWithOutput("mdadm", *tmpRoot, logger, "--detail",
+		"--scan")
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(*mountPoint, "etc", "mdadm", "mdadm.conf")
+	if _, err := os.Stat(filepath.Dir(filename)); err != nil {
+		filename = filepath.Join(*mountPoint, "etc", "mdadm.conf")
+	}
+	logger.Printf("Writing %s:\n%s", filename, output)
+	return ioutil.WriteFile(filename, []byte(output), fsutil.PublicFilePerms)
+}
+
+// partitionDevice returns the device for the specified partition, which is a
+// RAID 1 array if the drive is mirrored.
+func (drive driveType) partitionDevice(partitionNumber int) string {
+	if drive.mirror != nil {
+		return filepath.Join(mdDirectory, bootMirrorName(partitionNumber))
+	}
+	return partitionName(drive.devpath, partitionNumber)
+}
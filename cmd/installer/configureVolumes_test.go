@@ -0,0 +1,461 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	installer_proto "github.com/Cloud-Foundations/Dominator/proto/installer"
+)
+
+func TestCheckStorageLayout(t *testing.T) {
+	mirror := installer_proto.RaidArray{
+		Drives:     []uint{1, 2},
+		Level:      1,
+		MountPoint: "/data",
+		Name:       "data",
+	}
+	logicalVolume := installer_proto.LogicalVolume{
+		MountPoint: "/var/lib/vm",
+		Name:       "vm",
+	}
+	tests := []struct {
+		name        string
+		layout      installer_proto.StorageLayout
+		numDrives   int
+		expectError bool
+		usedDrives  []uint
+	}{
+		{
+			name:      "empty",
+			numDrives: 1,
+		},
+		{
+			name:       "mirrored boot drive",
+			layout:     installer_proto.StorageLayout{MirrorBootDrive: true},
+			numDrives:  2,
+			usedDrives: []uint{1},
+		},
+		{
+			name: "RAID array with mount point",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{mirror},
+			},
+			numDrives:  4,
+			usedDrives: []uint{1, 2},
+		},
+		{
+			name: "RAID 10 array",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:     []uint{1, 2, 3, 4},
+					Level:      10,
+					MountPoint: "/data",
+					Name:       "data",
+				}},
+			},
+			numDrives:  5,
+			usedDrives: []uint{1, 2, 3, 4},
+		},
+		{
+			name: "volume group on RAID array and drive",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives: []uint{1, 2},
+					Name:   "pv",
+				}},
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{3},
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						{
+							MountPoint: "/var/log",
+							Name:       "log",
+							SizeBytes:  1 << 30,
+						},
+						logicalVolume,
+					},
+					Name:       "vg",
+					RaidArrays: []string{"pv"},
+				}},
+			},
+			numDrives:  4,
+			usedDrives: []uint{1, 2, 3},
+		},
+		{
+			name:        "mirrored boot drive without second drive",
+			layout:      installer_proto.StorageLayout{MirrorBootDrive: true},
+			numDrives:   1,
+			expectError: true,
+		},
+		{
+			name: "bad boot partition file-system",
+			layout: installer_proto.StorageLayout{
+				BootDriveLayout: []installer_proto.Partition{
+					{FileSystemType: 99, MountPoint: "/"},
+				},
+			},
+			numDrives:   1,
+			expectError: true,
+		},
+		{
+			name: "invalid RAID array name",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:     []uint{1, 2},
+					Level:      1,
+					MountPoint: "/data",
+					Name:       bootMirrorName(1),
+				}},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "duplicate RAID array",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{mirror, {
+					Drives:     []uint{3, 4},
+					Level:      1,
+					MountPoint: "/data2",
+					Name:       "data",
+				}},
+			},
+			numDrives:   5,
+			expectError: true,
+		},
+		{
+			name: "unsupported RAID level",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:     []uint{1, 2, 3},
+					Level:      5,
+					MountPoint: "/data",
+					Name:       "data",
+				}},
+			},
+			numDrives:   4,
+			expectError: true,
+		},
+		{
+			name: "too few RAID drives",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:     []uint{1, 2},
+					Level:      10,
+					MountPoint: "/data",
+					Name:       "data",
+				}},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "RAID array uses boot drive",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:     []uint{0, 1},
+					Level:      1,
+					MountPoint: "/data",
+					Name:       "data",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "RAID array drive out of range",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{mirror},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "RAID array uses mirrored boot drive",
+			layout: installer_proto.StorageLayout{
+				MirrorBootDrive: true,
+				RaidArrays:      []installer_proto.RaidArray{mirror},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "invalid mount point",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:     []uint{1, 2},
+					Level:      1,
+					MountPoint: "/boot",
+					Name:       "data",
+				}},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "XFS label too long",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives:         []uint{1, 2},
+					FileSystemType: installer_proto.FileSystemTypeXfs,
+					Level:          1,
+					MountPoint:     "/a/very/long/path",
+					Name:           "data",
+				}},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "unused RAID array",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives: []uint{1, 2},
+					Level:  1,
+					Name:   "data",
+				}},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "RAID array with mount point used by LVM",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{mirror},
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						logicalVolume,
+					},
+					Name:       "vg",
+					RaidArrays: []string{"data"},
+				}},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "invalid volume group name",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{1},
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						logicalVolume,
+					},
+					Name: "v/g",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "duplicate volume group",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{
+					{
+						Drives: []uint{1},
+						LogicalVolumes: []installer_proto.LogicalVolume{
+							logicalVolume,
+						},
+						Name: "vg",
+					},
+					{
+						Drives: []uint{2},
+						LogicalVolumes: []installer_proto.LogicalVolume{{
+							MountPoint: "/srv",
+							Name:       "srv",
+						}},
+						Name: "vg",
+					},
+				},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "volume group without devices",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						logicalVolume,
+					},
+					Name: "vg",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "volume group drive used twice",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{1, 1},
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						logicalVolume,
+					},
+					Name: "vg",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "unknown RAID array in volume group",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						logicalVolume,
+					},
+					Name:       "vg",
+					RaidArrays: []string{"missing"},
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "RAID array used by two volume groups",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{{
+					Drives: []uint{1, 2},
+					Name:   "pv",
+				}},
+				VolumeGroups: []installer_proto.VolumeGroup{
+					{
+						LogicalVolumes: []installer_proto.LogicalVolume{
+							logicalVolume,
+						},
+						Name:       "vg0",
+						RaidArrays: []string{"pv"},
+					},
+					{
+						LogicalVolumes: []installer_proto.LogicalVolume{{
+							MountPoint: "/srv",
+							Name:       "srv",
+						}},
+						Name:       "vg1",
+						RaidArrays: []string{"pv"},
+					},
+				},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "volume group without logical volumes",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{1},
+					Name:   "vg",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "invalid logical volume name",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{1},
+					LogicalVolumes: []installer_proto.LogicalVolume{{
+						MountPoint: "/srv",
+					}},
+					Name: "vg",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "duplicate logical volume across groups",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{
+					{
+						Drives: []uint{1},
+						LogicalVolumes: []installer_proto.LogicalVolume{
+							logicalVolume,
+						},
+						Name: "vg0",
+					},
+					{
+						Drives: []uint{2},
+						LogicalVolumes: []installer_proto.LogicalVolume{{
+							MountPoint: "/srv",
+							Name:       "vm",
+						}},
+						Name: "vg1",
+					},
+				},
+			},
+			numDrives:   3,
+			expectError: true,
+		},
+		{
+			name: "remaining space before last logical volume",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{1},
+					LogicalVolumes: []installer_proto.LogicalVolume{
+						logicalVolume,
+						{MountPoint: "/srv", Name: "srv", SizeBytes: 1 << 30},
+					},
+					Name: "vg",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "logical volume without mount point",
+			layout: installer_proto.StorageLayout{
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{1},
+					LogicalVolumes: []installer_proto.LogicalVolume{{
+						Name: "srv",
+					}},
+					Name: "vg",
+				}},
+			},
+			numDrives:   2,
+			expectError: true,
+		},
+		{
+			name: "duplicate mount point",
+			layout: installer_proto.StorageLayout{
+				RaidArrays: []installer_proto.RaidArray{mirror},
+				VolumeGroups: []installer_proto.VolumeGroup{{
+					Drives: []uint{3},
+					LogicalVolumes: []installer_proto.LogicalVolume{{
+						MountPoint: "/data",
+						Name:       "srv",
+					}},
+					Name: "vg",
+				}},
+			},
+			numDrives:   4,
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		usedDrives, err := checkStorageLayout(test.layout, test.numDrives)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		expectedDrives := make(map[uint]struct{}, len(test.usedDrives))
+		for _, index := range test.usedDrives {
+			expectedDrives[index] = struct{}{}
+		}
+		if !reflect.DeepEqual(usedDrives, expectedDrives) {
+			t.Errorf("%s: expected drives: %v, got: %v",
+				test.name, test.usedDrives, usedDrives)
+		}
+	}
+}
@@ -226,9 +226,17 @@ func run(name, chroot string, logger log.DebugLogger, args ...string) error {
 
 func runAlways(name, chroot string, logger log.DebugLogger,
 	args ...string) error {
+	_, err := runWithOutput(name, chroot, logger, args...)
+	return err
+}
+
+// runWithOutput will run a command and return its standard output. The command
+// is run even if -dryRun is specified.
+func runWithOutput(name, chroot string, logger log.DebugLogger,
+	args ...string) (string, error) {
 	path, err := lookPath(chroot, name)
 	if err != nil {
-		return err
+		return "", err
 	}
 	// BusyBox ash sometimes closes standard output or standard error, which can
 	// lead to "write to closed pipe" error if using the exec.CombinedOuput()
@@ -256,9 +264,9 @@ func runAlways(name, chroot string, logger log.DebugLogger,
 				"%s succeeded, forced closed pipes, stdout: %s, stderr: %s\n",
 				name, strings.TrimSpace(stdout.String()),
 				strings.TrimSpace(stderr.String()))
-			return nil
+			return stdout.String(), nil
 		}
-		return fmt.Errorf("error running: %s: %s, stdout: %s, stderr: %s",
+		return "", fmt.Errorf("error running: %s: %s, stdout: %s, stderr: %s",
 			name, strings.TrimSpace(stdout.String()),
 			strings.TrimSpace(stderr.String()), err)
 	} else if stdout.Len() > 0 || stderr.Len() > 0 {
@@ -268,7 +276,7 @@ func runAlways(name, chroot string, logger log.DebugLogger,
 	} else {
 		logger.Debugf(3, "%s succeeded\n", name)
 	}
-	return nil
+	return stdout.String(), nil
 }
 
 func unpackAndMount(rootDir string, fileSystem *filesystem.FileSystem,
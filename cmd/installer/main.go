@@ -58,6 +58,8 @@ var (
 	imageServerPortNum = flag.Uint("imageServerPortNum",
 		constants.ImageServerPortNumber,
 		"Port number of image server (overrides TFTP data)")
+	imageSigningKeysFile = flag.String("imageSigningKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign images (default do not verify)")
 	logDebugLevel = flag.Int("logDebugLevel", -1, "Debug log level")
 	mountPoint    = flag.String("mountPoint", "/mnt",
 		"Mount point for new root file-system")
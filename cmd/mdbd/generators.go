@@ -8,11 +8,16 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/expand"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 )
 
+// httpClient is used for all HTTP requests to MDB sources. The timeout is set
+// from the -httpTimeout option.
+var httpClient = &http.Client{Timeout: time.Minute}
+
 type generatorInfo struct {
 	args                []string
 	driverName          string
@@ -138,7 +143,7 @@ func loadMdb(driverFunc sourceDriverFunc, url string, datacentre string,
 
 func loadHttpMdb(driverFunc sourceDriverFunc, url string, datacentre string,
 	logger log.Logger) (*mdbType, error) {
-	response, err := http.Get(url)
+	response, err := httpClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+)
+
+type consulGeneratorType struct {
+	address string
+	service string
+	tag     string
+}
+
+type consulNodeType struct {
+	Address        string
+	Datacenter     string
+	Meta           map[string]string // Only for the nodes catalog.
+	Node           string
+	NodeMeta       map[string]string
+	ServiceAddress string
+	ServiceMeta    map[string]string
+}
+
+func newConsulGenerator(params makeGeneratorParams) (generator, error) {
+	g := &consulGeneratorType{address: strings.TrimSuffix(params.args[0], "/")}
+	if len(params.args) > 1 {
+		g.service = params.args[1]
+	}
+	if len(params.args) > 2 {
+		g.tag = params.args[2]
+	}
+	return g, nil
+}
+
+func (g *consulGeneratorType) Generate(datacentre string,
+	logger log.DebugLogger) (*mdbType, error) {
+	query := make(url.Values)
+	if datacentre != "" {
+		query.Set("dc", datacentre)
+	}
+	var requestUrl string
+	if g.service == "" {
+		requestUrl = g.address + "/v1/catalog/nodes"
+	} else {
+		requestUrl = g.address + "/v1/catalog/service/" +
+			url.PathEscape(g.service)
+		if g.tag != "" {
+			query.Set("tag", g.tag)
+		}
+	}
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	request, err := http.NewRequest("GET", requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("CONSUL_HTTP_TOKEN"); token != "" {
+		request.Header.Set("X-Consul-Token", token)
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", requestUrl, response.Status)
+	}
+	var nodes []consulNodeType
+	if err := json.Read(response.Body, &nodes); err != nil {
+		return nil, fmt.Errorf("error decoding: %s", err)
+	}
+	var newMdb mdbType
+	found := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		// A service may have multiple instances on a node.
+		if _, ok := found[node.Node]; ok {
+			continue
+		}
+		found[node.Node] = struct{}{}
+		machine := &mdb.Machine{
+			Hostname:  node.Node,
+			IpAddress: node.Address,
+			Location:  node.Datacenter,
+		}
+		if node.ServiceAddress != "" {
+			machine.IpAddress = node.ServiceAddress
+		}
+		for _, meta := range []map[string]string{
+			node.Meta, node.NodeMeta, node.ServiceMeta} {
+			for key, value := range meta {
+				if machine.Tags == nil {
+					machine.Tags = make(map[string]string)
+				}
+				machine.Tags[key] = value
+			}
+		}
+		extractPlainTags(machine)
+		newMdb.Machines = append(newMdb.Machines, machine)
+	}
+	return &newMdb, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+)
+
+const testConsulService = `[
+{"Node": "host0", "Address": "10.0.0.1", "Datacenter": "dc1",
+ "NodeMeta": {"RequiredImage": "base/1"}, "ServiceAddress": "10.0.1.1"},
+{"Node": "host0", "Address": "10.0.0.1", "Datacenter": "dc1"},
+{"Node": "host1", "Address": "10.0.0.2", "Datacenter": "dc1",
+ "ServiceMeta": {"OwnerGroup": "team"}}
+]`
+
+func TestConsul(t *testing.T) {
+	var requestUrl string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			requestUrl = req.URL.String()
+			fmt.Fprintln(w, testConsulService)
+		}))
+	defer server.Close()
+	gen, err := newConsulGenerator(makeGeneratorParams{
+		args: []string{server.URL, "web", "prod"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdb, err := gen.Generate("dc1", testlogger.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requestUrl != "/v1/catalog/service/web?dc=dc1&tag=prod" {
+		t.Errorf("bad request URL: %s", requestUrl)
+	}
+	if len(mdb.Machines) != 2 {
+		t.Fatalf("expected 2 machines, got: %d", len(mdb.Machines))
+	}
+	if machine := mdb.Machines[0]; machine.Hostname != "host0" ||
+		machine.IpAddress != "10.0.1.1" || machine.Location != "dc1" ||
+		machine.RequiredImage != "base/1" {
+		t.Errorf("bad first machine: %v", machine)
+	}
+	if machine := mdb.Machines[1]; machine.IpAddress != "10.0.0.2" ||
+		machine.OwnerGroup != "team" {
+		t.Errorf("bad second machine: %v", machine)
+	}
+}
+
+func TestConsulTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			<-release
+		}))
+	defer server.Close()
+	defer close(release)
+	oldTimeout := httpClient.Timeout
+	httpClient.Timeout = 10 * time.Millisecond
+	defer func() { httpClient.Timeout = oldTimeout }()
+	gen, err := newConsulGenerator(makeGeneratorParams{
+		args: []string{server.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(chan error, 1)
+	go func() {
+		_, err := gen.Generate("", testlogger.New(t))
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("no error from stalled server")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request to stalled server did not time out")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+)
+
+type dnsSrvGeneratorType struct {
+	lookupSRV func(service, proto, name string) (string, []*net.SRV, error)
+	name      string
+}
+
+func newDnsSrvGenerator(params makeGeneratorParams) (generator, error) {
+	return &dnsSrvGeneratorType{
+		lookupSRV: net.LookupSRV,
+		name:      params.args[0],
+	}, nil
+}
+
+func (g *dnsSrvGeneratorType) Generate(unused_datacentre string,
+	logger log.DebugLogger) (*mdbType, error) {
+	_, records, err := g.lookupSRV("", "", g.name)
+	if err != nil {
+		return nil, err
+	}
+	var newMdb mdbType
+	found := make(map[string]struct{}, len(records))
+	for _, record := range records {
+		// A host may provide the service on multiple ports.
+		hostname := strings.TrimSuffix(record.Target, ".")
+		if hostname == "" {
+			continue
+		}
+		if _, ok := found[hostname]; ok {
+			continue
+		}
+		found[hostname] = struct{}{}
+		newMdb.Machines = append(newMdb.Machines,
+			&mdb.Machine{Hostname: hostname})
+	}
+	return &newMdb, nil
+}
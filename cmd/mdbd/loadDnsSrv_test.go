@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+)
+
+func TestDnsSrv(t *testing.T) {
+	var lookedUp string
+	gen := &dnsSrvGeneratorType{
+		lookupSRV: func(service, proto, name string) (string, []*net.SRV,
+			error) {
+			lookedUp = name
+			if name != "_sub._tcp.example.com" {
+				return "", nil, errors.New("no such host")
+			}
+			return "", []*net.SRV{
+				{Target: "host0.example.com.", Port: 6969},
+				{Target: "host1.example.com.", Port: 6969},
+				{Target: "host0.example.com.", Port: 6970},
+				{Target: "."},
+			}, nil
+		},
+		name: "_sub._tcp.example.com",
+	}
+	mdb, err := gen.Generate("", testlogger.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookedUp != "_sub._tcp.example.com" {
+		t.Errorf("bad lookup name: %s", lookedUp)
+	}
+	if len(mdb.Machines) != 2 {
+		t.Fatalf("expected 2 machines, got: %d", len(mdb.Machines))
+	}
+	if hostname := mdb.Machines[0].Hostname; hostname != "host0.example.com" {
+		t.Errorf("bad first hostname: %s", hostname)
+	}
+	if hostname := mdb.Machines[1].Hostname; hostname != "host1.example.com" {
+		t.Errorf("bad second hostname: %s", hostname)
+	}
+	gen.name = "_missing._tcp.example.com"
+	if _, err := gen.Generate("", testlogger.New(t)); err == nil {
+		t.Error("no error for failed lookup")
+	}
+}
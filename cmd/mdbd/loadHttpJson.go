@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	libjson "github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+)
+
+const maxHttpJsonPages = 10000
+
+type cachedPageType struct {
+	body []byte
+	etag string
+}
+
+type httpJsonConfigType struct {
+	Fields        map[string]string // Key: Machine field, value: item path.
+	HeaderFiles   map[string]string // Key: header, value: file with value.
+	Headers       map[string]string // Key: header, value: value.
+	ItemsPath     string            // Empty: response is a list of items.
+	NextPagePath  string            // Empty: use Link header.
+	PageParameter string            // Empty: next page is a URL.
+	TagPaths      map[string]string // Key: tag name, value: item path.
+	Url           string
+}
+
+type httpJsonGeneratorType struct {
+	config  httpJsonConfigType
+	headers http.Header
+	pages   map[string]cachedPageType // Key: URL.
+}
+
+var (
+	httpJsonFields = map[string]func(*mdb.Machine, interface{}){
+		"DisableUpdates": func(machine *mdb.Machine, value interface{}) {
+			machine.DisableUpdates, _ = strconv.ParseBool(
+				jsonValueToString(value))
+		},
+		"Hostname": func(machine *mdb.Machine, value interface{}) {
+			machine.Hostname = jsonValueToString(value)
+		},
+		"IpAddress": func(machine *mdb.Machine, value interface{}) {
+			machine.IpAddress = jsonValueToString(value)
+		},
+		"Location": func(machine *mdb.Machine, value interface{}) {
+			machine.Location = jsonValueToString(value)
+		},
+		"OwnerGroup": func(machine *mdb.Machine, value interface{}) {
+			machine.OwnerGroup = jsonValueToString(value)
+		},
+		"OwnerGroups": func(machine *mdb.Machine, value interface{}) {
+			machine.OwnerGroups = jsonValueToStrings(value)
+		},
+		"OwnerUsers": func(machine *mdb.Machine, value interface{}) {
+			machine.OwnerUsers = jsonValueToStrings(value)
+		},
+		"PlannedImage": func(machine *mdb.Machine, value interface{}) {
+			machine.PlannedImage = jsonValueToString(value)
+		},
+		"RequiredImage": func(machine *mdb.Machine, value interface{}) {
+			machine.RequiredImage = jsonValueToString(value)
+		},
+		"Tags": func(machine *mdb.Machine, value interface{}) {
+			if object, ok := value.(map[string]interface{}); ok {
+				if machine.Tags == nil {
+					machine.Tags = make(map[string]string, len(object))
+				}
+				for key, value := range object {
+					machine.Tags[key] = jsonValueToString(value)
+				}
+			}
+		},
+	}
+	linkNextRegex = regexp.MustCompile(`<([^>]*)>[^,]*rel="?next"?`)
+)
+
+func newHttpJsonGenerator(params makeGeneratorParams) (generator, error) {
+	g := &httpJsonGeneratorType{headers: make(http.Header)}
+	if err := libjson.ReadFromFile(params.args[0], &g.config); err != nil {
+		return nil, err
+	}
+	if g.config.Url == "" {
+		return nil, fmt.Errorf("%s: no Url specified", params.args[0])
+	}
+	if _, ok := g.config.Fields["Hostname"]; !ok {
+		if _, ok := g.config.Fields["IpAddress"]; !ok {
+			return nil, fmt.Errorf("%s: no Hostname or IpAddress field",
+				params.args[0])
+		}
+	}
+	for field := range g.config.Fields {
+		if _, ok := httpJsonFields[field]; !ok {
+			return nil, fmt.Errorf("%s: unsupported field: %s",
+				params.args[0], field)
+		}
+	}
+	for header, value := range g.config.Headers {
+		g.headers.Set(header, value)
+	}
+	for header, filename := range g.config.HeaderFiles {
+		value, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		g.headers.Set(header, strings.TrimSpace(string(value)))
+	}
+	return g, nil
+}
+
+// getJsonPath returns the value at the dot-separated path in the decoded JSON
+// value. An empty path yields the value itself.
+func getJsonPath(value interface{}, path string) interface{} {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok = object[key]; !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+func jsonValueToString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(value)
+	case json.Number:
+		return value.String()
+	case string:
+		return value
+	}
+	return ""
+}
+
+// jsonValueToStrings converts a list of values or a comma-separated string to
+// a list of strings.
+func jsonValueToStrings(value interface{}) []string {
+	var strs []string
+	switch value := value.(type) {
+	case []interface{}:
+		for _, entry := range value {
+			if str := jsonValueToString(entry); str != "" {
+				strs = append(strs, str)
+			}
+		}
+	case string:
+		for _, str := range strings.Split(value, ",") {
+			if str = strings.TrimSpace(str); str != "" {
+				strs = append(strs, str)
+			}
+		}
+	}
+	return strs
+}
+
+func (g *httpJsonGeneratorType) Generate(unused_datacentre string,
+	logger log.DebugLogger) (*mdbType, error) {
+	var newMdb mdbType
+	pages := make(map[string]cachedPageType)
+	pageUrl := g.config.Url
+	for numPages := 0; pageUrl != ""; numPages++ {
+		if numPages >= maxHttpJsonPages {
+			return nil, fmt.Errorf("too many pages from: %s", g.config.Url)
+		}
+		if _, ok := pages[pageUrl]; ok {
+			return nil, fmt.Errorf("pagination loop at: %s", pageUrl)
+		}
+		page, linkNext, err := g.getPage(pageUrl, logger)
+		if err != nil {
+			return nil, err
+		}
+		pages[pageUrl] = page
+		decoder := json.NewDecoder(bytes.NewReader(page.body))
+		decoder.UseNumber()
+		var response interface{}
+		if err := decoder.Decode(&response); err != nil {
+			return nil, fmt.Errorf("error decoding: %s: %s", pageUrl, err)
+		}
+		items, ok := getJsonPath(response, g.config.ItemsPath).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: no list of items at: \"%s\"",
+				pageUrl, g.config.ItemsPath)
+		}
+		for _, item := range items {
+			newMdb.Machines = append(newMdb.Machines, g.makeMachine(item))
+		}
+		nextPage := linkNext
+		if g.config.NextPagePath != "" {
+			nextPage = jsonValueToString(
+				getJsonPath(response, g.config.NextPagePath))
+		}
+		if pageUrl, err = g.makeNextPageUrl(pageUrl, nextPage); err != nil {
+			return nil, err
+		}
+	}
+	g.pages = pages
+	return &newMdb, nil
+}
+
+// getPage will fetch a page, using the cached copy if it has not changed. The
+// URL from a Link: rel="next" header, if present, is also returned.
+func (g *httpJsonGeneratorType) getPage(pageUrl string,
+	logger log.DebugLogger) (cachedPageType, string, error) {
+	request, err := http.NewRequest("GET", pageUrl, nil)
+	if err != nil {
+		return cachedPageType{}, "", err
+	}
+	for header, values := range g.headers {
+		request.Header[header] = values
+	}
+	request.Header.Set("Accept", "application/json")
+	cachedPage, haveCachedPage := g.pages[pageUrl]
+	if haveCachedPage && cachedPage.etag != "" {
+		request.Header.Set("If-None-Match", cachedPage.etag)
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return cachedPageType{}, "", err
+	}
+	defer response.Body.Close()
+	var linkNext string
+	for _, link := range response.Header.Values("Link") {
+		if match := linkNextRegex.FindStringSubmatch(link); match != nil {
+			linkNext = match[1]
+			break
+		}
+	}
+	if response.StatusCode == http.StatusNotModified && haveCachedPage {
+		logger.Debugf(1, "%s: not modified\n", pageUrl)
+		return cachedPage, linkNext, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return cachedPageType{}, "", fmt.Errorf("%s: %s",
+			pageUrl, response.Status)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return cachedPageType{}, "", err
+	}
+	return cachedPageType{
+		body: body,
+		etag: response.Header.Get("ETag"),
+	}, linkNext, nil
+}
+
+func (g *httpJsonGeneratorType) makeMachine(item interface{}) *mdb.Machine {
+	machine := &mdb.Machine{}
+	for tag, path := range g.config.TagPaths {
+		if value := getJsonPath(item, path); value != nil {
+			if machine.Tags == nil {
+				machine.Tags = make(map[string]string)
+			}
+			machine.Tags[tag] = jsonValueToString(value)
+		}
+	}
+	if path, ok := g.config.Fields["Tags"]; ok {
+		httpJsonFields["Tags"](machine, getJsonPath(item, path))
+	}
+	extractPlainTags(machine)
+	for field, path := range g.config.Fields {
+		if field == "Tags" {
+			continue
+		}
+		if value := getJsonPath(item, path); value != nil {
+			httpJsonFields[field](machine, value)
+		}
+	}
+	return machine
+}
+
+// makeNextPageUrl returns the URL of the next page, or an empty string if
+// there are no more pages.
+func (g *httpJsonGeneratorType) makeNextPageUrl(pageUrl, nextPage string) (
+	string, error) {
+	if nextPage == "" {
+		return "", nil
+	}
+	if g.config.PageParameter != "" {
+		parsedUrl, err := url.Parse(g.config.Url)
+		if err != nil {
+			return "", err
+		}
+		query := parsedUrl.Query()
+		query.Set(g.config.PageParameter, nextPage)
+		parsedUrl.RawQuery = query.Encode()
+		return parsedUrl.String(), nil
+	}
+	baseUrl, err := url.Parse(pageUrl)
+	if err != nil {
+		return "", err
+	}
+	nextUrl, err := baseUrl.Parse(nextPage)
+	if err != nil {
+		return "", errors.New("bad next page URL: " + err.Error())
+	}
+	return nextUrl.String(), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+)
+
+const (
+	testHttpJsonConfig = `{
+	"Fields": {
+		"Hostname": "name",
+		"OwnerGroups": "owners",
+		"RequiredImage": "image.required",
+		"Tags": "labels"
+	},
+	"Headers": {"Authorization": "Bearer secret"},
+	"ItemsPath": "data.machines",
+	"NextPagePath": "data.next",
+	"TagPaths": {"Rack": "location.rack"}
+}`
+	testHttpJsonPage0 = `{"data": {"machines": [
+{"name": "host0", "owners": ["team0", "team1"],
+ "image": {"required": "base/1"}, "labels": {"PlannedImage": "base/2"},
+ "location": {"rack": 42}}
+], "next": "/machines?page=1"}}`
+	testHttpJsonPage1 = `{"data": {"machines": [
+{"name": "host1", "owners": "team2", "labels": {"env": "prod"}}
+]}}`
+)
+
+func TestHttpJson(t *testing.T) {
+	var numFetched int
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "no auth", http.StatusUnauthorized)
+				return
+			}
+			etag := `"page` + req.URL.Query().Get("page") + `"`
+			if req.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			numFetched++
+			w.Header().Set("ETag", etag)
+			if req.URL.Query().Get("page") == "1" {
+				fmt.Fprintln(w, testHttpJsonPage1)
+			} else {
+				fmt.Fprintln(w, testHttpJsonPage0)
+			}
+		}))
+	defer server.Close()
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configFile,
+		[]byte(`{"Url": "`+server.URL+`/machines",`+testHttpJsonConfig[1:]),
+		0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := newHttpJsonGenerator(makeGeneratorParams{
+		args: []string{configFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := testlogger.New(t)
+	for iteration := 0; iteration < 2; iteration++ {
+		mdb, err := gen.Generate("", logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mdb.Machines) != 2 {
+			t.Fatalf("expected 2 machines, got: %d", len(mdb.Machines))
+		}
+		machine := mdb.Machines[0]
+		if machine.Hostname != "host0" || machine.RequiredImage != "base/1" ||
+			machine.PlannedImage != "base/2" ||
+			len(machine.OwnerGroups) != 2 || machine.Tags["Rack"] != "42" {
+			t.Errorf("bad first machine: %v", machine)
+		}
+		machine = mdb.Machines[1]
+		if machine.Hostname != "host1" || len(machine.OwnerGroups) != 1 ||
+			machine.Tags["env"] != "prod" {
+			t.Errorf("bad second machine: %v", machine)
+		}
+	}
+	if numFetched != 2 {
+		t.Errorf("expected 2 pages fetched, got: %d", numFetched)
+	}
+}
@@ -33,6 +33,8 @@ var (
 		"A file containing a list of hostnames to include")
 	hostnameRegex = flag.String("hostnameRegex", ".*",
 		"A regular expression to match the desired hostnames, leading ! inverts")
+	httpTimeout = flag.Duration("httpTimeout", time.Minute,
+		"Timeout for HTTP requests to MDB sources")
 	maximumPauseDuration = flag.Duration("maximumPauseDuration", 12*time.Hour,
 		"Maximum duration to pause updates for a machine")
 	maximumPausedMachinesPerUser = flag.Uint("maximumPausedMachinesPerUser", 10,
@@ -87,6 +89,22 @@ func printUsage() {
 		"  cis: url")
 	fmt.Fprintln(os.Stderr,
 		"    url: Cloud Intelligence Service endpoint search query")
+	fmt.Fprintln(os.Stderr,
+		"  consul: url [service [tag]]")
+	fmt.Fprintln(os.Stderr,
+		"    Query a Consul service catalog")
+	fmt.Fprintln(os.Stderr,
+		"    url:     URL of the Consul agent, such as http://localhost:8500")
+	fmt.Fprintln(os.Stderr,
+		"    service: optional service to select nodes (default all nodes)")
+	fmt.Fprintln(os.Stderr,
+		"    tag:     optional service tag to select nodes")
+	fmt.Fprintln(os.Stderr,
+		"  dns-srv: name")
+	fmt.Fprintln(os.Stderr,
+		"    Query DNS SRV records")
+	fmt.Fprintln(os.Stderr,
+		"    name: SRV record name, such as _sub._tcp.example.com")
 	fmt.Fprintln(os.Stderr,
 		"  ds.host.fqdn: url")
 	fmt.Fprintln(os.Stderr,
@@ -107,6 +125,12 @@ func printUsage() {
 		"    required-image: optional required image for machines")
 	fmt.Fprintln(os.Stderr,
 		"    planned-image:  optional planned image for machines")
+	fmt.Fprintln(os.Stderr,
+		"  http-json: config-file")
+	fmt.Fprintln(os.Stderr,
+		"    Query a paginated HTTP JSON API")
+	fmt.Fprintln(os.Stderr,
+		"    config-file: JSON file with the URL, headers and field mapping")
 	fmt.Fprintln(os.Stderr,
 		"  hypervisor")
 	fmt.Fprintln(os.Stderr,
@@ -147,9 +171,12 @@ var drivers = []driver{
 	{"aws-filtered", 2, 2, newAwsFilteredGenerator},
 	{"aws-local", 0, 0, newAwsLocalGenerator},
 	{"cis", 1, 1, newCisGenerator},
+	{"consul", 1, 3, newConsulGenerator},
+	{"dns-srv", 1, 1, newDnsSrvGenerator},
 	{"ds.host.fqdn", 1, 1, newDsHostFqdnGenerator},
 	{"fleet-manager", 1, 2, newFleetManagerGenerator},
 	{"hostlist", 1, 3, newHostlistGenerator},
+	{"http-json", 1, 1, newHttpJsonGenerator},
 	{"hypervisor", 0, 0, newHypervisorGenerator},
 	{"json", 1, 2, newJsonGenerator},
 	{"text", 1, 1, newTextGenerator},
@@ -225,6 +252,7 @@ func main() {
 	flag.Usage = printUsage
 	flag.Parse()
 	tricorder.RegisterFlags()
+	httpClient.Timeout = *httpTimeout
 	logger := serverlogger.New("")
 	if *debug { // Backwards compatibility.
 		logger.SetLevel(0)
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/policy"
+	"github.com/Cloud-Foundations/Dominator/lib/stringutil"
+)
+
+func checkPolicySubcommand(args []string, logger log.DebugLogger) error {
+	if err := checkPolicy(args[0], args[1]); err != nil {
+		return fmt.Errorf("error checking policy: %s", err)
+	}
+	return nil
+}
+
+func checkPolicy(filename, serviceMethod string) error {
+	authPolicy, err := policy.Load(filename)
+	if err != nil {
+		return err
+	}
+	request := srpc.PolicyRequest{
+		GroupList:     stringutil.ConvertListToMap(groups, false),
+		RemoteAddress: *remoteAddress,
+		ServiceMethod: serviceMethod,
+		TargetTags:    targetTags,
+		Time:          time.Now(),
+		Username:      *username,
+	}
+	if *atTime != "" {
+		request.Time, err = time.Parse(time.RFC3339, *atTime)
+		if err != nil {
+			return err
+		}
+	}
+	decision := authPolicy.Evaluate(request)
+	if decision.Action == srpc.PolicyActionNone {
+		fmt.Println("no rule matched: built-in authorisation is used")
+		return nil
+	}
+	fmt.Printf("%s (rule: \"%s\")\n", decision.Action, decision.Rule)
+	return nil
+}
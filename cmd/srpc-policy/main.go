@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Cloud-Foundations/Dominator/lib/flags/commands"
+	"github.com/Cloud-Foundations/Dominator/lib/flags/loadflags"
+	"github.com/Cloud-Foundations/Dominator/lib/flagutil"
+	"github.com/Cloud-Foundations/Dominator/lib/log/cmdlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+)
+
+var (
+	atTime = flag.String("atTime", "",
+		"Time of the call in RFC3339 format (default now)")
+	groups        flagutil.StringList
+	remoteAddress = flag.String("remoteAddress", "",
+		"Address of the caller")
+	targetTags tags.Tags
+	username   = flag.String("username", "", "Name of the caller")
+)
+
+func init() {
+	flag.Var(&groups, "groups", "Groups the caller is a member of")
+	flag.Var(&targetTags, "targetTags",
+		"Tags of the target (such as a VM) to check access to")
+}
+
+func printUsage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w,
+		"Usage: srpc-policy [flags...] check-policy|validate-policy [args...]")
+	fmt.Fprintln(w, "Common flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(w, "Commands:")
+	commands.PrintCommands(w, subcommands)
+}
+
+var subcommands = []commands.Command{
+	{"check-policy", "   policy-file Service.Method", 2, 2, checkPolicySubcommand},
+	{"validate-policy", "policy-file", 1, 1, validatePolicySubcommand},
+}
+
+func doMain() int {
+	if err := loadflags.LoadForCli("srpc-policy"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	flag.Usage = printUsage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		printUsage()
+		return 2
+	}
+	logger := cmdlogger.New()
+	return commands.RunCommands(subcommands, printUsage, logger)
+}
+
+func main() {
+	os.Exit(doMain())
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/policy"
+)
+
+func validatePolicySubcommand(args []string, logger log.DebugLogger) error {
+	authPolicy, err := policy.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("error validating policy: %s", err)
+	}
+	fmt.Printf("policy has %d rules\n", authPolicy.NumRules())
+	return nil
+}
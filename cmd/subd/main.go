@@ -14,6 +14,7 @@ import (
 
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
 	"github.com/Cloud-Foundations/Dominator/lib/cpulimiter"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/filter"
 	"github.com/Cloud-Foundations/Dominator/lib/flags/loadflags"
 	"github.com/Cloud-Foundations/Dominator/lib/flagutil"
@@ -48,6 +49,8 @@ var (
 		"Scan speed as percentage of capacity (default 2)")
 	disruptionManager = flag.String("disruptionManager", "",
 		"Path to DisruptionManager tool")
+	imageSigningKeysFile = flag.String("imageSigningKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign images (default do not verify)")
 	maxThreads = flag.Uint("maxThreads", 1,
 		"Maximum number of parallel OS threads to use")
 	noteGenerator = flag.String("noteGenerator", "",
@@ -290,6 +293,13 @@ func main() {
 			logger.Fatalln(err)
 		}
 	}
+	var imageSignatureVerifier *dsse.Verifier
+	if *imageSigningKeysFile != "" {
+		imageSignatureVerifier, err = dsse.LoadVerifier(*imageSigningKeysFile)
+		if err != nil {
+			logger.Fatalf("Cannot load image signing keys: %s\n", err)
+		}
+	}
 	bytesPerSecond, blocksPerSecond, firstScan, ok := getCachedFsSpeed(
 		workingRootDir, tmpDir)
 	if !ok {
@@ -393,6 +403,7 @@ func main() {
 			rpcd.Params{
 				DisableScannerFunction:    disableScanner,
 				FileSystemHistory:         &fsh,
+				ImageSignatureVerifier:    imageSignatureVerifier,
 				Logger:                    logger,
 				NetworkReaderContext:      networkReaderContext,
 				RescanObjectCacheFunction: rescanFunc,
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/sub"
+	"github.com/Cloud-Foundations/Dominator/sub/client"
+)
+
+func driftSubcommand(args []string, logger log.DebugLogger) error {
+	srpcClient := getSubClient(logger)
+	defer srpcClient.Close()
+	if err := showDrift(srpcClient); err != nil {
+		return fmt.Errorf("error getting drift report: %s", err)
+	}
+	return nil
+}
+
+func showDrift(srpcClient *srpc.Client) error {
+	roots := x509.NewCertPool()
+	if pemData, err := os.ReadFile(*driftCaFile); err != nil {
+		return err
+	} else if !roots.AppendCertsFromPEM(pemData) {
+		return errors.New("no certificates in: " + *driftCaFile)
+	}
+	hostname := *subHostname
+	if hostname == "localhost" {
+		if name, err := os.Hostname(); err == nil {
+			hostname = name
+		}
+	}
+	report, cert, err := client.GetDriftReport(srpcClient,
+		sub.GetDriftReportRequest{MaxChanges: *maxDriftChanges},
+		x509.VerifyOptions{DNSName: hostname, Roots: roots})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Host: %s, signed by: %s\n",
+		report.Hostname, cert.Subject.CommonName)
+	fmt.Printf("Baseline image: %s, recorded: %s\n",
+		report.BaselineImageName, report.BaselineTime.Format(format.TimeFormatSeconds))
+	fmt.Printf("Report time: %s, ScanCount: %d\n",
+		report.ReportTime.Format(format.TimeFormatSeconds), report.ScanCount)
+	if report.UpdatesDisabled {
+		fmt.Println("Updates are disabled")
+	}
+	if report.NumChanges > uint(len(report.Changes)) {
+		fmt.Printf("Changes: %d (showing %d)\n",
+			report.NumChanges, len(report.Changes))
+	} else {
+		fmt.Printf("Changes: %d\n", report.NumChanges)
+	}
+	for _, change := range report.Changes {
+		showDriftChange(change)
+	}
+	return nil
+}
+
+func showDriftChange(change sub.DriftChange) {
+	fmt.Printf("  %s: %s", change.Name, change.Flags)
+	if change.Flags&(sub.DriftFlagAdded|sub.DriftFlagDeleted) != 0 {
+		fmt.Println()
+		return
+	}
+	if change.Flags&(sub.DriftFlagModeChanged|sub.DriftFlagTypeChanged) != 0 {
+		fmt.Printf(" %s->%s", change.OldMode, change.NewMode)
+	}
+	if change.Flags&sub.DriftFlagOwnerChanged != 0 {
+		fmt.Printf(" %d:%d->%d:%d",
+			change.OldUid, change.OldGid, change.NewUid, change.NewGid)
+	}
+	fmt.Println()
+}
@@ -27,6 +27,8 @@ var (
 	debug             = flag.Bool("debug", false, "Enable debug mode")
 	deleteBeforeFetch = flag.Bool("deleteBeforeFetch", false,
 		"If true, delete prior to Fetch rather than during Update")
+	driftCaFile = flag.String("driftCaFile", "/etc/ssl/CA.pem",
+		"Name of file containing the root of trust for drift report signers")
 	file = flag.String("file", "",
 		"Name of file to write encoded data to")
 	forceDisruption = flag.Bool("forceDisruption", false,
@@ -44,6 +46,8 @@ var (
 		"Seconds to sleep between Polls")
 	lockDuration = flag.Duration("lockDuration", 15*time.Second,
 		"Time to lock client from mutations by other clients")
+	maxDriftChanges = flag.Uint("maxDriftChanges", 0,
+		"Maximum number of drift changes to show (0: all)")
 	networkSpeedPercent = flag.Uint("networkSpeedPercent",
 		constants.DefaultNetworkSpeedPercent,
 		"Network speed as percentage of capacity")
@@ -128,6 +132,7 @@ var subcommands = []commands.Command{
 	{"boost-scan-limit", "", 0, 0, boostScanLimitSubcommand},
 	{"cleanup", "", 0, 0, cleanupSubcommand},
 	{"delete", "pathname...", 1, 1, deleteSubcommand},
+	{"drift", "", 0, 0, driftSubcommand},
 	{"fetch", "hashesFile", 1, 1, fetchSubcommand},
 	{"fetch-image", "image", 1, 1, fetchImageSubcommand},
 	{"get-config", "", 0, 0, getConfigSubcommand},
@@ -347,6 +347,7 @@ func pollFetchPushAndUpdate(subObj *domlib.Sub, img *image.Image,
 	showTimeTaken(startTime)
 	expectDisconnect := expectUpdateToDisconnect(updateRequest)
 	updateRequest.ImageName = imageName
+	updateRequest.ImageFilter = img.Filter
 	updateRequest.Wait = true
 	stopTicker := make(chan struct{}, 1)
 	if !*showTimes {
@@ -84,7 +84,7 @@ var (
 	machineType      hyper_proto.MachineType
 	memory           flagutil.Size
 	milliCPUs        = flag.Uint("milliCPUs", 0, "milli CPUs (default 250)")
-	placement        placementType
+	placement        = placementType(placementChoiceServer)
 	placementCommand = flag.String("placementCommand", "",
 		"Command to make placement decisions when creating/copying/moving VM")
 	minFreeBytes     = flagutil.Size(256 << 20)
@@ -256,12 +256,14 @@ var subcommands = []commands.Command{
 		restoreVmFromSnapshotSubcommand},
 	{"restore-vm-image", "IPaddr", 1, 1, restoreVmImageSubcommand},
 	{"restore-vm-user-data", "IPaddr", 1, 1, restoreVmUserDataSubcommand},
+	{"resume-vm", "IPaddr", 1, 1, resumeVmSubcommand},
 	{"save-vm", "IPaddr destination", 2, 2, saveVmSubcommand},
 	{"scan-vm-root", "IPaddr", 1, 1, scanVmRootSubcommand},
 	{"set-vm-migrating", "IPaddr", 1, 1, setVmMigratingSubcommand},
 	{"snapshot-vm", "IPaddr", 1, 1, snapshotVmSubcommand},
 	{"start-vm", "IPaddr", 1, 1, startVmSubcommand},
 	{"stop-vm", "IPaddr", 1, 1, stopVmSubcommand},
+	{"suspend-vm", "IPaddr", 1, 1, suspendVmSubcommand},
 	{"trace-vm-metadata", "IPaddr", 1, 1, traceVmMetadataSubcommand},
 	{"unset-vm-migrating", "IPaddr", 1, 1, unsetVmMigratingSubcommand},
 }
@@ -66,7 +66,13 @@ func migrateVmFromHypervisor(sourceHypervisorAddress string, vmIP net.IP,
 		return err
 	}
 	defer discardAccessToken(sourceHypervisor, vmIP)
-	destHypervisorAddress, err := getHypervisorAddress(vmInfo)
+	sourceHypervisorHostname, _, err := net.SplitHostPort(
+		sourceHypervisorAddress)
+	if err != nil {
+		return err
+	}
+	destHypervisorAddress, err := getHypervisorAddress(vmInfo,
+		[]string{sourceHypervisorHostname})
 	if err != nil {
 		return err
 	}
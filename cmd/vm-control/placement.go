@@ -10,9 +10,11 @@ import (
 	"sort"
 	"time"
 
+	fm_client "github.com/Cloud-Foundations/Dominator/fleetmanager/client"
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
 	"github.com/Cloud-Foundations/Dominator/lib/json"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/stringutil"
 	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
 	hyper_proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
 )
@@ -30,6 +32,7 @@ const (
 	placementChoiceEmptiest
 	placmentChoiceFullest
 	placementChoiceRandom
+	placementChoiceServer
 
 	placementTypeUnknown = "UNKNOWN placementType"
 )
@@ -41,6 +44,7 @@ var (
 		placementChoiceEmptiest: "emptiest",
 		placmentChoiceFullest:   "fullest",
 		placementChoiceRandom:   "random",
+		placementChoiceServer:   "server",
 	}
 	textToPlacementType map[string]placementType
 )
@@ -73,6 +77,21 @@ func compareStorage(hypervisors []fm_proto.Hypervisor, i, j int) bool {
 		hypervisors[j].TotalVolumeBytes-hypervisors[j].AllocatedVolumeBytes
 }
 
+func excludeHypervisorsFromList(inputHypervisors []fm_proto.Hypervisor,
+	excludeHypervisors []string) []fm_proto.Hypervisor {
+	if len(excludeHypervisors) < 1 {
+		return inputHypervisors
+	}
+	excludeMap := stringutil.ConvertListToMap(excludeHypervisors, false)
+	outputHypervisors := make([]fm_proto.Hypervisor, 0, len(inputHypervisors))
+	for _, h := range inputHypervisors {
+		if _, ok := excludeMap[h.Hostname]; !ok {
+			outputHypervisors = append(outputHypervisors, h)
+		}
+	}
+	return outputHypervisors
+}
+
 func findHypervisorsWithCapacity(inputHypervisors []fm_proto.Hypervisor,
 	vmInfo hyper_proto.VmInfo) []fm_proto.Hypervisor {
 	outputHypervisors := make([]fm_proto.Hypervisor, 0, len(inputHypervisors))
@@ -96,7 +115,8 @@ func findHypervisorsWithCapacity(inputHypervisors []fm_proto.Hypervisor,
 	return outputHypervisors
 }
 
-func getHypervisorAddress(vmInfo hyper_proto.VmInfo) (string, error) {
+func getHypervisorAddress(vmInfo hyper_proto.VmInfo,
+	excludeHypervisors []string) (string, error) {
 	if *hypervisorHostname != "" {
 		return fmt.Sprintf("%s:%d", *hypervisorHostname, *hypervisorPortNum),
 			nil
@@ -117,6 +137,15 @@ func getHypervisorAddress(vmInfo hyper_proto.VmInfo) (string, error) {
 	if placement == placementChoiceAny { // Really dumb placement.
 		return selectAnyHypervisor(client)
 	}
+	if placement == placementChoiceServer {
+		return fm_client.PlaceVm(client, fm_proto.PlaceVmRequest{
+			ExcludeHypervisors:    excludeHypervisors,
+			HypervisorTagsToMatch: hypervisorTagsToMatch,
+			Location:              *location,
+			SubnetId:              *subnetId,
+			VmInfo:                vmInfo,
+		})
+	}
 	request := fm_proto.GetHypervisorsInLocationRequest{
 		HypervisorTagsToMatch: hypervisorTagsToMatch,
 		IncludeVMs:            placement == placementChoiceCommand,
@@ -132,7 +161,9 @@ func getHypervisorAddress(vmInfo hyper_proto.VmInfo) (string, error) {
 	if reply.Error != "" {
 		return "", errors.New(reply.Error)
 	}
-	hypervisors := findHypervisorsWithCapacity(reply.Hypervisors, vmInfo)
+	hypervisors := findHypervisorsWithCapacity(
+		excludeHypervisorsFromList(reply.Hypervisors, excludeHypervisors),
+		vmInfo)
 	hypervisor, err := selectHypervisor(client, hypervisors, vmInfo)
 	if err != nil {
 		return "", err
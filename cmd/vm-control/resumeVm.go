@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	hyperclient "github.com/Cloud-Foundations/Dominator/hypervisor/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func resumeVmSubcommand(args []string, logger log.DebugLogger) error {
+	if err := resumeVm(args[0], logger); err != nil {
+		return fmt.Errorf("error resuming VM: %s", err)
+	}
+	return nil
+}
+
+func resumeVm(vmHostname string, logger log.DebugLogger) error {
+	if vmIP, hypervisor, err := lookupVmAndHypervisor(vmHostname); err != nil {
+		return err
+	} else {
+		return resumeVmOnHypervisor(hypervisor, vmIP, logger)
+	}
+}
+
+func resumeVmOnHypervisor(hypervisor string, ipAddr net.IP,
+	logger log.DebugLogger) error {
+	client, err := dialHypervisor(hypervisor)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	logVmName(client, ipAddr, "resuming", logger)
+	return hyperclient.ResumeVm(client, ipAddr, nil)
+}
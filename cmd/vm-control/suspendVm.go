@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	hyperclient "github.com/Cloud-Foundations/Dominator/hypervisor/client"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+func suspendVmSubcommand(args []string, logger log.DebugLogger) error {
+	if err := suspendVm(args[0], logger); err != nil {
+		return fmt.Errorf("error suspending VM: %s", err)
+	}
+	return nil
+}
+
+func suspendVm(vmHostname string, logger log.DebugLogger) error {
+	if vmIP, hypervisor, err := lookupVmAndHypervisor(vmHostname); err != nil {
+		return err
+	} else {
+		return suspendVmOnHypervisor(hypervisor, vmIP, logger)
+	}
+}
+
+func suspendVmOnHypervisor(hypervisor string, ipAddr net.IP,
+	logger log.DebugLogger) error {
+	client, err := dialHypervisor(hypervisor)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	logVmName(client, ipAddr, "suspending", logger)
+	return hyperclient.SuspendVm(client, ipAddr, nil)
+}
@@ -7,6 +7,10 @@ import (
 	subproto "github.com/Cloud-Foundations/Dominator/proto/sub"
 )
 
+func AbortDefaultImageRollout(client srpc.ClientI) error {
+	return abortDefaultImageRollout(client)
+}
+
 func ClearSafetyShutoff(client srpc.ClientI, subHostname string) error {
 	return clearSafetyShutoff(client, subHostname)
 }
@@ -37,11 +41,21 @@ func GetDefaultImage(client srpc.ClientI) (string, error) {
 	return getDefaultImage(client)
 }
 
+func GetDefaultImageRollout(client srpc.ClientI) (
+	*proto.DefaultImageRolloutStatus, error) {
+	return getDefaultImageRollout(client)
+}
+
 func GetInfoForSubs(client srpc.ClientI, request proto.GetInfoForSubsRequest) (
 	proto.GetInfoForSubsResponse, error) {
 	return getInfoForSubs(client, request)
 }
 
+func GetPlannedUpdate(client srpc.ClientI,
+	request proto.GetPlannedUpdateRequest) (*proto.PlannedUpdate, error) {
+	return getPlannedUpdate(client, request)
+}
+
 func GetSubsConfiguration(client srpc.ClientI) (subproto.Configuration, error) {
 	return getSubsConfiguration(client)
 }
@@ -51,6 +65,19 @@ func ListSubs(client srpc.ClientI, request proto.ListSubsRequest) (
 	return listSubs(client, request)
 }
 
+func PauseDefaultImageRollout(client srpc.ClientI, reason string) error {
+	return pauseDefaultImageRollout(client, reason)
+}
+
+func ResumeDefaultImageRollout(client srpc.ClientI) error {
+	return resumeDefaultImageRollout(client)
+}
+
 func SetDefaultImage(client srpc.ClientI, imageName string) error {
 	return setDefaultImage(client, imageName)
 }
+
+func StartDefaultImageRollout(client srpc.ClientI,
+	plan proto.DefaultImageRolloutPlan) error {
+	return startDefaultImageRollout(client, plan)
+}
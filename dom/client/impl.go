@@ -10,6 +10,13 @@ import (
 	subproto "github.com/Cloud-Foundations/Dominator/proto/sub"
 )
 
+func abortDefaultImageRollout(client srpc.ClientI) error {
+	var request proto.AbortDefaultImageRolloutRequest
+	var reply proto.AbortDefaultImageRolloutResponse
+	return client.RequestReply("Dominator.AbortDefaultImageRollout", request,
+		&reply)
+}
+
 func clearSafetyShutoff(client srpc.ClientI, subHostname string) error {
 	request := proto.ClearSafetyShutoffRequest{Hostname: subHostname}
 	var reply proto.ClearSafetyShutoffResponse
@@ -88,6 +95,18 @@ func getDefaultImage(client srpc.ClientI) (string, error) {
 	return reply.ImageName, nil
 }
 
+func getDefaultImageRollout(client srpc.ClientI) (
+	*proto.DefaultImageRolloutStatus, error) {
+	var request proto.GetDefaultImageRolloutRequest
+	var reply proto.GetDefaultImageRolloutResponse
+	err := client.RequestReply("Dominator.GetDefaultImageRollout", request,
+		&reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Rollout, nil
+}
+
 func getInfoForSubs(client srpc.ClientI, request proto.GetInfoForSubsRequest) (
 	proto.GetInfoForSubsResponse, error) {
 	var reply proto.GetInfoForSubsResponse
@@ -101,6 +120,19 @@ func getInfoForSubs(client srpc.ClientI, request proto.GetInfoForSubsRequest) (
 	return reply, nil
 }
 
+func getPlannedUpdate(client srpc.ClientI,
+	request proto.GetPlannedUpdateRequest) (*proto.PlannedUpdate, error) {
+	var reply proto.GetPlannedUpdateResponse
+	err := client.RequestReply("Dominator.GetPlannedUpdate", request, &reply)
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.New(reply.Error); err != nil {
+		return nil, err
+	}
+	return reply.Update, nil
+}
+
 func getSubsConfiguration(client srpc.ClientI) (subproto.Configuration, error) {
 	var request proto.GetSubsConfigurationRequest
 	var reply proto.GetSubsConfigurationResponse
@@ -125,9 +157,34 @@ func listSubs(client srpc.ClientI, request proto.ListSubsRequest) (
 	return reply.Hostnames, nil
 }
 
+func pauseDefaultImageRollout(client srpc.ClientI, reason string) error {
+	if reason == "" {
+		return errors.New("cannot pause rollout: no reason given")
+	}
+	request := proto.PauseDefaultImageRolloutRequest{Reason: reason}
+	var reply proto.PauseDefaultImageRolloutResponse
+	return client.RequestReply("Dominator.PauseDefaultImageRollout", request,
+		&reply)
+}
+
+func resumeDefaultImageRollout(client srpc.ClientI) error {
+	var request proto.ResumeDefaultImageRolloutRequest
+	var reply proto.ResumeDefaultImageRolloutResponse
+	return client.RequestReply("Dominator.ResumeDefaultImageRollout", request,
+		&reply)
+}
+
 func setDefaultImage(client srpc.ClientI, imageName string) error {
 	request := proto.SetDefaultImageRequest{ImageName: imageName}
 	var reply proto.SetDefaultImageResponse
 	err := client.RequestReply("Dominator.SetDefaultImage", request, &reply)
 	return err
 }
+
+func startDefaultImageRollout(client srpc.ClientI,
+	plan proto.DefaultImageRolloutPlan) error {
+	request := proto.StartDefaultImageRolloutRequest(plan)
+	var reply proto.StartDefaultImageRolloutResponse
+	return client.RequestReply("Dominator.StartDefaultImageRollout", request,
+		&reply)
+}
@@ -124,6 +124,7 @@ type Sub struct {
 	lastPollWasFull              bool
 	lastScanDuration             time.Duration
 	lastComputeUpdateCpuDuration time.Duration
+	lastUpdateHadTriggerFailures bool
 	lastUpdateTime               time.Time
 	lastSyncTime                 time.Time
 	lastSuccessfulImageName      string
@@ -148,6 +149,7 @@ type Herd struct {
 	updatesDisabledTime      time.Time
 	defaultImageName         string
 	nextDefaultImageName     string
+	rollout                  *rolloutType
 	configurationForSubs     subproto.Configuration
 	nextSubToPoll            uint
 	subsByName               map[string]*Sub
@@ -180,6 +182,10 @@ func (herd *Herd) AddHtmlWriter(htmlWriter HtmlWriter) {
 	herd.addHtmlWriter(htmlWriter)
 }
 
+func (herd *Herd) AbortDefaultImageRollout(username string) error {
+	return herd.abortDefaultImageRollout(username)
+}
+
 func (herd *Herd) ClearSafetyShutoff(hostname string,
 	authInfo *srpc.AuthInformation) error {
 	return herd.clearSafetyShutoff(hostname, authInfo)
@@ -211,6 +217,17 @@ func (herd *Herd) GetDefaultImage() string {
 	return herd.defaultImageName
 }
 
+func (herd *Herd) GetDefaultImageRollout() *domproto.DefaultImageRolloutStatus {
+	return herd.getDefaultImageRollout()
+}
+
+// GetPlannedUpdate will compute the update which would be sent to a sub,
+// without sending it. The sub is polled to get its current file-system.
+func (herd *Herd) GetPlannedUpdate(request domproto.GetPlannedUpdateRequest) (
+	*domproto.PlannedUpdate, error) {
+	return herd.getPlannedUpdate(request)
+}
+
 func (herd *Herd) GetSubsConfiguration() subproto.Configuration {
 	return herd.getSubsConfiguration()
 }
@@ -232,10 +249,18 @@ func (herd *Herd) MdbUpdate(mdb *mdb.Mdb) {
 	herd.mdbUpdate(mdb)
 }
 
+func (herd *Herd) PauseDefaultImageRollout(username, reason string) error {
+	return herd.pauseDefaultImageRollout(username, reason)
+}
+
 func (herd *Herd) PollNextSub() bool {
 	return herd.pollNextSub()
 }
 
+func (herd *Herd) ResumeDefaultImageRollout(username string) error {
+	return herd.resumeDefaultImageRollout(username)
+}
+
 func (herd *Herd) RLockWithTimeout(timeout time.Duration) {
 	herd.rLockWithTimeout(timeout)
 }
@@ -244,6 +269,11 @@ func (herd *Herd) SetDefaultImage(imageName string) error {
 	return herd.setDefaultImage(imageName)
 }
 
+func (herd *Herd) StartDefaultImageRollout(
+	plan domproto.DefaultImageRolloutPlan, username string) error {
+	return herd.startDefaultImageRollout(plan, username)
+}
+
 func (herd *Herd) StartServer(portNum uint, daemon bool) error {
 	return herd.startServer(portNum, daemon)
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/Cloud-Foundations/Dominator/dom/images"
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
 	"github.com/Cloud-Foundations/Dominator/lib/cpusharer"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	filegenclient "github.com/Cloud-Foundations/Dominator/lib/filegen/client"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	libnet "github.com/Cloud-Foundations/Dominator/lib/net"
 	"github.com/Cloud-Foundations/Dominator/lib/net/reverseconnection"
@@ -24,10 +26,24 @@ import (
 )
 
 var (
+	blockImageVulnerabilitySeverity  vulnerability.Severity
+	defaultImageRolloutCheckInterval = flag.Duration(
+		"defaultImageRolloutCheckInterval", time.Minute,
+		"Interval between checks of the progress of a default image rollout")
+	chunkedFetch = flag.Bool("chunkedFetch", false,
+		"If true, subs fetch only the changed chunks of large objects")
 	disableUpdatesAtStartup = flag.Bool("disableUpdatesAtStartup", false,
 		"If true, updates are disabled at startup")
+	imageVulnerabilityCheckInterval = flag.Duration(
+		"imageVulnerabilityCheckInterval", time.Hour,
+		"Interval between re-checks of the vulnerabilities of loaded images. If zero, images are only checked when loaded")
+	imageSigningKeysFile = flag.String("imageSigningKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign images (default do not verify)")
 	pollSlotsPerCPU = flag.Uint("pollSlotsPerCPU", 100,
 		"Number of poll slots per CPU")
+	requireImageVulnerabilityCheck = flag.Bool(
+		"requireImageVulnerabilityCheck", false,
+		"If true, do not push images if the imageserver has no advisory database (default warn and push)")
 	subConnectTimeout = flag.Uint("subConnectTimeout", 15,
 		"Timeout in seconds for sub connections. If zero, OS timeout is used")
 	subdInstallDelay = flag.Duration("subdInstallDelay", 5*time.Minute,
@@ -38,10 +54,32 @@ var (
 		"Path to programme used to install subd if connections fail")
 )
 
+func init() {
+	flag.Var(&blockImageVulnerabilitySeverity,
+		"blockImageVulnerabilitySeverity",
+		"Do not push images with vulnerabilities at least this severe (low, medium, high or critical) (default do not block)")
+}
+
 func newHerd(imageServerAddress string, objectServer objectserver.ObjectServer,
 	metricsDir *tricorder.DirectorySpec, logger log.DebugLogger) *Herd {
 	var herd Herd
-	herd.imageManager = images.New(imageServerAddress, logger)
+	var signatureVerifier *dsse.Verifier
+	if *imageSigningKeysFile != "" {
+		var err error
+		signatureVerifier, err = dsse.LoadVerifier(*imageSigningKeysFile)
+		if err != nil {
+			logger.Fatalf("Cannot load image signing keys: %s\n", err)
+		}
+	}
+	herd.imageManager = images.NewWithConfigAndParams(
+		images.Config{
+			BlockVulnerabilitySeverity: blockImageVulnerabilitySeverity,
+			ImageServerAddress:         imageServerAddress,
+			RequireVulnerabilityCheck:  *requireImageVulnerabilityCheck,
+			SignatureVerifier:          signatureVerifier,
+			VulnerabilityCheckInterval: *imageVulnerabilityCheckInterval,
+		},
+		images.Params{Logger: logger})
 	herd.objectServer = objectServer
 	herd.computedFilesManager = filegenclient.New(objectServer, logger)
 	herd.logger = logger
@@ -63,6 +101,7 @@ func newHerd(imageServerAddress string, objectServer objectserver.ObjectServer,
 		herd.cpuSharer)
 	herd.currentScanStartTime = time.Now()
 	herd.setupMetrics(metricsDir)
+	go herd.rolloutLoop()
 	go herd.subdInstallerLoop()
 	return &herd
 }
@@ -89,6 +128,8 @@ func (herd *Herd) disableUpdates(username, reason string) error {
 	if reason == "" {
 		return errors.New("error disabling updates: no reason given")
 	}
+	herd.Lock()
+	defer herd.Unlock()
 	herd.updatesDisabledBy = username
 	herd.updatesDisabledReason = "because: " + reason
 	herd.updatesDisabledTime = time.Now()
@@ -96,6 +137,8 @@ func (herd *Herd) disableUpdates(username, reason string) error {
 }
 
 func (herd *Herd) enableUpdates() error {
+	herd.Lock()
+	defer herd.Unlock()
 	herd.updatesDisabledReason = ""
 	return nil
 }
@@ -225,6 +268,9 @@ func (herd *Herd) setDefaultImage(imageName string) error {
 	if imageName == "" {
 		herd.Lock()
 		defer herd.Unlock()
+		if err := herd.checkNoActiveRolloutWithLock(); err != nil {
+			return err
+		}
 		herd.defaultImageName = ""
 		// Cancel blocking operations by affected subs.
 		for _, sub := range herd.subsByIndex {
@@ -235,9 +281,32 @@ func (herd *Herd) setDefaultImage(imageName string) error {
 		}
 		return nil
 	}
+	// Fail early before checking the image, and check again with the lock held
+	// since a rollout may have started meanwhile.
+	if err := herd.checkNoActiveRollout(); err != nil {
+		return err
+	}
 	if imageName == herd.defaultImageName {
 		return nil
 	}
+	if err := herd.checkDefaultImage(imageName); err != nil {
+		return err
+	}
+	herd.Lock()
+	defer herd.Unlock()
+	herd.nextDefaultImageName = ""
+	if err := herd.checkNoActiveRolloutWithLock(); err != nil {
+		return err
+	}
+	herd.defaultImageName = imageName
+	herd.resetSubsUsingDefaultImage(nil)
+	return nil
+}
+
+// checkDefaultImage will check if an image may be used as a default image. On
+// success, the image is recorded in nextDefaultImageName so that it is not
+// dropped by an MDB update and the caller must clear this with the lock held.
+func (herd *Herd) checkDefaultImage(imageName string) error {
 	herd.Lock()
 	herd.nextDefaultImageName = imageName
 	herd.Unlock()
@@ -263,22 +332,28 @@ func (herd *Herd) setDefaultImage(imageName string) error {
 		return errors.New("cannot set default image with more than 100 inodes")
 	}
 	doLockedCleanup = false
-	herd.Lock()
-	defer herd.Unlock()
-	herd.defaultImageName = imageName
-	herd.nextDefaultImageName = ""
+	return nil
+}
+
+// resetSubsUsingDefaultImage will cancel blocking operations by subs which use
+// the default image and which are selected by selectFunc (if nil, all such subs
+// are selected), so that they will switch images. The lock must be held.
+func (herd *Herd) resetSubsUsingDefaultImage(selectFunc func(*Sub) bool) {
 	for _, sub := range herd.subsByIndex {
-		if sub.mdb.RequiredImage == "" {
-			sub.sendCancel()
-			if sub.status == statusSynced { // Synced to previous default image.
-				sub.status = statusWaitingToPoll
-			}
-			if sub.status == statusImageUndefined {
-				sub.status = statusWaitingToPoll
-			}
+		if sub.mdb.RequiredImage != "" {
+			continue
+		}
+		if selectFunc != nil && !selectFunc(sub) {
+			continue
+		}
+		sub.sendCancel()
+		if sub.status == statusSynced { // Synced to previous default image.
+			sub.status = statusWaitingToPoll
+		}
+		if sub.status == statusImageUndefined {
+			sub.status = statusWaitingToPoll
 		}
 	}
-	return nil
 }
 
 func timeoutFunction(f func(), timeout time.Duration) {
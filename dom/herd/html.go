@@ -84,6 +84,7 @@ func (herd *Herd) writeHtml(writer io.Writer) {
 			"Default image: <a href=\"http://%s/showImage?%s\">%s</a><br>\n",
 			herd.imageManager, herd.defaultImageName, herd.defaultImageName)
 	}
+	herd.writeRolloutHtml(writer)
 	fmt.Fprintf(writer,
 		"Number of <a href=\"listSubs\">subs</a>: <a href=\"showAllSubs\">%d</a>",
 		numSubs)
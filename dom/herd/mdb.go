@@ -59,6 +59,9 @@ func (herd *Herd) mdbUpdateGetLock(mdb *mdb.Mdb) (
 	wantedImages := make(map[string]struct{})
 	wantedImages[herd.defaultImageName] = struct{}{}
 	wantedImages[herd.nextDefaultImageName] = struct{}{}
+	if herd.rollout != nil && herd.rollout.State.IsActive() {
+		wantedImages[herd.rollout.ImageName] = struct{}{}
+	}
 	for _, machine := range mdb.Machines { // Sorted by Hostname.
 		if machine.Hostname == "" {
 			herd.logger.Printf("Empty Hostname field, ignoring \"%s\"\n",
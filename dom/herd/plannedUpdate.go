@@ -0,0 +1,230 @@
+package herd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Cloud-Foundations/Dominator/dom/lib"
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
+	domproto "github.com/Cloud-Foundations/Dominator/proto/dominator"
+	subproto "github.com/Cloud-Foundations/Dominator/proto/sub"
+	"github.com/Cloud-Foundations/Dominator/sub/client"
+)
+
+// copyTriggers returns a copy of the triggers, so that matching does not
+// modify triggers which are shared with images.
+func copyTriggers(trig *triggers.Triggers) *triggers.Triggers {
+	newTriggers := triggers.New()
+	if trig == nil {
+		return newTriggers
+	}
+	for _, trigger := range trig.Triggers {
+		newTriggers.Triggers = append(newTriggers.Triggers, &triggers.Trigger{
+			MatchLines: trigger.MatchLines,
+			Service:    trigger.Service,
+			SortName:   trigger.SortName,
+			DoReboot:   trigger.DoReboot,
+			HighImpact: trigger.HighImpact,
+		})
+	}
+	return newTriggers
+}
+
+// makePlannedUpdate summarises an update request. The old triggers are those
+// for the image currently on the sub.
+func makePlannedUpdate(subFS *filesystem.FileSystem,
+	request subproto.UpdateRequest,
+	oldTriggers *triggers.Triggers) *domproto.PlannedUpdate {
+	update := &domproto.PlannedUpdate{ImageName: request.ImageName}
+	oldTriggers = copyTriggers(oldTriggers)
+	newTriggers := copyTriggers(request.Triggers)
+	subFilenames := subFS.FilenameToInodeTable()
+	changes := make(map[string]*domproto.PlannedFileChange)
+	match := func(name string) {
+		oldTriggers.Match(name)
+		newTriggers.Match(name)
+	}
+	addOrChange := func(name string) {
+		match(name)
+		if _, ok := subFilenames[name]; !ok {
+			update.FilesAdded = append(update.FilesAdded, name)
+		} else if change, ok := changes[name]; ok {
+			change.Data = true
+		} else {
+			changes[name] = &domproto.PlannedFileChange{Name: name, Data: true}
+		}
+	}
+	for _, inode := range request.DirectoriesToMake {
+		if _, ok := subFilenames[inode.Name]; ok {
+			match(inode.Name)
+			changes[inode.Name] = &domproto.PlannedFileChange{
+				Name:     inode.Name,
+				Metadata: true,
+			}
+		} else {
+			addOrChange(inode.Name)
+		}
+	}
+	for _, inode := range request.InodesToMake {
+		addOrChange(inode.Name)
+	}
+	for _, hardlink := range request.HardlinksToMake {
+		addOrChange(hardlink.NewLink)
+	}
+	for _, pathname := range request.PathsToDelete {
+		match(pathname)
+		update.FilesRemoved = append(update.FilesRemoved, pathname)
+	}
+	for _, inode := range request.InodesToChange {
+		match(inode.Name)
+		if change, ok := changes[inode.Name]; ok {
+			change.Metadata = true
+		} else {
+			changes[inode.Name] = &domproto.PlannedFileChange{
+				Name:     inode.Name,
+				Metadata: true,
+			}
+		}
+	}
+	for _, change := range changes {
+		update.FilesChanged = append(update.FilesChanged, *change)
+	}
+	sort.Strings(update.FilesAdded)
+	sort.Slice(update.FilesChanged, func(left, right int) bool {
+		return update.FilesChanged[left].Name < update.FilesChanged[right].Name
+	})
+	sort.Strings(update.FilesRemoved)
+	for _, trigger := range oldTriggers.GetMatchedTriggers() {
+		update.TriggersToStop = append(update.TriggersToStop, *trigger)
+		if trigger.HighImpact {
+			update.Disruptive = true
+		}
+	}
+	for _, trigger := range newTriggers.GetMatchedTriggers() {
+		update.TriggersToStart = append(update.TriggersToStart, *trigger)
+		if trigger.DoReboot {
+			update.Disruptive = true
+			update.Reboot = true
+		}
+		if trigger.HighImpact {
+			update.Disruptive = true
+		}
+	}
+	return update
+}
+
+func (herd *Herd) getPlannedUpdate(request domproto.GetPlannedUpdateRequest) (
+	*domproto.PlannedUpdate, error) {
+	herd.RLock()
+	sub, ok := herd.subsByName[request.Hostname]
+	if !ok {
+		herd.RUnlock()
+		return nil, errors.New("unknown sub: " + request.Hostname)
+	}
+	machine := sub.mdb
+	updatesDisabled := machine.DisableUpdates ||
+		herd.updatesDisabledReason != ""
+	herd.RUnlock()
+	return sub.getPlannedUpdate(request.ImageName, machine, updatesDisabled)
+}
+
+// getPlannedUpdate computes the update for the sub. The MDB data for the sub
+// and whether updates are disabled must be read with the herd lock held.
+func (sub *Sub) getPlannedUpdate(imageName string, machine mdb.Machine,
+	updatesDisabled bool) (*domproto.PlannedUpdate, error) {
+	sub.makeBusy()
+	defer sub.makeUnbusy()
+	if imageName == "" {
+		imageName = machine.RequiredImage
+		if imageName == "" {
+			imageName = sub.herd.getDefaultImageForSub(sub)
+		}
+		if imageName == "" {
+			return nil, errors.New("no image for sub")
+		}
+	}
+	img, err := sub.herd.imageManager.Get(imageName, true)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, fmt.Errorf("image: %s does not exist", imageName)
+	}
+	if sub.clientResource == nil {
+		sub.clientResource = srpc.NewClientResource("tcp", sub.address())
+	}
+	srpcClient, err := sub.clientResource.GetHTTPWithDialer(nil,
+		sub.herd.dialer)
+	if err != nil {
+		return nil, err
+	}
+	defer srpcClient.Put()
+	var pollReply subproto.PollResponse
+	err = client.CallPoll(srpcClient, subproto.PollRequest{}, &pollReply)
+	if err != nil {
+		srpcClient.Close()
+		return nil, err
+	}
+	fs := pollReply.FileSystem
+	if fs == nil {
+		return nil, errors.New("sub not ready")
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		return nil, err
+	}
+	fs.BuildEntryMap()
+	// The computed inodes are for the required image, so they are omitted for
+	// any other image and its computed files are reported as missing.
+	var computedInodes map[string]*filesystem.RegularInode
+	if imageName == sub.requiredImageName {
+		computedInodes = sub.computedInodes
+	}
+	var missingComputedFiles []string
+	for _, computedFile := range sub.getComputedFiles(img) {
+		if _, ok := computedInodes[computedFile.Pathname]; !ok {
+			missingComputedFiles = append(missingComputedFiles,
+				computedFile.Pathname)
+		}
+	}
+	// Pretend that all objects have been fetched, since a planned update does
+	// not fetch objects.
+	objectCache := pollReply.ObjectCache
+	subHashes := fs.HashToInodesTable()
+	addObject := func(hashVal hash.Hash) {
+		if _, ok := subHashes[hashVal]; !ok {
+			objectCache = append(objectCache, hashVal)
+		}
+	}
+	for hashVal := range img.FileSystem.GetObjects() {
+		addObject(hashVal)
+	}
+	for _, inode := range computedInodes {
+		addObject(inode.Hash)
+	}
+	subObj := lib.Sub{
+		Hostname:       machine.Hostname,
+		FileSystem:     fs,
+		ComputedInodes: computedInodes,
+		ObjectCache:    objectCache,
+	}
+	var updateRequest subproto.UpdateRequest
+	lib.BuildUpdateRequest(subObj, img, &updateRequest, false, true,
+		sub.herd.logger)
+	updateRequest.ImageName = imageName
+	var oldTriggers *triggers.Triggers
+	if oldImage := sub.herd.imageManager.GetNoError(
+		pollReply.LastSuccessfulImageName); oldImage != nil {
+		oldTriggers = oldImage.Triggers
+	}
+	update := makePlannedUpdate(fs, updateRequest, oldTriggers)
+	update.Hostname = machine.Hostname
+	update.LastSuccessfulImageName = pollReply.LastSuccessfulImageName
+	update.MissingComputedFiles = missingComputedFiles
+	update.UpdatesDisabled = updatesDisabled
+	return update, nil
+}
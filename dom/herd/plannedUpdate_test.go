@@ -0,0 +1,161 @@
+package herd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
+	domproto "github.com/Cloud-Foundations/Dominator/proto/dominator"
+	subproto "github.com/Cloud-Foundations/Dominator/proto/sub"
+)
+
+func makeTestSubFileSystem(t *testing.T) *filesystem.FileSystem {
+	fs := &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			1: &filesystem.DirectoryInode{
+				EntryList: []*filesystem.DirectoryEntry{
+					{Name: "sshd_config", InodeNumber: 2},
+				},
+			},
+			2: &filesystem.RegularInode{Size: 1},
+			3: &filesystem.RegularInode{Size: 2},
+		},
+		DirectoryInode: filesystem.DirectoryInode{
+			EntryList: []*filesystem.DirectoryEntry{
+				{Name: "etc", InodeNumber: 1},
+				{Name: "old", InodeNumber: 3},
+			},
+		},
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func makeTestTriggers(trigs ...triggers.Trigger) *triggers.Triggers {
+	newTriggers := triggers.New()
+	for index := range trigs {
+		newTriggers.Triggers = append(newTriggers.Triggers, &trigs[index])
+	}
+	return newTriggers
+}
+
+func triggerServices(trigs []triggers.Trigger) []string {
+	var services []string
+	for _, trigger := range trigs {
+		services = append(services, trigger.Service)
+	}
+	return services
+}
+
+func TestMakePlannedUpdate(t *testing.T) {
+	legacyTrigger := triggers.Trigger{
+		MatchLines: []string{"/old"},
+		Service:    "legacy",
+		HighImpact: true,
+	}
+	rebootTrigger := triggers.Trigger{
+		MatchLines: []string{"/boot/.*"},
+		Service:    "kernel",
+		DoReboot:   true,
+	}
+	sshdTrigger := triggers.Trigger{
+		MatchLines: []string{"/etc/sshd_config"},
+		Service:    "sshd",
+	}
+	tests := []struct {
+		name            string
+		request         subproto.UpdateRequest
+		oldTriggers     *triggers.Triggers
+		expected        domproto.PlannedUpdate
+		triggersToStop  []string
+		triggersToStart []string
+	}{
+		{
+			name:    "empty",
+			request: subproto.UpdateRequest{ImageName: "image"},
+			expected: domproto.PlannedUpdate{
+				ImageName: "image",
+			},
+		},
+		{
+			name: "changes",
+			request: subproto.UpdateRequest{
+				ImageName: "image",
+				DirectoriesToMake: []subproto.Inode{
+					{Name: "/etc"},
+					{Name: "/var"},
+				},
+				InodesToMake: []subproto.Inode{
+					{Name: "/etc/sshd_config"},
+					{Name: "/var/new"},
+				},
+				HardlinksToMake: []subproto.Hardlink{
+					{NewLink: "/var/link", Target: "/var/new"},
+				},
+				PathsToDelete:  []string{"/old"},
+				InodesToChange: []subproto.Inode{{Name: "/etc/sshd_config"}},
+				Triggers:       makeTestTriggers(rebootTrigger, sshdTrigger),
+			},
+			oldTriggers: makeTestTriggers(legacyTrigger),
+			expected: domproto.PlannedUpdate{
+				ImageName:  "image",
+				Disruptive: true,
+				FilesAdded: []string{"/var", "/var/link", "/var/new"},
+				FilesChanged: []domproto.PlannedFileChange{
+					{Name: "/etc", Metadata: true},
+					{Name: "/etc/sshd_config", Data: true, Metadata: true},
+				},
+				FilesRemoved: []string{"/old"},
+			},
+			triggersToStop:  []string{"legacy"},
+			triggersToStart: []string{"sshd"},
+		},
+		{
+			name: "reboot",
+			request: subproto.UpdateRequest{
+				ImageName:    "image",
+				InodesToMake: []subproto.Inode{{Name: "/boot/vmlinuz"}},
+				Triggers:     makeTestTriggers(rebootTrigger, sshdTrigger),
+			},
+			oldTriggers: makeTestTriggers(legacyTrigger),
+			expected: domproto.PlannedUpdate{
+				ImageName:  "image",
+				Disruptive: true,
+				Reboot:     true,
+				FilesAdded: []string{"/boot/vmlinuz"},
+			},
+			triggersToStart: []string{"kernel"},
+		},
+	}
+	for _, test := range tests {
+		update := makePlannedUpdate(makeTestSubFileSystem(t), test.request,
+			test.oldTriggers)
+		services := triggerServices(update.TriggersToStop)
+		if !reflect.DeepEqual(services, test.triggersToStop) {
+			t.Errorf("%s: triggers to stop: %v, expected: %v",
+				test.name, services, test.triggersToStop)
+		}
+		services = triggerServices(update.TriggersToStart)
+		if !reflect.DeepEqual(services, test.triggersToStart) {
+			t.Errorf("%s: triggers to start: %v, expected: %v",
+				test.name, services, test.triggersToStart)
+		}
+		update.TriggersToStop = nil
+		update.TriggersToStart = nil
+		if !reflect.DeepEqual(*update, test.expected) {
+			t.Errorf("%s: got: %+v, expected: %+v",
+				test.name, *update, test.expected)
+		}
+		// The triggers in the request may be shared with an image, so they
+		// must not be matched.
+		if test.request.Triggers != nil {
+			matched := test.request.Triggers.GetMatchedTriggers()
+			if len(matched) > 0 {
+				t.Errorf("%s: request triggers were matched", test.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package herd
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/tags/tagmatcher"
+	proto "github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+type rolloutType struct {
+	proto.DefaultImageRolloutStatus
+	tagMatchers []*tagmatcher.TagMatcher // One per stage.
+}
+
+// hostnameBucket returns a stable value in the range [0,100) for a hostname,
+// used to select a percentage of subs.
+func hostnameBucket(hostname string) uint {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(hostname))
+	return uint(hasher.Sum32() % 100)
+}
+
+func (rollout *rolloutType) selectSub(sub *Sub) bool {
+	return rollout.selectSubUpToStage(sub, rollout.CurrentStage)
+}
+
+func (rollout *rolloutType) selectSubUpToStage(sub *Sub, lastStage uint) bool {
+	if sub.mdb.RequiredImage != "" {
+		return false
+	}
+	bucket := hostnameBucket(sub.mdb.Hostname)
+	for index := uint(0); index <= lastStage; index++ {
+		if index >= uint(len(rollout.Stages)) {
+			break
+		}
+		stage := rollout.Stages[index]
+		if stage.Percent > 0 && bucket >= stage.Percent {
+			continue
+		}
+		if rollout.tagMatchers[index].MatchEach(sub.mdb.Tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// getDefaultImageForSub returns the name of the image that a sub without a
+// RequiredImage should use.
+func (herd *Herd) getDefaultImageForSub(sub *Sub) string {
+	herd.RLockWithTimeout(time.Minute)
+	defer herd.RUnlock()
+	if rollout := herd.rollout; rollout != nil && rollout.State.IsActive() {
+		if rollout.selectSub(sub) {
+			return rollout.ImageName
+		}
+	}
+	return herd.defaultImageName
+}
+
+func (herd *Herd) checkNoActiveRollout() error {
+	herd.RLockWithTimeout(time.Minute)
+	defer herd.RUnlock()
+	return herd.checkNoActiveRolloutWithLock()
+}
+
+// checkNoActiveRolloutWithLock returns an error if a rollout is in progress.
+// The lock must be held.
+func (herd *Herd) checkNoActiveRolloutWithLock() error {
+	if herd.rollout != nil && herd.rollout.State.IsActive() {
+		return errors.New("rollout already in progress for: " +
+			herd.rollout.ImageName)
+	}
+	return nil
+}
+
+func (herd *Herd) getDefaultImageRollout() *proto.DefaultImageRolloutStatus {
+	herd.RLockWithTimeout(time.Minute)
+	defer herd.RUnlock()
+	if herd.rollout == nil {
+		return nil
+	}
+	status := herd.rollout.DefaultImageRolloutStatus
+	return &status
+}
+
+func (herd *Herd) abortDefaultImageRollout(username string) error {
+	herd.LockWithTimeout(time.Minute)
+	defer herd.Unlock()
+	rollout := herd.rollout
+	if rollout == nil || !rollout.State.IsActive() {
+		return errors.New("no active rollout")
+	}
+	herd.resetSubsUsingDefaultImage(rollout.selectSub)
+	rollout.State = proto.RolloutStateAborted
+	rollout.Message = "aborted by " + username
+	herd.logger.Printf("Rollout of default image: %s aborted by %s\n",
+		rollout.ImageName, username)
+	return nil
+}
+
+func (herd *Herd) pauseDefaultImageRollout(username, reason string) error {
+	if reason == "" {
+		return errors.New("error pausing rollout: no reason given")
+	}
+	herd.LockWithTimeout(time.Minute)
+	defer herd.Unlock()
+	rollout := herd.rollout
+	if rollout == nil || rollout.State != proto.RolloutStateRunning {
+		return errors.New("no running rollout")
+	}
+	rollout.State = proto.RolloutStatePaused
+	rollout.Message = fmt.Sprintf("paused by %s because: %s", username, reason)
+	herd.logger.Printf("Rollout of default image: %s %s\n",
+		rollout.ImageName, rollout.Message)
+	return nil
+}
+
+func (herd *Herd) resumeDefaultImageRollout(username string) error {
+	herd.LockWithTimeout(time.Minute)
+	defer herd.Unlock()
+	rollout := herd.rollout
+	if rollout == nil || rollout.State != proto.RolloutStatePaused {
+		return errors.New("no paused rollout")
+	}
+	rollout.State = proto.RolloutStateRunning
+	rollout.Message = "resumed by " + username
+	rollout.StageStartTime = time.Now()
+	rollout.StageSyncedTime = time.Time{}
+	herd.logger.Printf("Rollout of default image: %s resumed by %s\n",
+		rollout.ImageName, username)
+	return nil
+}
+
+// makeRollout validates the plan and returns a new rollout. A stage with no
+// tags and a Percent of 0 (or 100) selects every sub, so it may only be the
+// last stage, since later stages would have no effect.
+func makeRollout(plan proto.DefaultImageRolloutPlan) (*rolloutType, error) {
+	if len(plan.Stages) < 1 {
+		return nil, errors.New("no rollout stages specified")
+	}
+	if plan.MinimumSyncedPercent > 100 {
+		return nil, errors.New("MinimumSyncedPercent cannot exceed 100")
+	}
+	if plan.MinimumSyncedPercent < 1 {
+		plan.MinimumSyncedPercent = 100
+	}
+	rollout := &rolloutType{
+		tagMatchers: make([]*tagmatcher.TagMatcher, 0, len(plan.Stages)),
+	}
+	for index, stage := range plan.Stages {
+		if stage.Percent > 100 {
+			return nil, fmt.Errorf("stage: %d: Percent cannot exceed 100",
+				index)
+		}
+		if (stage.Percent == 0 || stage.Percent == 100) &&
+			len(stage.TagsToMatch) < 1 && index+1 < len(plan.Stages) {
+			return nil, fmt.Errorf(
+				"stage: %d: selects all subs but is not the last stage",
+				index)
+		}
+		rollout.tagMatchers = append(rollout.tagMatchers,
+			tagmatcher.New(stage.TagsToMatch, false))
+	}
+	rollout.DefaultImageRolloutPlan = plan
+	return rollout, nil
+}
+
+func (herd *Herd) startDefaultImageRollout(plan proto.DefaultImageRolloutPlan,
+	username string) error {
+	rollout, err := makeRollout(plan)
+	if err != nil {
+		return err
+	}
+	if err := herd.checkNoActiveRollout(); err != nil {
+		return err
+	}
+	if plan.ImageName == herd.defaultImageName {
+		return errors.New("image is already the default image")
+	}
+	if err := herd.checkDefaultImage(plan.ImageName); err != nil {
+		return err
+	}
+	herd.LockWithTimeout(time.Minute)
+	defer herd.Unlock()
+	herd.nextDefaultImageName = ""
+	if err := herd.checkNoActiveRolloutWithLock(); err != nil {
+		return err
+	}
+	rollout.PreviousImageName = herd.defaultImageName
+	rollout.StartedBy = username
+	rollout.StartTime = time.Now()
+	rollout.StageStartTime = rollout.StartTime
+	rollout.State = proto.RolloutStateRunning
+	herd.rollout = rollout
+	herd.resetSubsUsingDefaultImage(rollout.selectSub)
+	herd.logger.Printf("Rollout of default image: %s started by %s\n",
+		rollout.ImageName, username)
+	return nil
+}
+
+func (herd *Herd) rolloutLoop() {
+	for range time.Tick(*defaultImageRolloutCheckInterval) {
+		herd.checkRollout()
+	}
+}
+
+// checkRollout examines the subs in the cohort of a running rollout and either
+// advances it to the next stage, completes it or stops it if too many subs
+// have failed.
+func (herd *Herd) checkRollout() {
+	herd.LockWithTimeout(time.Minute)
+	defer herd.Unlock()
+	rollout := herd.rollout
+	if rollout == nil || rollout.State != proto.RolloutStateRunning {
+		return
+	}
+	var numSubs, numSynced, numFailed uint
+	for _, sub := range herd.subsByIndex {
+		if !rollout.selectSub(sub) {
+			continue
+		}
+		numSubs++
+		if sub.requiredImageName != rollout.ImageName {
+			continue
+		}
+		switch sub.publishedStatus {
+		case statusSynced:
+			numSynced++
+		case statusFailedToUpdate:
+			numFailed++
+			continue
+		}
+		if sub.lastUpdateHadTriggerFailures &&
+			sub.lastSuccessfulImageName == rollout.ImageName {
+			numFailed++
+		}
+	}
+	rollout.NumSubs = numSubs
+	rollout.NumSynced = numSynced
+	rollout.NumFailed = numFailed
+	if numFailed > rollout.MaximumFailures {
+		if rollout.RollBackOnFailure {
+			herd.resetSubsUsingDefaultImage(rollout.selectSub)
+			rollout.State = proto.RolloutStateRolledBack
+		} else {
+			rollout.State = proto.RolloutStatePaused
+		}
+		rollout.Message = fmt.Sprintf(
+			"%s at stage %d: %d failures exceeds maximum: %d",
+			rollout.State, rollout.CurrentStage, numFailed,
+			rollout.MaximumFailures)
+		herd.logger.Printf("Rollout of default image: %s %s\n",
+			rollout.ImageName, rollout.Message)
+		return
+	}
+	if numSynced*100 < numSubs*rollout.MinimumSyncedPercent {
+		rollout.StageSyncedTime = time.Time{}
+		return
+	}
+	if rollout.StageSyncedTime.IsZero() {
+		rollout.StageSyncedTime = time.Now()
+	}
+	soakTime := rollout.Stages[rollout.CurrentStage].SoakTime
+	if time.Since(rollout.StageSyncedTime) < soakTime {
+		return
+	}
+	herd.logger.Printf(
+		"Rollout of default image: %s stage %d completed in %s\n",
+		rollout.ImageName, rollout.CurrentStage,
+		format.Duration(time.Since(rollout.StageStartTime)))
+	if rollout.CurrentStage+1 >= uint(len(rollout.Stages)) {
+		herd.defaultImageName = rollout.ImageName
+		herd.resetSubsUsingDefaultImage(func(sub *Sub) bool {
+			return !rollout.selectSub(sub)
+		})
+		rollout.State = proto.RolloutStateCompleted
+		rollout.Message = "image is now the default image"
+		herd.logger.Printf("Rollout of default image: %s completed\n",
+			rollout.ImageName)
+		return
+	}
+	previousStage := rollout.CurrentStage
+	rollout.CurrentStage++
+	rollout.StageStartTime = time.Now()
+	rollout.StageSyncedTime = time.Time{}
+	rollout.Message = ""
+	herd.resetSubsUsingDefaultImage(func(sub *Sub) bool {
+		return rollout.selectSub(sub) &&
+			!rollout.selectSubUpToStage(sub, previousStage)
+	})
+}
+
+func (herd *Herd) writeRolloutHtml(writer io.Writer) {
+	rollout := herd.getDefaultImageRollout()
+	if rollout == nil {
+		return
+	}
+	fmt.Fprintf(writer,
+		"Default image rollout: <a href=\"http://%s/showImage?%s\">%s</a> %s",
+		herd.imageManager, rollout.ImageName, rollout.ImageName,
+		rollout.State)
+	if rollout.State.IsActive() {
+		fmt.Fprintf(writer, ", stage %d/%d, %d/%d synced, %d failed",
+			rollout.CurrentStage+1, len(rollout.Stages), rollout.NumSynced,
+			rollout.NumSubs, rollout.NumFailed)
+		fmt.Fprintf(writer, ", started %s ago",
+			format.Duration(time.Since(rollout.StartTime)))
+		if rollout.StartedBy != "" {
+			fmt.Fprintf(writer, " by %s", rollout.StartedBy)
+		}
+	}
+	if rollout.Message != "" {
+		fmt.Fprintf(writer, " (%s)", rollout.Message)
+	}
+	fmt.Fprintln(writer, "<br>")
+}
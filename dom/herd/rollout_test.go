@@ -0,0 +1,350 @@
+package herd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+	proto "github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func makeTestHerd(t *testing.T, numSubs int) *Herd {
+	herd := &Herd{
+		defaultImageName: "old",
+		logger:           testlogger.New(t),
+		subsByName:       make(map[string]*Sub, numSubs),
+	}
+	for index := 0; index < numSubs; index++ {
+		sub := &Sub{
+			herd: herd,
+			mdb:  mdb.Machine{Hostname: fmt.Sprintf("sub%d", index)},
+		}
+		herd.subsByName[sub.mdb.Hostname] = sub
+		herd.subsByIndex = append(herd.subsByIndex, sub)
+	}
+	return herd
+}
+
+// startTestRollout starts a rollout of the "new" image without checking the
+// image with the imageserver.
+func startTestRollout(t *testing.T, herd *Herd,
+	plan proto.DefaultImageRolloutPlan) *rolloutType {
+	plan.ImageName = "new"
+	rollout, err := makeRollout(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollout.PreviousImageName = herd.defaultImageName
+	rollout.State = proto.RolloutStateRunning
+	rollout.StageStartTime = time.Now()
+	herd.rollout = rollout
+	return rollout
+}
+
+// syncSelectedSubs simulates the subs in the current cohort updating to the
+// new image, with the first numFailed of them failing.
+func syncSelectedSubs(herd *Herd, numFailed int) int {
+	var numSelected int
+	for _, sub := range herd.subsByIndex {
+		if !herd.rollout.selectSub(sub) {
+			continue
+		}
+		numSelected++
+		sub.requiredImageName = herd.rollout.ImageName
+		if numFailed > 0 {
+			sub.publishedStatus = statusFailedToUpdate
+			numFailed--
+		} else {
+			sub.publishedStatus = statusSynced
+		}
+		sub.status = sub.publishedStatus
+	}
+	return numSelected
+}
+
+func TestMakeRollout(t *testing.T) {
+	percentStage := proto.DefaultImageRolloutStage{Percent: 10}
+	allStage := proto.DefaultImageRolloutStage{}
+	taggedStage := proto.DefaultImageRolloutStage{
+		TagsToMatch: tags.MatchTags{"Canary": {"true"}},
+	}
+	tests := []struct {
+		name                 string
+		plan                 proto.DefaultImageRolloutPlan
+		expectError          bool
+		minimumSyncedPercent uint
+	}{
+		{
+			name:        "no stages",
+			expectError: true,
+		},
+		{
+			name: "MinimumSyncedPercent too large",
+			plan: proto.DefaultImageRolloutPlan{
+				MinimumSyncedPercent: 101,
+				Stages: []proto.DefaultImageRolloutStage{
+					percentStage,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Percent too large",
+			plan: proto.DefaultImageRolloutPlan{
+				Stages: []proto.DefaultImageRolloutStage{{Percent: 101}},
+			},
+			expectError: true,
+		},
+		{
+			name: "all subs before last stage",
+			plan: proto.DefaultImageRolloutPlan{
+				Stages: []proto.DefaultImageRolloutStage{
+					allStage, percentStage,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "100 percent before last stage",
+			plan: proto.DefaultImageRolloutPlan{
+				Stages: []proto.DefaultImageRolloutStage{
+					{Percent: 100}, percentStage,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "default MinimumSyncedPercent",
+			plan: proto.DefaultImageRolloutPlan{
+				Stages: []proto.DefaultImageRolloutStage{
+					percentStage, allStage,
+				},
+			},
+			minimumSyncedPercent: 100,
+		},
+		{
+			name: "tagged stage before last stage",
+			plan: proto.DefaultImageRolloutPlan{
+				MinimumSyncedPercent: 90,
+				Stages: []proto.DefaultImageRolloutStage{
+					taggedStage, allStage,
+				},
+			},
+			minimumSyncedPercent: 90,
+		},
+	}
+	for _, test := range tests {
+		rollout, err := makeRollout(test.plan)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if rollout.MinimumSyncedPercent != test.minimumSyncedPercent {
+			t.Errorf("%s: expected MinimumSyncedPercent: %d, got: %d",
+				test.name, test.minimumSyncedPercent,
+				rollout.MinimumSyncedPercent)
+		}
+		if len(rollout.tagMatchers) != len(test.plan.Stages) {
+			t.Errorf("%s: expected %d tag matchers, got: %d",
+				test.name, len(test.plan.Stages), len(rollout.tagMatchers))
+		}
+	}
+}
+
+func TestCheckRollout(t *testing.T) {
+	twoStages := []proto.DefaultImageRolloutStage{{Percent: 50}, {}}
+	tests := []struct {
+		name              string
+		plan              proto.DefaultImageRolloutPlan
+		sync              bool
+		numFailed         int
+		expectedState     proto.RolloutState
+		expectedStage     uint
+		expectedDefault   string
+		expectSyncedTime  bool
+		expectSubsReset   bool
+		expectRolloutDone bool
+	}{
+		{
+			name:            "not synced",
+			plan:            proto.DefaultImageRolloutPlan{Stages: twoStages},
+			expectedState:   proto.RolloutStateRunning,
+			expectedDefault: "old",
+		},
+		{
+			name:            "advance",
+			plan:            proto.DefaultImageRolloutPlan{Stages: twoStages},
+			sync:            true,
+			expectedState:   proto.RolloutStateRunning,
+			expectedStage:   1,
+			expectedDefault: "old",
+		},
+		{
+			name: "soak",
+			plan: proto.DefaultImageRolloutPlan{
+				Stages: []proto.DefaultImageRolloutStage{
+					{Percent: 50, SoakTime: time.Hour}, {},
+				},
+			},
+			sync:             true,
+			expectedState:    proto.RolloutStateRunning,
+			expectedDefault:  "old",
+			expectSyncedTime: true,
+		},
+		{
+			name: "complete",
+			plan: proto.DefaultImageRolloutPlan{
+				Stages: []proto.DefaultImageRolloutStage{{}},
+			},
+			sync:              true,
+			expectedState:     proto.RolloutStateCompleted,
+			expectedDefault:   "new",
+			expectSyncedTime:  true,
+			expectRolloutDone: true,
+		},
+		{
+			name: "failures within threshold",
+			plan: proto.DefaultImageRolloutPlan{
+				MaximumFailures:      1,
+				MinimumSyncedPercent: 50,
+				Stages:               twoStages,
+			},
+			sync:            true,
+			numFailed:       1,
+			expectedState:   proto.RolloutStateRunning,
+			expectedStage:   1,
+			expectedDefault: "old",
+		},
+		{
+			name: "threshold pause",
+			plan: proto.DefaultImageRolloutPlan{
+				MaximumFailures: 0,
+				Stages:          twoStages,
+			},
+			sync:            true,
+			numFailed:       1,
+			expectedState:   proto.RolloutStatePaused,
+			expectedDefault: "old",
+		},
+		{
+			name: "rollback",
+			plan: proto.DefaultImageRolloutPlan{
+				RollBackOnFailure: true,
+				Stages:            twoStages,
+			},
+			sync:              true,
+			numFailed:         1,
+			expectedState:     proto.RolloutStateRolledBack,
+			expectedDefault:   "old",
+			expectSubsReset:   true,
+			expectRolloutDone: true,
+		},
+	}
+	for _, test := range tests {
+		herd := makeTestHerd(t, 20)
+		rollout := startTestRollout(t, herd, test.plan)
+		var numSelected int
+		if test.sync {
+			numSelected = syncSelectedSubs(herd, test.numFailed)
+			if numSelected < 1 || numSelected <= test.numFailed {
+				t.Fatalf("%s: %d subs selected", test.name, numSelected)
+			}
+		}
+		herd.checkRollout()
+		if rollout.State != test.expectedState {
+			t.Errorf("%s: expected state: %s, got: %s",
+				test.name, test.expectedState, rollout.State)
+		}
+		if rollout.CurrentStage != test.expectedStage {
+			t.Errorf("%s: expected stage: %d, got: %d",
+				test.name, test.expectedStage, rollout.CurrentStage)
+		}
+		if herd.defaultImageName != test.expectedDefault {
+			t.Errorf("%s: expected default image: %s, got: %s",
+				test.name, test.expectedDefault, herd.defaultImageName)
+		}
+		if rollout.StageSyncedTime.IsZero() == test.expectSyncedTime {
+			t.Errorf("%s: StageSyncedTime: %s", test.name,
+				rollout.StageSyncedTime)
+		}
+		if test.sync &&
+			rollout.NumSynced != uint(numSelected-test.numFailed) {
+			t.Errorf("%s: expected %d synced, got: %d", test.name,
+				numSelected-test.numFailed, rollout.NumSynced)
+		}
+		if test.sync && rollout.NumFailed != uint(test.numFailed) {
+			t.Errorf("%s: expected %d failed, got: %d",
+				test.name, test.numFailed, rollout.NumFailed)
+		}
+		for _, sub := range herd.subsByIndex {
+			if test.expectRolloutDone {
+				if image := herd.getDefaultImageForSub(sub); image !=
+					test.expectedDefault {
+					t.Errorf("%s: %s: expected image: %s, got: %s",
+						test.name, sub.mdb.Hostname, test.expectedDefault,
+						image)
+				}
+			}
+			if test.expectSubsReset && sub.status == statusSynced {
+				t.Errorf("%s: %s: still synced to rolled back image",
+					test.name, sub.mdb.Hostname)
+			}
+		}
+	}
+}
+
+func TestSetDefaultImageDuringRollout(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       proto.RolloutState
+		expectError bool
+	}{
+		{
+			name:        "running",
+			state:       proto.RolloutStateRunning,
+			expectError: true,
+		},
+		{
+			name:        "paused",
+			state:       proto.RolloutStatePaused,
+			expectError: true,
+		},
+		{
+			name:  "completed",
+			state: proto.RolloutStateCompleted,
+		},
+	}
+	for _, test := range tests {
+		herd := makeTestHerd(t, 2)
+		rollout := startTestRollout(t, herd, proto.DefaultImageRolloutPlan{
+			Stages: []proto.DefaultImageRolloutStage{{}},
+		})
+		rollout.State = test.state
+		err := herd.setDefaultImage("")
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			if herd.defaultImageName != "old" {
+				t.Errorf("%s: default image changed to: \"%s\"",
+					test.name, herd.defaultImageName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if herd.defaultImageName != "" {
+			t.Errorf("%s: default image not cleared: %s",
+				test.name, herd.defaultImageName)
+		}
+	}
+}
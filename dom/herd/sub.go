@@ -338,7 +338,7 @@ func (sub *Sub) loadConfiguration() {
 	// Get a stable copy of the configuration.
 	newRequiredImageName := sub.mdb.RequiredImage
 	if newRequiredImageName == "" {
-		newRequiredImageName = sub.herd.defaultImageName
+		newRequiredImageName = sub.herd.getDefaultImageForSub(sub)
 	}
 	if newRequiredImageName != sub.requiredImageName {
 		sub.computedInodes = nil
@@ -477,6 +477,7 @@ func (sub *Sub) poll(srpcClient *srpc.Client, previousStatus subStatus,
 		return retval
 	}
 	sub.lastDisruptionState = reply.DisruptionState
+	sub.lastUpdateHadTriggerFailures = reply.LastUpdateHadTriggerFailures
 	sub.lastPollSucceededTime = time.Now()
 	sub.lastSuccessfulImageName = reply.LastSuccessfulImageName
 	sub.lastNote = reply.LastNote
@@ -765,6 +766,10 @@ func (sub *Sub) fetchMissingObjects(srpcClient *srpc.Client, img *image.Image,
 		if fast {
 			request.SpeedPercent = 100
 		}
+		if *chunkedFetch {
+			request.ChunkBases = lib.BuildChunkBases(subObj, img,
+				objectsToFetch)
+		}
 		var response subproto.FetchResponse
 		err := client.CallFetch(srpcClient, request, &response)
 		if err != nil {
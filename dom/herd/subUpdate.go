@@ -14,6 +14,7 @@ func (sub *Sub) buildUpdateRequest(request *subproto.UpdateRequest) (
 	bool, bool) {
 	request.ImageName = sub.requiredImageName
 	request.Triggers = sub.requiredImage.Triggers
+	request.ImageFilter = sub.requiredImage.Filter
 	var rusageStart, rusageStop syscall.Rusage
 	computeStartTime := time.Now()
 	syscall.Getrusage(syscall.RUSAGE_SELF, &rusageStart)
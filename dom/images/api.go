@@ -2,16 +2,42 @@ package images
 
 import (
 	"sync"
+	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/stringutil"
 )
 
+type Config struct {
+	// Images with vulnerabilities of at least this severity are not loaded.
+	// The default (unknown) disables the check.
+	BlockVulnerabilitySeverity vulnerability.Severity
+	ImageServerAddress         string
+	// If true, images are not loaded if the imageserver has no advisory
+	// database. By default a warning is logged and images are loaded.
+	RequireVulnerabilityCheck bool
+	// If not nil, images must be signed by a key trusted by this verifier.
+	SignatureVerifier *dsse.Verifier
+	// Loaded images are re-checked at this interval, since the advisory
+	// database may change. If zero, images are only checked when loaded.
+	VulnerabilityCheckInterval time.Duration
+}
+
+type Params struct {
+	Logger log.Logger
+}
+
 type Manager struct {
-	imageServerAddress string
-	logger             log.Logger
-	loggedDialFailure  bool
+	blockSeverity              vulnerability.Severity
+	imageServerAddress         string
+	logger                     log.Logger
+	loggedDialFailure          bool
+	loggedNoDatabase           bool
+	requireVulnerabilityCheck  bool
+	vulnerabilityCheckInterval time.Duration
 	sync.RWMutex
 	deduper *stringutil.StringDeduplicator
 	// Protected by lock.
@@ -20,10 +46,17 @@ type Manager struct {
 	imageExpireChannel   chan<- string
 	imagesByName         map[string]*image.Image
 	missingImages        map[string]error
+	rejectedImages       map[string]error // Only used by manager goroutine.
+	signatureVerifier    *dsse.Verifier
 }
 
 func New(imageServerAddress string, logger log.Logger) *Manager {
-	return newManager(imageServerAddress, logger)
+	return newManager(Config{ImageServerAddress: imageServerAddress},
+		Params{Logger: logger})
+}
+
+func NewWithConfigAndParams(config Config, params Params) *Manager {
+	return newManager(config, params)
 }
 
 func (m *Manager) Get(name string, wait bool) (*image.Image, error) {
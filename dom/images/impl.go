@@ -1,28 +1,36 @@
 package images
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/imageserver/client"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
-	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	"github.com/Cloud-Foundations/Dominator/lib/stringutil"
 )
 
-func newManager(imageServerAddress string, logger log.Logger) *Manager {
+func newManager(config Config, params Params) *Manager {
 	imageInterestChannel := make(chan map[string]struct{})
 	imageRequestChannel := make(chan string)
 	imageExpireChannel := make(chan string, 16)
 	m := &Manager{
-		imageServerAddress:   imageServerAddress,
-		logger:               logger,
-		deduper:              stringutil.NewStringDeduplicator(false),
-		imageInterestChannel: imageInterestChannel,
-		imageRequestChannel:  imageRequestChannel,
-		imageExpireChannel:   imageExpireChannel,
-		imagesByName:         make(map[string]*image.Image),
-		missingImages:        make(map[string]error),
+		blockSeverity:              config.BlockVulnerabilitySeverity,
+		imageServerAddress:         config.ImageServerAddress,
+		logger:                     params.Logger,
+		requireVulnerabilityCheck:  config.RequireVulnerabilityCheck,
+		vulnerabilityCheckInterval: config.VulnerabilityCheckInterval,
+		deduper:                    stringutil.NewStringDeduplicator(false),
+		imageInterestChannel:       imageInterestChannel,
+		imageRequestChannel:        imageRequestChannel,
+		imageExpireChannel:         imageExpireChannel,
+		imagesByName:               make(map[string]*image.Image),
+		missingImages:              make(map[string]error),
+		rejectedImages:             make(map[string]error),
+		signatureVerifier:          config.SignatureVerifier,
 	}
 	go m.manager(imageInterestChannel, imageRequestChannel, imageExpireChannel)
 	return m
@@ -63,6 +71,11 @@ func (m *Manager) manager(imageInterestChannel <-chan map[string]struct{},
 	imageRequestChannel <-chan string,
 	imageExpireChannel <-chan string) {
 	var imageClient *srpc.Client
+	var recheckChannel <-chan time.Time
+	if m.blockSeverity > vulnerability.SeverityUnknown &&
+		m.vulnerabilityCheckInterval > 0 {
+		recheckChannel = time.NewTicker(m.vulnerabilityCheckInterval).C
+	}
 	timer := time.NewTimer(time.Second)
 	for {
 		select {
@@ -90,6 +103,8 @@ func (m *Manager) manager(imageInterestChannel <-chan map[string]struct{},
 			for name := range missingImages {
 				imageClient = m.requestImage(imageClient, name)
 			}
+		case <-recheckChannel:
+			imageClient = m.recheckVulnerabilities(imageClient)
 		}
 		m.RLock()
 		if len(m.missingImages) > 0 {
@@ -121,6 +136,11 @@ func (m *Manager) setInterest(imageClient *srpc.Client,
 			m.Unlock()
 		}
 	}
+	for name := range m.rejectedImages {
+		if _, ok := imageList[name]; !ok {
+			delete(m.rejectedImages, name)
+		}
+	}
 	if deletedSome {
 		m.rebuildDeDuper()
 	}
@@ -149,16 +169,27 @@ func (m *Manager) requestImage(imageClient *srpc.Client,
 
 func (m *Manager) loadImage(imageClient *srpc.Client, name string) (
 	*srpc.Client, *image.Image, error) {
+	// Images are immutable, so there is no point fetching rejected images.
+	if err := m.rejectedImages[name]; err != nil {
+		return imageClient, nil, err
+	}
 	if imageClient == nil {
 		var err error
-		imageClient, err = srpc.DialHTTP("tcp", m.imageServerAddress, 0)
+		if imageClient, err = m.dial(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if m.blockSeverity > vulnerability.SeverityUnknown {
+		exists, blocked, err := m.checkVulnerabilities(imageClient, name)
 		if err != nil {
-			if !m.loggedDialFailure {
-				m.logger.Printf("Error dialing: %s: %s\n",
-					m.imageServerAddress, err)
-				m.loggedDialFailure = true
+			if !blocked &&
+				!errors.Is(err, client.ErrNoVulnerabilityDatabase) {
+				imageClient.Close()
+				imageClient = nil
 			}
-			return nil, nil, err
+			return imageClient, nil, err
+		} else if !exists {
+			return imageClient, nil, nil
 		}
 	}
 	img, err := client.GetImage(imageClient, name)
@@ -170,6 +201,16 @@ func (m *Manager) loadImage(imageClient *srpc.Client, name string) (
 	if img == nil || m.scheduleExpiration(img, name) {
 		return imageClient, nil, nil
 	}
+	if m.signatureVerifier != nil {
+		// Verify before the file-system is filtered.
+		_, err := signature.Verify(name, img, m.signatureVerifier)
+		if err != nil {
+			err = fmt.Errorf("bad signature: %s", err)
+			m.logger.Printf("Image: %s %s\n", name, err)
+			m.rejectedImages[name] = err
+			return imageClient, nil, err
+		}
+	}
 	if err := img.FileSystem.RebuildInodePointers(); err != nil {
 		m.logger.Printf("Error building inode pointers for image: %s %s",
 			name, err)
@@ -187,6 +228,91 @@ func (m *Manager) loadImage(imageClient *srpc.Client, name string) (
 	return imageClient, img, nil
 }
 
+// checkVulnerabilities returns whether the image exists and whether it is
+// blocked because it has vulnerabilities which are too severe. An error is
+// returned if the image is blocked or if the vulnerabilities could not be
+// checked. Each distinct error is logged once per image. If the imageserver
+// has no advisory database, a warning is logged once and the image is not
+// blocked, unless a vulnerability check is required.
+func (m *Manager) checkVulnerabilities(imageClient *srpc.Client,
+	name string) (bool, bool, error) {
+	findings, exists, err := client.ListImageVulnerabilities(imageClient, name)
+	if err == client.ErrNoVulnerabilityDatabase &&
+		!m.requireVulnerabilityCheck {
+		if !m.loggedNoDatabase {
+			m.logger.Printf(
+				"Warning: %s: %s, not checking image vulnerabilities\n",
+				m.imageServerAddress, err)
+			m.loggedNoDatabase = true
+		}
+		return true, false, nil
+	}
+	var blocked bool
+	if err != nil {
+		err = fmt.Errorf("cannot check vulnerabilities: %w", err)
+	} else if severity := vulnerability.MaxSeverity(findings); exists &&
+		severity >= m.blockSeverity {
+		blocked = true
+		err = fmt.Errorf("blocked: %d vulnerabilities, maximum severity: %s",
+			len(findings), severity)
+	}
+	if err != nil {
+		// Only the manager goroutine writes missingImages.
+		if oldErr := m.missingImages[name]; oldErr == nil ||
+			oldErr.Error() != err.Error() {
+			m.logger.Printf("Image: %s %s\n", name, err)
+		}
+	}
+	return exists, blocked, err
+}
+
+func (m *Manager) dial() (*srpc.Client, error) {
+	imageClient, err := srpc.DialHTTP("tcp", m.imageServerAddress, 0)
+	if err != nil {
+		if !m.loggedDialFailure {
+			m.logger.Printf("Error dialing: %s: %s\n",
+				m.imageServerAddress, err)
+			m.loggedDialFailure = true
+		}
+		return nil, err
+	}
+	return imageClient, nil
+}
+
+// recheckVulnerabilities re-checks the vulnerabilities of the loaded images,
+// since the advisory database may have changed since they were loaded. Images
+// which are now blocked are unloaded, and are retried like missing images.
+// Images which cannot be checked are kept.
+func (m *Manager) recheckVulnerabilities(
+	imageClient *srpc.Client) *srpc.Client {
+	if imageClient == nil {
+		var err error
+		if imageClient, err = m.dial(); err != nil {
+			return nil
+		}
+	}
+	deletedSome := false
+	for name := range m.imagesByName {
+		_, blocked, err := m.checkVulnerabilities(imageClient, name)
+		if blocked {
+			m.Lock()
+			delete(m.imagesByName, name)
+			m.missingImages[name] = err
+			m.Unlock()
+			deletedSome = true
+		} else if err != nil &&
+			!errors.Is(err, client.ErrNoVulnerabilityDatabase) {
+			imageClient.Close()
+			imageClient = nil
+			break
+		}
+	}
+	if deletedSome {
+		m.rebuildDeDuper()
+	}
+	return imageClient
+}
+
 func (m *Manager) rebuildDeDuper() {
 	for _, image := range m.imagesByName {
 		image.RegisterStrings(m.deduper.Register)
@@ -83,6 +83,15 @@ func BuildBothMissingLists(sub Sub, requiredImage, plannedImage *image.Image,
 		ignoreMissingComputedFiles, logger)
 }
 
+// BuildChunkBases will construct a list of files on the sub which are likely
+// to share content with the objects in objectsToFetch, because they are at the
+// same pathnames in img. Only large files are included. The list may be given
+// to the sub so that it fetches only the chunks of objects that it lacks.
+func BuildChunkBases(sub Sub, img *image.Image,
+	objectsToFetch map[hash.Hash]uint64) []string {
+	return sub.buildChunkBases(img, objectsToFetch)
+}
+
 // BuildUpdateRequest will build an update request which can be sent to the sub.
 // If deleteMissingComputedFiles is true then missing computed files are deleted
 // on the sub, else missing computed files lead to the function failing.
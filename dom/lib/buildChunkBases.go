@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"sort"
+
+	"github.com/Cloud-Foundations/Dominator/lib/chunks"
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+)
+
+func (sub *Sub) buildChunkBases(img *image.Image,
+	objectsToFetch map[hash.Hash]uint64) []string {
+	if img == nil || sub.FileSystem == nil {
+		return nil
+	}
+	subFilenameToInodeTable := sub.FileSystem.FilenameToInodeTable()
+	chunkBases := make(map[string]struct{})
+	for inum, filenames := range img.FileSystem.InodeToFilenamesTable() {
+		inode, ok := img.FileSystem.InodeTable[inum].(*filesystem.RegularInode)
+		if !ok || inode.Size < chunks.MinimumObjectSize {
+			continue
+		}
+		if _, ok := objectsToFetch[inode.Hash]; !ok {
+			continue
+		}
+		for _, filename := range filenames {
+			subInum, ok := subFilenameToInodeTable[filename]
+			if !ok {
+				continue
+			}
+			subInode := sub.FileSystem.InodeTable[subInum]
+			rInode, ok := subInode.(*filesystem.RegularInode)
+			if !ok || rInode.Size < chunks.MinimumObjectSize {
+				continue
+			}
+			chunkBases[filename] = struct{}{}
+			break
+		}
+	}
+	if len(chunkBases) < 1 {
+		return nil
+	}
+	sortedBases := make([]string, 0, len(chunkBases))
+	for filename := range chunkBases {
+		sortedBases = append(sortedBases, filename)
+	}
+	sort.Strings(sortedBases)
+	return sortedBases
+}
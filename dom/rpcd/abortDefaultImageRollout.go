@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func (t *rpcType) AbortDefaultImageRollout(conn *srpc.Conn,
+	request dominator.AbortDefaultImageRolloutRequest,
+	reply *dominator.AbortDefaultImageRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Println("AbortDefaultImageRollout()")
+	} else {
+		t.logger.Printf("AbortDefaultImageRollout(): by %s\n",
+			conn.Username())
+	}
+	return t.herd.AbortDefaultImageRollout(conn.Username())
+}
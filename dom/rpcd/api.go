@@ -22,6 +22,7 @@ func Setup(herd *herd.Herd, logger log.Logger) {
 				"ClearSafetyShutoff":    1,
 				"ForceDisruptiveUpdate": 1,
 				"GetInfoForSubs":        1,
+				"GetPlannedUpdate":      1,
 				"ListSubs":              1,
 			}),
 	}
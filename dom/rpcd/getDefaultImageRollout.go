@@ -0,0 +1,13 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetDefaultImageRollout(conn *srpc.Conn,
+	request dominator.GetDefaultImageRolloutRequest,
+	reply *dominator.GetDefaultImageRolloutResponse) error {
+	reply.Rollout = t.herd.GetDefaultImageRollout()
+	return nil
+}
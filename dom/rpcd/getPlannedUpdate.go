@@ -0,0 +1,19 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetPlannedUpdate(conn *srpc.Conn,
+	request dominator.GetPlannedUpdateRequest,
+	reply *dominator.GetPlannedUpdateResponse) error {
+	update, err := t.herd.GetPlannedUpdate(request)
+	response := dominator.GetPlannedUpdateResponse{
+		Error:  errors.ErrorToString(err),
+		Update: update,
+	}
+	*reply = response
+	return nil
+}
@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func (t *rpcType) PauseDefaultImageRollout(conn *srpc.Conn,
+	request dominator.PauseDefaultImageRolloutRequest,
+	reply *dominator.PauseDefaultImageRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("PauseDefaultImageRollout(%s)\n", request.Reason)
+	} else {
+		t.logger.Printf("PauseDefaultImageRollout(%s): by %s\n",
+			request.Reason, conn.Username())
+	}
+	return t.herd.PauseDefaultImageRollout(conn.Username(), request.Reason)
+}
@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func (t *rpcType) ResumeDefaultImageRollout(conn *srpc.Conn,
+	request dominator.ResumeDefaultImageRolloutRequest,
+	reply *dominator.ResumeDefaultImageRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Println("ResumeDefaultImageRollout()")
+	} else {
+		t.logger.Printf("ResumeDefaultImageRollout(): by %s\n",
+			conn.Username())
+	}
+	return t.herd.ResumeDefaultImageRollout(conn.Username())
+}
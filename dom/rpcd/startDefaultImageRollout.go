@@ -0,0 +1,19 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/dominator"
+)
+
+func (t *rpcType) StartDefaultImageRollout(conn *srpc.Conn,
+	request dominator.StartDefaultImageRolloutRequest,
+	reply *dominator.StartDefaultImageRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("StartDefaultImageRollout(%s)\n", request.ImageName)
+	} else {
+		t.logger.Printf("StartDefaultImageRollout(%s): by %s\n",
+			request.ImageName, conn.Username())
+	}
+	return t.herd.StartDefaultImageRollout(
+		dominator.DefaultImageRolloutPlan(request), conn.Username())
+}
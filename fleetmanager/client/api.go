@@ -1,9 +1,36 @@
 package client
 
 import (
+	"github.com/Cloud-Foundations/Dominator/lib/power"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
 )
 
+func DrainHypervisor(client *srpc.Client,
+	request proto.DrainHypervisorRequest) error {
+	return drainHypervisor(client, request)
+}
+
+func GetMachineHealth(client *srpc.Client, hostname string) (
+	*power.Health, error) {
+	return getMachineHealth(client, hostname)
+}
+
+func PlaceVm(client *srpc.Client, request proto.PlaceVmRequest) (
+	string, error) {
+	return placeVm(client, request)
+}
+
+func PowerCycleMachine(client *srpc.Client,
+	request proto.PowerCycleMachineRequest) error {
+	return powerCycleMachine(client, request)
+}
+
+func PowerOffMachine(client *srpc.Client,
+	request proto.PowerOffMachineRequest) error {
+	return powerOffMachine(client, request)
+}
+
 func PowerOnMachine(client *srpc.Client, hostname string) error {
 	return powerOnMachine(client, hostname)
 }
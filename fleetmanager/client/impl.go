@@ -2,10 +2,69 @@ package client
 
 import (
 	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/power"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
 )
 
+func drainHypervisor(client *srpc.Client,
+	request proto.DrainHypervisorRequest) error {
+	var reply proto.DrainHypervisorResponse
+	err := client.RequestReply("FleetManager.DrainHypervisor", request, &reply)
+	if err != nil {
+		return err
+	}
+	return errors.New(reply.Error)
+}
+
+func getMachineHealth(client *srpc.Client, hostname string) (
+	*power.Health, error) {
+	request := proto.GetMachineHealthRequest{Hostname: hostname}
+	var reply proto.GetMachineHealthResponse
+	err := client.RequestReply("FleetManager.GetMachineHealth", request, &reply)
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.New(reply.Error); err != nil {
+		return nil, err
+	}
+	return &reply.Health, nil
+}
+
+func placeVm(client *srpc.Client, request proto.PlaceVmRequest) (
+	string, error) {
+	var reply proto.PlaceVmResponse
+	err := client.RequestReply("FleetManager.PlaceVm", request, &reply)
+	if err != nil {
+		return "", err
+	}
+	if err := errors.New(reply.Error); err != nil {
+		return "", err
+	}
+	return reply.HypervisorAddress, nil
+}
+
+func powerCycleMachine(client *srpc.Client,
+	request proto.PowerCycleMachineRequest) error {
+	var reply proto.PowerCycleMachineResponse
+	err := client.RequestReply("FleetManager.PowerCycleMachine", request,
+		&reply)
+	if err != nil {
+		return err
+	}
+	return errors.New(reply.Error)
+}
+
+func powerOffMachine(client *srpc.Client,
+	request proto.PowerOffMachineRequest) error {
+	var reply proto.PowerOffMachineResponse
+	err := client.RequestReply("FleetManager.PowerOffMachine", request, &reply)
+	if err != nil {
+		return err
+	}
+	return errors.New(reply.Error)
+}
+
 func powerOnMachine(client *srpc.Client, hostname string) error {
 	request := proto.PowerOnMachineRequest{Hostname: hostname}
 	var reply proto.PowerOnMachineResponse
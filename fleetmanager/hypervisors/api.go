@@ -8,6 +8,7 @@ import (
 
 	"github.com/Cloud-Foundations/Dominator/fleetmanager/topology"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/power"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	"github.com/Cloud-Foundations/Dominator/lib/tags"
 	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
@@ -38,6 +39,7 @@ type hypervisorType struct {
 	closeClientChannel chan<- struct{}
 	deleteScheduled    bool
 	disabled           bool
+	drainStatus        *fm_proto.DrainStatus
 	healthStatus       string
 	lastConnectedTime  time.Time
 	lastIpmiProbe      time.Time
@@ -51,6 +53,11 @@ type hypervisorType struct {
 	vms                map[string]*vmInfoType // Key: VM IP address.
 }
 
+type cachedPowerDriverType struct {
+	driver   power.Driver
+	password string
+}
+
 type ipStorer interface {
 	AddIPsForHypervisor(hypervisor net.IP, addrs []net.IP) error
 	CheckIpIsRegistered(addr net.IP) (bool, error)
@@ -65,11 +72,18 @@ type locationType struct {
 }
 
 type Manager struct {
+	drainRetryDelay  time.Duration
 	ipmiLimiter      chan struct{}
 	ipmiPasswordFile string
 	ipmiUsername     string
 	logger           log.DebugLogger
+	migrateVmFunc    func(source, dest string, ipAddr net.IP) error
+	placeVmFunc      func(request fm_proto.PlaceVmRequest) (string, error)
+	powerDriver      string
+	redfishInsecure  bool
 	storer           Storer
+	powerMutex       sync.Mutex // Protect powerDrivers.
+	powerDrivers     map[string]cachedPowerDriverType
 	mutex            sync.RWMutex               // Protect everything below.
 	allocatingIPs    map[string]struct{}        // Key: VM IP address.
 	hypervisors      map[string]*hypervisorType // Key: hypervisor machine name.
@@ -92,6 +106,8 @@ type StartOptions struct {
 	IpmiPasswordFile string
 	IpmiUsername     string
 	Logger           log.DebugLogger
+	PowerDriver      string // Default driver: "ipmi" (default) or "redfish".
+	RedfishInsecure  bool   // Do not verify Redfish BMC certificates.
 	Storer           Storer
 }
 
@@ -141,6 +157,11 @@ func (m *Manager) CloseUpdateChannel(channel <-chan fm_proto.Update) {
 	m.closeUpdateChannel(channel)
 }
 
+func (m *Manager) DrainHypervisor(request fm_proto.DrainHypervisorRequest,
+	authInfo *srpc.AuthInformation) error {
+	return m.drainHypervisor(request, authInfo)
+}
+
 func (m *Manager) GetHypervisorForVm(ipAddr net.IP) (string, error) {
 	return m.getHypervisorForVm(ipAddr)
 }
@@ -151,6 +172,10 @@ func (m *Manager) GetHypervisorsInLocation(
 	return m.getHypervisorsInLocation(request)
 }
 
+func (m *Manager) GetMachineHealth(hostname string) (*power.Health, error) {
+	return m.getMachineHealth(hostname)
+}
+
 func (m *Manager) GetMachineInfo(request fm_proto.GetMachineInfoRequest) (
 	fm_proto.Machine, error) {
 	return m.getMachineInfo(request)
@@ -184,6 +209,20 @@ func (m *Manager) MoveIpAddresses(hostname string, ipAddresses []net.IP) error {
 	return m.moveIpAddresses(hostname, ipAddresses)
 }
 
+func (m *Manager) PlaceVm(request fm_proto.PlaceVmRequest) (string, error) {
+	return m.placeVm(request)
+}
+
+func (m *Manager) PowerCycleMachine(hostname string, bootToPXE, force bool,
+	authInfo *srpc.AuthInformation) error {
+	return m.powerCycleMachine(hostname, bootToPXE, force, authInfo)
+}
+
+func (m *Manager) PowerOffMachine(hostname string, force bool,
+	authInfo *srpc.AuthInformation) error {
+	return m.powerOffMachine(hostname, force, authInfo)
+}
+
 func (m *Manager) PowerOnMachine(hostname string,
 	authInfo *srpc.AuthInformation) error {
 	return m.powerOnMachine(hostname, authInfo)
@@ -3,7 +3,13 @@ package hypervisors
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/html"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
 )
 
 func (m *Manager) writeHtml(writer io.Writer) {
@@ -21,8 +27,14 @@ func (m *Manager) writeHtml(writer io.Writer) {
 	}
 	numMachines := t.GetNumMachines()
 	var numConnected, numDisabled, numOff, numOK uint
+	var drainStatuses []*fm_proto.DrainStatus
 	m.mutex.RLock()
 	for _, hypervisor := range m.hypervisors {
+		hypervisor.mutex.RLock()
+		if drainStatus := hypervisor.getDrainStatus(); drainStatus != nil {
+			drainStatuses = append(drainStatuses, drainStatus)
+		}
+		hypervisor.mutex.RUnlock()
 		switch hypervisor.probeStatus {
 		case probeStatusConnected:
 			numConnected++
@@ -65,6 +77,52 @@ func (m *Manager) writeHtml(writer io.Writer) {
 		`, <a href="listLocations?status=healthy">healthy</a>`)
 	fmt.Fprintln(writer,
 		` (<a href="listLocations?output=text&status=healthy">text</a>)<br>`)
+	writeDrainStatuses(writer, drainStatuses)
+}
+
+func writeDrainStatuses(writer io.Writer,
+	drainStatuses []*fm_proto.DrainStatus) {
+	if len(drainStatuses) < 1 {
+		return
+	}
+	sort.Slice(drainStatuses, func(left, right int) bool {
+		return drainStatuses[left].Hostname < drainStatuses[right].Hostname
+	})
+	fmt.Fprintln(writer, "Hypervisor drains:<br>")
+	fmt.Fprintln(writer, `<table border="1">`)
+	tw, _ := html.NewTableWriter(writer, true, "Hypervisor", "Status",
+		"Duration", "VMs", "Migrated", "Migrating", "Failed")
+	for _, status := range drainStatuses {
+		var background, state string
+		var duration time.Duration
+		if status.Finished {
+			duration = status.FinishedTime.Sub(status.StartTime)
+			if status.Error != "" {
+				background = "#ffb0b0"
+				state = status.Error
+			} else if len(status.FailedVMs) > 0 {
+				background = "#ffb0b0"
+				state = "finished with failures"
+			} else {
+				state = "finished"
+			}
+		} else {
+			duration = time.Since(status.StartTime)
+			background = "#fffbd0"
+			state = "draining"
+		}
+		tw.WriteRow("", background,
+			fmt.Sprintf("<a href=\"showHypervisor?%s\">%s</a>",
+				status.Hostname, status.Hostname),
+			state,
+			format.Duration(duration),
+			fmt.Sprintf("%d", status.NumVMs),
+			fmt.Sprintf("%d", len(status.MigratedVMs)),
+			fmt.Sprintf("%d", len(status.MigratingVMs)),
+			fmt.Sprintf("%d", len(status.FailedVMs)),
+		)
+	}
+	tw.Close()
 }
 
 func writeCountLinksHT(writer io.Writer, text, path string, count uint) {
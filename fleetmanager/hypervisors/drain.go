@@ -0,0 +1,285 @@
+package hypervisors
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	hyper_client "github.com/Cloud-Foundations/Dominator/hypervisor/client"
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+	hyper_proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+const (
+	defaultDrainConcurrency = 2
+	defaultDrainRetryDelay  = time.Minute
+)
+
+func copyDrainStatus(status *fm_proto.DrainStatus) *fm_proto.DrainStatus {
+	newStatus := *status
+	if len(status.FailedVMs) > 0 {
+		newStatus.FailedVMs = make(map[string]string, len(status.FailedVMs))
+		for ipAddr, err := range status.FailedVMs {
+			newStatus.FailedVMs[ipAddr] = err
+		}
+	}
+	if len(status.MigratedVMs) > 0 {
+		newStatus.MigratedVMs = make(map[string]string,
+			len(status.MigratedVMs))
+		for ipAddr, hostname := range status.MigratedVMs {
+			newStatus.MigratedVMs[ipAddr] = hostname
+		}
+	}
+	newStatus.MigratingVMs = append([]string(nil), status.MigratingVMs...)
+	return &newStatus
+}
+
+// migrateVm live migrates a VM from the source Hypervisor to the destination
+// Hypervisor, committing the migration once the VM is running on the
+// destination.
+func migrateVm(sourceAddress, destAddress string, ipAddr net.IP) error {
+	source, err := srpc.DialHTTP("tcp", sourceAddress, time.Second*15)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	var tokenReply hyper_proto.GetVmAccessTokenResponse
+	err = source.RequestReply("Hypervisor.GetVmAccessToken",
+		hyper_proto.GetVmAccessTokenRequest{
+			IpAddress: ipAddr,
+			Lifetime:  time.Hour * 24,
+		},
+		&tokenReply)
+	if err != nil {
+		return err
+	}
+	if err := errors.New(tokenReply.Error); err != nil {
+		return err
+	}
+	defer source.RequestReply("Hypervisor.DiscardVmAccessToken",
+		hyper_proto.DiscardVmAccessTokenRequest{
+			AccessToken: tokenReply.Token,
+			IpAddress:   ipAddr,
+		},
+		&hyper_proto.DiscardVmAccessTokenResponse{})
+	dest, err := srpc.DialHTTP("tcp", destAddress, time.Second*15)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	conn, err := dest.Call("Hypervisor.MigrateVm")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	request := hyper_proto.MigrateVmRequest{
+		AccessToken:      tokenReply.Token,
+		IpAddress:        ipAddr,
+		SourceHypervisor: sourceAddress,
+	}
+	if err := conn.Encode(request); err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	for {
+		var reply hyper_proto.MigrateVmResponse
+		if err := conn.Decode(&reply); err != nil {
+			return err
+		}
+		if err := errors.New(reply.Error); err != nil {
+			return err
+		}
+		if reply.RequestCommit {
+			err := conn.Encode(hyper_proto.MigrateVmResponseResponse{
+				Commit: true,
+			})
+			if err != nil {
+				return err
+			}
+			if err := conn.Flush(); err != nil {
+				return err
+			}
+		}
+		if reply.Final {
+			return nil
+		}
+	}
+}
+
+func (m *Manager) drainHypervisor(request fm_proto.DrainHypervisorRequest,
+	authInfo *srpc.AuthInformation) error {
+	if !*manageHypervisors {
+		return errors.New("this is a read-only Fleet Manager")
+	}
+	h, err := m.getLockedHypervisor(request.Hostname, true)
+	if err != nil {
+		return err
+	}
+	if err := h.checkAuth(authInfo); err != nil {
+		h.mutex.Unlock()
+		return err
+	}
+	if h.drainStatus != nil && !h.drainStatus.Finished {
+		h.mutex.Unlock()
+		return errors.New("Hypervisor is already draining")
+	}
+	if h.probeStatus != probeStatusConnected {
+		h.mutex.Unlock()
+		return errors.New("Hypervisor is not connected")
+	}
+	ipAddrs := make([]string, 0, len(h.vms))
+	for ipAddr := range h.vms {
+		ipAddrs = append(ipAddrs, ipAddr)
+	}
+	sort.Strings(ipAddrs)
+	h.drainStatus = &fm_proto.DrainStatus{
+		Hostname:  h.Machine.Hostname,
+		NumVMs:    uint(len(ipAddrs)),
+		StartTime: time.Now(),
+	}
+	address := h.address()
+	h.mutex.Unlock()
+	h.logger.Printf("draining %d VMs\n", len(ipAddrs))
+	if err := setDisabledState(address); err != nil {
+		m.finishDrain(h, err)
+		return err
+	}
+	m.sendDrainStatus(h)
+	go m.drainVMs(h, address, ipAddrs, request)
+	return nil
+}
+
+func setDisabledState(address string) error {
+	client, err := srpc.DialHTTP("tcp", address, time.Second*15)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return hyper_client.SetDisabledState(client, true)
+}
+
+func (m *Manager) drainVMs(h *hypervisorType, address string,
+	ipAddrs []string, request fm_proto.DrainHypervisorRequest) {
+	concurrency := request.MaxConcurrency
+	if concurrency < 1 {
+		concurrency = defaultDrainConcurrency
+	}
+	semaphore := make(chan struct{}, concurrency)
+	for _, ipAddr := range ipAddrs {
+		semaphore <- struct{}{}
+		go func(ipAddr string) {
+			m.drainVm(h, address, ipAddr, request.MaxRetries)
+			<-semaphore
+		}(ipAddr)
+	}
+	for count := uint(0); count < concurrency; count++ {
+		semaphore <- struct{}{}
+	}
+	m.finishDrain(h, nil)
+}
+
+func (m *Manager) drainVm(h *hypervisorType, address string, ipAddr string,
+	maxRetries uint) {
+	h.mutex.Lock()
+	h.drainStatus.MigratingVMs = append(h.drainStatus.MigratingVMs, ipAddr)
+	hostname := h.Machine.Hostname
+	h.mutex.Unlock()
+	m.sendDrainStatus(h)
+	var destHostname string
+	var err error
+	for retry := uint(0); retry <= maxRetries; retry++ {
+		if retry > 0 {
+			time.Sleep(m.drainRetryDelay)
+		}
+		h.mutex.RLock()
+		vm, ok := h.vms[ipAddr]
+		var vmInfo hyper_proto.VmInfo
+		if ok {
+			vmInfo = vm.VmInfo
+		}
+		h.mutex.RUnlock()
+		if !ok {
+			err = nil // VM has been moved or destroyed by someone else.
+			break
+		}
+		var destAddress string
+		destAddress, err = m.placeVmFunc(fm_proto.PlaceVmRequest{
+			ExcludeHypervisors: []string{hostname},
+			SubnetId:           vmInfo.SubnetId,
+			VmInfo:             vmInfo,
+		})
+		if err == nil {
+			h.logger.Printf("migrating VM: %s to %s\n", ipAddr, destAddress)
+			err = m.migrateVmFunc(address, destAddress,
+				vmInfo.Address.IpAddress)
+		}
+		if err == nil {
+			destHostname, _, _ = net.SplitHostPort(destAddress)
+			break
+		}
+		h.logger.Printf("error migrating VM: %s: %s\n", ipAddr, err)
+	}
+	h.mutex.Lock()
+	status := h.drainStatus
+	for index, migratingVm := range status.MigratingVMs {
+		if migratingVm == ipAddr {
+			status.MigratingVMs = append(status.MigratingVMs[:index],
+				status.MigratingVMs[index+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		if status.FailedVMs == nil {
+			status.FailedVMs = make(map[string]string)
+		}
+		status.FailedVMs[ipAddr] = err.Error()
+	} else {
+		if status.MigratedVMs == nil {
+			status.MigratedVMs = make(map[string]string)
+		}
+		status.MigratedVMs[ipAddr] = destHostname
+	}
+	h.mutex.Unlock()
+	m.sendDrainStatus(h)
+}
+
+func (m *Manager) finishDrain(h *hypervisorType, err error) {
+	h.mutex.Lock()
+	status := h.drainStatus
+	status.Error = errors.ErrorToString(err)
+	status.Finished = true
+	status.FinishedTime = time.Now()
+	h.mutex.Unlock()
+	if err != nil {
+		h.logger.Printf("error draining: %s\n", err)
+	} else {
+		h.logger.Printf("drained: %d VMs migrated, %d failed\n",
+			len(status.MigratedVMs), len(status.FailedVMs))
+	}
+	m.sendDrainStatus(h)
+}
+
+// getDrainStatus returns a copy of the drain status, or nil if the Hypervisor
+// has not been drained. The Hypervisor lock must be held.
+func (h *hypervisorType) getDrainStatus() *fm_proto.DrainStatus {
+	if h.drainStatus == nil {
+		return nil
+	}
+	return copyDrainStatus(h.drainStatus)
+}
+
+func (m *Manager) sendDrainStatus(h *hypervisorType) {
+	h.mutex.RLock()
+	update := &fm_proto.Update{
+		DrainStatuses: []*fm_proto.DrainStatus{h.getDrainStatus()},
+	}
+	location := h.location
+	h.mutex.RUnlock()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sendUpdate(location, update)
+}
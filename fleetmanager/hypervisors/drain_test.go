@@ -0,0 +1,234 @@
+package hypervisors
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+	hyper_proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+const testVmIpAddress = "10.0.0.1"
+
+// makeTestDrain returns a Manager with stubbed placement and migration, and a
+// draining Hypervisor. The stubs pop results from placeErrors and
+// migrateErrors, succeeding once they are exhausted.
+func makeTestDrain(t *testing.T, haveVm bool, placeErrors,
+	migrateErrors []error) (*Manager, *hypervisorType,
+	*[]fm_proto.PlaceVmRequest, *int) {
+	h := &hypervisorType{
+		logger:      testlogger.New(t),
+		drainStatus: &fm_proto.DrainStatus{Hostname: "h0", NumVMs: 1},
+		vms:         make(map[string]*vmInfoType),
+	}
+	h.Machine.Hostname = "h0"
+	if haveVm {
+		h.vms[testVmIpAddress] = &vmInfoType{
+			ipAddr: testVmIpAddress,
+			VmInfo: hyper_proto.VmInfo{
+				Address: hyper_proto.Address{
+					IpAddress: net.ParseIP(testVmIpAddress),
+				},
+				SubnetId: "subnet0",
+			},
+		}
+	}
+	var placeRequests []fm_proto.PlaceVmRequest
+	var numMigrations int
+	m := &Manager{
+		migrateVmFunc: func(source, dest string, ipAddr net.IP) error {
+			numMigrations++
+			if len(migrateErrors) > 0 {
+				err := migrateErrors[0]
+				migrateErrors = migrateErrors[1:]
+				return err
+			}
+			return nil
+		},
+		placeVmFunc: func(request fm_proto.PlaceVmRequest) (string, error) {
+			placeRequests = append(placeRequests, request)
+			if len(placeErrors) > 0 {
+				err := placeErrors[0]
+				placeErrors = placeErrors[1:]
+				if err != nil {
+					return "", err
+				}
+			}
+			return "h1:6976", nil
+		},
+	}
+	return m, h, &placeRequests, &numMigrations
+}
+
+func TestCopyDrainStatus(t *testing.T) {
+	status := &fm_proto.DrainStatus{
+		FailedVMs:    map[string]string{"10.0.0.1": "failed"},
+		Hostname:     "h0",
+		MigratedVMs:  map[string]string{"10.0.0.2": "h1"},
+		MigratingVMs: []string{"10.0.0.3"},
+		NumVMs:       3,
+	}
+	newStatus := copyDrainStatus(status)
+	if !reflect.DeepEqual(newStatus, status) {
+		t.Fatalf("copy: %v differs from: %v", *newStatus, *status)
+	}
+	newStatus.FailedVMs["10.0.0.4"] = "failed"
+	newStatus.MigratedVMs["10.0.0.4"] = "h2"
+	newStatus.MigratingVMs[0] = "10.0.0.4"
+	if _, ok := status.FailedVMs["10.0.0.4"]; ok {
+		t.Error("FailedVMs shared with copy")
+	}
+	if _, ok := status.MigratedVMs["10.0.0.4"]; ok {
+		t.Error("MigratedVMs shared with copy")
+	}
+	if status.MigratingVMs[0] != "10.0.0.3" {
+		t.Error("MigratingVMs shared with copy")
+	}
+	emptyStatus := copyDrainStatus(&fm_proto.DrainStatus{Hostname: "h0"})
+	if emptyStatus.FailedVMs != nil || emptyStatus.MigratedVMs != nil ||
+		emptyStatus.MigratingVMs != nil {
+		t.Errorf("empty status copied to: %v", *emptyStatus)
+	}
+}
+
+func TestDrainVm(t *testing.T) {
+	errPlace := errors.New("no space")
+	errMigrate := errors.New("migration failed")
+	tests := []struct {
+		name              string
+		haveVm            bool
+		maxRetries        uint
+		placeErrors       []error
+		migrateErrors     []error
+		expectedFailure   string
+		expectedMigrated  bool
+		expectedPlaces    int
+		expectedMigration int
+	}{
+		{
+			name:              "migrated",
+			haveVm:            true,
+			expectedMigrated:  true,
+			expectedPlaces:    1,
+			expectedMigration: 1,
+		},
+		{
+			name:             "VM already gone",
+			expectedMigrated: true,
+		},
+		{
+			name:            "placement failed",
+			haveVm:          true,
+			placeErrors:     []error{errPlace},
+			expectedFailure: errPlace.Error(),
+			expectedPlaces:  1,
+		},
+		{
+			name:              "migration failed",
+			haveVm:            true,
+			migrateErrors:     []error{errMigrate},
+			expectedFailure:   errMigrate.Error(),
+			expectedPlaces:    1,
+			expectedMigration: 1,
+		},
+		{
+			name:              "migrated after retry",
+			haveVm:            true,
+			maxRetries:        2,
+			placeErrors:       []error{errPlace},
+			migrateErrors:     []error{errMigrate},
+			expectedMigrated:  true,
+			expectedPlaces:    3,
+			expectedMigration: 2,
+		},
+		{
+			name:              "retries exhausted",
+			haveVm:            true,
+			maxRetries:        1,
+			placeErrors:       []error{errPlace},
+			migrateErrors:     []error{errMigrate},
+			expectedFailure:   errMigrate.Error(),
+			expectedPlaces:    2,
+			expectedMigration: 1,
+		},
+	}
+	for _, test := range tests {
+		m, h, placeRequests, numMigrations := makeTestDrain(t, test.haveVm,
+			test.placeErrors, test.migrateErrors)
+		m.drainVm(h, "h0:6976", testVmIpAddress, test.maxRetries)
+		status := h.drainStatus
+		if len(status.MigratingVMs) > 0 {
+			t.Errorf("%s: still migrating: %v", test.name, status.MigratingVMs)
+		}
+		if len(*placeRequests) != test.expectedPlaces {
+			t.Errorf("%s: expected %d placements, got: %d",
+				test.name, test.expectedPlaces, len(*placeRequests))
+		}
+		for _, request := range *placeRequests {
+			if !reflect.DeepEqual(request.ExcludeHypervisors,
+				[]string{"h0"}) {
+				t.Errorf("%s: draining Hypervisor not excluded: %v",
+					test.name, request.ExcludeHypervisors)
+			}
+			if request.SubnetId != "subnet0" {
+				t.Errorf("%s: bad SubnetId: %s", test.name, request.SubnetId)
+			}
+		}
+		if *numMigrations != test.expectedMigration {
+			t.Errorf("%s: expected %d migrations, got: %d",
+				test.name, test.expectedMigration, *numMigrations)
+		}
+		failure, failed := status.FailedVMs[testVmIpAddress]
+		if test.expectedFailure == "" && failed {
+			t.Errorf("%s: unexpected failure: %s", test.name, failure)
+		} else if test.expectedFailure != "" &&
+			failure != test.expectedFailure {
+			t.Errorf("%s: expected failure: %s, got: %s",
+				test.name, test.expectedFailure, failure)
+		}
+		destHostname, migrated := status.MigratedVMs[testVmIpAddress]
+		if migrated != test.expectedMigrated {
+			t.Errorf("%s: expected migrated: %t, got: %t",
+				test.name, test.expectedMigrated, migrated)
+		}
+		if migrated && test.haveVm && destHostname != "h1" {
+			t.Errorf("%s: expected destination: h1, got: %s",
+				test.name, destHostname)
+		}
+	}
+}
+
+func TestFinishDrain(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectedError string
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:          "error",
+			err:           errors.New("cannot disable"),
+			expectedError: "cannot disable",
+		},
+	}
+	for _, test := range tests {
+		m, h, _, _ := makeTestDrain(t, false, nil, nil)
+		m.finishDrain(h, test.err)
+		status := h.drainStatus
+		if !status.Finished {
+			t.Errorf("%s: not finished", test.name)
+		}
+		if status.FinishedTime.IsZero() {
+			t.Errorf("%s: FinishedTime not set", test.name)
+		}
+		if status.Error != test.expectedError {
+			t.Errorf("%s: expected error: \"%s\", got: \"%s\"",
+				test.name, test.expectedError, status.Error)
+		}
+	}
+}
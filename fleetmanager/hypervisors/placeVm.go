@@ -0,0 +1,125 @@
+package hypervisors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Cloud-Foundations/Dominator/lib/constants"
+	"github.com/Cloud-Foundations/Dominator/lib/tags/tagmatcher"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+// freeFraction returns the fraction of total which is free, or 0 if total is
+// 0.
+func freeFraction(free, total int64) float64 {
+	if total < 1 {
+		return 0
+	}
+	return float64(free) / float64(total)
+}
+
+// selectPlacement selects the best Hypervisor for a VM. Hypervisors without
+// enough free CPU, memory or volume capacity are skipped, as are Hypervisors
+// running a VM in the same anti-affinity group. The remaining Hypervisors are
+// scored by the mean fraction of free capacity left after placement (higher is
+// better unless packing) plus one for each VM in the same affinity group.
+func selectPlacement(hypervisors []fm_proto.Hypervisor,
+	request fm_proto.PlaceVmRequest) (*fm_proto.Hypervisor, error) {
+	vmInfo := request.VmInfo
+	excludeHypervisors := make(map[string]struct{},
+		len(request.ExcludeHypervisors))
+	for _, hostname := range request.ExcludeHypervisors {
+		excludeHypervisors[hostname] = struct{}{}
+	}
+	affinityGroup := vmInfo.Tags[fm_proto.AffinityGroupTag]
+	antiAffinityGroup := vmInfo.Tags[fm_proto.AntiAffinityGroupTag]
+	var totalVolumeSize uint64
+	for _, volume := range vmInfo.Volumes {
+		totalVolumeSize += volume.Size
+	}
+	var bestHypervisor *fm_proto.Hypervisor
+	var bestScore float64
+	var numAntiAffinityConflicts uint
+	for index := range hypervisors {
+		h := &hypervisors[index]
+		if _, ok := excludeHypervisors[h.Hostname]; ok {
+			continue
+		}
+		totalCPU := int64(h.NumCPUs) * 1000
+		freeCPU := totalCPU - int64(h.AllocatedMilliCPUs) -
+			int64(vmInfo.MilliCPUs)
+		freeMemory := int64(h.MemoryInMiB) - int64(h.AllocatedMemory) -
+			int64(vmInfo.MemoryInMiB)
+		freeVolume := int64(h.TotalVolumeBytes) -
+			int64(h.AllocatedVolumeBytes) - int64(totalVolumeSize)
+		if freeCPU < 0 || freeMemory < 0 || freeVolume < 0 {
+			continue
+		}
+		var antiAffinityConflict bool
+		var numAffinity uint
+		for _, vm := range h.VMs {
+			if vm.Address.IpAddress != nil &&
+				vm.Address.IpAddress.Equal(vmInfo.Address.IpAddress) {
+				continue // Do not count the VM being placed.
+			}
+			if antiAffinityGroup != "" &&
+				vm.Tags[fm_proto.AntiAffinityGroupTag] == antiAffinityGroup {
+				antiAffinityConflict = true
+				break
+			}
+			if affinityGroup != "" &&
+				vm.Tags[fm_proto.AffinityGroupTag] == affinityGroup {
+				numAffinity++
+			}
+		}
+		if antiAffinityConflict {
+			numAntiAffinityConflicts++
+			continue
+		}
+		score := (freeFraction(freeCPU, totalCPU) +
+			freeFraction(freeMemory, int64(h.MemoryInMiB)) +
+			freeFraction(freeVolume, int64(h.TotalVolumeBytes))) / 3
+		if request.PackVMs {
+			score = 1 - score
+		}
+		score += float64(numAffinity)
+		if bestHypervisor == nil || score > bestScore ||
+			(score == bestScore && h.Hostname < bestHypervisor.Hostname) {
+			bestHypervisor = h
+			bestScore = score
+		}
+	}
+	if bestHypervisor != nil {
+		return bestHypervisor, nil
+	}
+	if numAntiAffinityConflicts > 0 {
+		return nil, fmt.Errorf(
+			"no Hypervisors with capacity outside anti-affinity group: %s",
+			antiAffinityGroup)
+	}
+	return nil, errors.New("no Hypervisors in location with capacity")
+}
+
+func (m *Manager) placeVm(request fm_proto.PlaceVmRequest) (string, error) {
+	hypervisors, err := m.listHypervisors(request.Location, showOK,
+		request.SubnetId, tagmatcher.New(request.HypervisorTagsToMatch, false))
+	if err != nil {
+		return "", err
+	}
+	candidates := make([]fm_proto.Hypervisor, 0, len(hypervisors))
+	for _, hypervisor := range hypervisors {
+		hypervisor.mutex.RLock()
+		disabled := hypervisor.disabled
+		hypervisor.mutex.RUnlock()
+		if !disabled {
+			candidates = append(candidates,
+				hypervisor.makeProtoHypervisor(true))
+		}
+	}
+	hypervisor, err := selectPlacement(candidates, request)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%d",
+		hypervisor.Hostname, constants.HypervisorPortNumber), nil
+}
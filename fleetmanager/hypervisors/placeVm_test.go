@@ -0,0 +1,99 @@
+package hypervisors
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+	hyper_proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func makeTestHypervisor(hostname string, allocatedMemory uint64,
+	vms ...hyper_proto.VmInfo) fm_proto.Hypervisor {
+	return fm_proto.Hypervisor{
+		AllocatedMemory: allocatedMemory,
+		Machine: fm_proto.Machine{
+			MemoryInMiB:      1024,
+			NetworkEntry:     fm_proto.NetworkEntry{Hostname: hostname},
+			NumCPUs:          4,
+			TotalVolumeBytes: 1 << 30,
+		},
+		VMs: vms,
+	}
+}
+
+func makeTestVm(ipAddr string, tgs tags.Tags) hyper_proto.VmInfo {
+	return hyper_proto.VmInfo{
+		Address:     hyper_proto.Address{IpAddress: net.ParseIP(ipAddr)},
+		MemoryInMiB: 256,
+		MilliCPUs:   1000,
+		Tags:        tgs,
+	}
+}
+
+func testPlacement(t *testing.T, hypervisors []fm_proto.Hypervisor,
+	request fm_proto.PlaceVmRequest, expected string) {
+	hypervisor, err := selectPlacement(hypervisors, request)
+	if expected == "" {
+		if err == nil {
+			t.Errorf("expected error, got: %s", hypervisor.Hostname)
+		}
+		return
+	}
+	if err != nil {
+		t.Error(err)
+	} else if hypervisor.Hostname != expected {
+		t.Errorf("expected: %s, got: %s", expected, hypervisor.Hostname)
+	}
+}
+
+func TestPlacementAffinity(t *testing.T) {
+	hypervisors := []fm_proto.Hypervisor{
+		makeTestHypervisor("h0", 0),
+		makeTestHypervisor("h1", 512, makeTestVm("10.0.0.1",
+			tags.Tags{fm_proto.AffinityGroupTag: "web"})),
+	}
+	request := fm_proto.PlaceVmRequest{
+		VmInfo: makeTestVm("10.0.0.2", tags.Tags{
+			fm_proto.AffinityGroupTag: "web"}),
+	}
+	testPlacement(t, hypervisors, request, "h1")
+	request.VmInfo.Tags = nil
+	testPlacement(t, hypervisors, request, "h0")
+}
+
+func TestPlacementAntiAffinity(t *testing.T) {
+	hypervisors := []fm_proto.Hypervisor{
+		makeTestHypervisor("h0", 0, makeTestVm("10.0.0.1",
+			tags.Tags{fm_proto.AntiAffinityGroupTag: "db"})),
+		makeTestHypervisor("h1", 512),
+	}
+	request := fm_proto.PlaceVmRequest{
+		VmInfo: makeTestVm("10.0.0.2", tags.Tags{
+			fm_proto.AntiAffinityGroupTag: "db"}),
+	}
+	testPlacement(t, hypervisors, request, "h1")
+	request.ExcludeHypervisors = []string{"h1"}
+	testPlacement(t, hypervisors, request, "")
+	// The VM being placed does not conflict with itself.
+	request.VmInfo.Address.IpAddress = net.ParseIP("10.0.0.1")
+	testPlacement(t, hypervisors, request, "h0")
+}
+
+func TestPlacementCapacity(t *testing.T) {
+	hypervisors := []fm_proto.Hypervisor{
+		makeTestHypervisor("h0", 900),
+		makeTestHypervisor("h1", 512),
+		makeTestHypervisor("h2", 512),
+	}
+	request := fm_proto.PlaceVmRequest{
+		VmInfo: makeTestVm("10.0.0.2", nil),
+	}
+	testPlacement(t, hypervisors, request, "h1")
+	request.VmInfo.MemoryInMiB = 2048
+	testPlacement(t, hypervisors, request, "")
+	request.VmInfo.MemoryInMiB = 64
+	request.PackVMs = true
+	testPlacement(t, hypervisors, request, "h0")
+}
@@ -0,0 +1,367 @@
+package hypervisors
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/net/util"
+	"github.com/Cloud-Foundations/Dominator/lib/power"
+	"github.com/Cloud-Foundations/Dominator/lib/power/ipmi"
+	"github.com/Cloud-Foundations/Dominator/lib/power/redfish"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+	hyper_proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+var (
+	myIP    net.IP
+	wolConn *net.UDPConn
+)
+
+func getBmcHostname(machine *fm_proto.Machine) string {
+	if len(machine.IPMI.HostIpAddress) > 0 {
+		return machine.IPMI.HostIpAddress.String()
+	}
+	return machine.IPMI.Hostname
+}
+
+func readPasswordFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0]), nil
+}
+
+// checkNoRunningVMs returns an error if the hypervisor has running VMs. If the
+// hypervisor is neither connected nor powered off the VM state is unknown and
+// an error is returned unless force is true. The hypervisor lock must be held.
+func (h *hypervisorType) checkNoRunningVMs(force bool) error {
+	switch h.probeStatus {
+	case probeStatusConnected:
+	case probeStatusOff:
+		return nil
+	default:
+		if force {
+			return nil
+		}
+		return fmt.Errorf("VM state unknown, probe status: %s", h.probeStatus)
+	}
+	for ipAddr, vm := range h.vms {
+		if vm.State == hyper_proto.StateRunning {
+			return fmt.Errorf("VM: %s is running", ipAddr)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) getMachineHealth(hostname string) (*power.Health, error) {
+	h, err := m.getLockedHypervisor(hostname, false)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := m.getPowerDriver(h)
+	h.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, fmt.Errorf("no power driver for: %s", hostname)
+	}
+	m.ipmiGetSlot()
+	defer m.ipmiReleaseSlot()
+	return driver.GetHealth()
+}
+
+// getPowerDriver returns a power driver for the BMC of the machine. If the
+// machine has no BMC address or no credentials are configured, nil is returned.
+// Drivers are cached so that connections to the BMC are re-used.
+// The hypervisor lock must be held.
+func (m *Manager) getPowerDriver(h *hypervisorType) (power.Driver, error) {
+	if m.ipmiPasswordFile == "" || m.ipmiUsername == "" {
+		return nil, nil
+	}
+	bmcHostname := getBmcHostname(&h.Machine)
+	if bmcHostname == "" {
+		return nil, nil
+	}
+	driverName := h.Machine.PowerDriver
+	if driverName == "" {
+		driverName = m.powerDriver
+	}
+	var password string
+	switch driverName {
+	case "", "ipmi":
+		driverName = "ipmi"
+	case "redfish":
+		var err error
+		password, err = readPasswordFile(m.ipmiPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown power driver: %s", driverName)
+	}
+	key := driverName + "/" + bmcHostname
+	m.powerMutex.Lock()
+	defer m.powerMutex.Unlock()
+	if cached, ok := m.powerDrivers[key]; ok && cached.password == password {
+		return cached.driver, nil
+	}
+	var driver power.Driver
+	if driverName == "ipmi" {
+		driver = ipmi.New(ipmi.Params{
+			Hostname:     bmcHostname,
+			PasswordFile: m.ipmiPasswordFile,
+			Username:     m.ipmiUsername,
+		})
+	} else {
+		driver = redfish.New(redfish.Params{
+			Address:            bmcHostname,
+			InsecureSkipVerify: m.redfishInsecure,
+			Password:           password,
+			Username:           m.ipmiUsername,
+		})
+	}
+	m.powerDrivers[key] = cachedPowerDriverType{driver, password}
+	return driver, nil
+}
+
+// getLockedPowerDriver returns the hypervisor and a power driver for its BMC,
+// if the caller is authorised and there are no running VMs. On success the
+// hypervisor is returned read-locked, so that the VM state cannot change until
+// the caller has finished with the driver and releases the lock. Callers must
+// get an IPMI slot first.
+func (m *Manager) getLockedPowerDriver(hostname string, force bool,
+	authInfo *srpc.AuthInformation) (*hypervisorType, power.Driver, error) {
+	h, err := m.getLockedHypervisor(hostname, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := h.checkAuth(authInfo); err != nil {
+		h.mutex.RUnlock()
+		return nil, nil, err
+	}
+	if err := h.checkNoRunningVMs(force); err != nil {
+		h.mutex.RUnlock()
+		return nil, nil, err
+	}
+	driver, err := m.getPowerDriver(h)
+	if err != nil {
+		h.mutex.RUnlock()
+		return nil, nil, err
+	}
+	if driver == nil {
+		h.mutex.RUnlock()
+		return nil, nil, fmt.Errorf("no power driver for: %s", hostname)
+	}
+	return h, driver, nil
+}
+
+func (m *Manager) ipmiGetSlot() {
+	m.ipmiLimiter <- struct{}{}
+}
+
+func (m *Manager) ipmiReleaseSlot() {
+	<-m.ipmiLimiter
+}
+
+func (m *Manager) powerCycleMachine(hostname string, bootToPXE, force bool,
+	authInfo *srpc.AuthInformation) error {
+	m.ipmiGetSlot()
+	defer m.ipmiReleaseSlot()
+	h, driver, err := m.getLockedPowerDriver(hostname, force, authInfo)
+	if err != nil {
+		return err
+	}
+	defer h.mutex.RUnlock()
+	if bootToPXE {
+		if err := driver.SetBootToPXE(); err != nil {
+			return err
+		}
+	}
+	if err := driver.PowerCycle(); err != nil {
+		return err
+	}
+	h.logger.Printf("power cycled by: %s, bootToPXE: %t\n", authInfo.Username,
+		bootToPXE)
+	return nil
+}
+
+func (m *Manager) powerOffMachine(hostname string, force bool,
+	authInfo *srpc.AuthInformation) error {
+	m.ipmiGetSlot()
+	defer m.ipmiReleaseSlot()
+	h, driver, err := m.getLockedPowerDriver(hostname, force, authInfo)
+	if err != nil {
+		return err
+	}
+	defer h.mutex.RUnlock()
+	if err := driver.PowerOff(); err != nil {
+		return err
+	}
+	h.logger.Printf("powered off by: %s\n", authInfo.Username)
+	return nil
+}
+
+func (m *Manager) powerOnMachine(hostname string,
+	authInfo *srpc.AuthInformation) error {
+	m.ipmiGetSlot()
+	defer m.ipmiReleaseSlot()
+	h, err := m.getLockedHypervisor(hostname, false)
+	if err != nil {
+		return err
+	}
+	defer h.mutex.RUnlock()
+	if err := h.checkAuth(authInfo); err != nil {
+		return err
+	}
+	if driver, err := m.getPowerDriver(h); err != nil {
+		return err
+	} else if driver != nil {
+		return driver.PowerOn()
+	}
+	if sentWakeOnLan, err := m.wakeOnLan(h); err != nil {
+		return err
+	} else if sentWakeOnLan {
+		return nil
+	}
+	return fmt.Errorf("no power driver for: %s", hostname)
+}
+
+// probeSerialNumber will start a delayed background BMC probe of the serial
+// number if not discovered otherwise.
+func (m *Manager) probeSerialNumber(h *hypervisorType) {
+	if h.serialNumber != "" {
+		return
+	}
+	driver, err := m.getPowerDriver(h)
+	if err != nil {
+		h.logger.Println(err)
+		return
+	}
+	if driver == nil {
+		return
+	}
+	// Run the rest in the background.
+	go func() {
+		time.Sleep(5 * time.Second)
+		if h.isDeleteScheduled() {
+			return
+		}
+		if h.getSerialNumber() != "" {
+			return
+		}
+		serialNumber := m.readSerialNumber(driver)
+		if h.isDeleteScheduled() {
+			return
+		}
+		if serialNumber == "" {
+			return
+		}
+		if h.getSerialNumber() != "" {
+			return
+		}
+		h.mutex.Lock()
+		if h.serialNumber != "" {
+			h.mutex.Unlock()
+			return
+		}
+		h.serialNumber = serialNumber
+		h.mutex.Unlock()
+		err := m.storer.WriteMachineSerialNumber(h.Machine.HostIpAddress,
+			serialNumber)
+		if err != nil {
+			h.logger.Println(err)
+		} else {
+			h.mutex.Lock()
+			h.cachedSerialNumber = serialNumber
+			h.mutex.Unlock()
+		}
+	}()
+}
+
+func (m *Manager) probeUnreachable(h *hypervisorType) probeStatus {
+	h.mutex.RLock()
+	previousProbeStatus := h.probeStatus
+	driver, err := m.getPowerDriver(h)
+	h.mutex.RUnlock()
+	if err != nil || driver == nil {
+		return probeStatusUnreachable
+	}
+	mimimumProbeInterval := time.Second * time.Duration(30+rand.Intn(30))
+	if previousProbeStatus == probeStatusOff &&
+		time.Until(h.lastIpmiProbe.Add(mimimumProbeInterval)) > 0 {
+		return probeStatusOff
+	}
+	h.lastIpmiProbe = time.Now()
+	if state, err := driver.GetPowerState(); err != nil {
+		if previousProbeStatus == probeStatusOff {
+			return probeStatusOff
+		} else {
+			return probeStatusUnreachable
+		}
+	} else if state == power.StateOff {
+		return probeStatusOff
+	}
+	return probeStatusUnreachable
+}
+
+func (m *Manager) readSerialNumber(driver power.Driver) string {
+	m.ipmiGetSlot()
+	serialNumber, err := driver.GetSerialNumber()
+	m.ipmiReleaseSlot()
+	if err != nil {
+		return ""
+	}
+	return serialNumber
+}
+
+func (m *Manager) wakeOnLan(h *hypervisorType) (bool, error) {
+	if len(h.Machine.HostMacAddress) < 1 {
+		return false, nil
+	}
+	routeTable, err := util.GetRouteTable()
+	if err != nil {
+		return false, err
+	}
+	var routeEntry *util.RouteEntry
+	for _, route := range routeTable.RouteEntries {
+		if route.Flags&util.RouteFlagUp == 0 {
+			continue
+		}
+		if route.Flags&util.RouteFlagGateway != 0 {
+			continue
+		}
+		if h.Machine.HostIpAddress.Mask(route.Mask).Equal(route.BaseAddr) {
+			routeEntry = route
+			break
+		}
+	}
+	if routeEntry == nil {
+		return false, nil
+	}
+	if wolConn == nil {
+		myIP, err = util.GetMyIP()
+		if err != nil {
+			return false, err
+		}
+		wolConn, err = net.ListenUDP("udp", &net.UDPAddr{IP: myIP})
+		if err != nil {
+			return false, err
+		}
+	}
+	packet := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	for count := 0; count < 16; count++ {
+		packet = append(packet, h.Machine.HostMacAddress...)
+	}
+	remoteAddr := &net.UDPAddr{IP: routeEntry.BroadcastAddr, Port: 9}
+	if _, err := wolConn.WriteToUDP(packet, remoteAddr); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,74 @@
+package hypervisors
+
+import (
+	"testing"
+
+	hyper_proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func TestCheckNoRunningVMs(t *testing.T) {
+	running := &vmInfoType{
+		VmInfo: hyper_proto.VmInfo{State: hyper_proto.StateRunning},
+	}
+	stopped := &vmInfoType{
+		VmInfo: hyper_proto.VmInfo{State: hyper_proto.StateStopped},
+	}
+	tests := []struct {
+		name        string
+		probeStatus probeStatus
+		vms         map[string]*vmInfoType
+		force       bool
+		expectError bool
+	}{
+		{
+			name:        "connected, no VMs",
+			probeStatus: probeStatusConnected,
+		},
+		{
+			name:        "connected, stopped VM",
+			probeStatus: probeStatusConnected,
+			vms:         map[string]*vmInfoType{"10.0.0.1": stopped},
+		},
+		{
+			name:        "connected, running VM",
+			probeStatus: probeStatusConnected,
+			vms:         map[string]*vmInfoType{"10.0.0.1": running},
+			expectError: true,
+		},
+		{
+			name:        "connected, running VM, forced",
+			probeStatus: probeStatusConnected,
+			vms:         map[string]*vmInfoType{"10.0.0.1": running},
+			force:       true,
+			expectError: true,
+		},
+		{
+			name:        "off",
+			probeStatus: probeStatusOff,
+		},
+		{
+			name:        "unreachable",
+			probeStatus: probeStatusUnreachable,
+			expectError: true,
+		},
+		{
+			name:        "not yet probed",
+			probeStatus: probeStatusNotYetProbed,
+			expectError: true,
+		},
+		{
+			name:        "unreachable, forced",
+			probeStatus: probeStatusUnreachable,
+			force:       true,
+		},
+	}
+	for _, test := range tests {
+		h := &hypervisorType{probeStatus: test.probeStatus, vms: test.vms}
+		err := h.checkNoRunningVMs(test.force)
+		if test.expectError && err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !test.expectError && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package hypervisors
 
 import (
+	"fmt"
 	"os"
 	"runtime"
 
@@ -18,11 +19,22 @@ func newManager(startOptions StartOptions) (*Manager, error) {
 		}
 		file.Close()
 	}
+	switch startOptions.PowerDriver {
+	case "", "ipmi", "redfish":
+	default:
+		return nil, fmt.Errorf("unknown power driver: %s",
+			startOptions.PowerDriver)
+	}
 	manager := &Manager{
+		drainRetryDelay:  defaultDrainRetryDelay,
 		ipmiLimiter:      make(chan struct{}, runtime.NumCPU()),
 		ipmiPasswordFile: startOptions.IpmiPasswordFile,
 		ipmiUsername:     startOptions.IpmiUsername,
 		logger:           startOptions.Logger,
+		migrateVmFunc:    migrateVm,
+		powerDriver:      startOptions.PowerDriver,
+		powerDrivers:     make(map[string]cachedPowerDriverType),
+		redfishInsecure:  startOptions.RedfishInsecure,
 		storer:           startOptions.Storer,
 		allocatingIPs:    make(map[string]struct{}),
 		hypervisors:      make(map[string]*hypervisorType),
@@ -30,6 +42,7 @@ func newManager(startOptions StartOptions) (*Manager, error) {
 		subnets:          make(map[string]*subnetType),
 		vms:              make(map[string]*vmInfoType),
 	}
+	manager.placeVmFunc = manager.placeVm
 	html.HandleFunc("/listHypervisors", manager.listHypervisorsHandler)
 	html.HandleFunc("/listLocations", manager.listLocationsHandler)
 	html.HandleFunc("/listVMs", manager.listVMsHandler)
@@ -12,6 +12,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/constants"
 	"github.com/Cloud-Foundations/Dominator/lib/errors"
 	"github.com/Cloud-Foundations/Dominator/lib/log/prefixlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/net/util"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	"github.com/Cloud-Foundations/Dominator/lib/stringutil"
 	"github.com/Cloud-Foundations/Dominator/lib/tags"
@@ -212,6 +213,7 @@ func (m *Manager) makeUpdateChannel(
 	location.notifiers[channel] = channel
 	m.notifiers[channel] = location
 	machines := make([]*fm_proto.Machine, 0)
+	var drainStatuses []*fm_proto.DrainStatus
 	vms := make(map[string]*hyper_proto.VmInfo, len(m.vms))
 	vmToHypervisor := make(map[string]string, len(m.vms))
 	for _, h := range m.hypervisors {
@@ -219,6 +221,11 @@ func (m *Manager) makeUpdateChannel(
 			continue
 		}
 		machines = append(machines, h.getMachine())
+		h.mutex.RLock()
+		if drainStatus := h.getDrainStatus(); drainStatus != nil {
+			drainStatuses = append(drainStatuses, drainStatus)
+		}
+		h.mutex.RUnlock()
 		for addr, vm := range h.vms {
 			vms[addr] = &vm.VmInfo
 			vmToHypervisor[addr] = h.Machine.Hostname
@@ -227,6 +234,7 @@ func (m *Manager) makeUpdateChannel(
 	channel <- fm_proto.Update{
 		ChangedMachines: machines,
 		ChangedVMs:      vms,
+		DrainStatuses:   drainStatuses,
 		VmToHypervisor:  vmToHypervisor,
 	}
 	return channel
@@ -561,11 +569,21 @@ func (m *Manager) processAddressPoolUpdates(h *hypervisorType,
 			}
 			for _, ip := range freeIPs {
 				ipsToAdd = append(ipsToAdd, ip)
-				addressesToAdd = append(addressesToAdd, hyper_proto.Address{
+				address := hyper_proto.Address{
 					IpAddress: ip,
 					MacAddress: fmt.Sprintf("52:54:%02x:%02x:%02x:%02x",
 						ip[0], ip[1], ip[2], ip[3]),
-				})
+				}
+				if len(tSubnet.Ipv6Prefix) > 0 {
+					hwAddr, _ := net.ParseMAC(address.MacAddress)
+					address.Ipv6Address, err = util.MakeEui64Address(
+						tSubnet.Ipv6Prefix, hwAddr)
+					if err != nil {
+						h.logger.Println(err)
+						return
+					}
+				}
+				addressesToAdd = append(addressesToAdd, address)
 			}
 			h.logger.Debugf(0, "Adding %d addresses to subnet: %s\n",
 				len(freeIPs), subnetId)
@@ -861,7 +879,8 @@ func (m *Manager) splitChanges(hypersToChange []*hypervisorType,
 
 func (m *Manager) sendUpdate(hyperLocation string, update *fm_proto.Update) {
 	if len(update.ChangedMachines) < 1 && len(update.ChangedVMs) < 1 &&
-		len(update.DeletedMachines) < 1 && len(update.DeletedVMs) < 1 {
+		len(update.DeletedMachines) < 1 && len(update.DeletedVMs) < 1 &&
+		len(update.DrainStatuses) < 1 {
 		return
 	}
 	for locationStr, location := range m.locations {
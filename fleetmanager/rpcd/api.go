@@ -28,21 +28,27 @@ func Setup(hypervisorsManager *hypervisors.Manager, logger log.DebugLogger) (
 		logger:             logger,
 		PerUserMethodLimiter: serverutil.NewPerUserMethodLimiter(
 			map[string]uint{
-				"GetMachineInfo": 1,
-				"GetUpdates":     1,
+				"GetMachineHealth": 1,
+				"GetMachineInfo":   1,
+				"GetUpdates":       1,
 			}),
 	}
 	srpc.RegisterNameWithOptions("FleetManager", srpcObj,
 		srpc.ReceiverOptions{
 			PublicMethods: []string{
 				"ChangeMachineTags",
+				"DrainHypervisor",
 				"GetHypervisorForVM",
 				"GetHypervisorsInLocation",
+				"GetMachineHealth",
 				"GetMachineInfo",
 				"GetUpdates",
 				"ListHypervisorLocations",
 				"ListHypervisorsInLocation",
 				"ListVMsInLocation",
+				"PlaceVm",
+				"PowerCycleMachine",
+				"PowerOffMachine",
 				"PowerOnMachine",
 			}})
 	return (*htmlWriter)(srpcObj), nil
@@ -0,0 +1,16 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func (t *srpcType) DrainHypervisor(conn *srpc.Conn,
+	request fleetmanager.DrainHypervisorRequest,
+	reply *fleetmanager.DrainHypervisorResponse) error {
+	*reply = fleetmanager.DrainHypervisorResponse{
+		errors.ErrorToString(t.hypervisorsManager.DrainHypervisor(request,
+			conn.GetAuthInformation()))}
+	return nil
+}
@@ -0,0 +1,20 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	fm_proto "github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func (t *srpcType) GetMachineHealth(conn *srpc.Conn,
+	request fm_proto.GetMachineHealthRequest,
+	reply *fm_proto.GetMachineHealthResponse) error {
+	health, err := t.hypervisorsManager.GetMachineHealth(request.Hostname)
+	if err != nil {
+		*reply = fm_proto.GetMachineHealthResponse{
+			Error: errors.ErrorToString(err)}
+	} else {
+		*reply = fm_proto.GetMachineHealthResponse{Health: *health}
+	}
+	return nil
+}
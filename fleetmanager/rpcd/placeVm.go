@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func (t *srpcType) PlaceVm(conn *srpc.Conn,
+	request fleetmanager.PlaceVmRequest,
+	reply *fleetmanager.PlaceVmResponse) error {
+	hypervisorAddress, err := t.hypervisorsManager.PlaceVm(request)
+	*reply = fleetmanager.PlaceVmResponse{
+		Error:             errors.ErrorToString(err),
+		HypervisorAddress: hypervisorAddress,
+	}
+	return nil
+}
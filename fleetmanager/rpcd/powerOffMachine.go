@@ -0,0 +1,16 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/fleetmanager"
+)
+
+func (t *srpcType) PowerOffMachine(conn *srpc.Conn,
+	request fleetmanager.PowerOffMachineRequest,
+	reply *fleetmanager.PowerOffMachineResponse) error {
+	*reply = fleetmanager.PowerOffMachineResponse{
+		errors.ErrorToString(t.hypervisorsManager.PowerOffMachine(
+			request.Hostname, request.Force, conn.GetAuthInformation()))}
+	return nil
+}
@@ -122,6 +122,34 @@ func loadOwners(filename string) (*ownersType, error) {
 	return &owners, nil
 }
 
+// checkIpv6Prefix checks that the IPv6 prefix (if any) is a /64 and that the
+// IPv6 gateway is inside the prefix.
+func checkIpv6Prefix(subnet *Subnet) error {
+	if len(subnet.Ipv6Prefix) < 1 {
+		if len(subnet.Ipv6Gateway) > 0 {
+			return fmt.Errorf("subnet: %s: Ipv6Gateway without Ipv6Prefix",
+				subnet.Id)
+		}
+		return nil
+	}
+	prefix := subnet.Ipv6Prefix.To16()
+	if prefix == nil || prefix.To4() != nil {
+		return fmt.Errorf("subnet: %s: invalid Ipv6Prefix: %s",
+			subnet.Id, subnet.Ipv6Prefix)
+	}
+	mask := net.CIDRMask(64, 128)
+	if !prefix.Mask(mask).Equal(prefix) {
+		return fmt.Errorf("subnet: %s: Ipv6Prefix: %s is not a /64",
+			subnet.Id, subnet.Ipv6Prefix)
+	}
+	if len(subnet.Ipv6Gateway) > 0 &&
+		!subnet.Ipv6Gateway.Mask(mask).Equal(prefix) {
+		return fmt.Errorf("subnet: %s: Ipv6Gateway: %s not in Ipv6Prefix",
+			subnet.Id, subnet.Ipv6Gateway)
+	}
+	return nil
+}
+
 func loadSubnets(filename string) ([]*Subnet, error) {
 	var subnets []*Subnet
 	if err := json.ReadFromFile(filename, &subnets); err != nil {
@@ -139,6 +167,9 @@ func loadSubnets(filename string) ([]*Subnet, error) {
 		} else {
 			gatewayIPs[gatewayIp] = struct{}{}
 		}
+		if err := checkIpv6Prefix(subnet); err != nil {
+			return nil, err
+		}
 		subnet.reservedIpAddrs = make(map[string]struct{})
 		for _, ipAddr := range subnet.ReservedIPs {
 			subnet.reservedIpAddrs[ipAddr.String()] = struct{}{}
@@ -218,6 +249,12 @@ func (cState *commonStateType) addMachine(machine *proto.Machine,
 	if err := cState.addNetworkEntry(machine.IPMI, nil); err != nil {
 		return err
 	}
+	switch machine.PowerDriver {
+	case "", "ipmi", "redfish":
+	default:
+		return fmt.Errorf("unknown PowerDriver: %s for: %s",
+			machine.PowerDriver, machine.Hostname)
+	}
 	for _, entry := range machine.SecondaryNetworkEntries {
 		if err := cState.addNetworkEntry(entry, subnetIds); err != nil {
 			return err
@@ -8,6 +8,7 @@ require (
 	github.com/d2g/dhcp4 v0.0.0-20170904100407-a1d1b6c41b1c
 	github.com/d2g/dhcp4client v1.0.0
 	github.com/fsnotify/fsnotify v1.5.1
+	github.com/klauspost/compress v1.17.11
 	github.com/krolaw/dhcp4 v0.0.0-20190909130307-a50d88189771
 	github.com/pin/tftp v2.1.0+incompatible
 	golang.org/x/crypto v0.30.0
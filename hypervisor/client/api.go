@@ -152,6 +152,10 @@ func ReorderVmVolumes(client *srpc.Client, ipAddr net.IP, accessToken []byte,
 	return reorderVmVolumes(client, ipAddr, accessToken, volumeIndices)
 }
 
+func ResumeVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
+	return resumeVm(client, ipAddr, accessToken)
+}
+
 func ReplaceVmIdentity(client srpc.ClientI,
 	request proto.ReplaceVmIdentityRequest) error {
 	return replaceVmIdentity(client, request)
@@ -173,3 +177,7 @@ func StartVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
 func StopVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
 	return stopVm(client, ipAddr, accessToken)
 }
+
+func SuspendVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
+	return suspendVm(client, ipAddr, accessToken)
+}
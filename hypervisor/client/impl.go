@@ -520,6 +520,19 @@ func setDisabledState(client *srpc.Client, disable bool) error {
 	return errors.New(reply.Error)
 }
 
+func resumeVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
+	request := proto.ResumeVmRequest{
+		AccessToken: accessToken,
+		IpAddress:   ipAddr,
+	}
+	var reply proto.ResumeVmResponse
+	err := client.RequestReply("Hypervisor.ResumeVm", request, &reply)
+	if err != nil {
+		return err
+	}
+	return errors.New(reply.Error)
+}
+
 func startVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
 	request := proto.StartVmRequest{
 		AccessToken: accessToken,
@@ -545,3 +558,16 @@ func stopVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
 	}
 	return errors.New(reply.Error)
 }
+
+func suspendVm(client *srpc.Client, ipAddr net.IP, accessToken []byte) error {
+	request := proto.SuspendVmRequest{
+		AccessToken: accessToken,
+		IpAddress:   ipAddr,
+	}
+	var reply proto.SuspendVmResponse
+	err := client.RequestReply("Hypervisor.SuspendVm", request, &reply)
+	if err != nil {
+		return err
+	}
+	return errors.New(reply.Error)
+}
@@ -16,15 +16,18 @@ type DhcpServer struct {
 	logger            log.DebugLogger
 	cleanupTrigger    chan<- struct{}
 	interfaceIPs      map[string][]net.IP // Key: interface name.
+	interfaceIpv6s    map[string][]net.IP // Key: interface name.
 	myIPs             []net.IP
 	networkBootImage  string
 	requestInterface  string
 	routeTable        map[string]*util.RouteEntry // Key: interface name.
+	serverDuid        []byte                      // DHCPv6 server identifier.
 	mutex             sync.RWMutex                // Protect everything below.
 	ackChannels       map[string]chan struct{}    // Key: IPaddr.
 	dynamicLeases     map[string]*leaseType       // Key: MACaddr.
 	interfaceSubnets  map[string][]*subnetType    // Key: interface name.
 	ipAddrToMacAddr   map[string]string           // Key: IPaddr, V: MACaddr.
+	ipv6IfSubnets     map[string][]*subnetType    // Key: interface name.
 	packetWatchers    map[<-chan proto.WatchDhcpResponse]chan<- proto.WatchDhcpResponse
 	requestChannels   map[string]chan net.IP // Key: MACaddr.
 	staticLeases      map[string]leaseType   // Key: MACaddr.
@@ -42,6 +45,7 @@ type leaseType struct {
 
 type subnetType struct {
 	amGateway     bool
+	amIpv6Gateway bool
 	myIP          net.IP
 	nextDynamicIP net.IP
 	proto.Subnet
@@ -0,0 +1,445 @@
+package dhcpd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/net/util"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	dhcp6ServerPort = 547
+
+	dhcp6MessageSolicit            = 1
+	dhcp6MessageAdvertise          = 2
+	dhcp6MessageRequest            = 3
+	dhcp6MessageConfirm            = 4
+	dhcp6MessageRenew              = 5
+	dhcp6MessageRebind             = 6
+	dhcp6MessageReply              = 7
+	dhcp6MessageRelease            = 8
+	dhcp6MessageDecline            = 9
+	dhcp6MessageInformationRequest = 11
+
+	dhcp6OptionClientId    = 1
+	dhcp6OptionServerId    = 2
+	dhcp6OptionIaNa        = 3
+	dhcp6OptionIaAddr      = 5
+	dhcp6OptionStatusCode  = 13
+	dhcp6OptionRapidCommit = 14
+	dhcp6OptionDnsServers  = 23
+	dhcp6OptionDomainList  = 24
+
+	dhcp6StatusSuccess   = 0
+	dhcp6StatusNoBinding = 3
+	dhcp6StatusNotOnLink = 4
+
+	duidTypeLinkLayerPlusTime = 1
+	duidTypeEnterprise        = 2
+	duidTypeLinkLayer         = 3
+	hardwareTypeEthernet      = 1
+)
+
+var dhcp6AllServersAndRelays = net.ParseIP("ff02::1:2")
+
+type dhcp6Message struct {
+	messageType   byte
+	transactionId [3]byte
+	options       []dhcp6Option
+}
+
+type dhcp6Option struct {
+	code uint16
+	data []byte
+}
+
+func decodeDhcp6Message(buffer []byte) (*dhcp6Message, error) {
+	if len(buffer) < 4 {
+		return nil, errors.New("short DHCPv6 message")
+	}
+	message := &dhcp6Message{messageType: buffer[0]}
+	copy(message.transactionId[:], buffer[1:4])
+	options, err := decodeDhcp6Options(buffer[4:])
+	if err != nil {
+		return nil, err
+	}
+	message.options = options
+	return message, nil
+}
+
+func decodeDhcp6Options(buffer []byte) ([]dhcp6Option, error) {
+	var options []dhcp6Option
+	for len(buffer) > 0 {
+		if len(buffer) < 4 {
+			return nil, errors.New("truncated DHCPv6 option header")
+		}
+		code := binary.BigEndian.Uint16(buffer[0:2])
+		length := int(binary.BigEndian.Uint16(buffer[2:4]))
+		if len(buffer) < 4+length {
+			return nil, fmt.Errorf("truncated DHCPv6 option: %d", code)
+		}
+		options = append(options,
+			dhcp6Option{code: code, data: buffer[4 : 4+length]})
+		buffer = buffer[4+length:]
+	}
+	return options, nil
+}
+
+func encodeDhcp6Options(options []dhcp6Option) []byte {
+	buffer := &bytes.Buffer{}
+	for _, option := range options {
+		binary.Write(buffer, binary.BigEndian, option.code)
+		binary.Write(buffer, binary.BigEndian, uint16(len(option.data)))
+		buffer.Write(option.data)
+	}
+	return buffer.Bytes()
+}
+
+// encodeDomainNames encodes a list of domain names in DNS wire format.
+func encodeDomainNames(names []string) []byte {
+	buffer := &bytes.Buffer{}
+	for _, name := range names {
+		for _, label := range strings.Split(strings.Trim(name, "."), ".") {
+			if label == "" || len(label) > 63 {
+				continue
+			}
+			buffer.WriteByte(byte(len(label)))
+			buffer.WriteString(label)
+		}
+		buffer.WriteByte(0)
+	}
+	return buffer.Bytes()
+}
+
+func encodeStatusCode(statusCode uint16, message string) dhcp6Option {
+	data := make([]byte, 2, 2+len(message))
+	binary.BigEndian.PutUint16(data, statusCode)
+	return dhcp6Option{
+		code: dhcp6OptionStatusCode,
+		data: append(data, message...),
+	}
+}
+
+// getMacFromDuid returns the MAC address from a link-layer DUID, or nil if the
+// DUID does not contain an Ethernet address.
+func getMacFromDuid(duid []byte) net.HardwareAddr {
+	if len(duid) < 2 {
+		return nil
+	}
+	var hwAddr []byte
+	switch binary.BigEndian.Uint16(duid[0:2]) {
+	case duidTypeLinkLayerPlusTime: // Type, hardware type, time, address.
+		if len(duid) < 8 {
+			return nil
+		}
+		hwAddr = duid[8:]
+	case duidTypeEnterprise: // Type, enterprise number, identifier.
+		// There is no hardware address: the caller should fall back to the
+		// interface identifier in the link-local address.
+		return nil
+	case duidTypeLinkLayer: // Type, hardware type, address.
+		if len(duid) < 4 {
+			return nil
+		}
+		hwAddr = duid[4:]
+	default:
+		return nil
+	}
+	if binary.BigEndian.Uint16(duid[2:4]) != hardwareTypeEthernet {
+		return nil
+	}
+	if len(hwAddr) != 6 {
+		return nil
+	}
+	return net.HardwareAddr(hwAddr)
+}
+
+func makeDuid(hwAddr net.HardwareAddr) []byte {
+	duid := make([]byte, 4, 4+len(hwAddr))
+	binary.BigEndian.PutUint16(duid[0:2], duidTypeLinkLayer)
+	binary.BigEndian.PutUint16(duid[2:4], hardwareTypeEthernet)
+	return append(duid, hwAddr...)
+}
+
+func (m *dhcp6Message) addOption(code uint16, data []byte) {
+	m.options = append(m.options, dhcp6Option{code: code, data: data})
+}
+
+func (m *dhcp6Message) encode() []byte {
+	buffer := make([]byte, 4)
+	buffer[0] = m.messageType
+	copy(buffer[1:4], m.transactionId[:])
+	return append(buffer, encodeDhcp6Options(m.options)...)
+}
+
+func (m *dhcp6Message) getOption(code uint16) []byte {
+	for _, option := range m.options {
+		if option.code == code {
+			return option.data
+		}
+	}
+	return nil
+}
+
+func (m *dhcp6Message) hasOption(code uint16) bool {
+	for _, option := range m.options {
+		if option.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *DhcpServer) startDhcp6Server(ifIndices map[int]string) error {
+	listener, err := net.ListenPacket("udp6",
+		fmt.Sprintf(":%d", dhcp6ServerPort))
+	if err != nil {
+		return err
+	}
+	pktConn := ipv6.NewPacketConn(listener)
+	if err := pktConn.SetControlMessage(ipv6.FlagInterface, true); err != nil {
+		listener.Close()
+		return err
+	}
+	for ifIndex := range ifIndices {
+		iface, err := net.InterfaceByIndex(ifIndex)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		err = pktConn.JoinGroup(iface,
+			&net.UDPAddr{IP: dhcp6AllServersAndRelays})
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("error joining DHCPv6 group on: %s: %s",
+				iface.Name, err)
+		}
+		if s.serverDuid == nil && len(iface.HardwareAddr) == 6 {
+			s.serverDuid = makeDuid(iface.HardwareAddr)
+		}
+	}
+	if s.serverDuid == nil {
+		listener.Close()
+		return errors.New("no Ethernet interface for DHCPv6 server DUID")
+	}
+	go s.serveDhcp6(pktConn, ifIndices)
+	return nil
+}
+
+func (s *DhcpServer) serveDhcp6(conn *ipv6.PacketConn,
+	ifIndices map[int]string) {
+	buffer := make([]byte, 65536)
+	for {
+		length, cm, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			s.logger.Println(err)
+			return
+		}
+		if cm == nil {
+			continue
+		}
+		interfaceName, ok := ifIndices[cm.IfIndex]
+		if !ok {
+			continue
+		}
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		request, err := decodeDhcp6Message(buffer[:length])
+		if err != nil {
+			s.logger.Debugf(0, "error decoding DHCPv6 message from: %s: %s\n",
+				udpAddr.IP, err)
+			continue
+		}
+		reply := s.processDhcp6Message(request, interfaceName, udpAddr.IP)
+		if reply == nil {
+			continue
+		}
+		_, err = conn.WriteTo(reply.encode(),
+			&ipv6.ControlMessage{IfIndex: cm.IfIndex}, addr)
+		if err != nil {
+			s.logger.Println(err)
+		}
+	}
+}
+
+// findDhcp6Lease finds the static lease for a DHCPv6 client. The client is
+// identified by the MAC address in its DUID, or else by the modified EUI-64
+// interface identifier in its link-local address.
+func (s *DhcpServer) findDhcp6Lease(clientId []byte, srcIP net.IP) (
+	*leaseType, *subnetType) {
+	hwAddr := getMacFromDuid(clientId)
+	if hwAddr == nil {
+		hwAddr = util.Eui64ToHardwareAddr(srcIP)
+	}
+	if hwAddr == nil {
+		return nil, nil
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	lease, subnet := s.findStaticLease(hwAddr.String())
+	if lease == nil || subnet == nil || len(lease.Ipv6Address) < 1 {
+		return nil, nil
+	}
+	return lease, subnet
+}
+
+func (s *DhcpServer) makeDhcp6IaNa(request *dhcp6Message,
+	lease *leaseType, statusCode uint16) []dhcp6Option {
+	var options []dhcp6Option
+	for _, option := range request.options {
+		if option.code != dhcp6OptionIaNa || len(option.data) < 12 {
+			continue
+		}
+		data := make([]byte, 12)
+		copy(data[0:4], option.data[0:4]) // IAID.
+		var iaOptions []dhcp6Option
+		if lease != nil {
+			leaseTime := uint32(staticLeaseTime.Seconds())
+			binary.BigEndian.PutUint32(data[4:8], leaseTime/2)
+			binary.BigEndian.PutUint32(data[8:12], leaseTime/5*4)
+			iaAddr := make([]byte, 24)
+			copy(iaAddr[0:16], lease.Ipv6Address.To16())
+			binary.BigEndian.PutUint32(iaAddr[16:20], leaseTime)
+			binary.BigEndian.PutUint32(iaAddr[20:24], leaseTime)
+			iaOptions = append(iaOptions,
+				dhcp6Option{code: dhcp6OptionIaAddr, data: iaAddr})
+		} else {
+			iaOptions = append(iaOptions, encodeStatusCode(statusCode, ""))
+		}
+		options = append(options, dhcp6Option{
+			code: dhcp6OptionIaNa,
+			data: append(data, encodeDhcp6Options(iaOptions)...),
+		})
+	}
+	return options
+}
+
+func (s *DhcpServer) makeDhcp6Reply(request *dhcp6Message, messageType byte,
+	subnet *subnetType) *dhcp6Message {
+	reply := &dhcp6Message{
+		messageType:   messageType,
+		transactionId: request.transactionId,
+	}
+	reply.addOption(dhcp6OptionClientId,
+		request.getOption(dhcp6OptionClientId))
+	reply.addOption(dhcp6OptionServerId, s.serverDuid)
+	if subnet == nil {
+		return reply
+	}
+	var dnsServers []byte
+	for _, dnsServer := range subnet.DomainNameServers {
+		if dnsServer.To4() == nil {
+			dnsServers = append(dnsServers, dnsServer.To16()...)
+		}
+	}
+	if len(dnsServers) > 0 {
+		reply.addOption(dhcp6OptionDnsServers, dnsServers)
+	}
+	if subnet.DomainName != "" {
+		reply.addOption(dhcp6OptionDomainList,
+			encodeDomainNames([]string{subnet.DomainName}))
+	}
+	return reply
+}
+
+func (s *DhcpServer) processDhcp6Message(request *dhcp6Message,
+	interfaceName string, srcIP net.IP) *dhcp6Message {
+	clientId := request.getOption(dhcp6OptionClientId)
+	if len(clientId) < 1 {
+		return nil
+	}
+	serverId := request.getOption(dhcp6OptionServerId)
+	if serverId != nil && !bytes.Equal(serverId, s.serverDuid) {
+		return nil // Message not for this DHCP server.
+	}
+	lease, subnet := s.findDhcp6Lease(clientId, srcIP)
+	var leaseAddr net.IP
+	if lease != nil {
+		leaseAddr = lease.Ipv6Address
+	}
+	switch request.messageType {
+	case dhcp6MessageSolicit:
+		if lease == nil {
+			return nil
+		}
+		messageType := byte(dhcp6MessageAdvertise)
+		if request.hasOption(dhcp6OptionRapidCommit) {
+			messageType = dhcp6MessageReply
+		}
+		s.logger.Debugf(0, "DHCPv6 Solicit from: %s on: %s, offering: %s\n",
+			lease.MacAddress, interfaceName, leaseAddr)
+		reply := s.makeDhcp6Reply(request, messageType, subnet)
+		if messageType == dhcp6MessageReply {
+			reply.addOption(dhcp6OptionRapidCommit, nil)
+		}
+		reply.options = append(reply.options,
+			s.makeDhcp6IaNa(request, lease, 0)...)
+		return reply
+	case dhcp6MessageRequest, dhcp6MessageRenew, dhcp6MessageRebind:
+		if lease == nil && request.messageType == dhcp6MessageRebind {
+			return nil
+		}
+		if lease != nil {
+			s.logger.Debugf(0, "DHCPv6 Reply for: %s to: %s on: %s\n",
+				leaseAddr, lease.MacAddress, interfaceName)
+		}
+		reply := s.makeDhcp6Reply(request, dhcp6MessageReply, subnet)
+		reply.options = append(reply.options,
+			s.makeDhcp6IaNa(request, lease, dhcp6StatusNoBinding)...)
+		return reply
+	case dhcp6MessageConfirm:
+		if lease == nil {
+			return nil
+		}
+		reply := s.makeDhcp6Reply(request, dhcp6MessageReply, subnet)
+		statusCode := uint16(dhcp6StatusSuccess)
+		for _, option := range request.options {
+			if option.code != dhcp6OptionIaNa || len(option.data) < 12 {
+				continue
+			}
+			iaOptions, err := decodeDhcp6Options(option.data[12:])
+			if err != nil {
+				return nil
+			}
+			for _, iaOption := range iaOptions {
+				if iaOption.code == dhcp6OptionIaAddr &&
+					len(iaOption.data) >= 16 &&
+					!net.IP(iaOption.data[:16]).Equal(leaseAddr) {
+					statusCode = dhcp6StatusNotOnLink
+				}
+			}
+		}
+		reply.options = append(reply.options, encodeStatusCode(statusCode, ""))
+		return reply
+	case dhcp6MessageInformationRequest:
+		if subnet == nil {
+			s.mutex.RLock()
+			if subnets := s.interfaceSubnets[interfaceName]; len(subnets) > 0 {
+				subnet = subnets[0]
+			}
+			s.mutex.RUnlock()
+		}
+		return s.makeDhcp6Reply(request, dhcp6MessageReply, subnet)
+	case dhcp6MessageRelease, dhcp6MessageDecline:
+		if lease == nil {
+			return nil
+		}
+		s.logger.Debugf(0, "DHCPv6 Release/Decline for: %s from: %s\n",
+			leaseAddr, lease.MacAddress)
+		reply := s.makeDhcp6Reply(request, dhcp6MessageReply, nil)
+		reply.options = append(reply.options,
+			encodeStatusCode(dhcp6StatusSuccess, ""))
+		return reply
+	default:
+		s.logger.Debugf(0, "Unsupported DHCPv6 message type: %d on: %s\n",
+			request.messageType, interfaceName)
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package dhcpd
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+var (
+	testClientMac  = net.HardwareAddr{0x52, 0x54, 0, 0, 0, 5}
+	testClientDuid = makeDuid(testClientMac)
+	testLeaseIp    = net.ParseIP("2001:db8::5")
+	testServerDuid = makeDuid(net.HardwareAddr{0x52, 0x54, 0, 0, 0, 1})
+)
+
+func makeTestDhcp6Server(t *testing.T) *DhcpServer {
+	subnet := &subnetType{
+		Subnet: proto.Subnet{
+			DomainName:        "example.com",
+			DomainNameServers: []net.IP{net.ParseIP("2001:db8::2")},
+			Ipv6Prefix:        net.ParseIP("2001:db8::"),
+		},
+	}
+	return &DhcpServer{
+		interfaceSubnets: map[string][]*subnetType{"br0": {subnet}},
+		logger:           testlogger.New(t),
+		serverDuid:       testServerDuid,
+		staticLeases: map[string]leaseType{
+			testClientMac.String(): {
+				Address: proto.Address{
+					Ipv6Address: testLeaseIp,
+					MacAddress:  testClientMac.String(),
+				},
+				subnet: subnet,
+			},
+		},
+	}
+}
+
+func makeTestIaNa(addr net.IP) dhcp6Option {
+	data := []byte{0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	if addr != nil {
+		iaAddr := make([]byte, 24)
+		copy(iaAddr, addr.To16())
+		data = append(data, encodeDhcp6Options(
+			[]dhcp6Option{{code: dhcp6OptionIaAddr, data: iaAddr}})...)
+	}
+	return dhcp6Option{code: dhcp6OptionIaNa, data: data}
+}
+
+func TestDecodeDhcp6Message(t *testing.T) {
+	tests := []struct {
+		name        string
+		buffer      []byte
+		expectError bool
+		messageType byte
+		numOptions  int
+	}{
+		{"empty", nil, true, 0, 0},
+		{"short", []byte{1, 2, 3}, true, 0, 0},
+		{"no options", []byte{1, 2, 3, 4}, false, 1, 0},
+		{
+			name:        "one option",
+			buffer:      []byte{3, 0, 0, 1, 0, 1, 0, 2, 0xaa, 0xbb},
+			messageType: 3,
+			numOptions:  1,
+		},
+		{
+			name:        "empty option",
+			buffer:      []byte{1, 0, 0, 1, 0, 14, 0, 0},
+			messageType: 1,
+			numOptions:  1,
+		},
+		{
+			name:        "truncated option header",
+			buffer:      []byte{1, 0, 0, 1, 0, 1, 0},
+			expectError: true,
+		},
+		{
+			name:        "truncated option data",
+			buffer:      []byte{1, 0, 0, 1, 0, 1, 0, 4, 0xaa},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		message, err := decodeDhcp6Message(test.buffer)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if message.messageType != test.messageType {
+			t.Errorf("%s: expected type: %d, got: %d",
+				test.name, test.messageType, message.messageType)
+		}
+		if len(message.options) != test.numOptions {
+			t.Errorf("%s: expected %d options, got: %d",
+				test.name, test.numOptions, len(message.options))
+		}
+		if !bytes.Equal(message.encode(), test.buffer) {
+			t.Errorf("%s: re-encoding mismatch", test.name)
+		}
+	}
+}
+
+func TestGetMacFromDuid(t *testing.T) {
+	tests := []struct {
+		name     string
+		duid     []byte
+		expected net.HardwareAddr
+	}{
+		{"empty", nil, nil},
+		{"type only", []byte{0, 1}, nil},
+		{"LL", testClientDuid, testClientMac},
+		{"LL short", []byte{0, 3, 0}, nil},
+		{"LL no address", []byte{0, 3, 0, 1}, nil},
+		{"LL non-Ethernet", []byte{0, 3, 0, 6, 1, 2, 3, 4, 5, 6}, nil},
+		{
+			name:     "LLT",
+			duid:     append([]byte{0, 1, 0, 1, 1, 2, 3, 4}, testClientMac...),
+			expected: testClientMac,
+		},
+		{"LLT short", []byte{0, 1, 0, 1, 1, 2}, nil},
+		{"LLT no address", []byte{0, 1, 0, 1, 1, 2, 3, 4}, nil},
+		{"EN", []byte{0, 2, 0, 0, 0, 9, 1, 2, 3, 4, 5, 6}, nil},
+		{"EN short", []byte{0, 2, 0}, nil},
+		{"UUID", append([]byte{0, 4}, make([]byte, 16)...), nil},
+	}
+	for _, test := range tests {
+		if hwAddr := getMacFromDuid(test.duid); !bytes.Equal(hwAddr,
+			test.expected) {
+			t.Errorf("%s: expected: %s, got: %s",
+				test.name, test.expected, hwAddr)
+		}
+	}
+}
+
+func TestProcessDhcp6Message(t *testing.T) {
+	otherServerDuid := makeDuid(net.HardwareAddr{0x52, 0x54, 0, 0, 0, 2})
+	unknownDuid := makeDuid(net.HardwareAddr{0x52, 0x54, 0, 0, 0, 9})
+	tests := []struct {
+		name        string
+		messageType byte
+		options     []dhcp6Option
+		expectReply bool
+		replyType   byte
+		leaseAddr   net.IP // Expected address in the IA_NA, if any.
+		statusCode  int    // Expected top-level status code, or -1.
+	}{
+		{
+			name:        "no client ID",
+			messageType: dhcp6MessageSolicit,
+			options:     []dhcp6Option{makeTestIaNa(nil)},
+		},
+		{
+			name:        "solicit",
+			messageType: dhcp6MessageSolicit,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				makeTestIaNa(nil),
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageAdvertise,
+			leaseAddr:   testLeaseIp,
+			statusCode:  -1,
+		},
+		{
+			name:        "solicit with rapid commit",
+			messageType: dhcp6MessageSolicit,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				{code: dhcp6OptionRapidCommit},
+				makeTestIaNa(nil),
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			leaseAddr:   testLeaseIp,
+			statusCode:  -1,
+		},
+		{
+			name:        "solicit from unknown client",
+			messageType: dhcp6MessageSolicit,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: unknownDuid},
+				makeTestIaNa(nil),
+			},
+		},
+		{
+			name:        "request for other server",
+			messageType: dhcp6MessageRequest,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				{code: dhcp6OptionServerId, data: otherServerDuid},
+				makeTestIaNa(nil),
+			},
+		},
+		{
+			name:        "request",
+			messageType: dhcp6MessageRequest,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				{code: dhcp6OptionServerId, data: testServerDuid},
+				makeTestIaNa(nil),
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			leaseAddr:   testLeaseIp,
+			statusCode:  -1,
+		},
+		{
+			name:        "renew from unknown client",
+			messageType: dhcp6MessageRenew,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: unknownDuid},
+				makeTestIaNa(nil),
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			statusCode:  -1,
+		},
+		{
+			name:        "rebind from unknown client",
+			messageType: dhcp6MessageRebind,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: unknownDuid},
+				makeTestIaNa(nil),
+			},
+		},
+		{
+			name:        "confirm",
+			messageType: dhcp6MessageConfirm,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				makeTestIaNa(testLeaseIp),
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			statusCode:  dhcp6StatusSuccess,
+		},
+		{
+			name:        "confirm wrong address",
+			messageType: dhcp6MessageConfirm,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				makeTestIaNa(net.ParseIP("2001:db8::6")),
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			statusCode:  dhcp6StatusNotOnLink,
+		},
+		{
+			name:        "information request from unknown client",
+			messageType: dhcp6MessageInformationRequest,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: unknownDuid},
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			statusCode:  -1,
+		},
+		{
+			name:        "release",
+			messageType: dhcp6MessageRelease,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+				{code: dhcp6OptionServerId, data: testServerDuid},
+			},
+			expectReply: true,
+			replyType:   dhcp6MessageReply,
+			statusCode:  dhcp6StatusSuccess,
+		},
+		{
+			name:        "unsupported type",
+			messageType: 12,
+			options: []dhcp6Option{
+				{code: dhcp6OptionClientId, data: testClientDuid},
+			},
+		},
+	}
+	server := makeTestDhcp6Server(t)
+	srcIP := net.ParseIP("fe80::1")
+	for _, test := range tests {
+		request := &dhcp6Message{
+			messageType:   test.messageType,
+			transactionId: [3]byte{1, 2, 3},
+			options:       test.options,
+		}
+		reply := server.processDhcp6Message(request, "br0", srcIP)
+		if !test.expectReply {
+			if reply != nil {
+				t.Errorf("%s: unexpected reply", test.name)
+			}
+			continue
+		}
+		if reply == nil {
+			t.Errorf("%s: no reply", test.name)
+			continue
+		}
+		if reply.messageType != test.replyType {
+			t.Errorf("%s: expected type: %d, got: %d",
+				test.name, test.replyType, reply.messageType)
+		}
+		if reply.transactionId != request.transactionId {
+			t.Errorf("%s: transaction ID mismatch", test.name)
+		}
+		if !bytes.Equal(reply.getOption(dhcp6OptionServerId),
+			testServerDuid) {
+			t.Errorf("%s: bad server ID", test.name)
+		}
+		statusCode := -1
+		if data := reply.getOption(dhcp6OptionStatusCode); len(data) >= 2 {
+			statusCode = int(data[0])<<8 | int(data[1])
+		}
+		if statusCode != test.statusCode {
+			t.Errorf("%s: expected status: %d, got: %d",
+				test.name, test.statusCode, statusCode)
+		}
+		if test.leaseAddr == nil {
+			continue
+		}
+		iaNa := reply.getOption(dhcp6OptionIaNa)
+		if len(iaNa) < 12 {
+			t.Errorf("%s: no IA_NA", test.name)
+			continue
+		}
+		iaOptions, err := decodeDhcp6Options(iaNa[12:])
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if len(iaOptions) != 1 || iaOptions[0].code != dhcp6OptionIaAddr ||
+			!net.IP(iaOptions[0].data[:16]).Equal(test.leaseAddr) {
+			t.Errorf("%s: expected address: %s, got: %v",
+				test.name, test.leaseAddr, iaOptions)
+		}
+	}
+}
@@ -49,12 +49,13 @@ func getClientIdentifier(options dhcp.Options) (string, string) {
 	return "(clientIdType=%s) ", fmt.Sprintf("%d", rawClientIdentifier[0])
 }
 
-func listMyIPs() (map[string][]net.IP, []net.IP, error) {
+func listMyIPs() (map[string][]net.IP, map[string][]net.IP, []net.IP, error) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	ifMap := make(map[string][]net.IP)
+	ifMap6 := make(map[string][]net.IP)
 	ipMap := make(map[string]net.IP)
 	for _, iface := range interfaces {
 		if iface.Flags&net.FlagUp == 0 {
@@ -65,14 +66,19 @@ func listMyIPs() (map[string][]net.IP, []net.IP, error) {
 		}
 		interfaceAddrs, err := iface.Addrs()
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		for _, addr := range interfaceAddrs {
 			IP, _, err := net.ParseCIDR(addr.String())
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
-			if IP = IP.To4(); IP == nil {
+			if ip4 := IP.To4(); ip4 != nil {
+				IP = ip4
+			} else {
+				if !IP.IsLinkLocalUnicast() {
+					ifMap6[iface.Name] = append(ifMap6[iface.Name], IP)
+				}
 				continue
 			}
 			ifMap[iface.Name] = append(ifMap[iface.Name], IP)
@@ -83,7 +89,7 @@ func listMyIPs() (map[string][]net.IP, []net.IP, error) {
 	for _, IP := range ipMap {
 		IPs = append(IPs, IP)
 	}
-	return ifMap, IPs, nil
+	return ifMap, ifMap6, IPs, nil
 }
 
 func newServer(interfaceNames []string, dynamicLeasesFile string,
@@ -98,6 +104,7 @@ func newServer(interfaceNames []string, dynamicLeasesFile string,
 		dynamicLeasesFile: dynamicLeasesFile,
 		interfaceSubnets:  make(map[string][]*subnetType),
 		ipAddrToMacAddr:   make(map[string]string),
+		ipv6IfSubnets:     make(map[string][]*subnetType),
 		logger:            logger,
 		packetWatchers: make(
 			map[<-chan proto.WatchDhcpResponse]chan<- proto.WatchDhcpResponse),
@@ -105,13 +112,14 @@ func newServer(interfaceNames []string, dynamicLeasesFile string,
 		routeTable:      make(map[string]*util.RouteEntry),
 		staticLeases:    make(map[string]leaseType),
 	}
-	if interfaceIPs, myIPs, err := listMyIPs(); err != nil {
+	if interfaceIPs, interfaceIpv6s, myIPs, err := listMyIPs(); err != nil {
 		return nil, err
 	} else {
 		if len(myIPs) < 1 {
 			return nil, errors.New("no IP addresses found")
 		}
 		dhcpServer.interfaceIPs = interfaceIPs
+		dhcpServer.interfaceIpv6s = interfaceIpv6s
 		dhcpServer.myIPs = myIPs
 	}
 	routeTable, err := util.GetRouteTable()
@@ -171,6 +179,13 @@ func newServer(interfaceNames []string, dynamicLeasesFile string,
 			logger.Println(err)
 		}
 	}()
+	// IPv6 is optional: continue serving IPv4 if it is not available.
+	if err := dhcpServer.startDhcp6Server(serveConn.ifIndices); err != nil {
+		logger.Printf("not serving DHCPv6: %s\n", err)
+	}
+	if err := dhcpServer.startRouterAdvertiser(serveConn.ifIndices); err != nil {
+		logger.Printf("not sending Router Advertisements: %s\n", err)
+	}
 	go dhcpServer.cleanupDynamicLeasesLoop(cleanupTriggerChannel)
 	html.HandleFunc("/showDhcpStatus", dhcpServer.showDhcpStatusHandler)
 	return dhcpServer, nil
@@ -261,12 +276,34 @@ func (s *DhcpServer) addSubnet(protoSubnet proto.Subnet) {
 			break
 		}
 	}
+	var ipv6IfaceName string
+	if len(protoSubnet.Ipv6Gateway) > 0 {
+		for name, ips := range s.interfaceIpv6s {
+			for _, ip := range ips {
+				if protoSubnet.Ipv6Gateway.Equal(ip) {
+					ipv6IfaceName = name
+					subnet.amIpv6Gateway = true
+					s.logger.Printf(
+						"attaching subnet IPv6 GW: %s to interface: %s\n",
+						ip, name)
+					break
+				}
+			}
+			if ipv6IfaceName != "" {
+				break
+			}
+		}
+	}
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if ifaceName != "" {
 		s.interfaceSubnets[ifaceName] = append(s.interfaceSubnets[ifaceName],
 			subnet)
 	}
+	if ipv6IfaceName != "" && len(protoSubnet.Ipv6Prefix) > 0 {
+		s.ipv6IfSubnets[ipv6IfaceName] = append(
+			s.ipv6IfSubnets[ipv6IfaceName], subnet)
+	}
 	s.subnets = append(s.subnets, subnet)
 }
 
@@ -511,7 +548,9 @@ func (s *DhcpServer) makeOptions(subnet *subnetType,
 	lease *leaseType, reqOptions dhcp.Options) dhcp.Options {
 	dnsServers := make([]byte, 0)
 	for _, dnsServer := range subnet.DomainNameServers {
-		dnsServers = append(dnsServers, dnsServer...)
+		if ip4 := dnsServer.To4(); ip4 != nil {
+			dnsServers = append(dnsServers, ip4...)
+		}
 	}
 	leaseOptions := dhcp.Options{
 		dhcp.OptionSubnetMask:       subnet.IpMask,
@@ -653,6 +692,23 @@ func (s *DhcpServer) removeSubnet(subnetId string) {
 		}
 		s.interfaceSubnets[name] = subnets
 	}
+	for name, subnets := range s.ipv6IfSubnets {
+		newSubnets := make([]*subnetType, 0, len(subnets))
+		for _, subnet := range subnets {
+			if subnet == subnetToDelete {
+				s.logger.Printf(
+					"detaching subnet IPv6 GW: %s from interface: %s\n",
+					subnet.Ipv6Gateway, name)
+			} else {
+				newSubnets = append(newSubnets, subnet)
+			}
+		}
+		if len(newSubnets) > 0 {
+			s.ipv6IfSubnets[name] = newSubnets
+		} else {
+			delete(s.ipv6IfSubnets, name)
+		}
+	}
 }
 
 func (s *DhcpServer) ServeDHCP(req dhcp.Packet, msgType dhcp.MessageType,
@@ -704,7 +760,7 @@ func (s *DhcpServer) ServeDHCP(req dhcp.Packet, msgType dhcp.MessageType,
 				"did not request an IP, using: %s", reqIP.String()))
 		}
 		reqIP = util.ShrinkIP(reqIP)
-		s.notifyRequest(proto.Address{reqIP, macAddr})
+		s.notifyRequest(proto.Address{IpAddress: reqIP, MacAddress: macAddr})
 		server, ok := options[dhcp.OptionServerIdentifier]
 		if ok {
 			serverIP := net.IP(server)
@@ -0,0 +1,193 @@
+package dhcpd
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	raFlagManaged             = 0x80
+	raFlagOtherConfig         = 0x40
+	raInterval                = 200 * time.Second
+	raMinDelayBetweenReplies  = 3 * time.Second // RFC 4861 MIN_DELAY_BETWEEN_RAS.
+	raOptionPrefixInformation = 3
+	raOptionRdnss             = 25
+	raOptionSourceLinkLayer   = 1
+	raPrefixFlagAutonomous    = 0x40
+	raPrefixFlagOnLink        = 0x80
+	raPrefixPreferredLifetime = 7 * 24 * time.Hour
+	raPrefixValidLifetime     = 30 * 24 * time.Hour
+	raRouterLifetime          = 1800 * time.Second
+)
+
+var (
+	ipv6AllNodes   = net.ParseIP("ff02::1")
+	ipv6AllRouters = net.ParseIP("ff02::2")
+)
+
+// makeRouterAdvertisement makes a Router Advertisement message body for the
+// subnets for which this machine is the IPv6 gateway. The Managed and
+// OtherConfig flags direct clients to use DHCPv6, and the prefixes are also
+// usable for stateless address autoconfiguration.
+func makeRouterAdvertisement(hwAddr net.HardwareAddr,
+	subnets []*subnetType) []byte {
+	body := make([]byte, 12)
+	body[0] = 64 // Current hop limit.
+	body[1] = raFlagManaged | raFlagOtherConfig
+	binary.BigEndian.PutUint16(body[2:4], uint16(raRouterLifetime.Seconds()))
+	if len(hwAddr) == 6 {
+		option := make([]byte, 8)
+		option[0] = raOptionSourceLinkLayer
+		option[1] = 1 // Units of 8 bytes.
+		copy(option[2:], hwAddr)
+		body = append(body, option...)
+	}
+	var dnsServers []net.IP
+	for _, subnet := range subnets {
+		option := make([]byte, 32)
+		option[0] = raOptionPrefixInformation
+		option[1] = 4
+		option[2] = 64 // Prefix length.
+		option[3] = raPrefixFlagOnLink | raPrefixFlagAutonomous
+		binary.BigEndian.PutUint32(option[4:8],
+			uint32(raPrefixValidLifetime.Seconds()))
+		binary.BigEndian.PutUint32(option[8:12],
+			uint32(raPrefixPreferredLifetime.Seconds()))
+		copy(option[16:32], subnet.Ipv6Prefix.To16())
+		body = append(body, option...)
+		for _, dnsServer := range subnet.DomainNameServers {
+			if dnsServer.To4() == nil {
+				dnsServers = append(dnsServers, dnsServer)
+			}
+		}
+	}
+	if len(dnsServers) > 0 {
+		option := make([]byte, 8, 8+16*len(dnsServers))
+		option[0] = raOptionRdnss
+		option[1] = byte(1 + 2*len(dnsServers))
+		binary.BigEndian.PutUint32(option[4:8],
+			uint32(raRouterLifetime.Seconds()))
+		for _, dnsServer := range dnsServers {
+			option = append(option, dnsServer.To16()...)
+		}
+		body = append(body, option...)
+	}
+	return body
+}
+
+func (s *DhcpServer) startRouterAdvertiser(ifIndices map[int]string) error {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return err
+	}
+	pktConn := conn.IPv6PacketConn()
+	if err := pktConn.SetMulticastHopLimit(255); err != nil {
+		conn.Close()
+		return err
+	}
+	if err := pktConn.SetControlMessage(ipv6.FlagInterface, true); err != nil {
+		conn.Close()
+		return err
+	}
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterSolicitation)
+	if err := pktConn.SetICMPFilter(&filter); err != nil {
+		conn.Close()
+		return err
+	}
+	for ifIndex := range ifIndices {
+		iface, err := net.InterfaceByIndex(ifIndex)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		err = pktConn.JoinGroup(iface, &net.IPAddr{IP: ipv6AllRouters})
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	go s.readRouterSolicitations(pktConn)
+	go s.sendRouterAdvertisementsLoop(pktConn)
+	return nil
+}
+
+func (s *DhcpServer) readRouterSolicitations(conn *ipv6.PacketConn) {
+	buffer := make([]byte, 1500)
+	lastReplyTimes := make(map[int]time.Time) // Key: interface index.
+	for {
+		_, cm, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			s.logger.Println(err)
+			return
+		}
+		if cm == nil {
+			continue
+		}
+		// Limit the rate of replies so that a guest sending a flood of Router
+		// Solicitations cannot cause a flood of Router Advertisements.
+		if time.Since(lastReplyTimes[cm.IfIndex]) < raMinDelayBetweenReplies {
+			continue
+		}
+		if iface, err := net.InterfaceByIndex(cm.IfIndex); err == nil {
+			lastReplyTimes[cm.IfIndex] = time.Now()
+			s.sendRouterAdvertisement(conn, iface)
+		}
+	}
+}
+
+// sendRouterAdvertisement will send a Router Advertisement on the interface if
+// this machine is the IPv6 gateway for any subnets on the interface.
+func (s *DhcpServer) sendRouterAdvertisement(conn *ipv6.PacketConn,
+	iface *net.Interface) {
+	s.mutex.RLock()
+	subnets := s.ipv6IfSubnets[iface.Name]
+	if len(subnets) < 1 {
+		s.mutex.RUnlock()
+		return
+	}
+	body := makeRouterAdvertisement(iface.HardwareAddr, subnets)
+	s.mutex.RUnlock()
+	message := icmp.Message{
+		Type: ipv6.ICMPTypeRouterAdvertisement,
+		Body: &icmp.RawBody{Data: body},
+	}
+	// The kernel computes the checksum for ICMPv6.
+	packet, err := message.Marshal(nil)
+	if err != nil {
+		s.logger.Println(err)
+		return
+	}
+	_, err = conn.WriteTo(packet, &ipv6.ControlMessage{
+		HopLimit: 255,
+		IfIndex:  iface.Index,
+	}, &net.IPAddr{IP: ipv6AllNodes, Zone: iface.Name})
+	if err != nil {
+		s.logger.Printf("error sending Router Advertisement on: %s: %s\n",
+			iface.Name, err)
+	}
+}
+
+func (s *DhcpServer) sendRouterAdvertisementsLoop(conn *ipv6.PacketConn) {
+	for ; ; time.Sleep(raInterval) {
+		s.mutex.RLock()
+		interfaceNames := make([]string, 0, len(s.ipv6IfSubnets))
+		for interfaceName := range s.ipv6IfSubnets {
+			interfaceNames = append(interfaceNames, interfaceName)
+		}
+		s.mutex.RUnlock()
+		for _, interfaceName := range interfaceNames {
+			iface, err := net.InterfaceByName(interfaceName)
+			if err != nil {
+				s.logger.Println(err)
+				continue
+			}
+			s.sendRouterAdvertisement(conn, iface)
+		}
+	}
+}
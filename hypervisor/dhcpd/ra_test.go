@@ -0,0 +1,98 @@
+package dhcpd
+
+import (
+	"net"
+	"testing"
+
+	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func TestMakeRouterAdvertisement(t *testing.T) {
+	hwAddr := net.HardwareAddr{0x52, 0x54, 0, 0, 0, 1}
+	subnet0 := &subnetType{Subnet: proto.Subnet{
+		DomainNameServers: []net.IP{
+			net.ParseIP("10.0.0.2"),
+			net.ParseIP("2001:db8::2"),
+		},
+		Ipv6Prefix: net.ParseIP("2001:db8::"),
+	}}
+	subnet1 := &subnetType{Subnet: proto.Subnet{
+		Ipv6Prefix: net.ParseIP("2001:db8:1::"),
+	}}
+	tests := []struct {
+		name       string
+		hwAddr     net.HardwareAddr
+		subnets    []*subnetType
+		prefixes   []net.IP
+		numDns     int
+		haveLLAddr bool
+	}{
+		{"no subnets", hwAddr, nil, nil, 0, true},
+		{"no hardware address", nil, []*subnetType{subnet1},
+			[]net.IP{subnet1.Ipv6Prefix}, 0, false},
+		{"one subnet", hwAddr, []*subnetType{subnet0},
+			[]net.IP{subnet0.Ipv6Prefix}, 1, true},
+		{"two subnets", hwAddr, []*subnetType{subnet0, subnet1},
+			[]net.IP{subnet0.Ipv6Prefix, subnet1.Ipv6Prefix}, 1, true},
+	}
+	for _, test := range tests {
+		body := makeRouterAdvertisement(test.hwAddr, test.subnets)
+		if len(body) < 12 {
+			t.Errorf("%s: short body: %d", test.name, len(body))
+			continue
+		}
+		if body[1] != raFlagManaged|raFlagOtherConfig {
+			t.Errorf("%s: bad flags: 0x%x", test.name, body[1])
+		}
+		var prefixes []net.IP
+		var haveLLAddr bool
+		numDns := -1
+		options := body[12:]
+		for len(options) > 0 {
+			if len(options) < 8 || options[1] < 1 ||
+				len(options) < int(options[1])*8 {
+				t.Fatalf("%s: malformed options", test.name)
+			}
+			option := options[:int(options[1])*8]
+			options = options[len(option):]
+			switch option[0] {
+			case raOptionSourceLinkLayer:
+				haveLLAddr = true
+				if hwAddr := net.HardwareAddr(option[2:8]); hwAddr.String() !=
+					test.hwAddr.String() {
+					t.Errorf("%s: bad link-layer address: %s",
+						test.name, hwAddr)
+				}
+			case raOptionPrefixInformation:
+				if option[2] != 64 {
+					t.Errorf("%s: bad prefix length: %d", test.name, option[2])
+				}
+				prefixes = append(prefixes, net.IP(option[16:32]))
+			case raOptionRdnss:
+				numDns = (len(option) - 8) / 16
+			}
+		}
+		if haveLLAddr != test.haveLLAddr {
+			t.Errorf("%s: link-layer address option present: %v",
+				test.name, haveLLAddr)
+		}
+		if len(prefixes) != len(test.prefixes) {
+			t.Errorf("%s: expected prefixes: %v, got: %v",
+				test.name, test.prefixes, prefixes)
+		} else {
+			for index, prefix := range prefixes {
+				if !prefix.Equal(test.prefixes[index]) {
+					t.Errorf("%s: expected prefixes: %v, got: %v",
+						test.name, test.prefixes, prefixes)
+				}
+			}
+		}
+		if numDns < 0 {
+			numDns = 0
+		}
+		if numDns != test.numDns {
+			t.Errorf("%s: expected %d DNS servers, got: %d",
+				test.name, test.numDns, numDns)
+		}
+	}
+}
@@ -72,6 +72,11 @@ func (m *Manager) addAddressesToPool(addresses []proto.Address) error {
 		existingIpAddresses[ipAddress] = struct{}{}
 		existingMacAddresses[vm.Address.MacAddress] = struct{}{}
 	}
+	for index := range addresses {
+		if err := m.setIpv6Address(&addresses[index]); err != nil {
+			return err
+		}
+	}
 	for _, address := range addresses {
 		ipAddr := address.IpAddress
 		if ipAddr != nil {
@@ -177,6 +182,10 @@ func (m *Manager) getFreeAddress(ipAddr net.IP, subnetId string,
 			return proto.Address{}, "", err
 		}
 		address := m.addressPool.Free[foundPos]
+		// Addresses added before the subnet had an IPv6 prefix lack one.
+		if err := m.setIpv6Address(&address); err != nil {
+			return proto.Address{}, "", err
+		}
 		m.addressPool = addressPool
 		return address, subnet.Id, nil
 	}
@@ -4,16 +4,19 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
 	"github.com/Cloud-Foundations/Dominator/lib/filter"
 	"github.com/Cloud-Foundations/Dominator/lib/lockwatcher"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/objectserver/cachingreader"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/serverutil"
 	"github.com/Cloud-Foundations/Dominator/lib/tags"
 	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
 )
@@ -79,20 +82,22 @@ type Manager struct {
 }
 
 type StartOptions struct {
-	BridgeMap            map[string]net.Interface // Key: interface name.
-	DhcpServer           DhcpServer
-	IdentityProvider     string
-	ImageServerAddress   string
-	LockCheckInterval    time.Duration
-	LockLogTimeout       time.Duration
-	Logger               log.DebugLogger
-	ObjectCacheDirectory string
-	ObjectCacheBytes     uint64
-	ShowVgaConsole       bool
-	StateDir             string
-	Username             string
-	VlanIdToBridge       map[uint]string // Key: VLAN ID, value: bridge interface.
-	VolumeDirectories    []string
+	BridgeMap              map[string]net.Interface // Key: interface name.
+	DhcpServer             DhcpServer
+	IdentityProvider       string
+	ImageServerAddress     string
+	ImageSignatureVerifier *dsse.Verifier // nil: do not verify.
+	LockCheckInterval      time.Duration
+	LockLogTimeout         time.Duration
+	Logger                 log.DebugLogger
+	ObjectCacheDirectory   string
+	ObjectCacheBytes       uint64
+	RateLimiter            *serverutil.RateLimiter // nil: no limits.
+	ShowVgaConsole         bool
+	StateDir               string
+	Username               string
+	VlanIdToBridge         map[uint]string // Key: VLAN ID, value: bridge interface.
+	VolumeDirectories      []string
 }
 
 type summaryData struct {
@@ -125,6 +130,8 @@ type vmInfoType struct {
 	logger                     log.DebugLogger
 	manager                    *Manager
 	metadataChannels           map[chan<- string]struct{}
+	migrationNotifier          chan<- string
+	monitorFile                *os.File
 	monitorSockname            string
 	blockMutations             bool
 	ownerUsers                 map[string]struct{}
@@ -488,6 +495,11 @@ func (m *Manager) RegisterVmMetadataNotifier(ipAddr net.IP,
 	return m.registerVmMetadataNotifier(ipAddr, authInfo, pathChannel)
 }
 
+func (m *Manager) ResumeVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
+	accessToken []byte) error {
+	return m.resumeVm(ipAddr, authInfo, accessToken)
+}
+
 func (m *Manager) ReplaceVmCredentials(
 	request proto.ReplaceVmCredentialsRequest,
 	authInfo *srpc.AuthInformation) error {
@@ -563,6 +575,11 @@ func (m *Manager) StopVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
 	return m.stopVm(ipAddr, authInfo, accessToken)
 }
 
+func (m *Manager) SuspendVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
+	accessToken []byte) error {
+	return m.suspendVm(ipAddr, authInfo, accessToken)
+}
+
 func (m *Manager) UpdateSubnets(request proto.UpdateSubnetsRequest) error {
 	return m.updateSubnets(request)
 }
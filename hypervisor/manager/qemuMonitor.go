@@ -17,8 +17,18 @@ type copyingReader struct {
 	r           io.Reader
 }
 
+type migrationDataType struct {
+	Status string `json:"status"`
+}
+
+type monitorErrorType struct {
+	Class       string `json:"class"`
+	Description string `json:"desc"`
+}
+
 type monitorMessageType struct {
 	Data      json.RawMessage      `json:data",omitempty"`
+	Error     *monitorErrorType    `json:"error,omitempty"`
 	Event     string               `json:event",omitempty"`
 	Timestamp monitorTimestampType `json:timestamp",omitempty"`
 }
@@ -76,7 +86,29 @@ func (vm *vmInfoType) processMonitorResponses(monitorSock net.Conn,
 		} else {
 			lastDecodeFailed = false
 		}
+		if message.Error != nil {
+			vm.logger.Printf("monitor error: %s: %s\n",
+				message.Error.Class, message.Error.Description)
+			vm.mutex.RLock()
+			vm.sendMigrationStatus("error: " + message.Error.Description)
+			vm.mutex.RUnlock()
+		}
 		switch message.Event {
+		case "MIGRATION":
+			var migrationData migrationDataType
+			if err := json.Unmarshal(message.Data, &migrationData); err != nil {
+				vm.logger.Printf(
+					"error unmarshaling migration event data: %s\n", err)
+				continue
+			}
+			vm.logger.Debugf(0, "VM migration status: %s\n",
+				migrationData.Status)
+			switch migrationData.Status {
+			case "cancelled", "completed", "failed":
+				vm.mutex.RLock()
+				vm.sendMigrationStatus(migrationData.Status)
+				vm.mutex.RUnlock()
+			}
 		case "SHUTDOWN":
 			var shutdownData shutdownDataType
 			if err := json.Unmarshal(message.Data, &shutdownData); err != nil {
@@ -163,6 +195,18 @@ func (vm *vmInfoType) processMonitorResponses(monitorSock net.Conn,
 	case proto.StateCrashed:
 		vm.logger.Println("monitor socket closed on already crashed VM")
 		return
+	case proto.StateSuspending:
+		vm.sendMigrationStatus("monitor socket closed")
+		if vm.hasSuspendedState() {
+			vm.setState(proto.StateSuspended)
+		} else {
+			vm.setState(proto.StateCrashed)
+		}
+		select {
+		case vm.stoppedNotifier <- struct{}{}:
+		default:
+		}
+		return
 	default:
 		vm.logger.Println("unknown state: " + vm.State.String())
 	}
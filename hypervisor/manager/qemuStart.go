@@ -47,7 +47,7 @@ func getQemuCpuModelFlags() (map[string]struct{}, error) {
 
 func (vm *vmInfoType) startQemuVm(enableNetboot, haveManagerLock bool,
 	pidfile string, nCpus uint, netOptions []string,
-	tapFiles []*os.File) error {
+	tapFiles []*os.File, incoming bool) error {
 	cpuModelFlags, err := getQemuCpuModelFlags()
 	if err != nil {
 		return err
@@ -176,7 +176,13 @@ func (vm *vmInfoType) startQemuVm(enableNetboot, haveManagerLock bool,
 			"-watchdog-action", vm.WatchdogAction.String(),
 			"-device", vm.WatchdogModel.String())
 	}
-	os.Remove(filepath.Join(vm.dirname, "bootlog"))
+	if incoming {
+		// The VM state saved by suspendVm is restored by
+		// restoreSuspendedState once the monitor is connected.
+		cmd.Args = append(cmd.Args, "-incoming", "defer")
+	} else {
+		os.Remove(filepath.Join(vm.dirname, "bootlog"))
+	}
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, "VM_HOSTNAME="+vm.Hostname)
 	if len(vm.OwnerGroups) > 0 {
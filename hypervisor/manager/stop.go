@@ -21,7 +21,8 @@ func (m *Manager) powerOff(stopVMs bool) error {
 	}
 	defer m.mutex.RUnlock()
 	for _, vm := range m.vms {
-		if vm.State != proto.StateStopped {
+		if vm.State != proto.StateStopped &&
+			vm.State != proto.StateSuspended {
 			return fmt.Errorf("%s is not shut down", vm.Address.IpAddress)
 		}
 	}
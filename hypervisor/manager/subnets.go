@@ -144,6 +144,30 @@ func (m *Manager) getSubnetAndAuth(subnetId string,
 	return m.subnets[subnetsPermitted[0]], nil
 }
 
+// setIpv6Address will set the IPv6 address if the subnet matching the IPv4
+// address has an IPv6 prefix. The address is generated from the MAC address so
+// that it matches the address chosen by stateless address autoconfiguration.
+// This must be called with the lock held.
+func (m *Manager) setIpv6Address(address *proto.Address) error {
+	if len(address.Ipv6Address) > 0 || len(address.IpAddress) < 1 {
+		return nil
+	}
+	subnet, ok := m.subnets[m.getMatchingSubnet(address.IpAddress)]
+	if !ok || len(subnet.Ipv6Prefix) < 1 {
+		return nil
+	}
+	hwAddr, err := net.ParseMAC(address.MacAddress)
+	if err != nil {
+		return err
+	}
+	ipAddr, err := util.MakeEui64Address(subnet.Ipv6Prefix, hwAddr)
+	if err != nil {
+		return fmt.Errorf("subnet: %s: %s", subnet.Id, err)
+	}
+	address.Ipv6Address = ipAddr
+	return nil
+}
+
 func (m *Manager) listSubnets(doSort bool) []proto.Subnet {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -0,0 +1,290 @@
+package manager
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+const (
+	suspendedStateFilename    = "suspended-state"
+	suspendedStateTmpFilename = "suspended-state.tmp"
+	suspendTimeout            = 15 * time.Minute
+
+	closeSuspendFdJson        = `{"execute":"closefd","arguments":{"fdname":"suspend"}}`
+	enableMigrationEventsJson = `{"execute":"migrate-set-capabilities","arguments":{"capabilities":[{"capability":"events","state":true}]}}`
+	getSuspendFdJson          = `{"execute":"getfd","arguments":{"fdname":"suspend"}}`
+	migrateFromSuspendFdJson  = `{"execute":"migrate-incoming","arguments":{"uri":"fd:suspend"}}`
+	migrateToSuspendFdJson    = `{"execute":"migrate","arguments":{"uri":"fd:suspend"}}`
+)
+
+func copyAndSync(file *os.File, reader io.ReadCloser) error {
+	defer reader.Close()
+	_, err := io.Copy(file, reader)
+	if err == nil {
+		err = file.Sync()
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (m *Manager) resumeVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
+	accessToken []byte) error {
+	if m.disabled {
+		return errors.New("Hypervisor is disabled")
+	}
+	vm, err := m.getVmLockAndAuth(ipAddr, true, authInfo, accessToken)
+	if err != nil {
+		return err
+	}
+	if vm.State != proto.StateSuspended {
+		vm.mutex.Unlock()
+		return errors.New("VM is not suspended")
+	}
+	if err := checkAvailableMemory(vm.MemoryInMiB); err != nil {
+		vm.mutex.Unlock()
+		return err
+	}
+	vm.setState(proto.StateStarting)
+	vm.mutex.Unlock()
+	if _, err := vm.startManaging(0, false, false); err != nil {
+		return err
+	}
+	// The saved state has been restored, so it is safe to remove it now.
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+	return vm.discardSuspendedState()
+}
+
+// suspendVm saves the device and memory state of a running VM to a file in the
+// VM directory using a QEMU migration to a file descriptor, and then stops the
+// VM. If the state cannot be saved, the VM continues running.
+func (m *Manager) suspendVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
+	accessToken []byte) error {
+	vm, err := m.getVmLockAndAuth(ipAddr, true, authInfo, accessToken)
+	if err != nil {
+		return err
+	}
+	doUnlock := true
+	defer func() {
+		if doUnlock {
+			vm.mutex.Unlock()
+		}
+	}()
+	switch vm.State {
+	case proto.StateRunning:
+	case proto.StateSuspending:
+		return errors.New("VM is already suspending")
+	case proto.StateSuspended:
+		return errors.New("VM is already suspended")
+	default:
+		return errors.New("VM is not running")
+	}
+	if len(vm.Address.IpAddress) < 1 {
+		return errors.New("cannot suspend VM with externally managed lease")
+	}
+	if vm.commandInput == nil {
+		return errors.New("no commandInput for VM")
+	}
+	tmpFilename := filepath.Join(vm.dirname, suspendedStateTmpFilename)
+	file, err := os.OpenFile(tmpFilename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		fsutil.PrivateFilePerms)
+	if err != nil {
+		return err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		os.Remove(tmpFilename)
+		return err
+	}
+	copyResult := make(chan error, 1)
+	go func() {
+		copyResult <- copyAndSync(file, reader)
+	}()
+	migrationNotifier := make(chan string, 1)
+	stoppedNotifier := make(chan struct{}, 1)
+	vm.migrationNotifier = migrationNotifier
+	vm.monitorFile = writer
+	vm.stoppedNotifier = stoppedNotifier
+	vm.setState(proto.StateSuspending)
+	vm.logger.Println("suspending VM")
+	vm.commandInput <- "stop"
+	vm.commandInput <- "\\" + enableMigrationEventsJson
+	vm.commandInput <- "getfd" // Sends vm.monitorFile.
+	vm.commandInput <- "\\" + migrateToSuspendFdJson
+	vm.mutex.Unlock()
+	doUnlock = false
+	timer := time.NewTimer(suspendTimeout)
+	var status string
+	select {
+	case status = <-migrationNotifier:
+		if !timer.Stop() {
+			<-timer.C
+		}
+	case <-timer.C:
+		status = "timed out"
+	}
+	writer.Close() // QEMU has its own copy.
+	if status == "completed" {
+		err = <-copyResult
+		if err == nil {
+			err = os.Rename(tmpFilename,
+				filepath.Join(vm.dirname, suspendedStateFilename))
+		}
+	} else {
+		reader.Close()
+		err = errors.New("error saving VM state: " + status)
+	}
+	vm.mutex.Lock()
+	vm.migrationNotifier = nil
+	if vm.State != proto.StateSuspending {
+		vm.mutex.Unlock()
+		os.Remove(tmpFilename)
+		if err != nil {
+			return err
+		}
+		return errors.New("VM is " + vm.State.String())
+	}
+	if err != nil {
+		vm.logger.Println(err)
+		if status == "timed out" {
+			vm.commandInput <- "migrate_cancel"
+		}
+		vm.commandInput <- "\\" + closeSuspendFdJson
+		vm.commandInput <- "cont"
+		vm.setState(proto.StateRunning)
+		vm.mutex.Unlock()
+		os.Remove(tmpFilename)
+		return err
+	}
+	vm.commandInput <- "quit"
+	vm.mutex.Unlock()
+	timer.Reset(time.Minute)
+	select {
+	case <-stoppedNotifier:
+		if !timer.Stop() {
+			<-timer.C
+		}
+	case <-timer.C:
+		return errors.New("timed out waiting for VM to stop after suspend")
+	}
+	vm.logger.Println("VM suspended")
+	return nil
+}
+
+// discardSuspendedState removes any saved VM state. The VM lock must be held.
+func (vm *vmInfoType) discardSuspendedState() error {
+	os.Remove(filepath.Join(vm.dirname, suspendedStateTmpFilename))
+	err := os.Remove(filepath.Join(vm.dirname, suspendedStateFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (vm *vmInfoType) hasSuspendedState() bool {
+	_, err := os.Stat(filepath.Join(vm.dirname, suspendedStateFilename))
+	return err == nil
+}
+
+// openSuspendedState opens the saved VM state. If there is no saved state, nil
+// is returned.
+func (vm *vmInfoType) openSuspendedState() (*os.File, error) {
+	file, err := os.Open(filepath.Join(vm.dirname, suspendedStateFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// restoreSuspendedState restores the VM state saved by suspendVm into QEMU,
+// which must have been started with "-incoming defer", and then continues the
+// VM. The VM was stopped when the state was saved, so QEMU leaves it paused
+// after the incoming migration. The VM lock must not be held.
+func (vm *vmInfoType) restoreSuspendedState(timeout time.Duration) error {
+	file, err := vm.openSuspendedState()
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return errors.New("no suspended state for VM")
+	}
+	defer file.Close()
+	migrationNotifier := make(chan string, 1)
+	vm.mutex.Lock()
+	if vm.commandInput == nil {
+		vm.mutex.Unlock()
+		return errors.New("no commandInput for VM")
+	}
+	vm.migrationNotifier = migrationNotifier
+	vm.monitorFile = file
+	vm.logger.Println("restoring VM state")
+	vm.commandInput <- "\\" + enableMigrationEventsJson
+	vm.commandInput <- "getfd" // Sends vm.monitorFile.
+	vm.commandInput <- "\\" + migrateFromSuspendFdJson
+	vm.mutex.Unlock()
+	timer := time.NewTimer(timeout)
+	var status string
+	select {
+	case status = <-migrationNotifier:
+		if !timer.Stop() {
+			<-timer.C
+		}
+	case <-timer.C:
+		status = "timed out"
+	}
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+	vm.migrationNotifier = nil
+	vm.monitorFile = nil
+	if status != "completed" {
+		return errors.New("error restoring VM state: " + status)
+	}
+	if vm.commandInput == nil {
+		return errors.New("VM stopped while restoring state")
+	}
+	vm.commandInput <- "cont"
+	vm.logger.Println("VM state restored")
+	return nil
+}
+
+// sendMigrationStatus sends a migration status if a suspend is in progress.
+// The VM lock must be held.
+func (vm *vmInfoType) sendMigrationStatus(status string) {
+	if vm.migrationNotifier == nil {
+		return
+	}
+	select {
+	case vm.migrationNotifier <- status:
+	default:
+	}
+}
+
+// sendMonitorFile sends vm.monitorFile to QEMU with the getfd command. It is
+// called from the monitor goroutine.
+func (vm *vmInfoType) sendMonitorFile(monitorSock net.Conn) error {
+	unixConn, ok := monitorSock.(*net.UnixConn)
+	if !ok {
+		return errors.New("monitor socket is not a Unix socket")
+	}
+	if vm.monitorFile == nil {
+		return errors.New("no file to send to monitor")
+	}
+	_, _, err := unixConn.WriteMsgUnix([]byte(getSuspendFdJson+"\n"),
+		syscall.UnixRights(int(vm.monitorFile.Fd())), nil)
+	return err
+}
@@ -0,0 +1,118 @@
+package manager
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func makeTestVm(t *testing.T, state proto.State) *vmInfoType {
+	vm := &vmInfoType{
+		dirname:          t.TempDir(),
+		doNotWriteOrSend: true,
+		logger:           testlogger.New(t),
+	}
+	vm.State = state
+	return vm
+}
+
+func writeSuspendedState(t *testing.T, vm *vmInfoType) {
+	err := os.WriteFile(filepath.Join(vm.dirname, suspendedStateFilename),
+		[]byte("state"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// closeMonitor simulates QEMU exiting and waits for the monitor to be
+// processed.
+func closeMonitor(t *testing.T, vm *vmInfoType) {
+	stoppedNotifier := make(chan struct{}, 1)
+	vm.commandInput = make(chan string, 1)
+	vm.stoppedNotifier = stoppedNotifier
+	client, server := net.Pipe()
+	go vm.processMonitorResponses(server, make(chan byte, 16))
+	client.Close()
+	select {
+	case <-stoppedNotifier:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for monitor to close")
+	}
+}
+
+func expectCommand(t *testing.T, commandInput <-chan string,
+	expected string) {
+	select {
+	case command := <-commandInput:
+		if command != expected {
+			t.Fatalf("expected command: %s, got: %s", expected, command)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for command: %s", expected)
+	}
+}
+
+func TestSuspendedStates(t *testing.T) {
+	vm := makeTestVm(t, proto.StateSuspending)
+	closeMonitor(t, vm)
+	if vm.State != proto.StateCrashed {
+		t.Fatalf("no saved state: expected: %s, got: %s",
+			proto.State(proto.StateCrashed), vm.State)
+	}
+	vm = makeTestVm(t, proto.StateSuspending)
+	writeSuspendedState(t, vm)
+	closeMonitor(t, vm)
+	if vm.State != proto.StateSuspended {
+		t.Fatalf("expected: %s, got: %s",
+			proto.State(proto.StateSuspended), vm.State)
+	}
+	// A suspended VM is not started when the Hypervisor restarts.
+	if _, err := vm.startManaging(0, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if vm.State != proto.StateSuspended {
+		t.Fatalf("expected: %s, got: %s",
+			proto.State(proto.StateSuspended), vm.State)
+	}
+}
+
+func TestRestoreSuspendedState(t *testing.T) {
+	for _, status := range []string{"completed", "failed"} {
+		vm := makeTestVm(t, proto.StateStarting)
+		writeSuspendedState(t, vm)
+		commandInput := make(chan string, 1)
+		vm.commandInput = commandInput
+		result := make(chan error, 1)
+		go func() {
+			result <- vm.restoreSuspendedState(5 * time.Second)
+		}()
+		expectCommand(t, commandInput, "\\"+enableMigrationEventsJson)
+		expectCommand(t, commandInput, "getfd")
+		expectCommand(t, commandInput, "\\"+migrateFromSuspendFdJson)
+		// The VM must not be continued before the state is restored.
+		select {
+		case command := <-commandInput:
+			t.Fatalf("%s: unexpected command: %s", status, command)
+		case <-time.After(10 * time.Millisecond):
+		}
+		vm.mutex.RLock()
+		vm.sendMigrationStatus(status)
+		vm.mutex.RUnlock()
+		if status == "completed" {
+			expectCommand(t, commandInput, "cont")
+			if err := <-result; err != nil {
+				t.Fatal(err)
+			}
+		} else if err := <-result; err == nil {
+			t.Fatalf("%s: no error", status)
+		}
+		if vm.migrationNotifier != nil || vm.monitorFile != nil {
+			t.Fatalf("%s: restore state not cleared", status)
+		}
+	}
+}
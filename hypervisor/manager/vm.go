@@ -32,6 +32,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/fsutil/mounts"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
 	"github.com/Cloud-Foundations/Dominator/lib/json"
 	"github.com/Cloud-Foundations/Dominator/lib/lockwatcher"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
@@ -1264,6 +1265,21 @@ func (m *Manager) createVm(conn *srpc.Conn) error {
 		return sendError(conn, errors.New("no authentication data"))
 	}
 	ownerUsers = append(ownerUsers, request.OwnerUsers...)
+	cost := float64(numSpecifiedVirtualCPUs(request.MilliCPUs,
+		request.VirtualCPUs))
+	err := m.RateLimiter.Charge("CreateVm", conn.GetAuthInformation(), cost)
+	if err != nil {
+		if err := maybeDrainAll(conn, request); err != nil {
+			return err
+		}
+		return sendError(conn, err)
+	}
+	refundCharge := true
+	defer func() {
+		if refundCharge { // Only successfully created VMs are charged.
+			m.RateLimiter.Refund("CreateVm", conn.GetAuthInformation(), cost)
+		}
+	}()
 	var identityExpires time.Time
 	var identityName string
 	if len(request.IdentityCertificate) > 0 && len(request.IdentityKey) > 0 {
@@ -1481,6 +1497,7 @@ func (m *Manager) createVm(conn *srpc.Conn) error {
 	vm.setupLockWatcher()
 	m.Logger.Debugf(1, "CreateVm(%s) finished, IP=%s\n",
 		conn.Username(), vm.ipAddress)
+	refundCharge = false
 	vm = nil // Cancel cleanup.
 	return nil
 }
@@ -1694,10 +1711,12 @@ func (m *Manager) destroyVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
 	case proto.StateStopping:
 		return errors.New("VM is stopping")
 	case proto.StateStopped, proto.StateFailedToStart, proto.StateMigrating,
-		proto.StateExporting, proto.StateCrashed:
+		proto.StateExporting, proto.StateCrashed, proto.StateSuspended:
 		vm.delete()
 	case proto.StateDestroying:
 		return errors.New("VM is already destroying")
+	case proto.StateSuspending:
+		return errors.New("VM is suspending")
 	default:
 		return errors.New("unknown state: " + vm.State.String())
 	}
@@ -1821,6 +1840,9 @@ func (m *Manager) getImage(searchName string, imageTimeout time.Duration) (
 		if err != nil {
 			return nil, nil, "", err
 		}
+		if err := m.verifyImageSignature(imageName, img); err != nil {
+			return nil, nil, "", err
+		}
 		img.FileSystem.RebuildInodePointers()
 		doClose = false
 		return client, img, imageName, nil
@@ -1832,6 +1854,9 @@ func (m *Manager) getImage(searchName string, imageTimeout time.Duration) (
 	if img == nil {
 		return nil, nil, "", errors.New("timeout getting image")
 	}
+	if err := m.verifyImageSignature(searchName, img); err != nil {
+		return nil, nil, "", err
+	}
 	if err := img.FileSystem.RebuildInodePointers(); err != nil {
 		return nil, nil, "", err
 	}
@@ -3666,6 +3691,10 @@ func (m *Manager) startVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
 		return false, errors.New("VM is destroying")
 	case proto.StateMigrating:
 		return false, errors.New("VM is migrating")
+	case proto.StateSuspending:
+		return false, errors.New("VM is suspending")
+	case proto.StateSuspended:
+		return false, errors.New("VM is suspended: resume or stop it")
 	case proto.StateDebugging:
 		debugRoot := vm.getDebugRoot()
 		if debugRoot == "" {
@@ -3727,12 +3756,17 @@ func (m *Manager) stopVm(ipAddr net.IP, authInfo *srpc.AuthInformation,
 		vm.mutex.Unlock()
 		doUnlock = false
 		<-stoppedNotifier
-	case proto.StateFailedToStart:
+	case proto.StateFailedToStart, proto.StateSuspended:
+		if err := vm.discardSuspendedState(); err != nil {
+			return err
+		}
 		vm.setState(proto.StateStopped)
 	case proto.StateStopping:
 		return errors.New("VM is stopping")
 	case proto.StateStopped:
 		return errors.New("VM is already stopped")
+	case proto.StateSuspending:
+		return errors.New("VM is suspending")
 	case proto.StateDestroying:
 		return errors.New("VM is destroying")
 	case proto.StateMigrating:
@@ -3758,6 +3792,20 @@ func (m *Manager) unregisterVmMetadataNotifier(ipAddr net.IP,
 	return nil
 }
 
+// verifyImageSignature will verify the signature of an image, if signatures
+// are required.
+func (m *Manager) verifyImageSignature(name string, img *image.Image) error {
+	if m.ImageSignatureVerifier == nil {
+		return nil
+	}
+	keyId, err := signature.Verify(name, img, m.ImageSignatureVerifier)
+	if err != nil {
+		return fmt.Errorf("image: %s bad signature: %s", name, err)
+	}
+	m.Logger.Debugf(0, "image: %s signed by key: %s\n", name, keyId)
+	return nil
+}
+
 func (m *Manager) writeRaw(volume proto.LocalVolume, extension string,
 	client *srpc.Client, fs *filesystem.FileSystem,
 	writeRawOptions util.WriteRawOptions, skipBootloader bool) error {
@@ -3853,6 +3901,9 @@ func (vm *vmInfoType) checkAuth(authInfo *srpc.AuthInformation,
 			return nil
 		}
 	}
+	if authInfo.CheckPolicyForTarget(vm.Tags) {
+		return nil
+	}
 	return errorNoAccessToResource
 }
 
@@ -4047,6 +4098,8 @@ func (vm *vmInfoType) monitor(monitorSock net.Conn,
 		var err error
 		if command == "reboot" { // Not a QMP command: convert to ctrl-alt-del.
 			_, err = monitorSock.Write([]byte(rebootJson))
+		} else if command == "getfd" { // Send vm.monitorFile with getfd.
+			err = vm.sendMonitorFile(monitorSock)
 		} else if command[0] == '\\' {
 			_, err = fmt.Fprintln(monitorSock, command[1:])
 		} else {
@@ -4214,6 +4267,11 @@ func (vm *vmInfoType) startManaging(dhcpTimeout time.Duration,
 		return false, nil
 	case proto.StateCrashed:
 	case proto.StateDebugging:
+	case proto.StateSuspending:
+		// The Hypervisor was restarted while suspending: abandon the suspend.
+		os.Remove(filepath.Join(vm.dirname, suspendedStateTmpFilename))
+	case proto.StateSuspended:
+		return false, nil
 	default:
 		vm.logger.Println("unknown state: " + vm.State.String())
 		return false, nil
@@ -4234,11 +4292,14 @@ func (vm *vmInfoType) startManaging(dhcpTimeout time.Duration,
 			}
 		}
 	}
+	var restoreState bool
 	monitorSock, err := net.Dial("unix", vm.monitorSockname)
 	if err != nil {
 		vm.logger.Debugf(1, "error connecting to: %s: %s\n",
 			vm.monitorSockname, err)
-		if err := vm.startVm(enableNetboot, haveManagerLock); err != nil {
+		restoreState = vm.hasSuspendedState()
+		err = vm.startVm(enableNetboot, haveManagerLock, restoreState)
+		if err != nil {
 			vm.logger.Println(err)
 			vm.setState(proto.StateFailedToStart)
 			return false, err
@@ -4256,6 +4317,16 @@ func (vm *vmInfoType) startManaging(dhcpTimeout time.Duration,
 	vm.commandOutput = commandOutput
 	go vm.monitor(monitorSock, commandInput, commandOutput)
 	commandInput <- "qmp_capabilities"
+	if restoreState {
+		if err := vm.restoreSuspendedState(suspendTimeout); err != nil {
+			vm.logger.Println(err)
+			commandInput <- "quit"
+			vm.setState(proto.StateFailedToStart)
+			return false, err
+		}
+	} else if vm.State == proto.StateSuspending {
+		commandInput <- "cont"
+	}
 	if vm.getDebugRoot() == "" {
 		vm.setState(proto.StateRunning)
 	} else {
@@ -4392,7 +4463,10 @@ func (vm *vmInfoType) setupLockWatcher() {
 		})
 }
 
-func (vm *vmInfoType) startVm(enableNetboot, haveManagerLock bool) error {
+// startVm starts QEMU. If incoming is true, QEMU waits for the suspended VM
+// state to be restored.
+func (vm *vmInfoType) startVm(enableNetboot, haveManagerLock,
+	incoming bool) error {
 	if err := checkAvailableMemory(vm.MemoryInMiB); err != nil {
 		return err
 	}
@@ -4415,7 +4489,7 @@ func (vm *vmInfoType) startVm(enableNetboot, haveManagerLock bool) error {
 	}
 	pidfile := filepath.Join(vm.dirname, "pidfile")
 	err = vm.startQemuVm(enableNetboot, haveManagerLock, pidfile, nCpus,
-		netOptions, tapFiles)
+		netOptions, tapFiles, incoming)
 	if err != nil {
 		return err
 	}
@@ -11,6 +11,15 @@ import (
 	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
 )
 
+type Options struct {
+	// If true, serve metadata in the EC2 and OpenStack layouts, for use by
+	// cloud-init in stock images.
+	CloudInit bool
+	// Directory containing authorized_keys files for VM owners (named by the
+	// username). Keys for the VM owners are included in cloud-init metadata.
+	OwnerSshKeysDirectory string
+}
+
 type rawHandlerFunc func(w http.ResponseWriter, ipAddr net.IP)
 type metadataWriter func(writer io.Writer, vmInfo proto.VmInfo) error
 
@@ -21,17 +30,26 @@ type server struct {
 	logger            log.DebugLogger
 	fileHandlers      map[string]string
 	infoHandlers      map[string]metadataWriter
+	options           Options
 	rawHandlers       map[string]rawHandlerFunc
 	paths             map[string]struct{}
 }
 
 func StartServer(hypervisorPortNum uint, bridges []net.Interface,
 	managerObj *manager.Manager, logger log.DebugLogger) error {
+	return StartServerWithOptions(hypervisorPortNum, bridges, managerObj,
+		Options{}, logger)
+}
+
+func StartServerWithOptions(hypervisorPortNum uint, bridges []net.Interface,
+	managerObj *manager.Manager, options Options,
+	logger log.DebugLogger) error {
 	s := &server{
 		bridges:           bridges,
 		hypervisorPortNum: hypervisorPortNum,
 		manager:           managerObj,
 		logger:            logger,
+		options:           options,
 	}
 	s.fileHandlers = map[string]string{
 		constants.MetadataIdentityEd25519SshCert:  manager.IdentityEd25519SshCertFile,
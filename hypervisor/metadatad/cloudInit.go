@@ -0,0 +1,387 @@
+package metadatad
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/hypervisor/manager"
+	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+const (
+	ec2MetadataPrefix     = "/latest/meta-data"
+	openStackPrefix       = "/openstack"
+	openStackLatestPrefix = openStackPrefix + "/latest"
+	openStackUserData     = openStackLatestPrefix + "/user_data"
+)
+
+type openStackKey struct {
+	Data string `json:"data"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type openStackLink struct {
+	EthernetMacAddress string `json:"ethernet_mac_address"`
+	Id                 string `json:"id"`
+	Type               string `json:"type"`
+}
+
+type openStackMetadata struct {
+	Hostname   string            `json:"hostname"`
+	Keys       []openStackKey    `json:"keys,omitempty"`
+	Meta       map[string]string `json:"meta,omitempty"`
+	Name       string            `json:"name"`
+	PublicKeys map[string]string `json:"public_keys,omitempty"`
+	UUID       string            `json:"uuid"`
+}
+
+type openStackNetwork struct {
+	Id        string           `json:"id"`
+	IpAddress string           `json:"ip_address,omitempty"`
+	Link      string           `json:"link"`
+	Netmask   string           `json:"netmask,omitempty"`
+	NetworkId string           `json:"network_id"`
+	Routes    []openStackRoute `json:"routes,omitempty"`
+	Type      string           `json:"type"`
+}
+
+type openStackNetworkData struct {
+	Links    []openStackLink    `json:"links"`
+	Networks []openStackNetwork `json:"networks"`
+	Services []openStackService `json:"services,omitempty"`
+}
+
+type openStackRoute struct {
+	Gateway string `json:"gateway"`
+	Netmask string `json:"netmask"`
+	Network string `json:"network"`
+}
+
+type openStackService struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+}
+
+type sshKeyType struct {
+	key   string
+	owner string
+}
+
+type vmInterfaceType struct {
+	address proto.Address
+	name    string
+	subnet  *proto.Subnet // nil if the subnet is unknown.
+}
+
+// listTree returns the names of the entries in the specified directory of the
+// tree. Entries which are directories have a trailing "/".
+func listTree(tree map[string][]byte, dirname string) []string {
+	dirname += "/"
+	entries := make(map[string]struct{})
+	for path := range tree {
+		if !strings.HasPrefix(path, dirname) {
+			continue
+		}
+		name := path[len(dirname):]
+		if index := strings.IndexByte(name, '/'); index >= 0 {
+			name = name[:index+1]
+		}
+		entries[name] = struct{}{}
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		if _, ok := entries[name+"/"]; ok {
+			continue // Show files which also have children as directories.
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// makeHostname returns the hostname for the VM. If the VM has no hostname, one
+// is derived from the IP address.
+func makeHostname(vmInfo proto.VmInfo) string {
+	if vmInfo.Hostname != "" {
+		return vmInfo.Hostname
+	}
+	ipAddr := vmInfo.Address.IpAddress.To4()
+	if ipAddr == nil {
+		return "vm"
+	}
+	return fmt.Sprintf("ip-%d-%d-%d-%d", ipAddr[0], ipAddr[1], ipAddr[2],
+		ipAddr[3])
+}
+
+// makeInstanceUUID returns a UUID for the VM. It includes the creation time so
+// that a new VM re-using an IP address is seen as a new instance.
+func makeInstanceUUID(vmInfo proto.VmInfo) string {
+	hash := sha256.Sum256([]byte(vmInfo.Address.IpAddress.String() + "@" +
+		vmInfo.CreatedOn.UTC().String()))
+	hash[6] = hash[6]&0x0f | 0x50 // Version 5 (name based).
+	hash[8] = hash[8]&0x3f | 0x80 // RFC 4122 variant.
+	return fmt.Sprintf("%x-%x-%x-%x-%x",
+		hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
+
+func makeInstanceId(vmInfo proto.VmInfo) string {
+	return "i-" + strings.Replace(makeInstanceUUID(vmInfo), "-", "", -1)[:17]
+}
+
+func writeJson(tree map[string][]byte, path string, value interface{}) {
+	if data, err := json.MarshalIndent(value, "", "    "); err == nil {
+		tree[path] = append(data, '\n')
+	}
+}
+
+func (s *server) getSshKeys(owners []string) []sshKeyType {
+	if s.options.OwnerSshKeysDirectory == "" {
+		return nil
+	}
+	var keys []sshKeyType
+	for _, owner := range owners {
+		if owner == "" || owner[0] == '.' || strings.Contains(owner, "/") {
+			continue
+		}
+		file, err := os.Open(filepath.Join(s.options.OwnerSshKeysDirectory,
+			owner))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				s.logger.Println(err)
+			}
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || line[0] == '#' {
+				continue
+			}
+			keys = append(keys, sshKeyType{key: line, owner: owner})
+		}
+		file.Close()
+	}
+	return keys
+}
+
+// getVmInterfaces returns the network interfaces for the VM, in the order
+// they are presented to the VM.
+func (s *server) getVmInterfaces(vmInfo proto.VmInfo) []vmInterfaceType {
+	subnets := make(map[string]*proto.Subnet)
+	for _, subnet := range s.manager.ListSubnets(false) {
+		subnet := subnet
+		subnets[subnet.Id] = &subnet
+	}
+	interfaces := make([]vmInterfaceType, 0, len(vmInfo.SecondaryAddresses)+1)
+	interfaces = append(interfaces, vmInterfaceType{
+		address: vmInfo.Address,
+		name:    "eth0",
+		subnet:  subnets[vmInfo.SubnetId],
+	})
+	for index, address := range vmInfo.SecondaryAddresses {
+		vmInterface := vmInterfaceType{
+			address: address,
+			name:    "eth" + strconv.Itoa(index+1),
+		}
+		if index < len(vmInfo.SecondarySubnetIDs) {
+			vmInterface.subnet = subnets[vmInfo.SecondarySubnetIDs[index]]
+		}
+		interfaces = append(interfaces, vmInterface)
+	}
+	return interfaces
+}
+
+// makeCloudInitTree generates the cloud-init compatible metadata for the VM,
+// using the EC2 and OpenStack layouts. The keys are the paths.
+func (s *server) makeCloudInitTree(vmInfo proto.VmInfo) map[string][]byte {
+	tree := make(map[string][]byte)
+	addText := func(path, text string) {
+		tree[path] = []byte(text + "\n")
+	}
+	hostname := makeHostname(vmInfo)
+	interfaces := s.getVmInterfaces(vmInfo)
+	sshKeys := s.getSshKeys(vmInfo.OwnerUsers)
+	// EC2 layout.
+	addText(ec2MetadataPrefix+"/hostname", hostname)
+	addText(ec2MetadataPrefix+"/instance-id", makeInstanceId(vmInfo))
+	addText(ec2MetadataPrefix+"/local-hostname", hostname)
+	if len(vmInfo.Address.IpAddress) > 0 {
+		addText(ec2MetadataPrefix+"/local-ipv4",
+			vmInfo.Address.IpAddress.String())
+	}
+	addText(ec2MetadataPrefix+"/mac", vmInfo.Address.MacAddress)
+	for index, vmInterface := range interfaces {
+		dirname := ec2MetadataPrefix + "/network/interfaces/macs/" +
+			vmInterface.address.MacAddress
+		addText(dirname+"/device-number", strconv.Itoa(index))
+		addText(dirname+"/mac", vmInterface.address.MacAddress)
+		if ipAddr := vmInterface.address.IpAddress; len(ipAddr) > 0 {
+			addText(dirname+"/local-ipv4s", ipAddr.String())
+			if subnet := vmInterface.subnet; subnet != nil {
+				ipNet := net.IPNet{IP: ipAddr, Mask: net.IPMask(subnet.IpMask)}
+				ipNet.IP = ipNet.IP.Mask(ipNet.Mask)
+				addText(dirname+"/subnet-ipv4-cidr-block", ipNet.String())
+			}
+		}
+		if ipAddr := vmInterface.address.Ipv6Address; len(ipAddr) > 0 {
+			addText(dirname+"/ipv6s", ipAddr.String())
+		}
+		if vmInterface.subnet != nil {
+			addText(dirname+"/subnet-id", vmInterface.subnet.Id)
+		}
+	}
+	if len(sshKeys) > 0 {
+		listing := &strings.Builder{}
+		for index, sshKey := range sshKeys {
+			fmt.Fprintf(listing, "%d=%s\n", index, sshKey.owner)
+			addText(fmt.Sprintf("%s/public-keys/%d/openssh-key",
+				ec2MetadataPrefix, index), sshKey.key)
+		}
+		tree[ec2MetadataPrefix+"/public-keys"] = []byte(listing.String())
+	}
+	for key, value := range vmInfo.Tags {
+		if key != "" && !strings.Contains(key, "/") {
+			addText(ec2MetadataPrefix+"/tags/instance/"+key, value)
+		}
+	}
+	// OpenStack layout.
+	metadata := openStackMetadata{
+		Hostname: hostname,
+		Meta:     vmInfo.Tags,
+		Name:     hostname,
+		UUID:     makeInstanceUUID(vmInfo),
+	}
+	if len(sshKeys) > 0 {
+		metadata.PublicKeys = make(map[string]string, len(sshKeys))
+		for index, sshKey := range sshKeys {
+			name := sshKey.owner + "-" + strconv.Itoa(index)
+			metadata.Keys = append(metadata.Keys,
+				openStackKey{Data: sshKey.key, Name: name, Type: "ssh"})
+			metadata.PublicKeys[name] = sshKey.key
+		}
+	}
+	writeJson(tree, openStackLatestPrefix+"/meta_data.json", metadata)
+	writeJson(tree, openStackLatestPrefix+"/network_data.json",
+		makeOpenStackNetworkData(interfaces))
+	tree[openStackLatestPrefix+"/vendor_data.json"] = []byte("{}\n")
+	return tree
+}
+
+func makeOpenStackNetworkData(
+	interfaces []vmInterfaceType) openStackNetworkData {
+	var networkData openStackNetworkData
+	dnsServers := make(map[string]struct{})
+	for index, vmInterface := range interfaces {
+		networkData.Links = append(networkData.Links, openStackLink{
+			EthernetMacAddress: vmInterface.address.MacAddress,
+			Id:                 vmInterface.name,
+			Type:               "phy",
+		})
+		var subnetId string
+		if vmInterface.subnet != nil {
+			subnetId = vmInterface.subnet.Id
+		}
+		network := openStackNetwork{
+			Id:        "network" + strconv.Itoa(len(networkData.Networks)),
+			Link:      vmInterface.name,
+			NetworkId: subnetId,
+			Type:      "ipv4_dhcp",
+		}
+		if len(vmInterface.address.IpAddress) > 0 &&
+			vmInterface.subnet != nil {
+			subnet := vmInterface.subnet
+			network.IpAddress = vmInterface.address.IpAddress.String()
+			network.Netmask = subnet.IpMask.String()
+			network.Type = "ipv4"
+			if index == 0 { // Default route only via the primary interface.
+				network.Routes = []openStackRoute{{
+					Gateway: subnet.IpGateway.String(),
+					Netmask: "0.0.0.0",
+					Network: "0.0.0.0",
+				}}
+			}
+			for _, nameserver := range subnet.DomainNameServers {
+				dnsServers[nameserver.String()] = struct{}{}
+			}
+		}
+		networkData.Networks = append(networkData.Networks, network)
+		if len(vmInterface.address.Ipv6Address) < 1 ||
+			vmInterface.subnet == nil {
+			continue
+		}
+		network = openStackNetwork{
+			Id:        "network" + strconv.Itoa(len(networkData.Networks)),
+			IpAddress: vmInterface.address.Ipv6Address.String(),
+			Link:      vmInterface.name,
+			Netmask:   net.IP(net.CIDRMask(64, 128)).String(),
+			NetworkId: subnetId,
+			Type:      "ipv6",
+		}
+		if gateway := vmInterface.subnet.Ipv6Gateway; index == 0 &&
+			len(gateway) > 0 {
+			network.Routes = []openStackRoute{{
+				Gateway: gateway.String(),
+				Netmask: "::",
+				Network: "::",
+			}}
+		}
+		networkData.Networks = append(networkData.Networks, network)
+	}
+	for nameserver := range dnsServers {
+		networkData.Services = append(networkData.Services,
+			openStackService{Address: nameserver, Type: "dns"})
+	}
+	sort.Slice(networkData.Services, func(left, right int) bool {
+		return networkData.Services[left].Address <
+			networkData.Services[right].Address
+	})
+	return networkData
+}
+
+// serveCloudInit serves the cloud-init compatible metadata. It returns false
+// if the path is not part of the cloud-init metadata.
+func (s *server) serveCloudInit(w http.ResponseWriter, path string,
+	ipAddr net.IP, vmInfo proto.VmInfo) bool {
+	if path == openStackUserData {
+		s.showFileData(w, ipAddr, manager.UserDataFile)
+		return true
+	}
+	if !strings.HasPrefix(path, ec2MetadataPrefix) &&
+		!strings.HasPrefix(path, openStackPrefix) {
+		return false
+	}
+	tree := s.makeCloudInitTree(vmInfo)
+	path = strings.TrimSuffix(path, "/")
+	if data, ok := tree[path]; ok {
+		w.Write(data)
+		return true
+	}
+	names := listTree(tree, path)
+	if path == openStackPrefix {
+		names = []string{"latest/"}
+	} else if path == openStackLatestPrefix {
+		names = append(names, "user_data")
+		sort.Strings(names)
+	}
+	if len(names) < 1 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		return true
+	}
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	for _, name := range names {
+		fmt.Fprintln(writer, name)
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package metadatad
+
+import (
+	"net"
+	"testing"
+
+	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func TestListTree(t *testing.T) {
+	tree := map[string][]byte{
+		"/m/hostname":              nil,
+		"/m/public-keys":           nil,
+		"/m/public-keys/0/openssh": nil,
+		"/m/tags/instance/Name":    nil,
+	}
+	names := listTree(tree, "/m")
+	expected := []string{"hostname", "public-keys/", "tags/"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected: %v, got: %v", expected, names)
+	}
+	for index, name := range names {
+		if name != expected[index] {
+			t.Fatalf("expected: %v, got: %v", expected, names)
+		}
+	}
+}
+
+func TestOpenStackNetworkData(t *testing.T) {
+	subnet := &proto.Subnet{
+		Id:                "s0",
+		IpGateway:         net.IP{10, 0, 0, 1},
+		IpMask:            net.IP{255, 255, 255, 0},
+		DomainNameServers: []net.IP{{10, 0, 0, 2}},
+	}
+	networkData := makeOpenStackNetworkData([]vmInterfaceType{
+		{
+			address: proto.Address{
+				IpAddress:  net.IP{10, 0, 0, 5},
+				MacAddress: "52:54:0a:00:00:05",
+			},
+			name:   "eth0",
+			subnet: subnet,
+		},
+		{
+			address: proto.Address{MacAddress: "52:54:0a:00:01:05"},
+			name:    "eth1",
+		},
+	})
+	if len(networkData.Links) != 2 || len(networkData.Networks) != 2 {
+		t.Fatalf("bad network data: %+v", networkData)
+	}
+	primary := networkData.Networks[0]
+	if primary.Type != "ipv4" || primary.IpAddress != "10.0.0.5" ||
+		primary.Netmask != "255.255.255.0" || len(primary.Routes) != 1 {
+		t.Errorf("bad primary network: %+v", primary)
+	}
+	if secondary := networkData.Networks[1]; secondary.Type != "ipv4_dhcp" {
+		t.Errorf("bad secondary network: %+v", secondary)
+	}
+	if len(networkData.Services) != 1 ||
+		networkData.Services[0].Address != "10.0.0.2" {
+		t.Errorf("bad services: %+v", networkData.Services)
+	}
+}
@@ -25,6 +25,10 @@ func (s *server) computePaths() {
 	for path := range s.rawHandlers {
 		s.paths[path] = struct{}{}
 	}
+	if s.options.CloudInit {
+		s.paths[ec2MetadataPrefix+"/"] = struct{}{}
+		s.paths[openStackLatestPrefix+"/"] = struct{}{}
+	}
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -41,6 +45,10 @@ func (s *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if s.options.CloudInit &&
+		s.serveCloudInit(w, req.URL.Path, ipAddr, vmInfo) {
+		return
+	}
 	if filename, ok := s.fileHandlers[req.URL.Path]; ok {
 		s.showFileData(w, ipAddr, filename)
 		return
@@ -8,6 +8,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/hypervisor/manager"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/serverutil"
 	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
 )
 
@@ -24,6 +25,7 @@ type DhcpServer interface {
 type ipv4Address [4]byte
 
 type srpcType struct {
+	*serverutil.RateLimiter
 	dhcpServer           DhcpServer
 	logger               log.DebugLogger
 	manager              *manager.Manager
@@ -48,6 +50,7 @@ func Setup(manager *manager.Manager, dhcpServer DhcpServer,
 	tftpbootServer TftpbootServer, logger log.DebugLogger) (
 	*htmlWriter, error) {
 	srpcObj := &srpcType{
+		RateLimiter:    manager.RateLimiter,
 		dhcpServer:     dhcpServer,
 		logger:         logger,
 		manager:        manager,
@@ -114,10 +117,12 @@ func Setup(manager *manager.Manager, dhcpServer DhcpServer,
 			"RestoreVmImage",
 			"RestoreVmUserData",
 			"ReorderVmVolumes",
+			"ResumeVm",
 			"ScanVmRoot",
 			"SnapshotVm",
 			"StartVm",
 			"StopVm",
+			"SuspendVm",
 			"TraceVmMetadata",
 		}})
 	return (*htmlWriter)(srpcObj), nil
@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func (t *srpcType) ResumeVm(conn *srpc.Conn,
+	request hypervisor.ResumeVmRequest,
+	reply *hypervisor.ResumeVmResponse) error {
+	*reply = hypervisor.ResumeVmResponse{
+		Error: errors.ErrorToString(t.manager.ResumeVm(request.IpAddress,
+			conn.GetAuthInformation(), request.AccessToken)),
+	}
+	return nil
+}
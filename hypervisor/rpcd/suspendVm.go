@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+)
+
+func (t *srpcType) SuspendVm(conn *srpc.Conn,
+	request hypervisor.SuspendVmRequest,
+	reply *hypervisor.SuspendVmResponse) error {
+	*reply = hypervisor.SuspendVmResponse{
+		Error: errors.ErrorToString(t.manager.SuspendVm(request.IpAddress,
+			conn.GetAuthInformation(), request.AccessToken)),
+	}
+	return nil
+}
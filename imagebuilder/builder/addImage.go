@@ -2,6 +2,7 @@ package builder
 
 import (
 	"bytes"
+	"crypto"
 	"errors"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
 	"github.com/Cloud-Foundations/Dominator/lib/image/packageutil"
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	objectclient "github.com/Cloud-Foundations/Dominator/lib/objectserver/client"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
@@ -97,12 +99,27 @@ func (h *hasher) ReadAndHash(inode *filesystem.RegularInode, file *os.File,
 	return true, nil
 }
 
+// addImage will add the image to the imageserver. If annotator is not nil, it
+// is called with the name of the image before it is added. If signer is not
+// nil, the image is signed.
 func addImage(client srpc.ClientI, request proto.BuildImageRequest,
-	img *image.Image) (string, error) {
+	img *image.Image, annotator func(name string) error,
+	signer crypto.Signer) (string, error) {
 	if request.ExpiresIn > 0 {
 		img.ExpiresAt = time.Now().Add(request.ExpiresIn)
 	}
 	name := makeImageName(request.StreamName)
+	if annotator != nil {
+		if err := annotator(name); err != nil {
+			return "", err
+		}
+	}
+	if signer != nil {
+		var err error
+		if img.Signature, err = signature.Sign(name, img, signer); err != nil {
+			return "", err
+		}
+	}
 	if err := imageclient.AddImage(client, name, img); err != nil {
 		return "", errors.New("remote error: " + err.Error())
 	}
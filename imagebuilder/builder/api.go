@@ -2,6 +2,7 @@ package builder
 
 import (
 	"bytes"
+	"crypto"
 	"io"
 	stdlog "log"
 	"regexp"
@@ -218,6 +219,7 @@ type Builder struct {
 	stateDir                    string
 	imageRebuildInterval        time.Duration
 	imageServerAddress          string
+	imageSigner                 crypto.Signer // nil: do not sign images.
 	linksImageServerAddress     string
 	logger                      log.DebugLogger
 	imageStreamsPublicUrl       string // No variable expansion applied.
@@ -227,6 +229,8 @@ type Builder struct {
 	maximumExpirationPrivileged time.Duration
 	minimumExpiration           time.Duration
 	mtimesCopyFilter            *filter.Filter
+	provenanceBuilderId         string
+	provenanceSigner            crypto.Signer   // nil: no provenance.
 	streamsLoadedChannel        <-chan struct{} // Closed when streams loaded.
 	streamsLock                 sync.RWMutex
 	bootstrapStreams            map[string]*bootstrapStream
@@ -250,10 +254,12 @@ type BuilderOptions struct {
 	CreateSlaveTimeout                  time.Duration
 	ImageRebuildInterval                time.Duration
 	ImageServerAddress                  string
+	ImageSigningKeyFile                 string        // Key to sign images.
 	MaximumExpirationDuration           time.Duration // Default: 1 day.
 	MaximumExpirationDurationPrivileged time.Duration // Default: 1 month.
 	MinimumExpirationDuration           time.Duration // Def: 15 min. Min: 5 min
 	PresentationImageServerAddress      string
+	ProvenanceKeyFile                   string // Key to sign provenance.
 	StateDirectory                      string
 	VariablesFile                       string
 }
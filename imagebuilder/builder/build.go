@@ -325,8 +325,16 @@ func (b *Builder) buildWithLogger(builder imageBuilder, client srpc.ClientI,
 	if authInfo != nil {
 		img.CreatedFor = authInfo.Username
 	}
+	var annotator func(name string) error
+	if b.provenanceSigner != nil {
+		annotator = func(name string) error {
+			return b.attachProvenance(client, builder, request, name, img,
+				startTime, buildLog)
+		}
+	}
 	uploadStartTime := time.Now()
-	if name, err := addImage(client, request, img); err != nil {
+	if name, err := addImage(client, request, img, annotator,
+		b.imageSigner); err != nil {
 		fmt.Fprintln(buildLog, err)
 		return nil, "", err
 	} else {
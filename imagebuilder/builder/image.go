@@ -376,7 +376,7 @@ func buildImageFromManifestAndUpload(client srpc.ClientI,
 	if err != nil {
 		return nil, "", err
 	}
-	name, err := addImage(client, request, img)
+	name, err := addImage(client, request, img, nil, nil)
 	if err != nil {
 		return nil, "", err
 	}
@@ -2,6 +2,7 @@ package builder
 
 import (
 	"bufio"
+	"crypto"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/imagebuilder/logarchiver"
 	"github.com/Cloud-Foundations/Dominator/imageserver/client"
 	"github.com/Cloud-Foundations/Dominator/lib/configwatch"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/expand"
 	"github.com/Cloud-Foundations/Dominator/lib/filter"
 	"github.com/Cloud-Foundations/Dominator/lib/format"
@@ -124,6 +126,26 @@ func load(options BuilderOptions, params BuilderParams) (*Builder, error) {
 			return nil, err
 		}
 	}
+	var provenanceSigner crypto.Signer
+	var provenanceBuilderId string
+	if options.ProvenanceKeyFile != "" {
+		provenanceSigner, err = dsse.LoadSigner(options.ProvenanceKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		provenanceBuilderId = "imaginator://" + hostname
+	}
+	var imageSigner crypto.Signer
+	if options.ImageSigningKeyFile != "" {
+		imageSigner, err = dsse.LoadSigner(options.ImageSigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 	generateDependencyTrigger := make(chan chan<- struct{}, 1)
 	streamsLoadedChannel := make(chan struct{})
 	b := &Builder{
@@ -135,6 +157,7 @@ func load(options BuilderOptions, params BuilderParams) (*Builder, error) {
 		stateDir:                    options.StateDirectory,
 		imageRebuildInterval:        options.ImageRebuildInterval,
 		imageServerAddress:          options.ImageServerAddress,
+		imageSigner:                 imageSigner,
 		linksImageServerAddress:     options.PresentationImageServerAddress,
 		logger:                      params.Logger,
 		imageStreamsPublicUrl:       masterConfiguration.ImageStreamsUrl,
@@ -142,6 +165,8 @@ func load(options BuilderOptions, params BuilderParams) (*Builder, error) {
 		maximumExpiration:           options.MaximumExpirationDuration,
 		maximumExpirationPrivileged: options.MaximumExpirationDurationPrivileged,
 		minimumExpiration:           options.MinimumExpirationDuration,
+		provenanceBuilderId:         provenanceBuilderId,
+		provenanceSigner:            provenanceSigner,
 		streamsLoadedChannel:        streamsLoadedChannel,
 		bootstrapStreams:            masterConfiguration.BootstrapStreams,
 		imageStreamsToAutoRebuild:   masterConfiguration.ImageStreamsToAutoRebuild,
@@ -0,0 +1,151 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	imgclient "github.com/Cloud-Foundations/Dominator/imageserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/provenance"
+	objectclient "github.com/Cloud-Foundations/Dominator/lib/objectserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	proto "github.com/Cloud-Foundations/Dominator/proto/imaginator"
+)
+
+// commands returns the non-empty packager commands, keyed by operation.
+func (packager *packagerType) commands() map[string][]string {
+	commands := make(map[string][]string)
+	for name, command := range map[string]argList{
+		"clean":   packager.CleanCommand,
+		"install": packager.InstallCommand,
+		"list":    packager.ListCommand.ArgList,
+		"remove":  packager.RemoveCommand,
+		"update":  packager.UpdateCommand,
+		"upgrade": packager.UpgradeCommand,
+	} {
+		if len(command) > 0 {
+			commands[name] = command
+		}
+	}
+	return commands
+}
+
+// attachProvenance will create a signed provenance statement for the image,
+// upload it to the imageserver and attach it to the image as an annotation.
+func (b *Builder) attachProvenance(client srpc.ClientI, builder imageBuilder,
+	request proto.BuildImageRequest, imageName string, img *image.Image,
+	startTime time.Time, buildLog buildLogger) error {
+	statement, err := provenance.NewStatement(imageName, img)
+	if err != nil {
+		return err
+	}
+	definition := &statement.Predicate.BuildDefinition
+	definition.ExternalParameters = provenance.ExternalParameters{
+		GitBranch:  request.GitBranch,
+		StreamName: request.StreamName,
+	}
+	variables := make(map[string]string)
+	var packagerTypeName string
+	switch stream := builder.(type) {
+	case *bootstrapStream:
+		definition.InternalParameters.BootstrapCommand =
+			stream.BootstrapCommand
+		packagerTypeName = stream.PackagerType
+	case *imageStreamType:
+		definition.ExternalParameters.ManifestDirectory =
+			stream.ManifestDirectory
+		definition.ExternalParameters.ManifestUrl = stream.ManifestUrl
+		for key, value := range stream.Variables {
+			variables[key] = value
+		}
+		packagerTypeName = b.getPackagerTypeName(request.StreamName)
+	}
+	for key, value := range request.Variables {
+		variables[key] = value
+	}
+	if len(variables) > 0 {
+		definition.ExternalParameters.Variables = variables
+	}
+	if packager, ok := b.packagerTypes[packagerTypeName]; ok {
+		definition.InternalParameters.PackagerType = packagerTypeName
+		definition.InternalParameters.PackagerCommands = packager.commands()
+	}
+	if img.BuildGitUrl != "" {
+		definition.ResolvedDependencies = append(
+			definition.ResolvedDependencies,
+			provenance.ResourceDescriptor{
+				Annotations: map[string]string{"branch": img.BuildBranch},
+				Digest:      map[string]string{"gitCommit": img.BuildCommitId},
+				Uri:         "git+" + img.BuildGitUrl,
+			})
+	}
+	if img.SourceImage != "" {
+		sourceImage, err := imgclient.GetImage(client, img.SourceImage)
+		if err != nil {
+			return err
+		}
+		if sourceImage == nil {
+			return fmt.Errorf("source image: %s not found", img.SourceImage)
+		}
+		resource, err := provenance.ImageResource(img.SourceImage,
+			sourceImage)
+		if err != nil {
+			return err
+		}
+		definition.ResolvedDependencies = append(
+			definition.ResolvedDependencies, resource)
+	}
+	runDetails := &statement.Predicate.RunDetails
+	runDetails.Builder.Id = b.provenanceBuilderId
+	runDetails.Metadata = provenance.BuildMetadata{
+		FinishedOn:   time.Now(),
+		InvocationId: imageName,
+		StartedOn:    startTime,
+	}
+	if img.BuildLog != nil && img.BuildLog.Object != nil {
+		runDetails.Byproducts = append(runDetails.Byproducts,
+			provenance.ResourceDescriptor{
+				Digest: map[string]string{
+					"sha512": fmt.Sprintf("%x", *img.BuildLog.Object),
+				},
+				Name: "buildLog",
+			})
+	}
+	data, err := statement.Sign(b.provenanceSigner)
+	if err != nil {
+		return err
+	}
+	objClient := objectclient.AttachObjectClient(client)
+	defer objClient.Close()
+	hashVal, _, err := objClient.AddObject(bytes.NewReader(data),
+		uint64(len(data)), nil)
+	if err != nil {
+		return err
+	}
+	img.Provenance = &image.Annotation{Object: &hashVal}
+	fmt.Fprintln(buildLog, "Attached signed build provenance")
+	return nil
+}
+
+// getPackagerTypeName returns the name of the packager type of the bootstrap
+// stream which the specified stream is ultimately built from.
+func (b *Builder) getPackagerTypeName(streamName string) string {
+	b.dependencyDataLock.RLock()
+	dependencyData := b.dependencyData
+	b.dependencyDataLock.RUnlock()
+	if dependencyData == nil {
+		return ""
+	}
+	for count := 0; count < 100; count++ {
+		if stream := b.getBootstrapStream(streamName); stream != nil {
+			return stream.PackagerType
+		}
+		sourceName, ok := dependencyData.streamToSource[streamName]
+		if !ok {
+			return ""
+		}
+		streamName = sourceName
+	}
+	return ""
+}
@@ -1,15 +1,21 @@
 package client
 
 import (
+	"errors"
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	proto "github.com/Cloud-Foundations/Dominator/proto/imageserver"
 )
 
+// ErrNoVulnerabilityDatabase is returned by ListImageVulnerabilities if the
+// imageserver does not have an advisory database configured.
+var ErrNoVulnerabilityDatabase = errors.New("no advisory database configured")
+
 func AddImage(client srpc.ClientI, name string, img *image.Image) error {
 	return addImage(client, name, img)
 }
@@ -75,6 +81,10 @@ func GetImageArchive(client srpc.ClientI, name string) (
 	return getImageArchive(client, name)
 }
 
+func GetImageSbom(client srpc.ClientI, name, format string) ([]byte, error) {
+	return getImageSbom(client, name, format)
+}
+
 func GetReplicationMaster(client srpc.ClientI) (string, error) {
 	return getReplicationMaster(client)
 }
@@ -88,6 +98,11 @@ func ListDirectories(client srpc.ClientI) ([]image.Directory, error) {
 	return listDirectories(client)
 }
 
+func ListImageVulnerabilities(client srpc.ClientI, name string) (
+	[]vulnerability.Finding, bool, error) {
+	return listImageVulnerabilities(client, name)
+}
+
 func ListImages(client srpc.ClientI) ([]string, error) {
 	return listImages(client)
 }
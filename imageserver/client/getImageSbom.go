@@ -0,0 +1,23 @@
+package client
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/imageserver"
+)
+
+func getImageSbom(client srpc.ClientI, name, format string) ([]byte, error) {
+	request := imageserver.GetImageSbomRequest{
+		Format:    format,
+		ImageName: name,
+	}
+	var reply imageserver.GetImageSbomResponse
+	err := client.RequestReply("ImageServer.GetImageSbom", request, &reply)
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.New(reply.Error); err != nil {
+		return nil, err
+	}
+	return reply.Sbom, nil
+}
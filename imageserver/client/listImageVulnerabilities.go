@@ -0,0 +1,26 @@
+package client
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/imageserver"
+)
+
+func listImageVulnerabilities(client srpc.ClientI, name string) (
+	[]vulnerability.Finding, bool, error) {
+	request := imageserver.ListImageVulnerabilitiesRequest{ImageName: name}
+	var reply imageserver.ListImageVulnerabilitiesResponse
+	err := client.RequestReply("ImageServer.ListImageVulnerabilities", request,
+		&reply)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply.NoDatabase {
+		return nil, false, ErrNoVulnerabilityDatabase
+	}
+	if err := errors.New(reply.Error); err != nil {
+		return nil, false, err
+	}
+	return reply.Vulnerabilities, reply.ImageExists, nil
+}
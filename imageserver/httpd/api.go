@@ -7,8 +7,9 @@ import (
 	"net/http"
 
 	"github.com/Cloud-Foundations/Dominator/imageserver/scanner"
+	"github.com/Cloud-Foundations/Dominator/imageserver/vulnerabilities"
 	"github.com/Cloud-Foundations/Dominator/lib/html"
-	"github.com/Cloud-Foundations/Dominator/lib/objectserver/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
 )
 
 type HtmlWriter interface {
@@ -18,17 +19,23 @@ type HtmlWriter interface {
 var htmlWriters []HtmlWriter
 
 type state struct {
-	imageDataBase *scanner.ImageDataBase
-	objectServer  *filesystem.ObjectServer
+	imageDataBase        *scanner.ImageDataBase
+	objectServer         objectserver.ObjectGetter
+	vulnerabilityScanner *vulnerabilities.Scanner // May be nil.
 }
 
 func StartServer(portNum uint, imdb *scanner.ImageDataBase,
-	objSrv *filesystem.ObjectServer, daemon bool) error {
+	objSrv objectserver.ObjectGetter,
+	vulnerabilityScanner *vulnerabilities.Scanner, daemon bool) error {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", portNum))
 	if err != nil {
 		return err
 	}
-	myState := state{imageDataBase: imdb, objectServer: objSrv}
+	myState := state{
+		imageDataBase:        imdb,
+		objectServer:         objSrv,
+		vulnerabilityScanner: vulnerabilityScanner,
+	}
 	html.HandleFunc("/", statusHandler)
 	html.HandleFunc("/listBuildLog", myState.listBuildLogHandler)
 	html.HandleFunc("/listComputedInodes", myState.listComputedInodesHandler)
@@ -37,8 +44,12 @@ func StartServer(portNum uint, imdb *scanner.ImageDataBase,
 	html.HandleFunc("/listImage", myState.listImageHandler)
 	html.HandleFunc("/listImages", myState.listImagesHandler)
 	html.HandleFunc("/listPackages", myState.listPackagesHandler)
+	html.HandleFunc("/listProvenance", myState.listProvenanceHandler)
 	html.HandleFunc("/listReleaseNotes", myState.listReleaseNotesHandler)
+	html.HandleFunc("/listSbom", myState.listSbomHandler)
 	html.HandleFunc("/listTriggers", myState.listTriggersHandler)
+	html.HandleFunc("/listVulnerabilities",
+		myState.listVulnerabilitiesHandler)
 	html.HandleFunc("/showImage", myState.showImageHandler)
 	if daemon {
 		go http.Serve(listener, nil)
@@ -62,6 +62,11 @@ func (s state) listPackagesHandler(w http.ResponseWriter, req *http.Request) {
 		imageName)
 	fmt.Fprintf(writer, " <a href=\"listPackages?%s&output=json\">json</a>",
 		imageName)
+	fmt.Fprintf(writer,
+		" <a href=\"listSbom?%s&format=cyclonedx\">CycloneDX SBOM</a>",
+		imageName)
+	fmt.Fprintf(writer, " <a href=\"listSbom?%s&format=spdx\">SPDX SBOM</a>",
+		imageName)
 	fmt.Fprintln(writer, "</h3>")
 	fmt.Fprintln(writer, `<table border="1" style="width:100%">`)
 	tw, _ := html.NewTableWriter(writer, true, "Name", "Version", "Size")
@@ -0,0 +1,63 @@
+package httpd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+)
+
+func (s state) listProvenanceHandler(w http.ResponseWriter,
+	req *http.Request) {
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	imageName := req.URL.RawQuery
+	fmt.Fprintf(writer, "<title>image %s</title>\n", imageName)
+	fmt.Fprintln(writer, "<body>")
+	fmt.Fprintln(writer, "<h3>")
+	image := s.imageDataBase.GetImage(imageName)
+	if image == nil {
+		fmt.Fprintf(writer, "Image: %s UNKNOWN!\n", imageName)
+		return
+	}
+	if image.Provenance == nil || image.Provenance.Object == nil {
+		fmt.Fprintf(writer, "No provenance for image: %s\n", imageName)
+		return
+	}
+	fmt.Fprintf(writer, "Build provenance for image: %s<br>\n", imageName)
+	fmt.Fprintln(writer, "</h3>")
+	size, reader, err := s.objectServer.GetObject(*image.Provenance.Object)
+	if err != nil {
+		fmt.Fprintln(writer, err)
+		return
+	}
+	defer reader.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		fmt.Fprintln(writer, err)
+		return
+	}
+	envelope, err := dsse.Decode(data)
+	if err != nil {
+		fmt.Fprintln(writer, err)
+		return
+	}
+	var buffer bytes.Buffer
+	if err := json.Indent(&buffer, envelope.Payload, "", "    "); err != nil {
+		fmt.Fprintln(writer, err)
+		return
+	}
+	fmt.Fprintln(writer, "Signed by keys:")
+	for _, signature := range envelope.Signatures {
+		fmt.Fprintf(writer, " %s", signature.KeyId)
+	}
+	fmt.Fprintln(writer, "<br>")
+	fmt.Fprintln(writer, "<pre>")
+	writer.Write(buffer.Bytes())
+	fmt.Fprintln(writer, "</pre>")
+	fmt.Fprintln(writer, "</body>")
+}
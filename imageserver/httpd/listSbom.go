@@ -0,0 +1,51 @@
+package httpd
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+	"github.com/Cloud-Foundations/Dominator/lib/url"
+)
+
+func (s state) listSbomHandler(w http.ResponseWriter, req *http.Request) {
+	parsedQuery := url.ParseQuery(req.URL)
+	if len(parsedQuery.Flags) != 1 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var imageName string
+	for name := range parsedQuery.Flags {
+		imageName = name
+	}
+	image := s.imageDataBase.GetImage(imageName)
+	if image == nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	format := parsedQuery.Table["format"]
+	switch format {
+	case "", sbom.FormatCycloneDx:
+		w.Header().Set("Content-Type",
+			"application/vnd.cyclonedx+json; charset=utf-8")
+	case sbom.FormatSpdx:
+		w.Header().Set("Content-Type", "application/spdx+json; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	err := sbom.Generate(writer, format, sbom.Params{
+		Image:        image,
+		ImageName:    imageName,
+		ObjectGetter: s.objectServer,
+	})
+	if err != nil {
+		fmt.Fprintln(writer, err)
+	}
+}
@@ -0,0 +1,100 @@
+package httpd
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/html"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/url"
+)
+
+func (s state) listVulnerabilitiesHandler(w http.ResponseWriter,
+	req *http.Request) {
+	parsedQuery := url.ParseQuery(req.URL)
+	if len(parsedQuery.Flags) != 1 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var imageName string
+	for name := range parsedQuery.Flags {
+		imageName = name
+	}
+	findings, imageExists, err :=
+		s.vulnerabilityScanner.GetImageVulnerabilities(imageName)
+	if err != nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, err)
+		return
+	}
+	if !imageExists {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	switch parsedQuery.OutputType() {
+	case url.OutputTypeText:
+		for _, finding := range findings {
+			fixedVersion := finding.FixedVersion
+			if fixedVersion == "" {
+				fixedVersion = "-"
+			}
+			fmt.Fprintln(writer, finding.Severity, finding.AdvisoryId,
+				finding.PackageName, finding.PackageVersion, fixedVersion)
+		}
+		return
+	case url.OutputTypeJson:
+		err := json.WriteWithIndent(writer, "    ", findings)
+		if err != nil {
+			fmt.Fprintln(writer, err)
+		}
+		return
+	case url.OutputTypeHtml:
+		break
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(writer, "<title>image %s vulnerabilities</title>\n", imageName)
+	fmt.Fprintln(writer, `<style>
+                          table, th, td {
+                          border-collapse: collapse;
+                          }
+                          </style>`)
+	fmt.Fprintln(writer, "<body>")
+	fmt.Fprintln(writer, "<h3>")
+	fmt.Fprintf(writer, "Vulnerabilities in image: %s", imageName)
+	fmt.Fprintf(writer,
+		" <a href=\"listVulnerabilities?%s&output=text\">text</a>", imageName)
+	fmt.Fprintf(writer,
+		" <a href=\"listVulnerabilities?%s&output=json\">json</a>", imageName)
+	fmt.Fprintln(writer, "</h3>")
+	if len(findings) < 1 {
+		fmt.Fprintln(writer, "No known vulnerabilities")
+		fmt.Fprintln(writer, "</body>")
+		return
+	}
+	fmt.Fprintln(writer, `<table border="1" style="width:100%">`)
+	tw, _ := html.NewTableWriter(writer, true, "Severity", "Advisory",
+		"Aliases", "Package", "Version", "Fixed Version", "Summary")
+	for _, finding := range findings {
+		tw.WriteRow("", "",
+			finding.Severity.String(),
+			finding.AdvisoryId,
+			strings.Join(finding.Aliases, " "),
+			finding.PackageName,
+			finding.PackageVersion,
+			finding.FixedVersion,
+			finding.Summary,
+		)
+	}
+	tw.Close()
+	fmt.Fprintln(writer, "</body>")
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/format"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
 	"github.com/Cloud-Foundations/Dominator/lib/json"
 )
 
@@ -64,6 +67,11 @@ func (s state) showImageHandler(w http.ResponseWriter, req *http.Request) {
 		"listReleaseNotes")
 	showAnnotation(writer, img.BuildLog, imageName, "Build log",
 		"listBuildLog")
+	showAnnotation(writer, img.Provenance, imageName, "Build provenance",
+		"listProvenance")
+	if len(img.Signature) > 0 {
+		showSignature(writer, img.Signature)
+	}
 	if img.CreatedBy != "" {
 		fmt.Fprintf(writer, "Created by: %s\n<br>", img.CreatedBy)
 	}
@@ -84,6 +92,7 @@ func (s state) showImageHandler(w http.ResponseWriter, req *http.Request) {
 		fmt.Fprintf(writer,
 			"Packages: <a href=\"listPackages?%s\">%d</a><br>\n",
 			imageName, len(img.Packages))
+		s.showVulnerabilities(writer, imageName)
 	}
 	if img.SourceImage != "" {
 		if s.imageDataBase.CheckImage(img.SourceImage) {
@@ -118,3 +127,37 @@ func showAnnotation(writer io.Writer, annotation *image.Annotation,
 	}
 	fmt.Fprintf(writer, "<a href=\"%s\">%s</a><br>\n", url, linkName)
 }
+
+// showSignature shows the IDs of the keys which signed the image. The
+// signatures are not verified, since the imageserver is not trusted.
+func showSignature(writer io.Writer, data []byte) {
+	envelope, err := dsse.Decode(data)
+	if err != nil {
+		fmt.Fprintf(writer, "Signature: %s<br>\n", err)
+		return
+	}
+	keyIds := make([]string, 0, len(envelope.Signatures))
+	for _, signature := range envelope.Signatures {
+		keyIds = append(keyIds, signature.KeyId)
+	}
+	fmt.Fprintf(writer, "Signed by key: %s<br>\n", strings.Join(keyIds, ", "))
+}
+
+func (s state) showVulnerabilities(writer io.Writer, imageName string) {
+	if s.vulnerabilityScanner == nil {
+		return
+	}
+	findings, _, err :=
+		s.vulnerabilityScanner.GetImageVulnerabilities(imageName)
+	if err != nil {
+		fmt.Fprintf(writer, "Vulnerabilities: %s<br>\n", err)
+		return
+	}
+	if len(findings) < 1 {
+		fmt.Fprintln(writer, "Vulnerabilities: none known<br>")
+		return
+	}
+	fmt.Fprintf(writer,
+		"Vulnerabilities: <a href=\"listVulnerabilities?%s\">%d</a> (maximum severity: %s)<br>\n",
+		imageName, len(findings), vulnerability.MaxSeverity(findings))
+}
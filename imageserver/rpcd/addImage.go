@@ -14,6 +14,14 @@ func (t *srpcType) AddImage(conn *srpc.Conn,
 	reply *imageserver.AddImageResponse) error {
 	request.Image.CreatedBy = conn.Username() // Must always set this field.
 	request.Image.CreatedOn = time.Now()      // Must always set this field.
+	if request.Image.FileSystem != nil {
+		request.Image.FileSystem.ComputeTotalDataBytes()
+		err := t.Charge("AddImage", conn.GetAuthInformation(),
+			float64(request.Image.FileSystem.TotalDataBytes))
+		if err != nil {
+			return err
+		}
+	}
 	return t.AddImageTrusted(conn, request, reply)
 }
 
@@ -33,6 +41,9 @@ func (t *srpcType) AddImageTrusted(conn *srpc.Conn,
 	if err != nil {
 		return err
 	}
+	if err := t.checkProvenance(request); err != nil {
+		return err
+	}
 	t.setImageInjectionState(request.ImageName, true)
 	defer t.setImageInjectionState(request.ImageName, false)
 	if err := t.injectImage(conn, request); err != nil {
@@ -7,10 +7,13 @@ import (
 	"sync"
 
 	"github.com/Cloud-Foundations/Dominator/imageserver/scanner"
+	"github.com/Cloud-Foundations/Dominator/imageserver/vulnerabilities"
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/filter"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/serverutil"
 )
 
 var (
@@ -18,13 +21,20 @@ var (
 		"If true, replicate expiring images when in archive mode")
 	archiveMode = flag.Bool("archiveMode", false,
 		"If true, disable delete operations and require update server")
+	provenanceKeysFile = flag.String("provenanceKeysFile", "",
+		"Name of file containing PEM public keys trusted to sign build provenance")
+	rateLimitsFile = flag.String("rateLimitsFile", "",
+		"Name of JSON file containing per-user method rate limits and quotas")
 	replicationExcludeFilter = flag.String("replicationExcludeFilter", "",
 		"Filename containing filter to exclude images from replication (default do not exclude any)")
 	replicationIncludeFilter = flag.String("replicationIncludeFilter", "",
 		"Filename containing filter to include images for replication (default include all)")
+	requireProvenance = flag.Bool("requireProvenance", false,
+		"If true, reject added images without valid signed build provenance")
 )
 
 type srpcType struct {
+	*serverutil.RateLimiter
 	imageDataBase             *scanner.ImageDataBase
 	excludeFilter             *filter.Filter
 	finishedReplication       <-chan struct{} // Closed when finished.
@@ -32,12 +42,14 @@ type srpcType struct {
 	replicationMaster         string
 	imageserverResource       *srpc.ClientResource
 	objSrv                    objectserver.FullObjectServer
+	provenanceVerifier        *dsse.Verifier
 	archiveMode               bool
 	logger                    log.DebugLogger
 	numReplicationClientsLock sync.RWMutex // Protect numReplicationClients.
 	numReplicationClients     uint
 	imagesBeingInjectedLock   sync.Mutex // Protect imagesBeingInjected.
 	imagesBeingInjected       map[string]struct{}
+	vulnerabilityScanner      *vulnerabilities.Scanner
 }
 
 type htmlWriter srpcType
@@ -51,22 +63,39 @@ var replicationMessage = "cannot make changes while under replication control" +
 
 func Setup(imdb *scanner.ImageDataBase, replicationMaster string,
 	objSrv objectserver.FullObjectServer,
+	vulnerabilityScanner *vulnerabilities.Scanner,
 	logger log.DebugLogger) (*htmlWriter, error) {
 	if *archiveMode && replicationMaster == "" {
 		return nil, errors.New("replication master required in archive mode")
 	}
+	if *requireProvenance && *provenanceKeysFile == "" {
+		return nil, errors.New(
+			"provenanceKeysFile required to require provenance")
+	}
 	finishedReplication := make(chan struct{})
 	srpcObj := &srpcType{
-		imageDataBase:       imdb,
-		finishedReplication: finishedReplication,
-		replicationMaster:   replicationMaster,
-		imageserverResource: srpc.NewClientResource("tcp", replicationMaster),
-		objSrv:              objSrv,
-		logger:              logger,
-		archiveMode:         *archiveMode,
-		imagesBeingInjected: make(map[string]struct{}),
+		imageDataBase:        imdb,
+		finishedReplication:  finishedReplication,
+		replicationMaster:    replicationMaster,
+		imageserverResource:  srpc.NewClientResource("tcp", replicationMaster),
+		objSrv:               objSrv,
+		logger:               logger,
+		archiveMode:          *archiveMode,
+		imagesBeingInjected:  make(map[string]struct{}),
+		vulnerabilityScanner: vulnerabilityScanner,
 	}
 	var err error
+	if *rateLimitsFile != "" {
+		config, err := serverutil.LoadRateLimiterConfig(*rateLimitsFile)
+		if err != nil {
+			return nil, err
+		}
+		srpcObj.RateLimiter, err = serverutil.NewRateLimiter("ImageServer",
+			config)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if *replicationExcludeFilter != "" {
 		srpcObj.excludeFilter, err = filter.Load(*replicationExcludeFilter)
 		if err != nil {
@@ -79,6 +108,13 @@ func Setup(imdb *scanner.ImageDataBase, replicationMaster string,
 			return nil, err
 		}
 	}
+	if *provenanceKeysFile != "" {
+		srpcObj.provenanceVerifier, err = dsse.LoadVerifier(
+			*provenanceKeysFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 	srpc.RegisterNameWithOptions("ImageServer", srpcObj, srpc.ReceiverOptions{
 		PublicMethods: []string{
 			"ChangeImageExpiration",
@@ -92,9 +128,11 @@ func Setup(imdb *scanner.ImageDataBase, replicationMaster string,
 			"GetImageArchive",
 			"GetImageComputedFiles",
 			"GetImageExpiration",
+			"GetImageSbom",
 			"GetImageUpdates",
 			"GetReplicationMaster",
 			"ListDirectories",
+			"ListImageVulnerabilities",
 			"ListImages",
 			"ListSelectedImages",
 		}})
@@ -0,0 +1,46 @@
+package rpcd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image/provenance"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
+	"github.com/Cloud-Foundations/Dominator/proto/imageserver"
+)
+
+// checkProvenance will verify the signed build provenance for an image being
+// added, if trusted keys were configured. If provenance is required, images
+// without provenance are rejected.
+func (t *srpcType) checkProvenance(
+	request imageserver.AddImageRequest) error {
+	if t.provenanceVerifier == nil {
+		return nil
+	}
+	annotation := request.Image.Provenance
+	if annotation == nil || annotation.Object == nil {
+		if *requireProvenance {
+			return errors.New("image has no build provenance")
+		}
+		return nil
+	}
+	size, reader, err := objectserver.GetObject(t.imageDataBase.ObjectServer(),
+		*annotation.Object)
+	if err != nil {
+		return fmt.Errorf("error getting provenance: %s", err)
+	}
+	defer reader.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return fmt.Errorf("error reading provenance: %s", err)
+	}
+	_, keyId, err := provenance.Verify(data, t.provenanceVerifier,
+		request.ImageName, request.Image)
+	if err != nil {
+		return fmt.Errorf("provenance verification failed: %s", err)
+	}
+	t.logger.Debugf(0, "AddImage(%s): provenance signed by key: %s\n",
+		request.ImageName, keyId)
+	return nil
+}
@@ -0,0 +1,32 @@
+package rpcd
+
+import (
+	"bytes"
+
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) GetImageSbom(conn *srpc.Conn,
+	request imageserver.GetImageSbomRequest,
+	reply *imageserver.GetImageSbomResponse) error {
+	img := t.imageDataBase.GetImage(request.ImageName)
+	if img == nil {
+		reply.Error = "image not found"
+		return nil
+	}
+	buffer := &bytes.Buffer{}
+	err := sbom.Generate(buffer, request.Format, sbom.Params{
+		Image:        img,
+		ImageName:    request.ImageName,
+		ObjectGetter: t.imageDataBase.ObjectServer(),
+	})
+	if err != nil {
+		reply.Error = errors.ErrorToString(err)
+		return nil
+	}
+	reply.Sbom = buffer.Bytes()
+	return nil
+}
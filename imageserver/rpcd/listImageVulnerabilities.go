@@ -0,0 +1,26 @@
+package rpcd
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) ListImageVulnerabilities(conn *srpc.Conn,
+	request imageserver.ListImageVulnerabilitiesRequest,
+	reply *imageserver.ListImageVulnerabilitiesResponse) error {
+	if t.vulnerabilityScanner == nil {
+		reply.Error = "no advisory database configured"
+		reply.NoDatabase = true
+		return nil
+	}
+	findings, imageExists, err :=
+		t.vulnerabilityScanner.GetImageVulnerabilities(request.ImageName)
+	if err != nil {
+		reply.Error = errors.ErrorToString(err)
+		return nil
+	}
+	reply.ImageExists = imageExists
+	reply.Vulnerabilities = findings
+	return nil
+}
@@ -57,7 +57,18 @@ type imageType struct {
 	modifying     bool
 }
 
+// FileMirror is used to copy the files in the image database directory to
+// durable storage, so that an image database may be loaded into an empty
+// directory. Names are relative to the image database directory.
+type FileMirror interface {
+	MakeDirectory(name string) error
+	RemoveFile(name string) error
+	RestoreFiles(baseDir string) error
+	SaveFile(name, filename string) error
+}
+
 type Params struct {
+	FileMirror   FileMirror // Optional.
 	Logger       log.DebugLogger
 	ObjectServer objectserver.FullObjectServer
 }
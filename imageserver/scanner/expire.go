@@ -37,6 +37,7 @@ func (imdb *ImageDataBase) expireImage(img *image.Image, name string) {
 	if err := os.Remove(pathname + "~"); err != nil {
 		imdb.Logger.Println(err)
 	}
+	imdb.mirrorRemove(name)
 }
 
 // This may be called with the lock held.
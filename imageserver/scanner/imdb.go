@@ -21,6 +21,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/image"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
 	"github.com/Cloud-Foundations/Dominator/lib/tags/tagmatcher"
 	proto "github.com/Cloud-Foundations/Dominator/proto/imageserver"
 )
@@ -257,13 +258,19 @@ func (imdb *ImageDataBase) checkPermissions(imageName string, img *image.Image,
 		}
 	}
 	dirname := filepath.Dir(imageName)
-	if directoryMetadata, ok := imdb.directoryMap[dirname]; !ok {
+	directoryMetadata, ok := imdb.directoryMap[dirname]
+	if !ok {
 		return fmt.Errorf("no metadata for: \"%s\"", dirname)
-	} else if directoryMetadata.OwnerGroup != "" {
+	}
+	if directoryMetadata.OwnerGroup != "" {
 		if _, ok := authInfo.GroupList[directoryMetadata.OwnerGroup]; ok {
 			return nil
 		}
 	}
+	if authInfo.CheckPolicyForTarget(
+		makeDirectoryTags(dirname, directoryMetadata)) {
+		return nil
+	}
 	return errNoAccess
 }
 
@@ -323,7 +330,11 @@ func (imdb *ImageDataBase) updateDirectoryMetadataFile(
 		if !ok {
 			return nil
 		}
-		return os.Remove(filename)
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		imdb.mirrorRemove(filepath.Join(directory.Name, metadataFile))
+		return nil
 	}
 	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR,
 		fsutil.PublicFilePerms)
@@ -334,7 +345,10 @@ func (imdb *ImageDataBase) updateDirectoryMetadataFile(
 		file.Close()
 		return err
 	}
-	return file.Close()
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return imdb.mirrorFile(filepath.Join(directory.Name, metadataFile))
 }
 
 func writeDirectoryMetadata(file io.Writer,
@@ -378,6 +392,7 @@ func (imdb *ImageDataBase) deleteImage(name string,
 		if err := os.Truncate(filename, 0); err != nil {
 			return err
 		}
+		imdb.mirrorRemove(name)
 		imdb.deleteImageAndUpdateUnreferencedObjectsList(name)
 		imdb.deleteNotifiers.sendPlain(name, "delete", imdb.Logger)
 		return nil
@@ -529,6 +544,9 @@ func (imdb *ImageDataBase) getSecret() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := imdb.mirrorFile(".secret"); err != nil {
+		return nil, err
+	}
 	imdb.secret = secret
 	return imdb.secret, nil
 }
@@ -565,6 +583,16 @@ func (imdb *ImageDataBase) listImages(
 	return names
 }
 
+// makeDirectoryTags returns the tags used to check the authorisation policy
+// for a directory.
+func makeDirectoryTags(dirname string,
+	directoryMetadata image.DirectoryMetadata) tags.Tags {
+	return tags.Tags{
+		"Directory":  dirname,
+		"OwnerGroup": directoryMetadata.OwnerGroup,
+	}
+}
+
 func (imdb *ImageDataBase) makeDirectory(directory image.Directory,
 	authInfo *srpc.AuthInformation, userRpc bool) error {
 	imdb.Lock()
@@ -630,6 +658,9 @@ func (imdb *ImageDataBase) makeDirectoryWithLock(directory image.Directory,
 	if e := os.Mkdir(pathname, fsutil.DirPerms); e != nil && !os.IsExist(e) {
 		return e
 	}
+	if err := imdb.mirrorDirectory(directory.Name); err != nil {
+		return err
+	}
 	return imdb.updateDirectoryMetadata(directory)
 }
 
@@ -757,6 +788,9 @@ func (imdb *ImageDataBase) writeImage(name string, img *image.Image,
 	if err != nil {
 		return err
 	}
+	if err := imdb.mirrorFile(name); err != nil {
+		return err
+	}
 	imdb.scheduleExpiration(img, name)
 	imdb.Lock()
 	imdb.imageMap[name] = &imageType{
@@ -776,7 +810,14 @@ func (imdb *ImageDataBase) writeNewExpiration(name string,
 	img := *oldImage
 	img.ExpiresAt = expiresAt
 	filename := filepath.Join(imdb.BaseDirectory, name)
-	return writeImage(filename, &img, false)
+	fileChecksum, err := writeImage(filename, &img, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := imdb.mirrorFile(name); err != nil {
+		return nil, err
+	}
+	return fileChecksum, nil
 }
 
 func (n notifiers) sendPlain(name string, operation string,
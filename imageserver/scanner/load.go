@@ -56,6 +56,12 @@ func loadImageDataBase(config Config, params Params) (*ImageDataBase, error) {
 			Logger:        prefixlogger.New("ImageServer: ", params.Logger),
 			LogTimeout:    config.LockLogTimeout,
 		})
+	if params.FileMirror != nil {
+		err := params.FileMirror.RestoreFiles(config.BaseDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("error restoring mirrored files: %s", err)
+		}
+	}
 	state := concurrent.NewState(0)
 	startTime := time.Now()
 	var rusageStart, rusageStop syscall.Rusage
@@ -162,6 +168,7 @@ func (imdb *ImageDataBase) loadFile(filename string) error {
 		}
 		e := os.Remove(pathname)
 		if e == nil {
+			imdb.mirrorRemove(filename)
 			imdb.Logger.Printf("Will re-replicate due to %s\n", err)
 			return nil
 		}
@@ -170,7 +177,11 @@ func (imdb *ImageDataBase) loadFile(filename string) error {
 	}
 	if imageIsExpired(img) {
 		imdb.Logger.Printf("Deleting already expired image: %s\n", filename)
-		return os.Remove(pathname)
+		if err := os.Remove(pathname); err != nil {
+			return err
+		}
+		imdb.mirrorRemove(filename)
+		return nil
 	}
 	if err := img.VerifyObjects(imdb.Params.ObjectServer); err != nil {
 		if imdb.ReplicationMaster == "" ||
@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"path/filepath"
+)
+
+func (imdb *ImageDataBase) mirrorDirectory(name string) error {
+	if imdb.FileMirror == nil {
+		return nil
+	}
+	return imdb.FileMirror.MakeDirectory(name)
+}
+
+func (imdb *ImageDataBase) mirrorFile(name string) error {
+	if imdb.FileMirror == nil {
+		return nil
+	}
+	return imdb.FileMirror.SaveFile(name,
+		filepath.Join(imdb.BaseDirectory, name))
+}
+
+// mirrorRemove removes a file from the mirror. Errors are logged, since the
+// local file has already been removed.
+func (imdb *ImageDataBase) mirrorRemove(name string) {
+	if imdb.FileMirror == nil {
+		return
+	}
+	if err := imdb.FileMirror.RemoveFile(name); err != nil {
+		imdb.Logger.Printf("Error removing mirrored file: %s: %s\n", name, err)
+	}
+}
@@ -0,0 +1,54 @@
+package vulnerabilities
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/imageserver/scanner"
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+)
+
+type Config struct {
+	AdvisoryDirectory string        // Directory tree of OSV JSON files.
+	ScanInterval      time.Duration // Interval between reloads and rescans.
+}
+
+type Params struct {
+	ImageDataBase *scanner.ImageDataBase
+	Logger        log.DebugLogger
+}
+
+// Scanner periodically matches the packages in all images against a database
+// of advisories. The methods may be called with a nil *Scanner, in which case
+// an error is returned indicating that no database is configured.
+type Scanner struct {
+	config       Config
+	params       Params
+	mutex        sync.RWMutex                       // Protect everything below.
+	database     *vulnerability.Database            // nil until loaded.
+	findings     map[string][]vulnerability.Finding // Key: image name.
+	inventories  map[string]*sbom.Inventory         // Key: image name.
+	lastLoadErr  error
+	lastScanTime time.Time
+}
+
+// New will create a Scanner and load the advisory database. The database is
+// reloaded and the images are rescanned every config.ScanInterval.
+func New(config Config, params Params) (*Scanner, error) {
+	return newScanner(config, params)
+}
+
+// GetImageVulnerabilities will return the advisories which affect the named
+// image. If the image has not yet been scanned, it is scanned now. The boolean
+// indicates whether the image exists.
+func (s *Scanner) GetImageVulnerabilities(imageName string) (
+	[]vulnerability.Finding, bool, error) {
+	return s.getImageVulnerabilities(imageName)
+}
+
+func (s *Scanner) WriteHtml(writer io.Writer) {
+	s.writeHtml(writer)
+}
@@ -0,0 +1,41 @@
+package vulnerabilities
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+)
+
+func (s *Scanner) writeHtml(writer io.Writer) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.database != nil {
+		fmt.Fprintf(writer,
+			"Advisory database: %d advisories, loaded %s ago",
+			s.database.NumAdvisories(),
+			format.Duration(time.Since(s.database.LoadedAt())))
+		if numBadFiles := s.database.NumBadFiles(); numBadFiles > 0 {
+			fmt.Fprintf(writer, ", %d bad files", numBadFiles)
+		}
+		fmt.Fprintln(writer, "<br>")
+	}
+	if s.lastLoadErr != nil {
+		fmt.Fprintf(writer,
+			"<font color=\"red\">Error loading advisories: %s</font><br>\n",
+			s.lastLoadErr)
+	}
+	if !s.lastScanTime.IsZero() {
+		var numVulnerableImages uint
+		for _, findings := range s.findings {
+			if len(findings) > 0 {
+				numVulnerableImages++
+			}
+		}
+		fmt.Fprintf(writer,
+			"Vulnerable images: %d of %d, scanned %s ago<br>\n",
+			numVulnerableImages, len(s.findings),
+			format.Duration(time.Since(s.lastScanTime)))
+	}
+}
@@ -0,0 +1,143 @@
+package vulnerabilities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
+)
+
+func newScanner(config Config, params Params) (*Scanner, error) {
+	if config.ScanInterval < time.Minute {
+		config.ScanInterval = time.Minute
+	}
+	s := &Scanner{
+		config:      config,
+		params:      params,
+		findings:    make(map[string][]vulnerability.Finding),
+		inventories: make(map[string]*sbom.Inventory),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	go s.loop()
+	return s, nil
+}
+
+func (s *Scanner) getImageVulnerabilities(imageName string) (
+	[]vulnerability.Finding, bool, error) {
+	if s == nil {
+		return nil, false, errors.New("no advisory database configured")
+	}
+	if !s.params.ImageDataBase.CheckImage(imageName) {
+		return nil, false, nil
+	}
+	s.mutex.RLock()
+	database := s.database
+	findings, ok := s.findings[imageName]
+	s.mutex.RUnlock()
+	if ok {
+		return findings, true, nil
+	}
+	inventory, err := s.getInventory(imageName)
+	if err != nil {
+		return nil, true, err
+	}
+	if inventory == nil {
+		return nil, false, nil
+	}
+	findings = database.Match(inventory)
+	s.mutex.Lock()
+	s.findings[imageName] = findings
+	s.inventories[imageName] = inventory
+	s.mutex.Unlock()
+	return findings, true, nil
+}
+
+// getInventory will get the cached inventory for an image, or make it if it
+// is not cached. Since images are immutable, the inventory never changes. If
+// the image does not exist, nil is returned.
+func (s *Scanner) getInventory(imageName string) (*sbom.Inventory, error) {
+	s.mutex.RLock()
+	inventory := s.inventories[imageName]
+	s.mutex.RUnlock()
+	if inventory != nil {
+		return inventory, nil
+	}
+	img := s.params.ImageDataBase.GetImage(imageName)
+	if img == nil {
+		return nil, nil
+	}
+	return sbom.MakeInventory(sbom.Params{
+		Image:        img,
+		ImageName:    imageName,
+		ObjectGetter: s.params.ImageDataBase.ObjectServer(),
+	})
+}
+
+func (s *Scanner) load() error {
+	startTime := time.Now()
+	database, err := vulnerability.Load(s.config.AdvisoryDirectory)
+	s.mutex.Lock()
+	s.lastLoadErr = err
+	if err == nil {
+		s.database = database
+	}
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	s.params.Logger.Printf(
+		"Loaded %d advisories (%d bad files) from: %s in %s\n",
+		database.NumAdvisories(), database.NumBadFiles(),
+		s.config.AdvisoryDirectory, format.Duration(time.Since(startTime)))
+	return nil
+}
+
+func (s *Scanner) loop() {
+	for {
+		s.scan()
+		time.Sleep(s.config.ScanInterval)
+		if err := s.load(); err != nil {
+			s.params.Logger.Printf("Error loading advisories: %s\n", err)
+		}
+	}
+}
+
+// scan will match all images against the advisory database.
+func (s *Scanner) scan() {
+	startTime := time.Now()
+	s.mutex.RLock()
+	database := s.database
+	s.mutex.RUnlock()
+	findings := make(map[string][]vulnerability.Finding)
+	inventories := make(map[string]*sbom.Inventory)
+	var numVulnerableImages uint
+	for _, imageName := range s.params.ImageDataBase.ListImages() {
+		inventory, err := s.getInventory(imageName)
+		if err != nil {
+			s.params.Logger.Printf("Error making inventory for: %s: %s\n",
+				imageName, err)
+			continue
+		}
+		if inventory == nil {
+			continue
+		}
+		inventories[imageName] = inventory
+		findings[imageName] = database.Match(inventory)
+		if len(findings[imageName]) > 0 {
+			numVulnerableImages++
+		}
+	}
+	s.mutex.Lock()
+	s.findings = findings
+	s.inventories = inventories
+	s.lastScanTime = time.Now()
+	s.mutex.Unlock()
+	s.params.Logger.Debugf(0,
+		"Scanned %d images for vulnerabilities in %s, %d are vulnerable\n",
+		len(inventories), format.Duration(time.Since(startTime)),
+		numVulnerableImages)
+}
@@ -0,0 +1,106 @@
+/*
+Package chunks implements content-defined chunking of data.
+
+Data are split into variable-sized chunks at boundaries determined by the
+content, using a rolling (gear) hash. Inserting or deleting data only changes
+the chunks near the modification, so that two versions of a large file will
+share most of their chunks. This is used to transfer only the chunks of an
+object which a receiver does not already have.
+*/
+package chunks
+
+import (
+	"io"
+	"os"
+
+	"github.com/Cloud-Foundations/Dominator/lib/fsrateio"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+const (
+	MinimumChunkSize = 16 << 10
+	AverageChunkSize = 64 << 10
+	MaximumChunkSize = 256 << 10
+
+	// Objects smaller than this are not worth chunking.
+	MinimumObjectSize = 1 << 20
+)
+
+type Chunker struct {
+	buffer []byte
+	end    int
+	err    error
+	reader io.Reader
+	start  int
+}
+
+// NewChunker will create a Chunker which reads data from reader.
+func NewChunker(reader io.Reader) *Chunker {
+	return newChunker(reader)
+}
+
+// Next returns the data for the next chunk. The data are only valid until the
+// next call to Next. At the end of the data, io.EOF is returned.
+func (c *Chunker) Next() ([]byte, error) {
+	return c.next()
+}
+
+// Index records the locations of chunks in local files, so that they may be
+// read back. An Index should be closed when no longer needed.
+type Index struct {
+	chunks    map[hash.Hash]chunkLocation
+	files     []*os.File
+	filenames []string
+	numBytes  uint64
+}
+
+type chunkLocation struct {
+	fileIndex int
+	length    uint64
+	offset    uint64
+}
+
+// NewIndex will create an empty Index.
+func NewIndex() *Index {
+	return &Index{chunks: make(map[hash.Hash]chunkLocation)}
+}
+
+// AddFile will read and chunk the specified file and add the chunks to the
+// index.
+func (index *Index) AddFile(filename string) error {
+	return index.addFile(filename, nil)
+}
+
+// AddRateLimitedFile is like AddFile, except that the file is read at the rate
+// permitted by ctx.
+func (index *Index) AddRateLimitedFile(filename string,
+	ctx *fsrateio.ReaderContext) error {
+	return index.addFile(filename, ctx)
+}
+
+// Close will close any files opened by ReadChunk.
+func (index *Index) Close() error {
+	return index.close()
+}
+
+// Hashes returns the hashes of the chunks in the index.
+func (index *Index) Hashes() []hash.Hash {
+	return index.hashes()
+}
+
+// NumBytes returns the total number of bytes in the unique chunks in the index.
+func (index *Index) NumBytes() uint64 {
+	return index.numBytes
+}
+
+// ReadChunk will read the chunk with the specified hash and length. The chunk
+// data are verified.
+func (index *Index) ReadChunk(hashVal hash.Hash, length uint64) (
+	[]byte, error) {
+	return index.readChunk(hashVal, length)
+}
+
+// HashChunk returns the hash of the chunk data.
+func HashChunk(data []byte) hash.Hash {
+	return hashChunk(data)
+}
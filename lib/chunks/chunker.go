@@ -0,0 +1,82 @@
+package chunks
+
+import (
+	"crypto/sha512"
+	"io"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+// The boundary test uses the high bits of the hash, since with a gear hash
+// these depend on the most bytes.
+const boundaryMask = uint64(AverageChunkSize-1) << (64 - 16)
+
+var gearTable [256]uint64
+
+func init() {
+	// Generate a fixed table with splitmix64, so that all versions of the code
+	// find the same boundaries.
+	seed := uint64(0x446f6d696e61746f)
+	for index := range gearTable {
+		seed += 0x9e3779b97f4a7c15
+		value := seed
+		value = (value ^ (value >> 30)) * 0xbf58476d1ce4e5b9
+		value = (value ^ (value >> 27)) * 0x94d049bb133111eb
+		gearTable[index] = value ^ (value >> 31)
+	}
+}
+
+// findBoundary returns the length of the first chunk in data.
+func findBoundary(data []byte) int {
+	if len(data) <= MinimumChunkSize {
+		return len(data)
+	}
+	if len(data) > MaximumChunkSize {
+		data = data[:MaximumChunkSize]
+	}
+	var rollingHash uint64
+	for index := MinimumChunkSize; index < len(data); index++ {
+		rollingHash = (rollingHash << 1) + gearTable[data[index]]
+		if rollingHash&boundaryMask == 0 {
+			return index + 1
+		}
+	}
+	return len(data)
+}
+
+func hashChunk(data []byte) hash.Hash {
+	var hashVal hash.Hash
+	hasher := sha512.New()
+	hasher.Write(data)
+	copy(hashVal[:], hasher.Sum(nil))
+	return hashVal
+}
+
+func newChunker(reader io.Reader) *Chunker {
+	return &Chunker{
+		buffer: make([]byte, MaximumChunkSize),
+		reader: reader,
+	}
+}
+
+func (c *Chunker) next() ([]byte, error) {
+	if c.start > 0 {
+		copy(c.buffer, c.buffer[c.start:c.end])
+		c.end -= c.start
+		c.start = 0
+	}
+	if c.end < len(c.buffer) && c.err == nil {
+		nRead, err := io.ReadFull(c.reader, c.buffer[c.end:])
+		c.end += nRead
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			c.err = io.EOF
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.end < 1 {
+		return nil, c.err
+	}
+	c.start = findBoundary(c.buffer[:c.end])
+	return c.buffer[:c.start], nil
+}
@@ -0,0 +1,63 @@
+package chunks
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+func chunkData(t *testing.T, data []byte) []hash.Hash {
+	var hashes []hash.Hash
+	chunker := NewChunker(bytes.NewReader(data))
+	var total int
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk) > MaximumChunkSize {
+			t.Fatalf("chunk length: %d exceeds maximum", len(chunk))
+		}
+		total += len(chunk)
+		hashes = append(hashes, HashChunk(chunk))
+	}
+	if total != len(data) {
+		t.Fatalf("chunked: %d bytes, expected: %d", total, len(data))
+	}
+	return hashes
+}
+
+func TestInsertionSharesChunks(t *testing.T) {
+	data := make([]byte, 8<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	modified := make([]byte, 0, len(data)+10)
+	modified = append(modified, data[:len(data)/2]...)
+	modified = append(modified, []byte("inserted!!")...)
+	modified = append(modified, data[len(data)/2:]...)
+	originalChunks := make(map[hash.Hash]struct{})
+	for _, hashVal := range chunkData(t, data) {
+		originalChunks[hashVal] = struct{}{}
+	}
+	modifiedChunks := chunkData(t, modified)
+	var numShared int
+	for _, hashVal := range modifiedChunks {
+		if _, ok := originalChunks[hashVal]; ok {
+			numShared++
+		}
+	}
+	if numShared < len(modifiedChunks)-3 {
+		t.Fatalf("only %d of %d chunks shared", numShared, len(modifiedChunks))
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	if hashes := chunkData(t, nil); len(hashes) != 0 {
+		t.Fatalf("got %d chunks for empty data", len(hashes))
+	}
+}
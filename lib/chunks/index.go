@@ -0,0 +1,99 @@
+package chunks
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Cloud-Foundations/Dominator/lib/fsrateio"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+func (index *Index) addFile(filename string,
+	ctx *fsrateio.ReaderContext) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if ctx != nil {
+		reader = ctx.NewReader(file)
+	}
+	fileIndex := len(index.filenames)
+	index.filenames = append(index.filenames, filename)
+	index.files = append(index.files, nil)
+	chunker := newChunker(reader)
+	var offset uint64
+	for {
+		data, err := chunker.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		hashVal := hashChunk(data)
+		if _, ok := index.chunks[hashVal]; !ok {
+			index.chunks[hashVal] = chunkLocation{
+				fileIndex: fileIndex,
+				length:    uint64(len(data)),
+				offset:    offset,
+			}
+			index.numBytes += uint64(len(data))
+		}
+		offset += uint64(len(data))
+	}
+}
+
+func (index *Index) close() error {
+	var firstError error
+	for fileIndex, file := range index.files {
+		if file == nil {
+			continue
+		}
+		if err := file.Close(); err != nil && firstError == nil {
+			firstError = err
+		}
+		index.files[fileIndex] = nil
+	}
+	return firstError
+}
+
+func (index *Index) hashes() []hash.Hash {
+	hashes := make([]hash.Hash, 0, len(index.chunks))
+	for hashVal := range index.chunks {
+		hashes = append(hashes, hashVal)
+	}
+	return hashes
+}
+
+func (index *Index) readChunk(hashVal hash.Hash, length uint64) (
+	[]byte, error) {
+	location, ok := index.chunks[hashVal]
+	if !ok {
+		return nil, fmt.Errorf("unknown chunk: %x", hashVal)
+	}
+	if location.length != length {
+		return nil, fmt.Errorf("chunk: %x length: %d, expected: %d",
+			hashVal, location.length, length)
+	}
+	file := index.files[location.fileIndex]
+	if file == nil {
+		var err error
+		file, err = os.Open(index.filenames[location.fileIndex])
+		if err != nil {
+			return nil, err
+		}
+		index.files[location.fileIndex] = file
+	}
+	data := make([]byte, length)
+	if _, err := file.ReadAt(data, int64(location.offset)); err != nil {
+		return nil, err
+	}
+	if hashChunk(data) != hashVal {
+		return nil, fmt.Errorf("chunk: %x in: %s changed",
+			hashVal, index.filenames[location.fileIndex])
+	}
+	return data, nil
+}
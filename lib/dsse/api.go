@@ -0,0 +1,66 @@
+/*
+Package dsse implements signing and verification of Dead Simple Signing
+Envelopes (DSSE), which are used to sign in-toto attestations such as build
+provenance. Ed25519, ECDSA (with SHA-256) and RSA (PKCS #1 v1.5 with SHA-256)
+keys are supported.
+*/
+package dsse
+
+import (
+	"crypto"
+)
+
+type Envelope struct {
+	Payload     []byte      `json:"payload"` // Base64 encoded in JSON.
+	PayloadType string      `json:"payloadType"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+type Signature struct {
+	KeyId     string `json:"keyid"`
+	Signature []byte `json:"sig"` // Base64 encoded in JSON.
+}
+
+// Verifier contains a set of trusted public keys.
+type Verifier struct {
+	keys map[string]crypto.PublicKey // Key: key ID.
+}
+
+// Decode will decode a JSON encoded envelope.
+func Decode(data []byte) (*Envelope, error) {
+	return decode(data)
+}
+
+// KeyId returns the key ID for a public key, which is the hexadecimal SHA-256
+// hash of the DER encoded PKIX public key.
+func KeyId(publicKey crypto.PublicKey) (string, error) {
+	return keyId(publicKey)
+}
+
+// LoadSigner will load a PEM encoded private key from a file.
+func LoadSigner(filename string) (crypto.Signer, error) {
+	return loadSigner(filename)
+}
+
+// LoadVerifier will load a Verifier from a file containing PEM encoded public
+// keys and/or certificates.
+func LoadVerifier(filename string) (*Verifier, error) {
+	return loadVerifier(filename)
+}
+
+func NewVerifier(publicKeys []crypto.PublicKey) (*Verifier, error) {
+	return newVerifier(publicKeys)
+}
+
+// Sign will sign the payload with the signer and return an envelope.
+func Sign(payloadType string, payload []byte, signer crypto.Signer) (
+	*Envelope, error) {
+	return sign(payloadType, payload, signer)
+}
+
+// Verify will check that the envelope has a valid signature from a key
+// trusted by the verifier. The ID of the first trusted key with a valid
+// signature is returned.
+func (envelope *Envelope) Verify(verifier *Verifier) (string, error) {
+	return envelope.verify(verifier)
+}
@@ -0,0 +1,68 @@
+package dsse
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+const testPayloadType = "application/vnd.in-toto+json"
+
+func testSignAndVerify(t *testing.T, signer crypto.Signer) {
+	payload := []byte(`{"hello":"world"}`)
+	envelope, err := Sign(testPayloadType, payload, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err = Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewVerifier([]crypto.PublicKey{signer.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envelope.Verify(verifier); err != nil {
+		t.Fatal(err)
+	}
+	envelope.Payload = []byte(`{"hello":"mallory"}`)
+	if _, err := envelope.Verify(verifier); err == nil {
+		t.Fatal("tampered payload verified")
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err = NewVerifier([]crypto.PublicKey{otherKey.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope.Payload = payload
+	if _, err := envelope.Verify(verifier); err == nil {
+		t.Fatal("untrusted key verified")
+	}
+}
+
+func TestEcdsa(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSignAndVerify(t, key)
+}
+
+func TestEd25519(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSignAndVerify(t, key)
+}
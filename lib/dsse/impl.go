@@ -0,0 +1,177 @@
+package dsse
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+func decode(data []byte) (*Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.PayloadType == "" {
+		return nil, errors.New("missing payload type")
+	}
+	return &envelope, nil
+}
+
+func keyId(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(der)), nil
+}
+
+func loadSigner(filename string) (crypto.Signer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in: %s", filename)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type: %s in: %s",
+			block.Type, filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported private key in: %s", filename)
+}
+
+func loadVerifier(filename string) (*Verifier, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var publicKeys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			publicKeys = append(publicKeys, cert.PublicKey)
+		case "PUBLIC KEY":
+			publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			publicKeys = append(publicKeys, publicKey)
+		}
+	}
+	if len(publicKeys) < 1 {
+		return nil, fmt.Errorf("no public keys in: %s", filename)
+	}
+	return newVerifier(publicKeys)
+}
+
+func newVerifier(publicKeys []crypto.PublicKey) (*Verifier, error) {
+	verifier := &Verifier{keys: make(map[string]crypto.PublicKey)}
+	for _, publicKey := range publicKeys {
+		id, err := keyId(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		verifier.keys[id] = publicKey
+	}
+	return verifier, nil
+}
+
+// preAuthEncode implements the DSSE Pre-Authentication Encoding (PAE).
+func preAuthEncode(payloadType string, payload []byte) []byte {
+	header := fmt.Sprintf("DSSEv1 %d %s %d ",
+		len(payloadType), payloadType, len(payload))
+	return append([]byte(header), payload...)
+}
+
+func sign(payloadType string, payload []byte, signer crypto.Signer) (
+	*Envelope, error) {
+	id, err := keyId(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	message := preAuthEncode(payloadType, payload)
+	var signature []byte
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		signature, err = signer.Sign(rand.Reader, message, crypto.Hash(0))
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Payload:     payload,
+		PayloadType: payloadType,
+		Signatures:  []Signature{{KeyId: id, Signature: signature}},
+	}, nil
+}
+
+func verifySignature(publicKey crypto.PublicKey, message,
+	signature []byte) bool {
+	digest := sha256.Sum256(message)
+	switch publicKey := publicKey.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(publicKey, message, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(publicKey, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:],
+			signature) == nil
+	}
+	return false
+}
+
+func (envelope *Envelope) verify(verifier *Verifier) (string, error) {
+	if len(envelope.Signatures) < 1 {
+		return "", errors.New("envelope is not signed")
+	}
+	message := preAuthEncode(envelope.PayloadType, envelope.Payload)
+	for _, signature := range envelope.Signatures {
+		publicKey, ok := verifier.keys[signature.KeyId]
+		if !ok {
+			continue
+		}
+		if verifySignature(publicKey, message, signature.Signature) {
+			return signature.KeyId, nil
+		}
+		return "", fmt.Errorf("bad signature from key: %s", signature.KeyId)
+	}
+	return "", errors.New("no signatures from trusted keys")
+}
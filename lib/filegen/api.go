@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/mdb"
 	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
@@ -33,6 +34,15 @@ type FileGenerator interface {
 		data []byte, validUntil time.Time, err error)
 }
 
+// Validator is the interface that wraps the Validate method.
+//
+// Validate checks the data generated for a pathname for a machine before they
+// are served. If an error is returned, the last valid data for the machine (if
+// any) continue to be served and an alert is raised.
+type Validator interface {
+	Validate(machine mdb.Machine, pathname string, data []byte) error
+}
+
 type expiringHash struct {
 	hash       hash.Hash
 	length     uint64
@@ -43,9 +53,15 @@ type pathManager struct {
 	distributionFailed     *tricorder.CumulativeDistribution
 	distributionSuccessful *tricorder.CumulativeDistribution
 	generator              hashGenerator
+	objectServer           *memory.ObjectServer
+	pathname               string
+	sendOnlyChanged        bool
 	rwMutex                sync.RWMutex
 	// Protected by lock.
+	failures      map[string]failureType  // Key: hostname.
+	lastValid     map[string]expiringHash // Key: hostname.
 	machineHashes map[string]expiringHash // Key: hostname.
+	validators    []Validator
 }
 
 type Manager struct {
@@ -53,24 +69,76 @@ type Manager struct {
 	// Protected by lock.
 	pathManagers map[string]*pathManager // Key: pathname.
 	machineData  map[string]mdb.Machine  // Key: hostname.
+	validators   map[string][]Validator  // Key: pathname.
 	clients      map[<-chan *proto.ServerMessage]chan<- *proto.ServerMessage
 	// Not protected by lock.
 	bucketer     *tricorder.Bucketer
+	fleet        *fleetType
 	objectServer *memory.ObjectServer
 	logger       log.DebugLogger
 }
 
+// TemplateContext is the data passed to templates registered with
+// RegisterContextTemplateFileForPath. In addition to the fields, the methods
+// may be used in templates, such as {{range .LocationPeers}}.
+type TemplateContext struct {
+	Machine      mdb.Machine       // The machine to generate data for.
+	Variables    map[string]string // Set with Manager.SetVariable.
+	dependencies map[dependencyType]struct{}
+	fleet        *fleetType
+}
+
 // New creates a new *Manager. Only one should be created per application.
 // The logger will be used to log problems.
 func New(logger log.Logger) *Manager {
 	return newManager(logger)
 }
 
+// NewCommandValidator returns a Validator which runs the specified command
+// with args. The data are written to the standard input and the hostname and
+// pathname are provided in the FILEGEN_HOSTNAME and FILEGEN_PATHNAME
+// environment variables. The data are valid if the command exits successfully.
+func NewCommandValidator(command string, args []string) Validator {
+	return newCommandValidator(command, args)
+}
+
+// NewJsonValidator returns a Validator which checks that the data are valid
+// JSON.
+func NewJsonValidator() Validator {
+	return jsonValidator{}
+}
+
+// NewSizeValidator returns a Validator which checks that the length of the
+// data is within the specified bounds. A maximum of 0 means no maximum.
+func NewSizeValidator(minimum, maximum uint64) Validator {
+	return &sizeValidator{maximum: maximum, minimum: minimum}
+}
+
+// GetAlerts returns alerts for generators which are failing to generate valid
+// data for some machines.
+func (m *Manager) GetAlerts() []proto.GeneratorAlert {
+	return m.getAlerts()
+}
+
 // GetRegisteredPaths returns a slice of filenames which have generators.
 func (m *Manager) GetRegisteredPaths() []string {
 	return m.getRegisteredPaths()
 }
 
+// RegisterContextTemplateFileForPath registers a template file for a specific
+// pathname. It is similar to RegisterTemplateFileForPath, except that the
+// template is executed with a *TemplateContext, which provides access to the
+// other machines, the metadata for the required image of the machine and
+// variables. The data are regenerated when the inputs used by the template
+// change. Generated data are cached by a digest of those inputs, so changes to
+// machine data only cause data to be regenerated for machines which depend on
+// them.
+func (m *Manager) RegisterContextTemplateFileForPath(pathname string,
+	templateFile string, watchForUpdates bool) error {
+	return m.registerContextTemplateFileForPath(pathname, templateFile,
+		watchForUpdates)
+}
+
 // RegisterFileForPath registers a source file for a specific pathname. The
 // source file is used as the data source. If the source file changes, the data
 // are re-read.
@@ -144,8 +212,75 @@ func (m *Manager) RegisterUrlForPath(pathname, URL string) {
 	m.registerUrlForPath(pathname, URL)
 }
 
+// RegisterValidatorForPath registers a Validator for a specific pathname. It
+// may be called before or after a generator is registered for the pathname.
+// Multiple validators may be registered for a pathname.
+func (m *Manager) RegisterValidatorForPath(pathname string,
+	validator Validator) {
+	m.registerValidatorForPath(pathname, validator)
+}
+
+// SetImageServerAddress sets the address of the imageserver from which image
+// metadata are fetched for context templates.
+func (m *Manager) SetImageServerAddress(address string) {
+	m.setImageServerAddress(address)
+}
+
+// SetMdb sets the machines available to context templates. Until it is called,
+// the machines are those which have requested data and they are never removed.
+// Once it is called, only the machines in the MDB are available, so machines
+// which are deleted from the MDB are removed. Context templates which depend on
+// changed machines are regenerated.
+func (m *Manager) SetMdb(mdb *mdb.Mdb) {
+	m.setMdb(mdb)
+}
+
+// SetVariable sets a variable which is available to all context templates.
+// Context templates are regenerated.
+func (m *Manager) SetVariable(name, value string) {
+	m.setVariable(name, value)
+}
+
 // WriteHtml will write status information about the Manager to w, with
 // appropriate HTML markups.
 func (m *Manager) WriteHtml(writer io.Writer) {
 	m.writeHtml(writer)
 }
+
+// Image returns the metadata (without the file-system) of the required image
+// for the machine, or nil if there is no required image.
+func (context *TemplateContext) Image() (*image.Image, error) {
+	return context.getImage()
+}
+
+// LocationPeers returns the machines (including this machine) in the same
+// location as this machine, sorted by hostname.
+func (context *TemplateContext) LocationPeers() []mdb.Machine {
+	return context.getMachines(dependencyType{
+		kind:  dependencyMachinesWithLocation,
+		value: context.Machine.Location,
+	})
+}
+
+// Machines returns all the machines, sorted by hostname. Since data depend on
+// every machine, they are regenerated whenever any machine changes.
+func (context *TemplateContext) Machines() []mdb.Machine {
+	return context.getMachines(dependencyType{kind: dependencyMachines})
+}
+
+// MachinesWithTag returns the machines with the specified tag key and value,
+// sorted by hostname.
+func (context *TemplateContext) MachinesWithTag(key,
+	value string) []mdb.Machine {
+	return context.getMachines(dependencyType{
+		kind:  dependencyMachinesWithTag,
+		key:   key,
+		value: value,
+	})
+}
+
+// TagPeers returns the machines (including this machine) with the same value
+// for the specified tag key as this machine, sorted by hostname.
+func (context *TemplateContext) TagPeers(key string) []mdb.Machine {
+	return context.MachinesWithTag(key, context.Machine.Tags[key])
+}
@@ -108,6 +108,7 @@ func (m *Manager) updateMachineData(machine mdb.Machine) {
 	defer m.rwMutex.Unlock()
 	if oldMachine, ok := m.machineData[machine.Hostname]; !ok {
 		m.machineData[machine.Hostname] = machine
+		m.fleet.updateMachine(machine)
 		m.logger.Debugf(0, "updateMachineData(%s): added\n", machine.Hostname)
 	} else if !oldMachine.Compare(machine) {
 		m.machineData[machine.Hostname] = machine
@@ -116,6 +117,7 @@ func (m *Manager) updateMachineData(machine mdb.Machine) {
 			delete(pathMgr.machineHashes, machine.Hostname)
 			pathMgr.rwMutex.Unlock()
 		}
+		m.fleet.updateMachine(machine)
 		m.logger.Debugf(0, "updateMachineData(%s): changed\n", machine.Hostname)
 	}
 }
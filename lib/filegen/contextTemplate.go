@@ -0,0 +1,152 @@
+package filegen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
+)
+
+type cachedOutputType struct {
+	dependencies map[dependencyType]struct{}
+	hash         hash.Hash
+	inputDigest  digestType
+	length       uint64
+}
+
+type contextTemplateGenerator struct {
+	fleet              *fleetType
+	logger             log.Logger
+	notifierChannel    chan<- string
+	objectServer       *memory.ObjectServer
+	mutex              sync.Mutex                  // Protect everything below.
+	cache              map[string]cachedOutputType // Key: hostname.
+	template           *template.Template
+	templateGeneration uint64
+}
+
+func (m *Manager) registerContextTemplateFileForPath(pathname string,
+	templateFile string, watchForUpdates bool) error {
+	tgen := &contextTemplateGenerator{
+		cache:        make(map[string]cachedOutputType),
+		fleet:        m.fleet,
+		logger:       m.logger,
+		objectServer: m.objectServer,
+	}
+	tgen.notifierChannel = m.registerHashGeneratorForPath(pathname, tgen)
+	m.fleet.registerNotifier(tgen.notifierChannel)
+	if watchForUpdates {
+		readCloserChannel := fsutil.WatchFile(templateFile, m.logger)
+		go tgen.handleReadClosers(readCloserChannel)
+	} else {
+		file, err := os.Open(templateFile)
+		if err != nil {
+			return err
+		}
+		if err := tgen.handleReadCloser(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tgen *contextTemplateGenerator) generate(machine mdb.Machine,
+	logger log.Logger) (
+	hash.Hash, uint64, time.Time, error) {
+	tgen.mutex.Lock()
+	tmpl := tgen.template
+	templateGeneration := tgen.templateGeneration
+	cachedOutput, haveCachedOutput := tgen.cache[machine.Hostname]
+	tgen.mutex.Unlock()
+	if tmpl == nil {
+		return hash.Hash{}, 0, time.Time{}, errors.New("no template data yet")
+	}
+	if haveCachedOutput {
+		inputDigest, _ := tgen.fleet.computeInputDigest(machine,
+			templateGeneration, cachedOutput.dependencies)
+		if inputDigest == cachedOutput.inputDigest {
+			return cachedOutput.hash, cachedOutput.length, time.Time{}, nil
+		}
+	}
+	fleetGeneration := tgen.fleet.getGeneration()
+	context := &TemplateContext{
+		Machine:      machine,
+		Variables:    tgen.fleet.getVariables(),
+		dependencies: make(map[dependencyType]struct{}),
+		fleet:        tgen.fleet,
+	}
+	buffer := new(bytes.Buffer)
+	if err := tmpl.Execute(buffer, context); err != nil {
+		return hash.Hash{}, 0, time.Time{}, err
+	}
+	length := uint64(buffer.Len())
+	hashVal, _, err := tgen.objectServer.AddObject(buffer, length, nil)
+	if err != nil {
+		return hash.Hash{}, 0, time.Time{}, err
+	}
+	inputDigest, generation := tgen.fleet.computeInputDigest(machine,
+		templateGeneration, context.dependencies)
+	if generation == fleetGeneration { // Inputs did not change while running.
+		tgen.mutex.Lock()
+		if templateGeneration == tgen.templateGeneration {
+			tgen.cache[machine.Hostname] = cachedOutputType{
+				dependencies: context.dependencies,
+				hash:         hashVal,
+				inputDigest:  inputDigest,
+				length:       length,
+			}
+		}
+		tgen.mutex.Unlock()
+	}
+	return hashVal, length, time.Time{}, nil
+}
+
+func (tgen *contextTemplateGenerator) handleReadClosers(
+	readCloserChannel <-chan io.ReadCloser) {
+	for readCloser := range readCloserChannel {
+		if err := tgen.handleReadCloser(readCloser); err != nil {
+			tgen.logger.Println(err)
+		}
+	}
+}
+
+func (tgen *contextTemplateGenerator) handleReadCloser(
+	readCloser io.ReadCloser) error {
+	data, err := ioutil.ReadAll(readCloser)
+	readCloser.Close()
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("generatorTemplate").Parse(string(data))
+	if err != nil {
+		return err
+	}
+	tgen.mutex.Lock()
+	tgen.cache = make(map[string]cachedOutputType)
+	tgen.template = tmpl
+	tgen.templateGeneration++
+	tgen.mutex.Unlock()
+	tgen.notifierChannel <- ""
+	return nil
+}
+
+func (context *TemplateContext) getImage() (*image.Image, error) {
+	return context.fleet.getImage(context.Machine.RequiredImage)
+}
+
+func (context *TemplateContext) getMachines(
+	dependency dependencyType) []mdb.Machine {
+	context.dependencies[dependency] = struct{}{}
+	return context.fleet.getMachines(dependency)
+}
@@ -0,0 +1,118 @@
+package filegen
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+)
+
+const testContextTemplate = `{{.Variables.domain}}:
+{{- range .LocationPeers}} {{.Hostname}}{{end}};
+{{- range .TagPeers "Cluster"}} {{.Hostname}}{{end}}`
+
+func TestContextTemplate(t *testing.T) {
+	fleet := newFleet()
+	notifierChannel := make(chan string, 1)
+	tgen := &contextTemplateGenerator{
+		cache:           make(map[string]cachedOutputType),
+		fleet:           fleet,
+		logger:          testlogger.New(t),
+		notifierChannel: notifierChannel,
+		objectServer:    memory.NewObjectServer(),
+	}
+	err := tgen.handleReadCloser(
+		ioutil.NopCloser(strings.NewReader(testContextTemplate)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	machines := []mdb.Machine{
+		{Hostname: "a", Location: "x", Tags: tags.Tags{"Cluster": "1"}},
+		{Hostname: "b", Location: "x", Tags: tags.Tags{"Cluster": "2"}},
+		{Hostname: "c", Location: "y", Tags: tags.Tags{"Cluster": "1"}},
+		{Hostname: "d", Location: "z"},
+	}
+	for _, machine := range machines {
+		fleet.updateMachine(machine)
+	}
+	m := &Manager{fleet: fleet}
+	m.SetVariable("domain", "example.com")
+	if got := generateString(t, tgen, machines[0]); got !=
+		"example.com: a b; a c" {
+		t.Fatalf("unexpected output: \"%s\"", got)
+	}
+	cachedOutput := tgen.cache["a"]
+	// Changing a machine which is not a peer should not regenerate data.
+	machines[3].IpAddress = "10.0.0.1"
+	fleet.updateMachine(machines[3])
+	if got := generateString(t, tgen, machines[0]); got !=
+		"example.com: a b; a c" {
+		t.Fatalf("unexpected output: \"%s\"", got)
+	}
+	if tgen.cache["a"].inputDigest != cachedOutput.inputDigest {
+		t.Fatal("data were regenerated")
+	}
+	machines[2].Tags["Cluster"] = "2"
+	fleet.updateMachine(machines[2])
+	if got := generateString(t, tgen, machines[0]); got !=
+		"example.com: a b; a" {
+		t.Fatalf("unexpected output: \"%s\"", got)
+	}
+}
+
+func TestSetMdb(t *testing.T) {
+	fleet := newFleet()
+	notifierChannel := make(chan string, 1)
+	fleet.registerNotifier(notifierChannel)
+	m := &Manager{fleet: fleet}
+	fleet.updateMachine(mdb.Machine{Hostname: "requester"})
+	<-notifierChannel
+	machines := []mdb.Machine{{Hostname: "a"}, {Hostname: "b"}}
+	m.SetMdb(&mdb.Mdb{Machines: machines})
+	select {
+	case <-notifierChannel:
+	default:
+		t.Fatal("no notification for new MDB")
+	}
+	dependency := dependencyType{kind: dependencyMachines}
+	if got := fleet.getMachines(dependency); len(got) != 2 {
+		t.Fatalf("unexpected machines: %v", got)
+	}
+	// Machines which request data are ignored once there is an MDB.
+	fleet.updateMachine(mdb.Machine{Hostname: "requester"})
+	// Unchanged MDB data should not cause a notification.
+	m.SetMdb(&mdb.Mdb{Machines: machines})
+	select {
+	case <-notifierChannel:
+		t.Fatal("notification for unchanged MDB")
+	default:
+	}
+	// Deleted machines are removed.
+	m.SetMdb(&mdb.Mdb{Machines: machines[:1]})
+	if got := fleet.getMachines(dependency); len(got) != 1 ||
+		got[0].Hostname != "a" {
+		t.Fatalf("unexpected machines: %v", got)
+	}
+}
+
+func generateString(t *testing.T, tgen *contextTemplateGenerator,
+	machine mdb.Machine) string {
+	hashVal, _, _, err := tgen.generate(machine, tgen.logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, reader, err := tgen.objectServer.GetObject(hashVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
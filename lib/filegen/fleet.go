@@ -0,0 +1,300 @@
+package filegen
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/imageserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+)
+
+const (
+	dependencyMachines = iota
+	dependencyMachinesWithLocation
+	dependencyMachinesWithTag
+)
+
+const (
+	imageDialTimeout  = 15 * time.Second
+	imageFetchTimeout = time.Minute
+)
+
+type dependencyType struct {
+	kind  uint
+	key   string
+	value string
+}
+
+type digestType [sha256.Size]byte
+
+// fleetType holds the context shared by context template generators. The lock
+// order is Manager.rwMutex, then fleetType.mutex. Until an MDB is set with
+// setMdb, the machines are those which have requested data, and they are never
+// removed.
+type fleetType struct {
+	imagesMutex sync.Mutex // Protect images.
+	images      map[string]*image.Image
+	mutex       sync.RWMutex // Protect everything below.
+	generation  uint64       // Incremented when anything below changes.
+	digests     map[dependencyType]digestType
+	haveMdb     bool // If true, machines are only updated by setMdb.
+	imageServer string
+	machines    map[string]mdb.Machine // Key: hostname.
+	notifiers   []chan<- string
+	variables   map[string]string // Copy on write.
+}
+
+func newFleet() *fleetType {
+	return &fleetType{
+		images:   make(map[string]*image.Image),
+		machines: make(map[string]mdb.Machine),
+	}
+}
+
+func (m *Manager) setImageServerAddress(address string) {
+	m.fleet.mutex.Lock()
+	defer m.fleet.mutex.Unlock()
+	m.fleet.imageServer = address
+}
+
+func (m *Manager) setMdb(mdb *mdb.Mdb) {
+	if m.fleet.setMdb(mdb) {
+		m.fleet.notify()
+	}
+}
+
+func (m *Manager) setVariable(name, value string) {
+	m.fleet.mutex.Lock()
+	variables := make(map[string]string, len(m.fleet.variables)+1)
+	for key, value := range m.fleet.variables {
+		variables[key] = value
+	}
+	variables[name] = value
+	m.fleet.variables = variables
+	m.fleet.invalidate()
+	m.fleet.mutex.Unlock()
+	m.fleet.notify()
+}
+
+// computeInputDigest computes a digest of all the inputs for generating data
+// for a machine and returns the digest and the fleet generation.
+func (fleet *fleetType) computeInputDigest(machine mdb.Machine,
+	templateGeneration uint64,
+	dependencies map[dependencyType]struct{}) (digestType, uint64) {
+	sortedDependencies := make([]dependencyType, 0, len(dependencies))
+	for dependency := range dependencies {
+		sortedDependencies = append(sortedDependencies, dependency)
+	}
+	sort.Slice(sortedDependencies, func(left, right int) bool {
+		return sortedDependencies[left].less(sortedDependencies[right])
+	})
+	hasher := sha256.New()
+	binary.Write(hasher, binary.LittleEndian, templateGeneration)
+	writeJson(hasher, machine)
+	fleet.mutex.Lock()
+	defer fleet.mutex.Unlock()
+	writeJson(hasher, fleet.variables)
+	for _, dependency := range sortedDependencies {
+		fmt.Fprintf(hasher, "%d\x00%s\x00%s\x00",
+			dependency.kind, dependency.key, dependency.value)
+		digest := fleet.getGroupDigest(dependency)
+		hasher.Write(digest[:])
+	}
+	var digest digestType
+	copy(digest[:], hasher.Sum(nil))
+	return digest, fleet.generation
+}
+
+// getGroupDigest returns the digest of the machines selected by a dependency.
+// This must be called with the lock held.
+func (fleet *fleetType) getGroupDigest(dependency dependencyType) digestType {
+	if digest, ok := fleet.digests[dependency]; ok {
+		return digest
+	}
+	hasher := sha256.New()
+	for _, machine := range fleet.selectMachines(dependency) {
+		writeJson(hasher, machine)
+	}
+	var digest digestType
+	copy(digest[:], hasher.Sum(nil))
+	if fleet.digests == nil {
+		fleet.digests = make(map[dependencyType]digestType)
+	}
+	fleet.digests[dependency] = digest
+	return digest
+}
+
+// getImage returns the metadata (without the file-system) for an image.
+func (fleet *fleetType) getImage(name string) (*image.Image, error) {
+	if name == "" {
+		return nil, nil
+	}
+	fleet.imagesMutex.Lock()
+	img := fleet.images[name]
+	fleet.imagesMutex.Unlock()
+	if img != nil {
+		return img, nil
+	}
+	fleet.mutex.RLock()
+	imageServer := fleet.imageServer
+	fleet.mutex.RUnlock()
+	if imageServer == "" {
+		return nil, errors.New("no image server address")
+	}
+	srpcClient, err := srpc.DialHTTP("tcp", imageServer, imageDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer srpcClient.Close()
+	img, err = client.GetImageWithTimeout(srpcClient, name, imageFetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, fmt.Errorf("image: %s not found", name)
+	}
+	metadata := *img
+	metadata.BuildLog = nil
+	metadata.FileSystem = nil
+	metadata.Filter = nil
+	metadata.Provenance = nil
+	metadata.Signature = nil
+	fleet.imagesMutex.Lock()
+	fleet.images[name] = &metadata
+	fleet.imagesMutex.Unlock()
+	return &metadata, nil
+}
+
+func (fleet *fleetType) getGeneration() uint64 {
+	fleet.mutex.RLock()
+	defer fleet.mutex.RUnlock()
+	return fleet.generation
+}
+
+func (fleet *fleetType) getMachines(
+	dependency dependencyType) []mdb.Machine {
+	fleet.mutex.RLock()
+	defer fleet.mutex.RUnlock()
+	return fleet.selectMachines(dependency)
+}
+
+func (fleet *fleetType) getVariables() map[string]string {
+	fleet.mutex.RLock()
+	defer fleet.mutex.RUnlock()
+	return fleet.variables
+}
+
+// invalidate discards cached digests. This must be called with the lock held.
+func (fleet *fleetType) invalidate() {
+	fleet.generation++
+	fleet.digests = nil
+}
+
+// notify notifies the generators that data should be regenerated for all
+// machines.
+func (fleet *fleetType) notify() {
+	fleet.mutex.RLock()
+	notifiers := fleet.notifiers
+	fleet.mutex.RUnlock()
+	for _, notifier := range notifiers {
+		select {
+		case notifier <- "":
+		default: // A notification is already pending.
+		}
+	}
+}
+
+func (fleet *fleetType) registerNotifier(notifier chan<- string) {
+	fleet.mutex.Lock()
+	defer fleet.mutex.Unlock()
+	fleet.notifiers = append(fleet.notifiers, notifier)
+}
+
+// selectMachines returns the machines selected by a dependency, sorted by
+// hostname. This must be called with the lock held.
+func (fleet *fleetType) selectMachines(
+	dependency dependencyType) []mdb.Machine {
+	var machines []mdb.Machine
+	for _, machine := range fleet.machines {
+		switch dependency.kind {
+		case dependencyMachinesWithLocation:
+			if machine.Location != dependency.value {
+				continue
+			}
+		case dependencyMachinesWithTag:
+			if value, ok := machine.Tags[dependency.key]; !ok ||
+				value != dependency.value {
+				continue
+			}
+		}
+		machines = append(machines, machine)
+	}
+	sort.Slice(machines, func(left, right int) bool {
+		return machines[left].Hostname < machines[right].Hostname
+	})
+	return machines
+}
+
+// setMdb replaces the machines and returns true if they changed.
+func (fleet *fleetType) setMdb(mdbData *mdb.Mdb) bool {
+	machines := make(map[string]mdb.Machine, len(mdbData.Machines))
+	for _, machine := range mdbData.Machines {
+		machines[machine.Hostname] = machine
+	}
+	fleet.mutex.Lock()
+	defer fleet.mutex.Unlock()
+	fleet.haveMdb = true
+	changed := len(machines) != len(fleet.machines)
+	if !changed {
+		for hostname, machine := range machines {
+			if oldMachine, ok := fleet.machines[hostname]; !ok ||
+				!oldMachine.Compare(machine) {
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return false
+	}
+	fleet.machines = machines
+	fleet.invalidate()
+	return true
+}
+
+// updateMachine adds or updates a machine which has requested data. This is
+// ignored once an MDB has been set.
+func (fleet *fleetType) updateMachine(machine mdb.Machine) {
+	fleet.mutex.Lock()
+	if fleet.haveMdb {
+		fleet.mutex.Unlock()
+		return
+	}
+	fleet.machines[machine.Hostname] = machine
+	fleet.invalidate()
+	fleet.mutex.Unlock()
+	fleet.notify()
+}
+
+func (left dependencyType) less(right dependencyType) bool {
+	if left.kind != right.kind {
+		return left.kind < right.kind
+	}
+	if left.key != right.key {
+		return left.key < right.key
+	}
+	return left.value < right.value
+}
+
+func writeJson(writer io.Writer, value interface{}) {
+	json.NewEncoder(writer).Encode(value)
+}
@@ -2,6 +2,7 @@ package filegen
 
 import (
 	"fmt"
+	"html"
 	"io"
 )
 
@@ -9,4 +10,10 @@ func (m *Manager) writeHtml(writer io.Writer) {
 	fmt.Fprintf(writer,
 		"Number of generated files: <a href=\"listGenerators\">%d</a><br>\n",
 		len(m.pathManagers))
+	for _, alert := range m.getAlerts() {
+		fmt.Fprintf(writer, "<font color=\"red\">"+
+			"%s: invalid data for %d machines: %s</font><br>\n",
+			html.EscapeString(alert.Pathname), alert.NumFailingMachines,
+			html.EscapeString(alert.LastError))
+	}
 }
@@ -19,10 +19,12 @@ func newManager(logger log.Logger) *Manager {
 		bucketer: tricorder.NewGeometricBucketer(0.01, 1e5),
 		clients: make(
 			map[<-chan *proto.ServerMessage]chan<- *proto.ServerMessage),
+		fleet:        newFleet(),
 		logger:       debuglogger.Upgrade(logger),
 		machineData:  make(map[string]mdb.Machine),
 		objectServer: memory.NewObjectServer(),
 		pathManagers: make(map[string]*pathManager),
+		validators:   make(map[string][]Validator),
 	}
 	m.registerMdbGeneratorForPath("/etc/mdb.json")
 	srpc.RegisterNameWithOptions("FileGenerator", &rpcType{m},
@@ -36,6 +38,7 @@ func newManager(logger log.Logger) *Manager {
 func (t *rpcType) ListGenerators(conn *srpc.Conn,
 	request proto.ListGeneratorsRequest,
 	reply *proto.ListGeneratorsResponse) error {
+	reply.Alerts = t.manager.GetAlerts()
 	reply.Pathnames = t.manager.GetRegisteredPaths()
 	return nil
 }
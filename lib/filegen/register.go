@@ -40,11 +40,21 @@ func (m *Manager) registerHashGeneratorForPath(pathname string,
 		panic(pathname + " already registered")
 	}
 	notifyChan := make(chan string, 1)
+	// Context templates are notified to regenerate for all machines whenever
+	// any machine changes, but most data will be unchanged, so only changed
+	// data are sent.
+	_, sendOnlyChanged := gen.(*contextTemplateGenerator)
 	pathMgr := &pathManager{
 		distributionFailed:     m.bucketer.NewCumulativeDistribution(),
 		distributionSuccessful: m.bucketer.NewCumulativeDistribution(),
+		failures:               make(map[string]failureType),
 		generator:              gen,
-		machineHashes:          make(map[string]expiringHash)}
+		lastValid:              make(map[string]expiringHash),
+		machineHashes:          make(map[string]expiringHash),
+		objectServer:           m.objectServer,
+		pathname:               pathname,
+		sendOnlyChanged:        sendOnlyChanged,
+	}
 	err := tricorder.RegisterMetric(
 		path.Join("filegen/generators", pathname, "failed-durations"),
 		pathMgr.distributionFailed,
@@ -62,6 +72,7 @@ func (m *Manager) registerHashGeneratorForPath(pathname string,
 		panic(err)
 	}
 	m.rwMutex.Lock()
+	pathMgr.validators = m.validators[pathname]
 	m.pathManagers[pathname] = pathMgr
 	m.rwMutex.Unlock()
 	go m.processPathDataInvalidations(pathname, notifyChan)
@@ -83,9 +94,14 @@ func (m *Manager) processPathDataInvalidations(pathname string,
 					continue
 				}
 				pathMgr.rwMutex.Lock()
+				oldHash, ok := pathMgr.machineHashes[mdbData.Hostname]
 				pathMgr.machineHashes[mdbData.Hostname] = expiringHash{
 					hashVal, length, validUntil}
 				pathMgr.rwMutex.Unlock()
+				if pathMgr.sendOnlyChanged && ok &&
+					oldHash.hash == hashVal && validUntil.IsZero() {
+					continue // Unchanged: no need to send.
+				}
 				files := make([]proto.FileInfo, 1)
 				files[0].Pathname = pathname
 				files[0].Hash = hashVal
@@ -182,13 +198,17 @@ func (p *pathManager) generate(machine mdb.Machine, logger log.Logger) (
 	hash.Hash, uint64, time.Time, error) {
 	startTime := time.Now()
 	hashVal, length, expiresAt, err := p.generator.generate(machine, logger)
-	timeTaken := time.Since(startTime)
 	if err == nil {
-		p.distributionSuccessful.Add(timeTaken)
-	} else {
+		err = p.validate(machine, hashVal)
+	}
+	timeTaken := time.Since(startTime)
+	if err != nil {
 		p.distributionFailed.Add(timeTaken)
+		return p.recordFailure(machine.Hostname, err, logger)
 	}
-	return hashVal, length, expiresAt, err
+	p.distributionSuccessful.Add(timeTaken)
+	p.recordSuccess(machine.Hostname, hashVal, length, expiresAt)
+	return hashVal, length, expiresAt, nil
 }
 
 func (g *hashGeneratorWrapper) generate(machine mdb.Machine,
@@ -2,18 +2,31 @@ package filegen
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/log/debuglogger"
 	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
 	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
+	proto "github.com/Cloud-Foundations/Dominator/proto/filegenerator"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder"
 )
 
+type constantGenerator struct{}
+
 type testGenerator struct{}
 
 var testData = []byte("data")
 
+func (g *constantGenerator) Generate(machine mdb.Machine,
+	logger log.Logger) ([]byte, time.Time, error) {
+	return testData, time.Time{}, nil
+}
+
 func (g *testGenerator) Generate(machine mdb.Machine, logger log.Logger) (
 	data []byte, validUntil time.Time, err error) {
 	return testData, time.Now().Add(time.Minute), nil
@@ -30,3 +43,67 @@ func TestManyRegisters(t *testing.T) {
 		m.RegisterGeneratorForPath(pathname, dataGenerator)
 	}
 }
+
+// makeTestManager makes a Manager without registering the RPC receiver, since
+// that may only be done once.
+func makeTestManager(t *testing.T) (*Manager, <-chan *proto.ServerMessage) {
+	clientChannel := make(chan *proto.ServerMessage, 16)
+	m := &Manager{
+		bucketer: tricorder.NewGeometricBucketer(0.01, 1e5),
+		clients: map[<-chan *proto.ServerMessage]chan<- *proto.ServerMessage{
+			clientChannel: clientChannel,
+		},
+		fleet:        newFleet(),
+		logger:       debuglogger.Upgrade(testlogger.New(t)),
+		machineData:  map[string]mdb.Machine{"a": {Hostname: "a"}},
+		objectServer: memory.NewObjectServer(),
+		pathManagers: make(map[string]*pathManager),
+		validators:   make(map[string][]Validator),
+	}
+	return m, clientChannel
+}
+
+// countMessages counts the messages received until none are received for a
+// short time.
+func countMessages(clientChannel <-chan *proto.ServerMessage) int {
+	var count int
+	for {
+		select {
+		case <-clientChannel:
+			count++
+		case <-time.After(50 * time.Millisecond):
+			return count
+		}
+	}
+}
+
+func TestSendOnlyChanged(t *testing.T) {
+	m, clientChannel := makeTestManager(t)
+	// Unchanged data are sent again for other generators.
+	notifyChan := m.registerDataGeneratorForPath("test/constant",
+		&constantGenerator{})
+	for count := 0; count < 2; count++ {
+		notifyChan <- ""
+		if numMessages := countMessages(clientChannel); numMessages != 1 {
+			t.Fatalf("constant generator: %d messages sent", numMessages)
+		}
+	}
+	// Unchanged data are not sent again for context templates.
+	templateFile := filepath.Join(t.TempDir(), "template")
+	err := os.WriteFile(templateFile, []byte("{{.Machine.Hostname}}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.registerContextTemplateFileForPath("test/context", templateFile,
+		false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numMessages := countMessages(clientChannel); numMessages != 1 {
+		t.Fatalf("context template: %d messages sent", numMessages)
+	}
+	m.fleet.notify()
+	if numMessages := countMessages(clientChannel); numMessages != 0 {
+		t.Fatalf("context template: %d unchanged messages sent", numMessages)
+	}
+}
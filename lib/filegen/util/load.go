@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ type configType struct {
 }
 
 var configs = map[string]configType{
+	"ContextTemplateFile": {1, 1, contextTemplateFileGenerator},
 	"DynamicTemplateFile": {1, 1, dynamicTemplateFileGenerator},
 	"File":                {1, 1, fileGenerator},
 	"MdbFieldDirectory":   {2, 3, mdbFieldDirectoryGenerator},
@@ -25,6 +27,10 @@ var configs = map[string]configType{
 	"Programme":           {1, 1, programmeGenerator},
 	"StaticTemplateFile":  {1, 1, staticTemplateFileGenerator},
 	"URL":                 {1, 1, urlGenerator},
+	"ValidateCommand":     {1, -1, commandValidator},
+	"ValidateJSON":        {0, 0, jsonValidator},
+	"ValidateSize":        {1, 2, sizeValidator},
+	"Variable":            {0, -1, variableSetter},
 }
 
 func loadConfiguration(manager *filegen.Manager, filename string) error {
@@ -56,6 +62,18 @@ func loadConfiguration(manager *filegen.Manager, filename string) error {
 	return nil
 }
 
+func commandValidator(manager *filegen.Manager, pathname string,
+	params []string) error {
+	manager.RegisterValidatorForPath(pathname,
+		filegen.NewCommandValidator(params[0], params[1:]))
+	return nil
+}
+
+func contextTemplateFileGenerator(manager *filegen.Manager, pathname string,
+	params []string) error {
+	return manager.RegisterContextTemplateFileForPath(pathname, params[0], true)
+}
+
 func dynamicTemplateFileGenerator(manager *filegen.Manager, pathname string,
 	params []string) error {
 	return manager.RegisterTemplateFileForPath(pathname, params[0], true)
@@ -67,6 +85,12 @@ func fileGenerator(manager *filegen.Manager, pathname string,
 	return nil
 }
 
+func jsonValidator(manager *filegen.Manager, pathname string,
+	params []string) error {
+	manager.RegisterValidatorForPath(pathname, filegen.NewJsonValidator())
+	return nil
+}
+
 func mdbFieldDirectoryGenerator(manager *filegen.Manager, pathname string,
 	params []string) error {
 	interval := time.Duration(-1)
@@ -96,6 +120,24 @@ func programmeGenerator(manager *filegen.Manager, pathname string,
 	return nil
 }
 
+func sizeValidator(manager *filegen.Manager, pathname string,
+	params []string) error {
+	var maximum uint64
+	minimum, err := strconv.ParseUint(params[0], 10, 64)
+	if err != nil {
+		return err
+	}
+	if len(params) > 1 {
+		maximum, err = strconv.ParseUint(params[1], 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+	manager.RegisterValidatorForPath(pathname,
+		filegen.NewSizeValidator(minimum, maximum))
+	return nil
+}
+
 func staticTemplateFileGenerator(manager *filegen.Manager, pathname string,
 	params []string) error {
 	return manager.RegisterTemplateFileForPath(pathname, params[0], false)
@@ -106,3 +148,11 @@ func urlGenerator(manager *filegen.Manager, pathname string,
 	manager.RegisterUrlForPath(pathname, params[0])
 	return nil
 }
+
+// variableSetter sets a variable for context templates. The name is in place
+// of the pathname and the value is the remainder of the line.
+func variableSetter(manager *filegen.Manager, name string,
+	params []string) error {
+	manager.SetVariable(name, strings.Join(params, " "))
+	return nil
+}
@@ -0,0 +1,190 @@
+package filegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	proto "github.com/Cloud-Foundations/Dominator/proto/filegenerator"
+)
+
+const validateCommandTimeout = 30 * time.Second
+
+type commandValidator struct {
+	args    []string
+	command string
+}
+
+type failureType struct {
+	err  string
+	time time.Time
+}
+
+type jsonValidator struct{}
+
+type sizeValidator struct {
+	maximum uint64
+	minimum uint64
+}
+
+func newCommandValidator(command string, args []string) *commandValidator {
+	return &commandValidator{
+		args:    append([]string(nil), args...),
+		command: command,
+	}
+}
+
+func (m *Manager) getAlerts() []proto.GeneratorAlert {
+	m.rwMutex.RLock()
+	pathManagers := make(map[string]*pathManager, len(m.pathManagers))
+	for pathname, pathMgr := range m.pathManagers {
+		pathManagers[pathname] = pathMgr
+	}
+	m.rwMutex.RUnlock()
+	var alerts []proto.GeneratorAlert
+	for pathname, pathMgr := range pathManagers {
+		pathMgr.rwMutex.RLock()
+		if len(pathMgr.failures) > 0 {
+			alert := proto.GeneratorAlert{
+				NumFailingMachines: uint(len(pathMgr.failures)),
+				Pathname:           pathname,
+			}
+			for _, failure := range pathMgr.failures {
+				if failure.time.After(alert.LastFailure) {
+					alert.LastError = failure.err
+					alert.LastFailure = failure.time
+				}
+			}
+			alerts = append(alerts, alert)
+		}
+		pathMgr.rwMutex.RUnlock()
+	}
+	sort.Slice(alerts, func(left, right int) bool {
+		return alerts[left].Pathname < alerts[right].Pathname
+	})
+	return alerts
+}
+
+func (m *Manager) registerValidatorForPath(pathname string,
+	validator Validator) {
+	m.rwMutex.Lock()
+	defer m.rwMutex.Unlock()
+	m.validators[pathname] = append(m.validators[pathname], validator)
+	if pathMgr, ok := m.pathManagers[pathname]; ok {
+		pathMgr.rwMutex.Lock()
+		pathMgr.validators = m.validators[pathname]
+		pathMgr.rwMutex.Unlock()
+	}
+}
+
+func (v *commandValidator) Validate(machine mdb.Machine, pathname string,
+	data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		validateCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, v.command, v.args...)
+	cmd.Env = append(os.Environ(),
+		"FILEGEN_HOSTNAME="+machine.Hostname,
+		"FILEGEN_PATHNAME="+pathname)
+	cmd.Stdin = bytes.NewReader(data)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%s: %s: %s", v.command, err,
+				strings.TrimSpace(string(output)))
+		}
+		return fmt.Errorf("%s: %s", v.command, err)
+	}
+	return nil
+}
+
+func (jsonValidator) Validate(machine mdb.Machine, pathname string,
+	data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %s", err)
+	}
+	return nil
+}
+
+func (v *sizeValidator) Validate(machine mdb.Machine, pathname string,
+	data []byte) error {
+	length := uint64(len(data))
+	if length < v.minimum {
+		return fmt.Errorf("size: %d is less than minimum: %d",
+			length, v.minimum)
+	}
+	if v.maximum > 0 && length > v.maximum {
+		return fmt.Errorf("size: %d is greater than maximum: %d",
+			length, v.maximum)
+	}
+	return nil
+}
+
+// recordFailure records a failure to generate valid data for a machine. If
+// there are unexpired valid data for the machine, those are returned with a
+// validity time which will trigger a retry.
+func (p *pathManager) recordFailure(hostname string, err error,
+	logger log.Logger) (hash.Hash, uint64, time.Time, error) {
+	p.rwMutex.Lock()
+	defer p.rwMutex.Unlock()
+	p.failures[hostname] = failureType{err: err.Error(), time: time.Now()}
+	lastValid, ok := p.lastValid[hostname]
+	if !ok {
+		return hash.Hash{}, 0, time.Time{}, err
+	}
+	retryTime := time.Now().Add(generateFailureRetryInterval)
+	if !lastValid.validUntil.IsZero() {
+		if time.Now().After(lastValid.validUntil) {
+			return hash.Hash{}, 0, time.Time{}, err
+		}
+		if lastValid.validUntil.Before(retryTime) {
+			retryTime = lastValid.validUntil
+		}
+	}
+	logger.Printf("Serving last valid path: %s for machine: %s: %s\n",
+		p.pathname, hostname, err)
+	return lastValid.hash, lastValid.length, retryTime, nil
+}
+
+func (p *pathManager) recordSuccess(hostname string, hashVal hash.Hash,
+	length uint64, validUntil time.Time) {
+	p.rwMutex.Lock()
+	defer p.rwMutex.Unlock()
+	delete(p.failures, hostname)
+	p.lastValid[hostname] = expiringHash{hashVal, length, validUntil}
+}
+
+// validate runs the validators for the path on the generated data.
+func (p *pathManager) validate(machine mdb.Machine, hashVal hash.Hash) error {
+	p.rwMutex.RLock()
+	validators := p.validators
+	p.rwMutex.RUnlock()
+	if len(validators) < 1 {
+		return nil
+	}
+	_, reader, err := p.objectServer.GetObject(hashVal)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	for _, validator := range validators {
+		if err := validator.Validate(machine, p.pathname, data); err != nil {
+			return fmt.Errorf("validation failed: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package filegen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/mdb"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder/units"
+)
+
+type variableGenerator struct {
+	data         []byte
+	objectServer *memory.ObjectServer
+}
+
+func (g *variableGenerator) generate(machine mdb.Machine, logger log.Logger) (
+	hash.Hash, uint64, time.Time, error) {
+	length := uint64(len(g.data))
+	hashVal, _, err := g.objectServer.AddObject(bytes.NewReader(g.data),
+		length, nil)
+	return hashVal, length, time.Time{}, err
+}
+
+func TestValidate(t *testing.T) {
+	logger := testlogger.New(t)
+	objectServer := memory.NewObjectServer()
+	generator := &variableGenerator{
+		data:         []byte(`{"a": 1}`),
+		objectServer: objectServer,
+	}
+	bucketer := tricorder.NewGeometricBucketer(0.01, 1e5)
+	pathMgr := &pathManager{
+		distributionFailed:     bucketer.NewCumulativeDistribution(),
+		distributionSuccessful: bucketer.NewCumulativeDistribution(),
+		failures:               make(map[string]failureType),
+		generator:              generator,
+		lastValid:              make(map[string]expiringHash),
+		machineHashes:          make(map[string]expiringHash),
+		objectServer:           objectServer,
+		pathname:               "/etc/test.json",
+		validators: []Validator{
+			NewJsonValidator(),
+			NewSizeValidator(1, 100),
+		},
+	}
+	err := tricorder.RegisterMetric("test/validate/failed-durations",
+		pathMgr.distributionFailed, units.Millisecond, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tricorder.RegisterMetric("test/validate/successful-durations",
+		pathMgr.distributionSuccessful, units.Millisecond, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{pathManagers: map[string]*pathManager{
+		pathMgr.pathname: pathMgr,
+	}}
+	machine := mdb.Machine{Hostname: "a"}
+	goodHash, _, _, err := pathMgr.generate(machine, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts := m.GetAlerts(); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts: %v", alerts)
+	}
+	generator.data = []byte(`{"a": `)
+	hashVal, _, validUntil, err := pathMgr.generate(machine, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashVal != goodHash {
+		t.Fatal("last valid data not served")
+	}
+	if validUntil.IsZero() {
+		t.Fatal("no retry scheduled")
+	}
+	if alerts := m.GetAlerts(); len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got: %v", alerts)
+	}
+	generator.data = nil
+	if _, _, _, err := pathMgr.generate(mdb.Machine{Hostname: "b"},
+		logger); err == nil {
+		t.Fatal("empty data not rejected")
+	}
+	generator.data = []byte(`{"a": 2}`)
+	if _, _, _, err := pathMgr.generate(machine, logger); err != nil {
+		t.Fatal(err)
+	}
+	alerts := m.GetAlerts()
+	if len(alerts) != 1 || alerts[0].NumFailingMachines != 1 {
+		t.Fatalf("expected 1 failing machine, got: %v", alerts)
+	}
+}
@@ -61,12 +61,12 @@ func copyToWriter(writer io.Writer, filename string, reader io.Reader,
 	length uint64) error {
 	if length < 1 {
 		if _, err := io.Copy(writer, reader); err != nil {
-			return fmt.Errorf("error copying: %s", err)
+			return fmt.Errorf("error copying: %w", err)
 		}
 	} else {
 		length := int64(length)
 		if nCopied, err := io.CopyN(writer, reader, length); err != nil {
-			return fmt.Errorf("error copying: %s", err)
+			return fmt.Errorf("error copying: %w", err)
 		} else if nCopied != length {
 			return fmt.Errorf("expected length: %d, got: %d for: %s\n",
 				length, nCopied, filename)
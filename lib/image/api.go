@@ -37,6 +37,8 @@ type Image struct {
 	Triggers      *triggers.Triggers
 	ReleaseNotes  *Annotation
 	BuildLog      *Annotation
+	Provenance    *Annotation // Signed build provenance (DSSE envelope).
+	Signature     []byte      // Detached DSSE envelope. See lib/image/signature.
 	CreatedOn     time.Time
 	ExpiresAt     time.Time
 	Packages      []Package
@@ -50,6 +52,14 @@ type Package struct {
 	Version string
 }
 
+// ComputeDigest will compute a SHA-256 digest of the contents of the image:
+// the file-system, filter and triggers. Metadata which are set or may be
+// changed by the imageserver (such as the expiration time) and annotations are
+// not included. The digest is returned as a hexadecimal string.
+func (image *Image) ComputeDigest() (string, error) {
+	return image.computeDigest()
+}
+
 // ForEachObject will call objectFunc for all objects (including those for
 // annotations) for the image. If objectFunc returns a non-nil error, processing
 // stops and the error is returned.
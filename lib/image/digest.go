@@ -0,0 +1,85 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+)
+
+func (image *Image) computeDigest() (string, error) {
+	hasher := sha256.New()
+	if image.FileSystem != nil {
+		err := digestDirectory(hasher, image.FileSystem,
+			&image.FileSystem.DirectoryInode, "/")
+		if err != nil {
+			return "", err
+		}
+	}
+	if image.Filter != nil {
+		for _, line := range image.Filter.FilterLines {
+			fmt.Fprintf(hasher, "filter %q\n", line)
+		}
+	}
+	if image.Triggers != nil {
+		for _, trigger := range image.Triggers.Triggers {
+			data, err := json.Marshal(trigger)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(hasher, "trigger %s\n", data)
+		}
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
+
+// digestDirectory writes a canonical listing of the directory tree to the
+// hasher. Inodes are found from the inode table so that the inode pointers do
+// not need to be rebuilt.
+func digestDirectory(hasher hash.Hash, fs *filesystem.FileSystem,
+	directory *filesystem.DirectoryInode, name string) error {
+	fmt.Fprintf(hasher, "d %o %d %d %q\n",
+		directory.Mode, directory.Uid, directory.Gid, name)
+	for _, dirent := range directory.EntryList {
+		pathname := path.Join(name, dirent.Name)
+		switch inode := fs.InodeTable[dirent.InodeNumber].(type) {
+		case *filesystem.DirectoryInode:
+			if err := digestDirectory(hasher, fs, inode, pathname); err != nil {
+				return err
+			}
+		default:
+			if err := digestInode(hasher, inode, dirent.InodeNumber,
+				pathname); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func digestInode(writer io.Writer, genericInode filesystem.GenericInode,
+	inum uint64, name string) error {
+	switch inode := genericInode.(type) {
+	case *filesystem.RegularInode:
+		fmt.Fprintf(writer, "f %d %o %d %d %d %d.%09d %x %q\n",
+			inum, inode.Mode, inode.Uid, inode.Gid, inode.Size,
+			inode.MtimeSeconds, inode.MtimeNanoSeconds, inode.Hash, name)
+	case *filesystem.ComputedRegularInode:
+		fmt.Fprintf(writer, "c %d %o %d %d %q %q\n",
+			inum, inode.Mode, inode.Uid, inode.Gid, inode.Source, name)
+	case *filesystem.SymlinkInode:
+		fmt.Fprintf(writer, "l %d %d %d %q %q\n",
+			inum, inode.Uid, inode.Gid, inode.Symlink, name)
+	case *filesystem.SpecialInode:
+		fmt.Fprintf(writer, "s %d %o %d %d %d %d.%09d %q\n",
+			inum, inode.Mode, inode.Uid, inode.Gid, inode.Rdev,
+			inode.MtimeSeconds, inode.MtimeNanoSeconds, name)
+	default:
+		return fmt.Errorf("unsupported inode type for: %s", name)
+	}
+	return nil
+}
@@ -25,5 +25,10 @@ func (image *Image) forEachObject(objectFunc func(hash.Hash) error) error {
 			return err
 		}
 	}
+	if image.Provenance != nil && image.Provenance.Object != nil {
+		if err := objectFunc(*image.Provenance.Object); err != nil {
+			return err
+		}
+	}
 	return nil
 }
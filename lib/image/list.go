@@ -6,7 +6,7 @@ import (
 )
 
 func (image *Image) listObjects() []hash.Hash {
-	hashes := make([]hash.Hash, 0, image.FileSystem.NumRegularInodes+2)
+	hashes := make([]hash.Hash, 0, image.FileSystem.NumRegularInodes+3)
 	image.forEachObject(func(hashVal hash.Hash) error {
 		hashes = append(hashes, hashVal)
 		return nil
@@ -0,0 +1,103 @@
+/*
+Package provenance implements signed build provenance for images. The
+provenance is an in-toto Statement with a SLSA v1 provenance predicate, signed
+with a DSSE envelope and attached to an image as an annotation.
+*/
+package provenance
+
+import (
+	"crypto"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+)
+
+const (
+	BuildType     = "https://github.com/Cloud-Foundations/Dominator/imagebuilder/v1"
+	PayloadType   = "application/vnd.in-toto+json"
+	PredicateType = "https://slsa.dev/provenance/v1"
+	StatementType = "https://in-toto.io/Statement/v1"
+)
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+type Builder struct {
+	Id string `json:"id"`
+}
+
+type BuildMetadata struct {
+	FinishedOn   time.Time `json:"finishedOn"`
+	InvocationId string    `json:"invocationId,omitempty"`
+	StartedOn    time.Time `json:"startedOn"`
+}
+
+type ExternalParameters struct {
+	GitBranch         string            `json:"gitBranch,omitempty"`
+	ManifestDirectory string            `json:"manifestDirectory,omitempty"`
+	ManifestUrl       string            `json:"manifestUrl,omitempty"`
+	StreamName        string            `json:"streamName"`
+	Variables         map[string]string `json:"variables,omitempty"`
+}
+
+type InternalParameters struct {
+	BootstrapCommand []string            `json:"bootstrapCommand,omitempty"`
+	PackagerCommands map[string][]string `json:"packagerCommands,omitempty"`
+	PackagerType     string              `json:"packagerType,omitempty"`
+}
+
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type ResourceDescriptor struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Uri         string            `json:"uri,omitempty"`
+}
+
+type RunDetails struct {
+	Builder    Builder              `json:"builder"`
+	Byproducts []ResourceDescriptor `json:"byproducts,omitempty"`
+	Metadata   BuildMetadata        `json:"metadata"`
+}
+
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Predicate            `json:"predicate"`
+}
+
+// ImageResource returns a resource descriptor for an image, with the digest
+// computed by image.ComputeDigest and the digest of the list of objects in the
+// image.
+func ImageResource(name string, img *image.Image) (ResourceDescriptor, error) {
+	return imageResource(name, img)
+}
+
+// NewStatement returns a Statement for the named image, with the type fields
+// and the subject filled in.
+func NewStatement(imageName string, img *image.Image) (*Statement, error) {
+	return newStatement(imageName, img)
+}
+
+// Sign will sign the statement and return the JSON encoded DSSE envelope.
+func (statement *Statement) Sign(signer crypto.Signer) ([]byte, error) {
+	return statement.sign(signer)
+}
+
+// Verify will verify that the JSON encoded DSSE envelope in data is signed by a
+// key trusted by verifier and that it contains a provenance statement for the
+// named image. The statement and the ID of the signing key are returned.
+func Verify(data []byte, verifier *dsse.Verifier, imageName string,
+	img *image.Image) (*Statement, string, error) {
+	return verify(data, verifier, imageName, img)
+}
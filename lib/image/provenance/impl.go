@@ -0,0 +1,131 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+)
+
+const (
+	digestAlgorithm        = "sha256"
+	objectsDigestAlgorithm = "objectsSha256"
+)
+
+// computeObjectsDigest returns the digest of the sorted list of (SHA-512)
+// object hashes in the file-system of the image.
+func computeObjectsDigest(img *image.Image) (string, uint) {
+	var hashes []hash.Hash
+	for hashVal := range img.FileSystem.GetObjects() {
+		hashes = append(hashes, hashVal)
+	}
+	sort.Slice(hashes, func(left, right int) bool {
+		return bytes.Compare(hashes[left][:], hashes[right][:]) < 0
+	})
+	hasher := sha256.New()
+	for _, hashVal := range hashes {
+		hasher.Write(hashVal[:])
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), uint(len(hashes))
+}
+
+func imageResource(name string, img *image.Image) (ResourceDescriptor, error) {
+	if img.FileSystem == nil {
+		return ResourceDescriptor{}, errors.New("image has no file-system")
+	}
+	digest, err := img.ComputeDigest()
+	if err != nil {
+		return ResourceDescriptor{}, err
+	}
+	objectsDigest, numObjects := computeObjectsDigest(img)
+	return ResourceDescriptor{
+		Annotations: map[string]string{
+			"numObjects": fmt.Sprintf("%d", numObjects),
+		},
+		Digest: map[string]string{
+			digestAlgorithm:        digest,
+			objectsDigestAlgorithm: objectsDigest,
+		},
+		Name: name,
+	}, nil
+}
+
+func newStatement(imageName string, img *image.Image) (*Statement, error) {
+	subject, err := imageResource(imageName, img)
+	if err != nil {
+		return nil, err
+	}
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []ResourceDescriptor{subject},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{BuildType: BuildType},
+		},
+	}, nil
+}
+
+func (statement *Statement) sign(signer crypto.Signer) ([]byte, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := dsse.Sign(PayloadType, payload, signer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+func verify(data []byte, verifier *dsse.Verifier, imageName string,
+	img *image.Image) (*Statement, string, error) {
+	envelope, err := dsse.Decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if envelope.PayloadType != PayloadType {
+		return nil, "", fmt.Errorf("unsupported payload type: %s",
+			envelope.PayloadType)
+	}
+	keyId, err := envelope.Verify(verifier)
+	if err != nil {
+		return nil, "", err
+	}
+	var statement Statement
+	if err := json.Unmarshal(envelope.Payload, &statement); err != nil {
+		return nil, "", err
+	}
+	if statement.Type != StatementType {
+		return nil, "", fmt.Errorf("unsupported statement type: %s",
+			statement.Type)
+	}
+	if statement.PredicateType != PredicateType {
+		return nil, "", fmt.Errorf("unsupported predicate type: %s",
+			statement.PredicateType)
+	}
+	if len(statement.Subject) != 1 {
+		return nil, "", errors.New("statement must have exactly one subject")
+	}
+	expected, err := imageResource(imageName, img)
+	if err != nil {
+		return nil, "", err
+	}
+	subject := statement.Subject[0]
+	if subject.Name != expected.Name {
+		return nil, "", fmt.Errorf("provenance is for image: %s",
+			subject.Name)
+	}
+	for algorithm, digest := range expected.Digest {
+		if subject.Digest[algorithm] != digest {
+			return nil, "", fmt.Errorf("image %s digest mismatch", algorithm)
+		}
+	}
+	return &statement, keyId, nil
+}
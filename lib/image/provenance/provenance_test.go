@@ -0,0 +1,67 @@
+package provenance
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+)
+
+func makeTestImage(hashVal hash.Hash) *image.Image {
+	fs := &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			1: &filesystem.RegularInode{Mode: 0100644, Size: 1, Hash: hashVal},
+		},
+	}
+	fs.DirectoryInode.Mode = 040755
+	fs.DirectoryInode.EntryList = []*filesystem.DirectoryEntry{
+		{Name: "file", InodeNumber: 1},
+	}
+	return &image.Image{FileSystem: fs}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := dsse.NewVerifier([]crypto.PublicKey{publicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := makeTestImage(hash.Hash{1})
+	statement, err := NewStatement("stream/image", img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := statement.Sign(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Verify(data, verifier, "stream/image", img); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Verify(data, verifier, "stream/other", img); err == nil {
+		t.Fatal("no error for wrong image name")
+	}
+	otherImg := makeTestImage(hash.Hash{2})
+	_, _, err = Verify(data, verifier, "stream/image", otherImg)
+	if err == nil {
+		t.Fatal("no error for modified image")
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = statement.Sign(otherKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Verify(data, verifier, "stream/image", img); err == nil {
+		t.Fatal("no error for untrusted key")
+	}
+}
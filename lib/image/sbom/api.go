@@ -0,0 +1,70 @@
+/*
+Package sbom generates Software Bills of Materials (SBOMs) for images from the
+package list recorded when the image was built. CycloneDX (v1.5) and SPDX
+(v2.3) JSON formats are supported.
+*/
+package sbom
+
+import (
+	"io"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
+)
+
+const (
+	FormatCycloneDx = "cyclonedx"
+	FormatSpdx      = "spdx"
+
+	PackageTypeApk     = "apk"
+	PackageTypeDeb     = "deb"
+	PackageTypeGeneric = "generic"
+	PackageTypeRpm     = "rpm"
+)
+
+type Component struct {
+	Architecture  string // Only known for multi-arch Debian packages.
+	Name          string
+	PackageUrl    string
+	Size          uint64 // Bytes.
+	SourceName    string // Name of source package, if known.
+	SourceVersion string // Version of source package, if known.
+	Version       string
+}
+
+// Inventory is the list of packages in an image and the operating system they
+// were built for.
+type Inventory struct {
+	Components      []Component
+	OperatingSystem OperatingSystem
+}
+
+type OperatingSystem struct {
+	Id          string // ID from os-release, e.g. "debian".
+	PackageType string // PackageType* constants.
+	VersionId   string // VERSION_ID from os-release, e.g. "12".
+}
+
+type Params struct {
+	Image        *image.Image
+	ImageName    string
+	ObjectGetter objectserver.ObjectGetter // Optional: used to read os-release.
+}
+
+// Decode will decode a JSON encoded SBOM in CycloneDX or SPDX format.
+func Decode(reader io.Reader) (*Inventory, error) {
+	return decode(reader)
+}
+
+// Generate will write a JSON encoded SBOM in the specified format to writer.
+func Generate(writer io.Writer, format string, params Params) error {
+	return generate(writer, format, params)
+}
+
+// MakeInventory will make an inventory of the packages in an image. The package
+// type is determined from the package database in the image. If
+// params.ObjectGetter is not nil, the operating system is identified from the
+// os-release file in the image.
+func MakeInventory(params Params) (*Inventory, error) {
+	return makeInventory(params)
+}
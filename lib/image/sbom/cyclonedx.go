@@ -0,0 +1,152 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	cycloneDxSizeProperty = "dominator:package:size"
+	cycloneDxSpecVersion  = "1.5"
+	toolName              = "Dominator"
+)
+
+type cycloneDxBom struct {
+	BomFormat    string               `json:"bomFormat"`
+	Components   []cycloneDxComponent `json:"components"`
+	Metadata     cycloneDxMetadata    `json:"metadata"`
+	SerialNumber string               `json:"serialNumber"`
+	SpecVersion  string               `json:"specVersion"`
+	Version      int                  `json:"version"`
+}
+
+type cycloneDxComponent struct {
+	BomRef     string              `json:"bom-ref,omitempty"`
+	Hashes     []cycloneDxHash     `json:"hashes,omitempty"`
+	Name       string              `json:"name"`
+	Properties []cycloneDxProperty `json:"properties,omitempty"`
+	Purl       string              `json:"purl,omitempty"`
+	Type       string              `json:"type"`
+	Version    string              `json:"version,omitempty"`
+}
+
+type cycloneDxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cycloneDxMetadata struct {
+	Component *cycloneDxComponent `json:"component,omitempty"`
+	Timestamp string              `json:"timestamp"`
+	Tools     cycloneDxTools      `json:"tools"`
+}
+
+type cycloneDxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDxTools struct {
+	Components []cycloneDxComponent `json:"components"`
+}
+
+func decodeCycloneDx(data []byte) (*Inventory, error) {
+	var bom cycloneDxBom
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, err
+	}
+	inventory := &Inventory{}
+	for _, component := range bom.Components {
+		if component.Type == "operating-system" {
+			inventory.OperatingSystem.Id = component.Name
+			inventory.OperatingSystem.VersionId = component.Version
+			continue
+		}
+		var size uint64
+		for _, property := range component.Properties {
+			if property.Name == cycloneDxSizeProperty {
+				size, _ = strconv.ParseUint(property.Value, 10, 64)
+			}
+		}
+		if inventory.OperatingSystem.PackageType == "" {
+			inventory.OperatingSystem.PackageType = parsePackageType(
+				component.Purl)
+		}
+		inventory.Components = append(inventory.Components, Component{
+			Name:       component.Name,
+			PackageUrl: component.Purl,
+			Size:       size,
+			Version:    component.Version,
+		})
+	}
+	return inventory, nil
+}
+
+func generateCycloneDx(writer io.Writer, params Params) error {
+	inventory, err := makeInventory(params)
+	if err != nil {
+		return err
+	}
+	digest, err := params.Image.ComputeDigest()
+	if err != nil {
+		return err
+	}
+	bom := cycloneDxBom{
+		BomFormat:  "CycloneDX",
+		Components: make([]cycloneDxComponent, 0, len(inventory.Components)+1),
+		Metadata: cycloneDxMetadata{
+			Component: &cycloneDxComponent{
+				BomRef: params.ImageName,
+				Hashes: []cycloneDxHash{{"SHA-256", digest}},
+				Name:   params.ImageName,
+				Type:   "container",
+			},
+			Timestamp: timestamp(params),
+			Tools: cycloneDxTools{
+				Components: []cycloneDxComponent{{
+					Name: toolName,
+					Type: "application",
+				}},
+			},
+		},
+		SerialNumber: "urn:uuid:" + makeSerialNumber(params.ImageName, digest),
+		SpecVersion:  cycloneDxSpecVersion,
+		Version:      1,
+	}
+	if operatingSystem := inventory.OperatingSystem; operatingSystem.Id != "" {
+		bom.Components = append(bom.Components, cycloneDxComponent{
+			BomRef:  "os:" + operatingSystem.Id,
+			Name:    operatingSystem.Id,
+			Type:    "operating-system",
+			Version: operatingSystem.VersionId,
+		})
+	}
+	for _, component := range inventory.Components {
+		bom.Components = append(bom.Components, cycloneDxComponent{
+			BomRef: component.PackageUrl,
+			Name:   component.Name,
+			Properties: []cycloneDxProperty{{
+				Name:  cycloneDxSizeProperty,
+				Value: strconv.FormatUint(component.Size, 10),
+			}},
+			Purl:    component.PackageUrl,
+			Type:    "library",
+			Version: component.Version,
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(bom)
+}
+
+// timestamp returns the creation time of the image, or the current time if
+// that is not known.
+func timestamp(params Params) string {
+	createdOn := params.Image.CreatedOn
+	if createdOn.IsZero() {
+		createdOn = time.Now()
+	}
+	return createdOn.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,356 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
+)
+
+const (
+	dpkgStatusFile    = "/var/lib/dpkg/status"
+	maxDpkgStatusSize = 256 << 20
+	maxOsReleaseSize  = 64 << 10
+	maxSymlinks       = 8
+)
+
+var (
+	osReleaseFiles = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+	packageDatabases = []struct {
+		pathname    string
+		packageType string
+	}{
+		{dpkgStatusFile, PackageTypeDeb},
+		{"/var/lib/rpm", PackageTypeRpm},
+		{"/usr/lib/sysimage/rpm", PackageTypeRpm},
+		{"/lib/apk/db/installed", PackageTypeApk},
+	}
+)
+
+type source struct {
+	name    string
+	version string
+}
+
+func decode(reader io.Reader) (*Inventory, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		BomFormat   string `json:"bomFormat"`
+		SpdxVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if probe.BomFormat == "CycloneDX" {
+		return decodeCycloneDx(data)
+	}
+	if strings.HasPrefix(probe.SpdxVersion, "SPDX-") {
+		return decodeSpdx(data)
+	}
+	return nil, fmt.Errorf("unknown SBOM format")
+}
+
+// escapePurl will percent-encode a string for use in a package URL.
+func escapePurl(value string) string {
+	var builder strings.Builder
+	for _, ch := range []byte(value) {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z',
+			ch >= '0' && ch <= '9', ch == '.', ch == '-', ch == '_',
+			ch == '~':
+			builder.WriteByte(ch)
+		default:
+			fmt.Fprintf(&builder, "%%%02X", ch)
+		}
+	}
+	return builder.String()
+}
+
+func generate(writer io.Writer, format string, params Params) error {
+	switch format {
+	case FormatCycloneDx, "":
+		return generateCycloneDx(writer, params)
+	case FormatSpdx:
+		return generateSpdx(writer, params)
+	}
+	return fmt.Errorf("unsupported SBOM format: %s", format)
+}
+
+// lookupPath will find the inode for pathname in the file-system, following
+// symbolic links. The inode table is used so that the inode pointers do not
+// need to be rebuilt. If the path does not exist, nil is returned.
+func lookupPath(fs *filesystem.FileSystem,
+	pathname string) filesystem.GenericInode {
+	for count := 0; count < maxSymlinks; count++ {
+		var inode filesystem.GenericInode = &fs.DirectoryInode
+		parent := "/"
+		resolved := true
+		for _, name := range strings.Split(path.Clean(pathname), "/") {
+			if name == "" {
+				continue
+			}
+			directory, ok := inode.(*filesystem.DirectoryInode)
+			if !ok {
+				return nil
+			}
+			inode = nil
+			for _, dirent := range directory.EntryList {
+				if dirent.Name == name {
+					inode = fs.InodeTable[dirent.InodeNumber]
+					break
+				}
+			}
+			if inode == nil {
+				return nil
+			}
+			if symlink, ok := inode.(*filesystem.SymlinkInode); ok {
+				rest := strings.TrimPrefix(path.Clean(pathname),
+					path.Join(parent, name))
+				if path.IsAbs(symlink.Symlink) {
+					pathname = path.Join(symlink.Symlink, rest)
+				} else {
+					pathname = path.Join(parent, symlink.Symlink, rest)
+				}
+				resolved = false
+				break
+			}
+			parent = path.Join(parent, name)
+		}
+		if resolved {
+			return inode
+		}
+	}
+	return nil
+}
+
+func makeInventory(params Params) (*Inventory, error) {
+	inventory := &Inventory{
+		Components: make([]Component, 0, len(params.Image.Packages)),
+		OperatingSystem: OperatingSystem{
+			PackageType: PackageTypeGeneric,
+		},
+	}
+	var sources map[string]source
+	if fs := params.Image.FileSystem; fs != nil {
+		for _, database := range packageDatabases {
+			if lookupPath(fs, database.pathname) != nil {
+				inventory.OperatingSystem.PackageType = database.packageType
+				break
+			}
+		}
+		if params.ObjectGetter != nil {
+			err := readOsRelease(fs, params.ObjectGetter,
+				&inventory.OperatingSystem)
+			if err != nil {
+				return nil, err
+			}
+			if inventory.OperatingSystem.PackageType == PackageTypeDeb {
+				sources, err = readDpkgSources(fs, params.ObjectGetter)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, pkg := range params.Image.Packages {
+		component := Component{
+			Name:    pkg.Name,
+			Size:    pkg.Size,
+			Version: pkg.Version,
+		}
+		switch inventory.OperatingSystem.PackageType {
+		case PackageTypeDeb:
+			// Multi-arch packages are listed as name:arch.
+			if name, arch, ok := strings.Cut(pkg.Name, ":"); ok {
+				component.Architecture = arch
+				component.Name = name
+			}
+			if source, ok := sources[component.Name]; ok {
+				component.SourceName = source.name
+				component.SourceVersion = source.version
+			}
+		case PackageTypeRpm:
+			// Packages are listed as VERSION_RELEASE.
+			if !strings.Contains(pkg.Version, "-") {
+				if index := strings.LastIndex(pkg.Version, "_"); index > 0 {
+					component.Version = pkg.Version[:index] + "-" +
+						pkg.Version[index+1:]
+				}
+			}
+		}
+		component.PackageUrl = packageUrl(inventory.OperatingSystem,
+			component)
+		inventory.Components = append(inventory.Components, component)
+	}
+	return inventory, nil
+}
+
+// makeSerialNumber will make a UUID which is derived from the image name and
+// digest, so that the same SBOM is generated for an image each time.
+func makeSerialNumber(imageName, digest string) string {
+	sum := sha256.Sum256([]byte(imageName + "\n" + digest))
+	sum[6] = (sum[6] & 0x0f) | 0x50 // Version 5 (name based).
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant.
+	return fmt.Sprintf("%x-%x-%x-%x-%x",
+		sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// packageUrl will make a package URL (purl) for a component.
+func packageUrl(operatingSystem OperatingSystem, component Component) string {
+	var builder strings.Builder
+	builder.WriteString("pkg:")
+	builder.WriteString(operatingSystem.PackageType)
+	builder.WriteString("/")
+	haveDistro := operatingSystem.PackageType != PackageTypeGeneric &&
+		operatingSystem.Id != ""
+	if haveDistro {
+		builder.WriteString(escapePurl(operatingSystem.Id))
+		builder.WriteString("/")
+	}
+	builder.WriteString(escapePurl(component.Name))
+	if component.Version != "" {
+		builder.WriteString("@")
+		builder.WriteString(escapePurl(component.Version))
+	}
+	separator := "?"
+	if component.Architecture != "" {
+		builder.WriteString(separator + "arch=")
+		builder.WriteString(escapePurl(component.Architecture))
+		separator = "&"
+	}
+	if haveDistro && operatingSystem.VersionId != "" {
+		builder.WriteString(separator + "distro=")
+		builder.WriteString(escapePurl(operatingSystem.Id + "-" +
+			operatingSystem.VersionId))
+	}
+	return builder.String()
+}
+
+// parsePackageType will extract the package type from a package URL.
+func parsePackageType(purl string) string {
+	if !strings.HasPrefix(purl, "pkg:") {
+		return ""
+	}
+	packageType, _, _ := strings.Cut(purl[4:], "/")
+	return packageType
+}
+
+// readDpkgSources will read the dpkg status file in the image and return a
+// table of source packages, keyed by binary package name.
+func readDpkgSources(fs *filesystem.FileSystem,
+	objectGetter objectserver.ObjectGetter) (map[string]source, error) {
+	data, err := readFile(fs, objectGetter, dpkgStatusFile, maxDpkgStatusSize)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	sources := make(map[string]source)
+	var binaryName string
+	var pkgSource source
+	var version string
+	flush := func() {
+		if binaryName != "" && pkgSource.name != "" {
+			if pkgSource.version == "" {
+				pkgSource.version = version
+			}
+			sources[binaryName] = pkgSource
+		}
+		binaryName = ""
+		pkgSource = source{}
+		version = ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Package":
+			binaryName = value
+		case "Source": // Format: "name" or "name (version)".
+			name, rest, _ := strings.Cut(value, " ")
+			pkgSource.name = name
+			pkgSource.version = strings.Trim(rest, "()")
+		case "Version":
+			version = value
+		}
+	}
+	flush()
+	return sources, scanner.Err()
+}
+
+// readFile will read the contents of a regular file in the image. If the file
+// does not exist or is larger than maxSize, nil is returned.
+func readFile(fs *filesystem.FileSystem,
+	objectGetter objectserver.ObjectGetter, filename string,
+	maxSize uint64) ([]byte, error) {
+	inode, ok := lookupPath(fs, filename).(*filesystem.RegularInode)
+	if !ok {
+		return nil, nil
+	}
+	if inode.Size < 1 || inode.Size > maxSize {
+		return nil, nil
+	}
+	_, reader, err := objectGetter.GetObject(inode.Hash)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data := make([]byte, inode.Size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func readOsRelease(fs *filesystem.FileSystem,
+	objectGetter objectserver.ObjectGetter,
+	operatingSystem *OperatingSystem) error {
+	for _, filename := range osReleaseFiles {
+		data, err := readFile(fs, objectGetter, filename, maxOsReleaseSize)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			if !ok {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(value, `'"`)
+			}
+			switch key {
+			case "ID":
+				operatingSystem.Id = value
+			case "VERSION_ID":
+				operatingSystem.VersionId = value
+			}
+		}
+		return scanner.Err()
+	}
+	return nil
+}
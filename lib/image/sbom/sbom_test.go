@@ -0,0 +1,147 @@
+package sbom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
+)
+
+const (
+	dpkgStatus = `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Source: glibc
+Version: 2.36-9+deb12u4
+
+Package: libssl3
+Status: install ok installed
+Architecture: amd64
+Source: openssl (3.0.11-1~deb12u2)
+Version: 3.0.11-1~deb12u2
+`
+	osRelease = `PRETTY_NAME="Debian GNU/Linux 12 (bookworm)"
+ID=debian
+VERSION_ID="12"
+`
+)
+
+func addObject(t *testing.T, objSrv *memory.ObjectServer,
+	data string) *filesystem.RegularInode {
+	hashVal, _, err := objSrv.AddObject(strings.NewReader(data),
+		uint64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &filesystem.RegularInode{Hash: hashVal, Size: uint64(len(data))}
+}
+
+func makeTestImage(t *testing.T) (*image.Image, *memory.ObjectServer) {
+	objSrv := memory.NewObjectServer()
+	directory := func(
+		entries ...*filesystem.DirectoryEntry) filesystem.GenericInode {
+		return &filesystem.DirectoryInode{EntryList: entries}
+	}
+	entry := func(name string, inum uint64) *filesystem.DirectoryEntry {
+		return &filesystem.DirectoryEntry{Name: name, InodeNumber: inum}
+	}
+	fs := &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			1: directory(entry("os-release", 2)),
+			2: &filesystem.SymlinkInode{Symlink: "../usr/lib/os-release"},
+			3: directory(entry("lib", 4)),
+			4: directory(entry("dpkg", 5), entry("os-release", 7)),
+			5: directory(entry("status", 6)),
+			6: addObject(t, objSrv, dpkgStatus),
+			7: addObject(t, objSrv, osRelease),
+		},
+	}
+	// /var/lib and /usr/lib share an inode to keep the table small.
+	fs.DirectoryInode.EntryList = []*filesystem.DirectoryEntry{
+		entry("etc", 1),
+		entry("usr", 3),
+		entry("var", 3),
+	}
+	return &image.Image{
+		FileSystem: fs,
+		Packages: []image.Package{
+			{Name: "libc6:amd64", Size: 12 << 20, Version: "2.36-9+deb12u4"},
+			{Name: "libssl3", Size: 6 << 20, Version: "3.0.11-1~deb12u2"},
+		},
+	}, objSrv
+}
+
+func TestMakeInventory(t *testing.T) {
+	img, objSrv := makeTestImage(t)
+	inventory, err := MakeInventory(Params{
+		Image:        img,
+		ImageName:    "test/image",
+		ObjectGetter: objSrv,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOs := OperatingSystem{
+		Id:          "debian",
+		PackageType: PackageTypeDeb,
+		VersionId:   "12",
+	}
+	if inventory.OperatingSystem != expectedOs {
+		t.Fatalf("expected OS: %v, got: %v",
+			expectedOs, inventory.OperatingSystem)
+	}
+	component := inventory.Components[0]
+	expectedPurl :=
+		"pkg:deb/debian/libc6@2.36-9%2Bdeb12u4?arch=amd64&distro=debian-12"
+	if component.PackageUrl != expectedPurl {
+		t.Fatalf("expected purl: %s, got: %s",
+			expectedPurl, component.PackageUrl)
+	}
+	if component.SourceName != "glibc" ||
+		component.SourceVersion != "2.36-9+deb12u4" {
+		t.Fatalf("bad source package: %v", component)
+	}
+	component = inventory.Components[1]
+	if component.SourceName != "openssl" ||
+		component.SourceVersion != "3.0.11-1~deb12u2" {
+		t.Fatalf("bad source package: %v", component)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	img, objSrv := makeTestImage(t)
+	params := Params{Image: img, ImageName: "test/image", ObjectGetter: objSrv}
+	expected, err := MakeInventory(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{FormatCycloneDx, FormatSpdx} {
+		buffer := &bytes.Buffer{}
+		if err := Generate(buffer, format, params); err != nil {
+			t.Fatal(err)
+		}
+		inventory, err := Decode(buffer)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if inventory.OperatingSystem != expected.OperatingSystem {
+			t.Fatalf("%s: expected OS: %v, got: %v",
+				format, expected.OperatingSystem, inventory.OperatingSystem)
+		}
+		if len(inventory.Components) != len(expected.Components) {
+			t.Fatalf("%s: expected %d components, got: %d", format,
+				len(expected.Components), len(inventory.Components))
+		}
+		for index, component := range inventory.Components {
+			want := expected.Components[index]
+			if component.Name != want.Name ||
+				component.PackageUrl != want.PackageUrl ||
+				component.Version != want.Version {
+				t.Fatalf("%s: expected: %v, got: %v", format, want, component)
+			}
+		}
+	}
+}
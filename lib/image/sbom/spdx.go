@@ -0,0 +1,158 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+const (
+	spdxImageId         = "SPDXRef-Image"
+	spdxNamespacePrefix = "https://spdx.org/spdxdocs/dominator/"
+	spdxNoAssertion     = "NOASSERTION"
+	spdxOperatingSystem = "OPERATING-SYSTEM"
+	spdxVersion         = "SPDX-2.3"
+)
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxDocument struct {
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DataLicense       string             `json:"dataLicense"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	Name              string             `json:"name"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+	SpdxId            string             `json:"SPDXID"`
+	SpdxVersion       string             `json:"spdxVersion"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceLocator  string `json:"referenceLocator"`
+	ReferenceType     string `json:"referenceType"`
+}
+
+type spdxPackage struct {
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Name                  string            `json:"name"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	SpdxId                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+}
+
+type spdxRelationship struct {
+	RelatedSpdxElement string `json:"relatedSpdxElement"`
+	RelationshipType   string `json:"relationshipType"`
+	SpdxElementId      string `json:"spdxElementId"`
+}
+
+func decodeSpdx(data []byte) (*Inventory, error) {
+	var document spdxDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	inventory := &Inventory{}
+	for _, pkg := range document.Packages {
+		if pkg.SpdxId == spdxImageId {
+			continue
+		}
+		if pkg.PrimaryPackagePurpose == spdxOperatingSystem {
+			inventory.OperatingSystem.Id = pkg.Name
+			inventory.OperatingSystem.VersionId = pkg.VersionInfo
+			continue
+		}
+		component := Component{Name: pkg.Name, Version: pkg.VersionInfo}
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				component.PackageUrl = ref.ReferenceLocator
+			}
+		}
+		if inventory.OperatingSystem.PackageType == "" {
+			inventory.OperatingSystem.PackageType = parsePackageType(
+				component.PackageUrl)
+		}
+		inventory.Components = append(inventory.Components, component)
+	}
+	return inventory, nil
+}
+
+func generateSpdx(writer io.Writer, params Params) error {
+	inventory, err := makeInventory(params)
+	if err != nil {
+		return err
+	}
+	digest, err := params.Image.ComputeDigest()
+	if err != nil {
+		return err
+	}
+	document := spdxDocument{
+		CreationInfo: spdxCreationInfo{
+			Created:  timestamp(params),
+			Creators: []string{"Tool: " + toolName},
+		},
+		DataLicense: "CC0-1.0",
+		DocumentNamespace: spdxNamespacePrefix +
+			url.PathEscape(params.ImageName) + "-" +
+			makeSerialNumber(params.ImageName, digest),
+		Name: params.ImageName,
+		Packages: []spdxPackage{{
+			Checksums:             []spdxChecksum{{"SHA256", digest}},
+			DownloadLocation:      spdxNoAssertion,
+			Name:                  params.ImageName,
+			PrimaryPackagePurpose: "CONTAINER",
+			SpdxId:                spdxImageId,
+		}},
+		Relationships: []spdxRelationship{{
+			RelatedSpdxElement: spdxImageId,
+			RelationshipType:   "DESCRIBES",
+			SpdxElementId:      "SPDXRef-DOCUMENT",
+		}},
+		SpdxId:      "SPDXRef-DOCUMENT",
+		SpdxVersion: spdxVersion,
+	}
+	addPackage := func(pkg spdxPackage) {
+		pkg.DownloadLocation = spdxNoAssertion
+		pkg.SpdxId = fmt.Sprintf("SPDXRef-Package-%d", len(document.Packages))
+		document.Packages = append(document.Packages, pkg)
+		document.Relationships = append(document.Relationships,
+			spdxRelationship{
+				RelatedSpdxElement: pkg.SpdxId,
+				RelationshipType:   "CONTAINS",
+				SpdxElementId:      spdxImageId,
+			})
+	}
+	if operatingSystem := inventory.OperatingSystem; operatingSystem.Id != "" {
+		addPackage(spdxPackage{
+			Name:                  operatingSystem.Id,
+			PrimaryPackagePurpose: spdxOperatingSystem,
+			VersionInfo:           operatingSystem.VersionId,
+		})
+	}
+	for _, component := range inventory.Components {
+		addPackage(spdxPackage{
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceLocator:  component.PackageUrl,
+				ReferenceType:     "purl",
+			}},
+			Name:        component.Name,
+			VersionInfo: component.Version,
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(document)
+}
@@ -0,0 +1,62 @@
+/*
+Package signature implements detached signatures for images. A signature is a
+DSSE envelope containing the name of an image and the digests of its
+file-system, filter and triggers, signed with an offline key when the image is
+added to the imageserver. The signature is stored in the Signature field of
+the image, which is not included in the digests, so that consumers of images
+(such as the dominator, subd, the hypervisor and the installer) can verify
+that the image was not modified or renamed by the imageserver.
+
+Subd is sent only the filter and triggers of an image, not the file-system, so
+it can verify only that they and the image name match the signature. The file
+changes in an update are not bound to the signature on subd: it relies on the
+dominator having verified the full image before computing the update.
+*/
+package signature
+
+import (
+	"crypto"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/filter"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
+)
+
+const PayloadType = "application/vnd.dominator.image-signature+json"
+
+type Payload struct {
+	FilterDigest   string `json:"filterDigest,omitempty"` // Empty: no filter.
+	ImageDigest    string `json:"imageDigest"`            // All content.
+	ImageName      string `json:"imageName"`
+	TriggersDigest string `json:"triggersDigest"`
+}
+
+// NewPayload returns the payload to be signed for an image.
+func NewPayload(name string, img *image.Image) (*Payload, error) {
+	return newPayload(name, img)
+}
+
+// Sign will sign the image with the specified name and return the JSON encoded
+// DSSE envelope, which should be stored in the Signature field of the image.
+func Sign(name string, img *image.Image, signer crypto.Signer) ([]byte, error) {
+	return sign(name, img, signer)
+}
+
+// Verify will verify that the Signature field of the image contains a valid
+// signature from a key trusted by verifier and that it matches the name and
+// contents of the image. The ID of the signing key is returned.
+// The image file-system must not have been filtered.
+func Verify(name string, img *image.Image, verifier *dsse.Verifier) (
+	string, error) {
+	return verify(name, img, verifier)
+}
+
+// VerifyFilterAndTriggers will verify that the JSON encoded DSSE envelope in
+// data is signed by a key trusted by verifier and that it matches the image
+// name, filter and triggers. This is used by consumers (such as subd) which
+// are sent only part of an image. The ID of the signing key is returned.
+func VerifyFilterAndTriggers(data []byte, verifier *dsse.Verifier,
+	name string, filt *filter.Filter, trig *triggers.Triggers) (string, error) {
+	return verifyFilterAndTriggers(data, verifier, name, filt, trig)
+}
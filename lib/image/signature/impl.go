@@ -0,0 +1,135 @@
+package signature
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/filter"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
+)
+
+func computeFilterDigest(filt *filter.Filter) (string, error) {
+	if filt == nil {
+		return "", nil
+	}
+	return (&image.Image{Filter: filt}).ComputeDigest()
+}
+
+func computeTriggersDigest(trig *triggers.Triggers) (string, error) {
+	return (&image.Image{Triggers: trig}).ComputeDigest()
+}
+
+func decodePayload(data []byte, verifier *dsse.Verifier) (
+	*Payload, string, error) {
+	if len(data) < 1 {
+		return nil, "", errors.New("image is not signed")
+	}
+	envelope, err := dsse.Decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if envelope.PayloadType != PayloadType {
+		return nil, "", fmt.Errorf("unsupported payload type: %s",
+			envelope.PayloadType)
+	}
+	keyId, err := envelope.Verify(verifier)
+	if err != nil {
+		return nil, "", err
+	}
+	var payload Payload
+	if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+		return nil, "", err
+	}
+	return &payload, keyId, nil
+}
+
+func newPayload(name string, img *image.Image) (*Payload, error) {
+	if name == "" {
+		return nil, errors.New("no image name")
+	}
+	if img.FileSystem == nil {
+		return nil, errors.New("image has no file-system")
+	}
+	imageDigest, err := img.ComputeDigest()
+	if err != nil {
+		return nil, err
+	}
+	filterDigest, err := computeFilterDigest(img.Filter)
+	if err != nil {
+		return nil, err
+	}
+	triggersDigest, err := computeTriggersDigest(img.Triggers)
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{
+		FilterDigest:   filterDigest,
+		ImageDigest:    imageDigest,
+		ImageName:      name,
+		TriggersDigest: triggersDigest,
+	}, nil
+}
+
+func sign(name string, img *image.Image, signer crypto.Signer) (
+	[]byte, error) {
+	payload, err := newPayload(name, img)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := dsse.Sign(PayloadType, data, signer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+func verify(name string, img *image.Image, verifier *dsse.Verifier) (
+	string, error) {
+	signed, keyId, err := decodePayload(img.Signature, verifier)
+	if err != nil {
+		return "", err
+	}
+	if signed.ImageName != name {
+		return "", fmt.Errorf("signature is for image: \"%s\"",
+			signed.ImageName)
+	}
+	expected, err := newPayload(name, img)
+	if err != nil {
+		return "", err
+	}
+	if *signed != *expected {
+		return "", errors.New("image does not match signature")
+	}
+	return keyId, nil
+}
+
+func verifyFilterAndTriggers(data []byte, verifier *dsse.Verifier,
+	name string, filt *filter.Filter, trig *triggers.Triggers) (string, error) {
+	signed, keyId, err := decodePayload(data, verifier)
+	if err != nil {
+		return "", err
+	}
+	if name == "" || signed.ImageName != name {
+		return "", fmt.Errorf("signature is for image: \"%s\"",
+			signed.ImageName)
+	}
+	if filterDigest, err := computeFilterDigest(filt); err != nil {
+		return "", err
+	} else if filterDigest != signed.FilterDigest {
+		return "", errors.New("filter does not match signature")
+	}
+	if triggersDigest, err := computeTriggersDigest(trig); err != nil {
+		return "", err
+	} else if triggersDigest != signed.TriggersDigest {
+		return "", errors.New("triggers do not match signature")
+	}
+	return keyId, nil
+}
@@ -0,0 +1,107 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/filter"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
+)
+
+func makeTestImage(hashVal hash.Hash) *image.Image {
+	fs := &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			1: &filesystem.RegularInode{Mode: 0100644, Size: 1, Hash: hashVal},
+		},
+	}
+	fs.DirectoryInode.Mode = 040755
+	fs.DirectoryInode.EntryList = []*filesystem.DirectoryEntry{
+		{Name: "file", InodeNumber: 1},
+	}
+	trig := triggers.New()
+	trig.Triggers = append(trig.Triggers, &triggers.Trigger{
+		MatchLines: []string{"/etc/ssh/.*"},
+		Service:    "sshd",
+	})
+	return &image.Image{FileSystem: fs, Triggers: trig}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := dsse.NewVerifier([]crypto.PublicKey{publicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := makeTestImage(hash.Hash{1})
+	const name = "base/image/1"
+	if _, err := Verify(name, img, verifier); err == nil {
+		t.Fatal("unsigned image verified")
+	}
+	img.Signature, err = Sign(name, img, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(name, img, verifier); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify("base/image/2", img, verifier); err == nil {
+		t.Fatal("renamed image verified")
+	}
+	_, err = VerifyFilterAndTriggers(img.Signature, verifier, name,
+		img.Filter, img.Triggers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The signature of one image cannot be replayed for another image with
+	// the same filter and triggers.
+	_, err = VerifyFilterAndTriggers(img.Signature, verifier, "base/image/2",
+		img.Filter, img.Triggers)
+	if err == nil {
+		t.Fatal("signature replayed for other image")
+	}
+	_, err = VerifyFilterAndTriggers(img.Signature, verifier, "", img.Filter,
+		img.Triggers)
+	if err == nil {
+		t.Fatal("signature verified without image name")
+	}
+	// An empty filter is not the same as no filter.
+	emptyFilter, err := filter.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = VerifyFilterAndTriggers(img.Signature, verifier, name,
+		emptyFilter, img.Triggers)
+	if err == nil {
+		t.Fatal("empty filter verified")
+	}
+	_, err = VerifyFilterAndTriggers(img.Signature, verifier, name,
+		img.Filter, nil)
+	if err == nil {
+		t.Fatal("missing triggers verified")
+	}
+	otherImage := makeTestImage(hash.Hash{2})
+	otherImage.Signature = img.Signature
+	if _, err := Verify(name, otherImage, verifier); err == nil {
+		t.Fatal("modified image verified")
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img.Signature, err = Sign(name, img, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(name, img, verifier); err == nil {
+		t.Fatal("image signed with untrusted key verified")
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Package vulnerability matches the packages in images against an offline
+database of security advisories. Advisories are read from a directory tree of
+files in the Open Source Vulnerability (OSV) JSON format, as published for
+Debian, Ubuntu, Alpine, AlmaLinux and Rocky Linux (among others), or in the
+JSON format of the Debian Security Tracker.
+*/
+package vulnerability
+
+import (
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+)
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// Database is an immutable, indexed set of advisories.
+type Database struct {
+	entries        map[string]map[string][]*entryType // Ecosystem, package.
+	loadedAt       time.Time
+	newestAdvisory time.Time
+	numAdvisories  uint
+	numBadFiles    uint
+}
+
+type Finding struct {
+	Aliases        []string `json:",omitempty"` // For example, CVE IDs.
+	AdvisoryId     string
+	FixedVersion   string `json:",omitempty"` // Empty if not yet fixed.
+	PackageName    string
+	PackageVersion string
+	Severity       Severity
+	Summary        string `json:",omitempty"`
+}
+
+type Severity uint
+
+// Load will load the advisories in the JSON files (*.json) in dirname and its
+// sub-directories. A file may contain a single OSV advisory, an array of OSV
+// advisories or Debian Security Tracker data. Files which cannot be decoded are
+// counted and skipped.
+func Load(dirname string) (*Database, error) {
+	return load(dirname)
+}
+
+// CompareVersions will compare two package versions using the rules for the
+// specified package type (see the sbom.PackageType* constants). It returns -1,
+// 0 or 1 if left is less than, equal to or greater than right.
+func CompareVersions(packageType, left, right string) int {
+	return compareVersions(packageType, left, right)
+}
+
+// LoadedAt returns the time the database was loaded.
+func (db *Database) LoadedAt() time.Time {
+	return db.loadedAt
+}
+
+// Match will return the advisories which affect the packages in the inventory,
+// most severe first.
+func (db *Database) Match(inventory *sbom.Inventory) []Finding {
+	return db.match(inventory)
+}
+
+// NewestAdvisory returns the latest modification time of the advisories.
+func (db *Database) NewestAdvisory() time.Time {
+	return db.newestAdvisory
+}
+
+// NumAdvisories returns the number of advisories loaded.
+func (db *Database) NumAdvisories() uint {
+	return db.numAdvisories
+}
+
+// NumBadFiles returns the number of files which could not be decoded.
+func (db *Database) NumBadFiles() uint {
+	return db.numBadFiles
+}
+
+// MaxSeverity returns the highest severity in the list of findings.
+func MaxSeverity(findings []Finding) Severity {
+	var maxSeverity Severity
+	for _, finding := range findings {
+		if finding.Severity > maxSeverity {
+			maxSeverity = finding.Severity
+		}
+	}
+	return maxSeverity
+}
+
+// ParseSeverity will parse a severity name, such as "high" or "moderate".
+func ParseSeverity(name string) (Severity, error) {
+	return parseSeverity(name)
+}
+
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+func (severity *Severity) Set(value string) error {
+	return severity.set(value)
+}
+
+func (severity Severity) String() string {
+	return severity.string()
+}
+
+func (severity *Severity) UnmarshalText(text []byte) error {
+	return severity.set(string(text))
+}
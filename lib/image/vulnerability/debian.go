@@ -0,0 +1,82 @@
+package vulnerability
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// debianReleases maps Debian release codenames to version numbers.
+var debianReleases = map[string]string{
+	"bookworm": "12",
+	"bullseye": "11",
+	"buster":   "10",
+	"forky":    "14",
+	"stretch":  "9",
+	"trixie":   "13",
+}
+
+// debianIssueType is an issue in the Debian Security Tracker JSON format, as
+// published at https://security-tracker.debian.org/tracker/data/json, which is
+// a map of source package names to maps of issue IDs to issues.
+type debianIssueType struct {
+	Description string                       `json:"description"`
+	Releases    map[string]debianReleaseType `json:"releases"`
+}
+
+type debianReleaseType struct {
+	FixedVersion string `json:"fixed_version"`
+	Status       string `json:"status"`
+	Urgency      string `json:"urgency"`
+}
+
+// decodeDebianTracker will convert the Debian Security Tracker data into OSV
+// advisories, one per package and issue.
+func decodeDebianTracker(data []byte) ([]*osvType, error) {
+	var packages map[string]map[string]debianIssueType
+	if err := json.Unmarshal(data, &packages); err != nil {
+		return nil, err
+	}
+	var advisories []*osvType
+	for packageName, issues := range packages {
+		for issueId, issue := range issues {
+			advisory := &osvType{Id: issueId, Summary: issue.Description}
+			if strings.HasPrefix(issueId, "CVE-") {
+				advisory.Aliases = []string{issueId}
+			}
+			for codename, release := range issue.Releases {
+				version, ok := debianReleases[codename]
+				if !ok {
+					continue
+				}
+				var event osvEvent
+				switch release.Status {
+				case "open":
+				case "resolved":
+					if release.FixedVersion == "" ||
+						release.FixedVersion == "0" {
+						continue // Never affected.
+					}
+					event.Fixed = release.FixedVersion
+				default:
+					continue
+				}
+				var affected osvAffectedType
+				affected.EcosystemSpecific.Urgency = release.Urgency
+				affected.Package.Ecosystem = "Debian:" + version
+				affected.Package.Name = packageName
+				events := []osvEvent{{Introduced: "0"}}
+				if event.Fixed != "" {
+					events = append(events, event)
+				}
+				affected.Ranges = []osvRange{
+					{Events: events, Type: "ECOSYSTEM"},
+				}
+				advisory.Affected = append(advisory.Affected, affected)
+			}
+			if len(advisory.Affected) > 0 {
+				advisories = append(advisories, advisory)
+			}
+		}
+	}
+	return advisories, nil
+}
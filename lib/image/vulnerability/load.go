@@ -0,0 +1,173 @@
+package vulnerability
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type entryType struct {
+	advisory *osvType
+	affected *osvAffectedType
+	release  string // Ecosystem release, such as "12" for "Debian:12".
+	severity Severity
+}
+
+// osvType is the subset of the OSV schema which is used.
+type osvType struct {
+	Affected         []osvAffectedType `json:"affected"`
+	Aliases          []string          `json:"aliases"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+	Id        string        `json:"id"`
+	Modified  time.Time     `json:"modified"`
+	Severity  []osvSeverity `json:"severity"`
+	Summary   string        `json:"summary"`
+	Withdrawn *time.Time    `json:"withdrawn"`
+}
+
+type osvAffectedType struct {
+	EcosystemSpecific struct {
+		Urgency string `json:"urgency"`
+	} `json:"ecosystem_specific"`
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []osvRange    `json:"ranges"`
+	Severity []osvSeverity `json:"severity"`
+	Versions []string      `json:"versions"`
+}
+
+type osvEvent struct {
+	Fixed        string `json:"fixed"`
+	Introduced   string `json:"introduced"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+type osvRange struct {
+	Events []osvEvent `json:"events"`
+	Type   string     `json:"type"`
+}
+
+type osvSeverity struct {
+	Score string `json:"score"`
+	Type  string `json:"type"`
+}
+
+func computeSeverity(advisory *osvType, affected *osvAffectedType) Severity {
+	var maxSeverity Severity
+	check := func(severity Severity) {
+		if severity > maxSeverity {
+			maxSeverity = severity
+		}
+	}
+	for _, severities := range [][]osvSeverity{
+		advisory.Severity, affected.Severity} {
+		for _, severity := range severities {
+			if strings.HasPrefix(severity.Score, "CVSS:3") {
+				if score, err := computeCvss3Score(severity.Score); err == nil {
+					check(scoreToSeverity(score))
+				}
+			} else if score, err := strconv.ParseFloat(severity.Score,
+				64); err == nil {
+				check(scoreToSeverity(score))
+			} else if value, err := parseSeverity(severity.Score); err == nil {
+				check(value)
+			}
+		}
+	}
+	for _, name := range []string{advisory.DatabaseSpecific.Severity,
+		affected.EcosystemSpecific.Urgency} {
+		value, err := parseSeverity(strings.TrimRight(name, "*"))
+		if err == nil {
+			check(value)
+		}
+	}
+	return maxSeverity
+}
+
+func decodeFile(filename string) ([]*osvType, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var advisories []*osvType
+		if err := json.Unmarshal(data, &advisories); err != nil {
+			return nil, err
+		}
+		return advisories, nil
+	}
+	var advisory osvType
+	if err := json.Unmarshal(data, &advisory); err == nil && advisory.Id != "" {
+		return []*osvType{&advisory}, nil
+	}
+	return decodeDebianTracker(data)
+}
+
+func load(dirname string) (*Database, error) {
+	db := &Database{
+		entries:  make(map[string]map[string][]*entryType),
+		loadedAt: time.Now(),
+	}
+	err := filepath.WalkDir(dirname,
+		func(path string, dirent fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if dirent.IsDir() || !strings.HasSuffix(path, ".json") {
+				return nil
+			}
+			advisories, err := decodeFile(path)
+			if err != nil {
+				db.numBadFiles++
+				return nil
+			}
+			for _, advisory := range advisories {
+				db.addAdvisory(advisory)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *Database) addAdvisory(advisory *osvType) {
+	if advisory.Id == "" || advisory.Withdrawn != nil {
+		return
+	}
+	db.numAdvisories++
+	if advisory.Modified.After(db.newestAdvisory) {
+		db.newestAdvisory = advisory.Modified
+	}
+	for index := range advisory.Affected {
+		affected := &advisory.Affected[index]
+		ecosystem, release, _ := strings.Cut(affected.Package.Ecosystem, ":")
+		if ecosystem == "" || affected.Package.Name == "" {
+			continue
+		}
+		packages := db.entries[ecosystem]
+		if packages == nil {
+			packages = make(map[string][]*entryType)
+			db.entries[ecosystem] = packages
+		}
+		packages[affected.Package.Name] = append(
+			packages[affected.Package.Name], &entryType{
+				advisory: advisory,
+				affected: affected,
+				release:  release,
+				severity: computeSeverity(advisory, affected),
+			})
+	}
+}
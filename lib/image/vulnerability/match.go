@@ -0,0 +1,145 @@
+package vulnerability
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+)
+
+// osToEcosystem maps the ID from os-release to the OSV ecosystem name.
+var osToEcosystem = map[string]string{
+	"almalinux": "AlmaLinux",
+	"alpine":    "Alpine",
+	"debian":    "Debian",
+	"rocky":     "Rocky Linux",
+	"ubuntu":    "Ubuntu",
+}
+
+// matchRelease returns true if the ecosystem release (such as "12",
+// "22.04:LTS", "Pro:18.04:LTS" or "v3.18") matches the OS version.
+func matchRelease(release, versionId string) bool {
+	if release == "" {
+		return true
+	}
+	if versionId == "" {
+		return false
+	}
+	for _, field := range strings.Split(release, ":") {
+		field = strings.TrimPrefix(field, "v")
+		if field == versionId || strings.HasPrefix(versionId, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isAffected returns true if the version is affected, along with the version
+// which fixes the vulnerability, if known.
+func (entry *entryType) isAffected(packageType, version string) (
+	bool, string) {
+	for _, affectedVersion := range entry.affected.Versions {
+		if compareVersions(packageType, version, affectedVersion) == 0 {
+			return true, ""
+		}
+	}
+	for _, versionRange := range entry.affected.Ranges {
+		if versionRange.Type == "GIT" {
+			continue
+		}
+		var introduced string
+		var inRange bool
+		for _, event := range versionRange.Events {
+			switch {
+			case event.Introduced != "":
+				introduced = event.Introduced
+				inRange = introduced == "0" ||
+					compareVersions(packageType, version, introduced) >= 0
+			case event.Fixed != "":
+				if inRange &&
+					compareVersions(packageType, version, event.Fixed) < 0 {
+					return true, event.Fixed
+				}
+				inRange = false
+			case event.LastAffected != "":
+				if inRange && compareVersions(packageType, version,
+					event.LastAffected) <= 0 {
+					return true, ""
+				}
+				inRange = false
+			case event.Limit != "":
+				if inRange &&
+					compareVersions(packageType, version, event.Limit) < 0 {
+					return true, ""
+				}
+				inRange = false
+			}
+		}
+		if inRange {
+			return true, ""
+		}
+	}
+	return false, ""
+}
+
+func (db *Database) match(inventory *sbom.Inventory) []Finding {
+	operatingSystem := inventory.OperatingSystem
+	packages := db.entries[osToEcosystem[operatingSystem.Id]]
+	if len(packages) < 1 {
+		return nil
+	}
+	var findings []Finding
+	found := make(map[string]struct{})
+	for _, component := range inventory.Components {
+		// Advisories are usually for source packages.
+		names := []string{component.Name}
+		versions := []string{component.Version}
+		if component.SourceName != "" &&
+			component.SourceName != component.Name {
+			names = append(names, component.SourceName)
+			if component.SourceVersion != "" {
+				versions = append(versions, component.SourceVersion)
+			} else {
+				versions = append(versions, component.Version)
+			}
+		} else if component.SourceVersion != "" {
+			versions[0] = component.SourceVersion
+		}
+		for index, name := range names {
+			for _, entry := range packages[name] {
+				if !matchRelease(entry.release, operatingSystem.VersionId) {
+					continue
+				}
+				key := component.Name + "\x00" + entry.advisory.Id
+				if _, ok := found[key]; ok {
+					continue
+				}
+				affected, fixedVersion := entry.isAffected(
+					operatingSystem.PackageType, versions[index])
+				if !affected {
+					continue
+				}
+				found[key] = struct{}{}
+				findings = append(findings, Finding{
+					Aliases:        entry.advisory.Aliases,
+					AdvisoryId:     entry.advisory.Id,
+					FixedVersion:   fixedVersion,
+					PackageName:    component.Name,
+					PackageVersion: component.Version,
+					Severity:       entry.severity,
+					Summary:        entry.advisory.Summary,
+				})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(left, right int) bool {
+		if findings[left].Severity != findings[right].Severity {
+			return findings[left].Severity > findings[right].Severity
+		}
+		if findings[left].PackageName != findings[right].PackageName {
+			return findings[left].PackageName < findings[right].PackageName
+		}
+		return findings[left].AdvisoryId < findings[right].AdvisoryId
+	})
+	return findings
+}
@@ -0,0 +1,145 @@
+package vulnerability
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	severityNames = map[string]Severity{
+		"critical":         SeverityCritical,
+		"high":             SeverityHigh,
+		"important":        SeverityHigh,
+		"low":              SeverityLow,
+		"medium":           SeverityMedium,
+		"moderate":         SeverityMedium,
+		"negligible":       SeverityLow,
+		"not yet assigned": SeverityUnknown,
+		"unimportant":      SeverityLow,
+		"unknown":          SeverityUnknown,
+	}
+
+	severityToText = map[Severity]string{
+		SeverityUnknown:  "unknown",
+		SeverityLow:      "low",
+		SeverityMedium:   "medium",
+		SeverityHigh:     "high",
+		SeverityCritical: "critical",
+	}
+
+	cvss3Weights = map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+)
+
+// computeCvss3Score will compute the CVSS v3 base score from a vector string,
+// such as "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H".
+func computeCvss3Score(vector string) (float64, error) {
+	fields := strings.Split(vector, "/")
+	if len(fields) < 1 || !strings.HasPrefix(fields[0], "CVSS:3") {
+		return 0, fmt.Errorf("not a CVSS v3 vector: %s", vector)
+	}
+	metrics := make(map[string]string, len(fields)-1)
+	for _, field := range fields[1:] {
+		if name, value, ok := strings.Cut(field, ":"); ok {
+			metrics[name] = value
+		}
+	}
+	weights := make(map[string]float64, len(cvss3Weights))
+	for name, table := range cvss3Weights {
+		weight, ok := table[metrics[name]]
+		if !ok {
+			return 0, fmt.Errorf("bad or missing metric: %s in: %s",
+				name, vector)
+		}
+		weights[name] = weight
+	}
+	scopeChanged := metrics["S"] == "C"
+	var privileges float64
+	switch metrics["PR"] {
+	case "N":
+		privileges = 0.85
+	case "L":
+		privileges = 0.62
+		if scopeChanged {
+			privileges = 0.68
+		}
+	case "H":
+		privileges = 0.27
+		if scopeChanged {
+			privileges = 0.5
+		}
+	default:
+		return 0, fmt.Errorf("bad or missing metric: PR in: %s", vector)
+	}
+	iss := 1 - (1-weights["C"])*(1-weights["I"])*(1-weights["A"])
+	var impact float64
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+	exploitability := 8.22 * weights["AV"] * weights["AC"] * privileges *
+		weights["UI"]
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp implements the Roundup function from the CVSS v3.1 specification.
+func roundUp(value float64) float64 {
+	intValue := int(math.Round(value * 100000))
+	if intValue%10000 == 0 {
+		return float64(intValue) / 100000
+	}
+	return float64(intValue/10000+1) / 10
+}
+
+func parseSeverity(name string) (Severity, error) {
+	if severity, ok := severityNames[strings.ToLower(name)]; ok {
+		return severity, nil
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity: %s", name)
+}
+
+// scoreToSeverity converts a CVSS score to a severity rating.
+func scoreToSeverity(score float64) Severity {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	}
+	return SeverityUnknown
+}
+
+func (severity *Severity) set(value string) error {
+	if newSeverity, err := parseSeverity(value); err != nil {
+		return err
+	} else {
+		*severity = newSeverity
+		return nil
+	}
+}
+
+func (severity Severity) string() string {
+	if text, ok := severityToText[severity]; ok {
+		return text
+	}
+	return strconv.Itoa(int(severity))
+}
@@ -0,0 +1,236 @@
+package vulnerability
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+)
+
+func compareInts(left, right int) int {
+	if left < right {
+		return -1
+	}
+	if left > right {
+		return 1
+	}
+	return 0
+}
+
+func compareVersions(packageType, left, right string) int {
+	if packageType == sbom.PackageTypeDeb {
+		return compareDebianVersions(left, right)
+	}
+	return compareRpmVersions(left, right)
+}
+
+// compareDebianVersions compares [epoch:]upstream[-revision] versions using
+// the dpkg algorithm.
+func compareDebianVersions(left, right string) int {
+	leftEpoch, leftUpstream, leftRevision := splitDebianVersion(left)
+	rightEpoch, rightUpstream, rightRevision := splitDebianVersion(right)
+	if result := compareInts(leftEpoch, rightEpoch); result != 0 {
+		return result
+	}
+	result := compareDebianStrings(leftUpstream, rightUpstream)
+	if result != 0 {
+		return result
+	}
+	return compareDebianStrings(leftRevision, rightRevision)
+}
+
+// compareDebianStrings implements verrevcmp from dpkg.
+func compareDebianStrings(left, right string) int {
+	order := func(str string, index int) int {
+		if index >= len(str) {
+			return 0
+		}
+		ch := str[index]
+		switch {
+		case ch >= '0' && ch <= '9':
+			return 0
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z':
+			return int(ch)
+		case ch == '~':
+			return -1
+		}
+		return int(ch) + 256
+	}
+	isDigit := func(str string, index int) bool {
+		return index < len(str) && str[index] >= '0' && str[index] <= '9'
+	}
+	leftIndex, rightIndex := 0, 0
+	for leftIndex < len(left) || rightIndex < len(right) {
+		for (leftIndex < len(left) && !isDigit(left, leftIndex)) ||
+			(rightIndex < len(right) && !isDigit(right, rightIndex)) {
+			leftOrder := order(left, leftIndex)
+			rightOrder := order(right, rightIndex)
+			if leftOrder != rightOrder {
+				return compareInts(leftOrder, rightOrder)
+			}
+			leftIndex++
+			rightIndex++
+		}
+		for leftIndex < len(left) && left[leftIndex] == '0' {
+			leftIndex++
+		}
+		for rightIndex < len(right) && right[rightIndex] == '0' {
+			rightIndex++
+		}
+		firstDiff := 0
+		for isDigit(left, leftIndex) && isDigit(right, rightIndex) {
+			if firstDiff == 0 {
+				firstDiff = compareInts(int(left[leftIndex]),
+					int(right[rightIndex]))
+			}
+			leftIndex++
+			rightIndex++
+		}
+		if isDigit(left, leftIndex) {
+			return 1
+		}
+		if isDigit(right, rightIndex) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// compareRpmVersions compares [epoch:]version[-release] versions using the
+// rpm algorithm. It is also used for other package types.
+func compareRpmVersions(left, right string) int {
+	leftEpoch, leftVersion := splitEpoch(left)
+	rightEpoch, rightVersion := splitEpoch(right)
+	if result := compareInts(leftEpoch, rightEpoch); result != 0 {
+		return result
+	}
+	leftVersion, leftRelease, leftHasRelease := cutLast(leftVersion, "-")
+	rightVersion, rightRelease, rightHasRelease := cutLast(rightVersion, "-")
+	if result := compareRpmStrings(leftVersion, rightVersion); result != 0 {
+		return result
+	}
+	if !leftHasRelease || !rightHasRelease {
+		return 0
+	}
+	return compareRpmStrings(leftRelease, rightRelease)
+}
+
+// compareRpmStrings implements rpmvercmp from rpm.
+func compareRpmStrings(left, right string) int {
+	if left == right {
+		return 0
+	}
+	isAlnum := func(ch byte) bool {
+		return ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' ||
+			ch >= 'A' && ch <= 'Z'
+	}
+	isDigit := func(ch byte) bool { return ch >= '0' && ch <= '9' }
+	for len(left) > 0 || len(right) > 0 {
+		for len(left) > 0 && !isAlnum(left[0]) && left[0] != '~' &&
+			left[0] != '^' {
+			left = left[1:]
+		}
+		for len(right) > 0 && !isAlnum(right[0]) && right[0] != '~' &&
+			right[0] != '^' {
+			right = right[1:]
+		}
+		// A tilde sorts before everything, even the end of a version.
+		leftTilde := len(left) > 0 && left[0] == '~'
+		rightTilde := len(right) > 0 && right[0] == '~'
+		if leftTilde || rightTilde {
+			if !leftTilde {
+				return 1
+			}
+			if !rightTilde {
+				return -1
+			}
+			left, right = left[1:], right[1:]
+			continue
+		}
+		// A caret sorts after the end of a version, but before everything
+		// else.
+		leftCaret := len(left) > 0 && left[0] == '^'
+		rightCaret := len(right) > 0 && right[0] == '^'
+		if leftCaret || rightCaret {
+			if len(left) < 1 {
+				return -1
+			}
+			if len(right) < 1 {
+				return 1
+			}
+			if !leftCaret {
+				return 1
+			}
+			if !rightCaret {
+				return -1
+			}
+			left, right = left[1:], right[1:]
+			continue
+		}
+		if len(left) < 1 || len(right) < 1 {
+			break
+		}
+		numeric := isDigit(left[0])
+		segmentLength := func(str string) int {
+			index := 0
+			for index < len(str) && isAlnum(str[index]) &&
+				isDigit(str[index]) == numeric {
+				index++
+			}
+			return index
+		}
+		leftLength := segmentLength(left)
+		rightLength := segmentLength(right)
+		if rightLength < 1 { // Segments of different types.
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+		leftSegment, rightSegment := left[:leftLength], right[:rightLength]
+		left, right = left[leftLength:], right[rightLength:]
+		if numeric {
+			leftSegment = strings.TrimLeft(leftSegment, "0")
+			rightSegment = strings.TrimLeft(rightSegment, "0")
+			if result := compareInts(len(leftSegment),
+				len(rightSegment)); result != 0 {
+				return result
+			}
+		}
+		if result := strings.Compare(leftSegment, rightSegment); result != 0 {
+			return result
+		}
+	}
+	if len(left) < 1 && len(right) < 1 {
+		return 0
+	}
+	if len(left) > 0 {
+		return 1
+	}
+	return -1
+}
+
+func cutLast(str, separator string) (string, string, bool) {
+	if index := strings.LastIndex(str, separator); index >= 0 {
+		return str[:index], str[index+len(separator):], true
+	}
+	return str, "", false
+}
+
+func splitDebianVersion(version string) (int, string, string) {
+	epoch, version := splitEpoch(version)
+	upstream, revision, _ := cutLast(version, "-")
+	return epoch, upstream, revision
+}
+
+func splitEpoch(version string) (int, string) {
+	if epochString, rest, ok := strings.Cut(version, ":"); ok {
+		if epoch, err := strconv.Atoi(epochString); err == nil {
+			return epoch, rest
+		}
+	}
+	return 0, version
+}
@@ -0,0 +1,176 @@
+package vulnerability
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/image/sbom"
+)
+
+const testAdvisories = `[
+{
+  "id": "DSA-0001-1",
+  "aliases": ["CVE-2024-0001"],
+  "modified": "2024-03-01T00:00:00Z",
+  "summary": "openssl - security update",
+  "affected": [{
+    "package": {"ecosystem": "Debian:12", "name": "openssl"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [
+      {"introduced": "0"}, {"fixed": "3.0.13-1~deb12u1"}]}]
+  }],
+  "severity": [{"type": "CVSS_V3",
+    "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]
+},
+{
+  "id": "DSA-0002-1",
+  "modified": "2024-02-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "Debian:11", "name": "openssl"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [
+      {"introduced": "0"}, {"fixed": "1.1.1w-0+deb11u2"}]}]
+  }]
+},
+{
+  "id": "DEBIAN-CVE-2024-0003",
+  "modified": "2024-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "Debian:12", "name": "glibc"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}]}],
+    "ecosystem_specific": {"urgency": "low"}
+  }]
+}
+]`
+
+const testDebianTracker = `{
+  "zlib": {
+    "CVE-2024-0004": {
+      "description": "zlib overflow",
+      "releases": {
+        "bookworm": {"status": "open", "urgency": "medium**"},
+        "bullseye": {"status": "resolved", "fixed_version": "0"}
+      }
+    },
+    "CVE-2024-0005": {
+      "releases": {
+        "bookworm": {"status": "resolved",
+          "fixed_version": "1:1.2.13.dfsg-1", "urgency": "high"}
+      }
+    }
+  }
+}`
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		packageType string
+		left        string
+		right       string
+		expected    int
+	}{
+		{sbom.PackageTypeDeb, "1.0", "1.0", 0},
+		{sbom.PackageTypeDeb, "1.0~rc1", "1.0", -1},
+		{sbom.PackageTypeDeb, "1:0.9", "2.0", 1},
+		{sbom.PackageTypeDeb, "3.0.11-1~deb12u2", "3.0.13-1~deb12u1", -1},
+		{sbom.PackageTypeDeb, "2.36-9+deb12u4", "2.36-9", 1},
+		{sbom.PackageTypeDeb, "1.10", "1.9", 1},
+		{sbom.PackageTypeRpm, "3.0.7-25.el9", "1:3.0.7-27.el9", -1},
+		{sbom.PackageTypeRpm, "1.0-1.el9", "1.0-1.el9_3", -1},
+		{sbom.PackageTypeRpm, "1.0~beta", "1.0", -1},
+		{sbom.PackageTypeRpm, "1.0^post", "1.0", 1},
+		{sbom.PackageTypeRpm, "1.0a", "1.0.1", -1},
+	}
+	for _, test := range tests {
+		result := CompareVersions(test.packageType, test.left, test.right)
+		if result != test.expected {
+			t.Errorf("%s: compare(%s, %s): expected: %d, got: %d",
+				test.packageType, test.left, test.right, test.expected,
+				result)
+		}
+	}
+}
+
+func TestCvss3Score(t *testing.T) {
+	tests := map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N": 6.1,
+		"CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:N/I:N/A:N": 0,
+	}
+	for vector, expected := range tests {
+		if score, err := computeCvss3Score(vector); err != nil {
+			t.Error(err)
+		} else if score != expected {
+			t.Errorf("%s: expected: %.1f, got: %.1f", vector, expected, score)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	dirname := t.TempDir()
+	err := os.WriteFile(filepath.Join(dirname, "advisories.json"),
+		[]byte(testAdvisories), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dirname, "bad.json"), []byte("{"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dirname, "debian.json"),
+		[]byte(testDebianTracker), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := Load(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.NumAdvisories() != 5 || db.NumBadFiles() != 1 {
+		t.Fatalf("expected 5 advisories and 1 bad file, got: %d and %d",
+			db.NumAdvisories(), db.NumBadFiles())
+	}
+	findings := db.Match(&sbom.Inventory{
+		Components: []sbom.Component{
+			{
+				Name:       "libc6",
+				SourceName: "glibc",
+				Version:    "2.36-9+deb12u4",
+			},
+			{
+				Name:          "libssl3",
+				SourceName:    "openssl",
+				SourceVersion: "3.0.11-1~deb12u2",
+				Version:       "3.0.11-1~deb12u2",
+			},
+			{
+				Name:       "zlib1g",
+				SourceName: "zlib",
+				Version:    "1:1.2.13.dfsg-1",
+			},
+		},
+		OperatingSystem: sbom.OperatingSystem{
+			Id:          "debian",
+			PackageType: sbom.PackageTypeDeb,
+			VersionId:   "12",
+		},
+	})
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got: %v", findings)
+	}
+	if finding := findings[0]; finding.AdvisoryId != "DSA-0001-1" ||
+		finding.PackageName != "libssl3" ||
+		finding.FixedVersion != "3.0.13-1~deb12u1" ||
+		finding.Severity != SeverityCritical {
+		t.Fatalf("bad first finding: %v", finding)
+	}
+	if finding := findings[1]; finding.AdvisoryId != "CVE-2024-0004" ||
+		finding.PackageName != "zlib1g" || finding.Severity != SeverityMedium {
+		t.Fatalf("bad second finding: %v", finding)
+	}
+	if finding := findings[2]; finding.AdvisoryId != "DEBIAN-CVE-2024-0003" ||
+		finding.FixedVersion != "" || finding.Severity != SeverityLow {
+		t.Fatalf("bad third finding: %v", finding)
+	}
+	if severity := MaxSeverity(findings); severity != SeverityCritical {
+		t.Fatalf("expected maximum severity: critical, got: %s", severity)
+	}
+}
@@ -48,6 +48,14 @@ func CopyIP(ip net.IP) net.IP {
 func DecrementIP(ip net.IP) {
 	decrementIP(ip)
 }
+
+// Eui64ToHardwareAddr returns the MAC address encoded in the interface
+// identifier of an IPv6 address generated using the modified EUI-64 format.
+// If the address was not generated that way, nil is returned.
+func Eui64ToHardwareAddr(ip net.IP) net.HardwareAddr {
+	return eui64ToHardwareAddr(ip)
+}
+
 func GetDefaultRoute() (*DefaultRouteInfo, error) {
 	return getDefaultRoute()
 }
@@ -72,6 +80,13 @@ func InvertIP(input net.IP) {
 	invertIP(input)
 }
 
+// MakeEui64Address returns an IPv6 address in the /64 prefix with an interface
+// identifier generated from hwAddr using the modified EUI-64 format. This is
+// the address that stateless address autoconfiguration (SLAAC) will generate.
+func MakeEui64Address(prefix net.IP, hwAddr net.HardwareAddr) (net.IP, error) {
+	return makeEui64Address(prefix, hwAddr)
+}
+
 func ShrinkIP(netIP net.IP) net.IP {
 	return shrinkIP(netIP)
 }
@@ -41,6 +41,16 @@ func decrementIP(ip net.IP) {
 	}
 }
 
+func eui64ToHardwareAddr(ip net.IP) net.HardwareAddr {
+	if len(ip) != net.IPv6len || ip.To4() != nil {
+		return nil
+	}
+	if ip[11] != 0xff || ip[12] != 0xfe {
+		return nil
+	}
+	return net.HardwareAddr{ip[8] ^ 0x02, ip[9], ip[10], ip[13], ip[14], ip[15]}
+}
+
 func incrementIP(ip net.IP) {
 	for index := len(ip) - 1; index >= 0; index-- {
 		if ip[index] < 255 {
@@ -69,6 +79,25 @@ func invertIP(ip net.IP) {
 	}
 }
 
+func makeEui64Address(prefix net.IP, hwAddr net.HardwareAddr) (net.IP, error) {
+	if len(prefix) != net.IPv6len || prefix.To4() != nil {
+		return nil, errors.New("prefix is not IPv6")
+	}
+	if len(hwAddr) != 6 {
+		return nil, errors.New("hardware address is not 48 bits")
+	}
+	ip := copyIP(prefix)
+	ip[8] = hwAddr[0] ^ 0x02 // Flip the universal/local bit.
+	ip[9] = hwAddr[1]
+	ip[10] = hwAddr[2]
+	ip[11] = 0xff
+	ip[12] = 0xfe
+	ip[13] = hwAddr[3]
+	ip[14] = hwAddr[4]
+	ip[15] = hwAddr[5]
+	return ip, nil
+}
+
 func ipToValue(ip net.IP) (uint32, error) {
 	ip = ip.To4()
 	if ip == nil {
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEui64(t *testing.T) {
+	hwAddr, err := net.ParseMAC("52:54:0a:01:02:03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip, err := MakeEui64Address(net.ParseIP("2001:db8:1:2::"), hwAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := net.ParseIP("2001:db8:1:2:5054:aff:fe01:203"); !ip.Equal(
+		expected) {
+		t.Fatalf("expected: %s, got: %s", expected, ip)
+	}
+	if got := Eui64ToHardwareAddr(ip); got.String() != hwAddr.String() {
+		t.Fatalf("expected: %s, got: %s", hwAddr, got)
+	}
+	if got := Eui64ToHardwareAddr(net.ParseIP("2001:db8::1")); got != nil {
+		t.Fatalf("expected nil, got: %s", got)
+	}
+	if _, err := MakeEui64Address(net.ParseIP("10.0.0.0"), hwAddr); err == nil {
+		t.Fatal("no error for IPv4 prefix")
+	}
+}
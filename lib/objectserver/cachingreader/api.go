@@ -35,6 +35,7 @@ type ObjectServer struct {
 	newest              *objectType // For unused objects only.
 	objects             map[hash.Hash]*objectType
 	oldest              *objectType // For unused objects only.
+	upstream            objectserver.ObjectsGetter
 }
 
 type Stats struct {
@@ -49,6 +50,21 @@ func NewObjectServer(baseDir string, maxCachedBytes uint64,
 	return newObjectServer(baseDir, maxCachedBytes, objectServerAddress, logger)
 }
 
+// NewObjectServerWithUpstream is similar to NewObjectServer, except that
+// objects are read from upstream rather than from an object server at a
+// network address. The upstream ObjectsReader must implement the
+// objectserver.FullObjectsReader interface.
+func NewObjectServerWithUpstream(baseDir string, maxCachedBytes uint64,
+	upstream objectserver.ObjectsGetter, logger log.DebugLogger) (
+	*ObjectServer, error) {
+	objSrv, err := newObjectServer(baseDir, maxCachedBytes, "", logger)
+	if err != nil {
+		return nil, err
+	}
+	objSrv.upstream = upstream
+	return objSrv, nil
+}
+
 func (objSrv *ObjectServer) FetchObjects(hashes []hash.Hash) error {
 	return objSrv.fetchObjects(hashes)
 }
@@ -109,8 +109,12 @@ func (objSrv *ObjectServer) getObjects(hashes []hash.Hash) (
 	if len(hashesToFetch) < 1 {
 		return &or, nil
 	}
-	or.objectClient = client.NewObjectClient(objSrv.objectServerAddress)
-	if realOR, err := or.objectClient.GetObjects(hashesToFetch); err != nil {
+	upstream := objSrv.upstream
+	if upstream == nil {
+		or.objectClient = client.NewObjectClient(objSrv.objectServerAddress)
+		upstream = or.objectClient
+	}
+	if realOR, err := upstream.GetObjects(hashesToFetch); err != nil {
 		or.Close()
 		return nil, err
 	} else {
@@ -164,20 +168,16 @@ func (or *objectsReader) Close() error {
 		}
 	}
 	or.objSrv.rwLock.Unlock()
-	if or.objectClient == nil {
-		return nil
-	}
 	var err error
-	if e := or.objectsReader.Close(); err == nil && e != nil {
-		err = e
+	if or.objectsReader != nil {
+		err = or.objectsReader.Close()
 	}
-	if e := or.objectClient.Close(); err == nil && e != nil {
-		err = e
-	}
-	if err != nil {
-		return err
+	if or.objectClient != nil {
+		if e := or.objectClient.Close(); err == nil && e != nil {
+			err = e
+		}
 	}
-	return nil
+	return err
 }
 
 func (or *objectsReader) NextObject() (uint64, io.ReadCloser, error) {
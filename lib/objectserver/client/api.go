@@ -1,8 +1,10 @@
 package client
 
 import (
+	"errors"
 	"io"
 
+	"github.com/Cloud-Foundations/Dominator/lib/chunks"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
@@ -10,6 +12,7 @@ import (
 
 type ObjectClient struct {
 	address      string
+	chunkIndex   *chunks.Index
 	client       srpc.ClientI
 	exclusiveGet bool
 }
@@ -46,15 +49,32 @@ func (objClient *ObjectClient) GetObjects(hashes []hash.Hash) (
 	return objClient.getObjects(hashes)
 }
 
+// SetChunkIndex will set the index of chunks which are available locally. If
+// the server supports it, GetObjects will only transfer chunks of objects
+// which are not in the index. The index must remain open until all objects
+// have been read.
+func (objClient *ObjectClient) SetChunkIndex(index *chunks.Index) {
+	objClient.chunkIndex = index
+}
+
 func (objClient *ObjectClient) SetExclusiveGetObjects(exclusive bool) {
 	objClient.exclusiveGet = exclusive
 }
 
+// ErrLocalChunk is wrapped by the error returned when reading an object which
+// refers to a local chunk which can no longer be read, for example because the
+// file containing it has changed. The reader may be closed to skip the rest of
+// the object and the object should be fetched again without a chunk index.
+var ErrLocalChunk = errors.New("local chunk unavailable")
+
 type ObjectsReader struct {
-	sizes     []uint64
-	client    *ObjectClient
-	reader    *srpc.Conn
-	nextIndex int64
+	sizes         []uint64
+	chunkIndex    *chunks.Index // If not nil: objects are sent in chunks.
+	client        *ObjectClient
+	currentReader *chunkedReader
+	reader        *srpc.Conn
+	nextIndex     int64
+	reusedBytes   uint64
 }
 
 func (or *ObjectsReader) Close() error {
@@ -69,6 +89,12 @@ func (or *ObjectsReader) ObjectSizes() []uint64 {
 	return or.sizes
 }
 
+// ReusedBytes returns the number of bytes which were read from local chunks
+// rather than transferred.
+func (or *ObjectsReader) ReusedBytes() uint64 {
+	return or.reusedBytes
+}
+
 type ObjectAdderQueue struct {
 	conn            *srpc.Conn
 	getResponseChan chan<- struct{}
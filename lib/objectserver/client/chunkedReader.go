@@ -0,0 +1,96 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Cloud-Foundations/Dominator/proto/objectserver"
+)
+
+// chunkedReader reads an object which is sent as a stream of chunks.
+type chunkedReader struct {
+	data          []byte // Data from a local chunk.
+	discard       bool   // If true, do not read local chunks.
+	eof           bool
+	objectsReader *ObjectsReader
+	remaining     uint64 // Bytes remaining in the current chunk sent inline.
+}
+
+// Close will consume the remainder of the object, so that the next object may
+// be read.
+func (r *chunkedReader) Close() error {
+	r.discard = true
+	for {
+		if r.remaining > 0 {
+			nCopied, err := io.CopyN(io.Discard, r.objectsReader.reader,
+				int64(r.remaining))
+			r.remaining -= uint64(nCopied)
+			if err != nil {
+				return err
+			}
+		}
+		r.data = nil
+		if r.eof {
+			return nil
+		}
+		if err := r.nextChunk(); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for len(r.data) < 1 && r.remaining < 1 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if len(r.data) > 0 {
+		nCopied := copy(p, r.data)
+		r.data = r.data[nCopied:]
+		return nCopied, nil
+	}
+	if uint64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	nRead, err := r.objectsReader.reader.Read(p)
+	r.remaining -= uint64(nRead)
+	if err == io.EOF && r.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return nRead, err
+}
+
+// nextChunk will read the next chunk header and the chunk data if the chunk is
+// available locally.
+func (r *chunkedReader) nextChunk() error {
+	var chunk objectserver.ObjectChunk
+	if err := r.objectsReader.reader.Decode(&chunk); err != nil {
+		return err
+	}
+	if chunk.Error != "" {
+		return errors.New(chunk.Error)
+	}
+	if chunk.Length < 1 {
+		r.eof = true
+		return nil
+	}
+	if !chunk.Reference {
+		r.remaining = chunk.Length
+		return nil
+	}
+	if r.discard {
+		return nil
+	}
+	data, err := r.objectsReader.chunkIndex.ReadChunk(chunk.Hash, chunk.Length)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrLocalChunk, err)
+	}
+	r.data = data
+	r.objectsReader.reusedBytes += chunk.Length
+	return nil
+}
@@ -24,6 +24,10 @@ func (objClient *ObjectClient) getObjects(hashes []hash.Hash) (
 	var reply objectserver.GetObjectsResponse
 	request.Exclusive = objClient.exclusiveGet
 	request.Hashes = hashes
+	if objClient.chunkIndex != nil {
+		request.AvailableChunks = objClient.chunkIndex.Hashes()
+		request.Chunked = true
+	}
 	conn.Encode(request)
 	conn.Flush()
 	var objectsReader ObjectsReader
@@ -36,6 +40,9 @@ func (objClient *ObjectClient) getObjects(hashes []hash.Hash) (
 	if reply.ResponseString != "" {
 		return nil, errors.New(reply.ResponseString)
 	}
+	if reply.Chunked {
+		objectsReader.chunkIndex = objClient.chunkIndex
+	}
 	objectsReader.nextIndex = -1
 	objectsReader.sizes = reply.ObjectSizes
 	return &objectsReader, nil
@@ -46,11 +53,21 @@ func (or *ObjectsReader) close() error {
 }
 
 func (or *ObjectsReader) nextObject() (uint64, io.ReadCloser, error) {
+	if or.currentReader != nil {
+		if err := or.currentReader.Close(); err != nil {
+			return 0, nil, err
+		}
+		or.currentReader = nil
+	}
 	or.nextIndex++
 	if or.nextIndex >= int64(len(or.sizes)) {
 		return 0, nil, errors.New("all objects have been consumed")
 	}
 	size := or.sizes[or.nextIndex]
+	if or.chunkIndex != nil {
+		or.currentReader = &chunkedReader{objectsReader: or}
+		return size, or.currentReader, nil
+	}
 	return size,
 		ioutil.NopCloser(&io.LimitedReader{R: or.reader, N: int64(size)}), nil
 }
@@ -27,6 +27,7 @@ func (objSrv *ObjectServer) add(object *objectType) {
 	objSrv.addUnreferenced(object)
 	objSrv.lastMutationTime = time.Now()
 	objSrv.totalBytes += object.size
+	objSrv.totalPhysicalBytes += object.physicalSize
 }
 
 func (objSrv *ObjectServer) addObject(reader io.Reader, length uint64,
@@ -42,6 +43,10 @@ func (objSrv *ObjectServer) addObject(reader io.Reader, length uint64,
 		return hashVal, false, err
 	} else {
 		object := &objectType{hash: hashVal, size: uint64(len(data))}
+		object.compression, object.physicalSize, err = statObjectFile(filename)
+		if err != nil {
+			return hashVal, false, err
+		}
 		objSrv.rwLock.Lock()
 		if _, ok := objSrv.objects[object.hash]; !ok {
 			objSrv.add(object)
@@ -87,12 +92,13 @@ func (objSrv *ObjectServer) addOrCompare(hashVal hash.Hash, data []byte,
 
 func (objSrv *ObjectServer) addOrCompareOnce(hashVal hash.Hash, data []byte,
 	filename string, gc *objectserver.GarbageCollector) (bool, error) {
-	fi, err := os.Lstat(filename)
+	existingFilename, compression, fi, err := findObjectFile(filename)
 	if err == nil {
 		if !fi.Mode().IsRegular() {
-			return false, errors.New("existing non-file: " + filename)
+			return false, errors.New("existing non-file: " + existingFilename)
 		}
-		if err := collisionCheck(data, filename, fi.Size()); err != nil {
+		err := collisionCheck(data, existingFilename, compression)
+		if err != nil {
 			return false, errors.New("collision detected: " + err.Error())
 		}
 		// No collision and no error: it's the same object. Go home early.
@@ -106,6 +112,17 @@ func (objSrv *ObjectServer) addOrCompareOnce(hashVal hash.Hash, data []byte,
 	if err != nil {
 		return false, err
 	}
+	if objSrv.Compression != CompressionNone {
+		compressedData, err := compressData(data, objSrv.Compression)
+		if err != nil {
+			return false, err
+		}
+		// Only store compressed if it saves space.
+		if len(compressedData) < len(data) {
+			data = compressedData
+			filename += compressionToSuffix[objSrv.Compression]
+		}
+	}
 	err = fsutil.CopyToFileExclusive(filename, fsutil.PrivateFilePerms,
 		bytes.NewReader(data), uint64(len(data)))
 	if err != nil {
@@ -114,13 +131,14 @@ func (objSrv *ObjectServer) addOrCompareOnce(hashVal hash.Hash, data []byte,
 	return true, nil
 }
 
-func collisionCheck(data []byte, filename string, size int64) error {
-	file, err := os.Open(filename)
+func collisionCheck(data []byte, filename string,
+	compression Compression) error {
+	size, file, err := openObjectFile(filename, compression)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	if int64(len(data)) != size {
+	if uint64(len(data)) != size {
 		return fmt.Errorf("length mismatch. Data=%d, existing object=%d",
 			len(data), size)
 	}
@@ -132,7 +150,7 @@ func collisionCheck(data []byte, filename string, size int64) error {
 			numToRead = cap(buffer)
 		}
 		buf := buffer[:numToRead]
-		nread, err := reader.Read(buf)
+		nread, err := io.ReadFull(reader, buf)
 		if err != nil {
 			return err
 		}
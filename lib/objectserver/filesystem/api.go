@@ -31,16 +31,41 @@ func init() {
 	flag.Var(&objectServerCleanupStopSize, "objectServerCleanupStopSize", "")
 }
 
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionZstd
+)
+
 type objectType struct {
+	compression       Compression
 	hash              hash.Hash
 	newerUnreferenced *objectType
 	olderUnreferenced *objectType
+	physicalSize      uint64 // Size of the file.
 	refcount          uint64
-	size              uint64
+	size              uint64 // Size of the uncompressed object.
+}
+
+// Compression specifies how new objects are stored. Existing objects are read
+// regardless of the compression used to store them. Objects which do not
+// compress are stored uncompressed. Hashes and sizes reported for objects are
+// always for the uncompressed data.
+type Compression uint
+
+func (compression Compression) String() string {
+	return compression.string()
+}
+
+// Set implements the flag.Value interface. Valid values are: "none", "gzip"
+// and "zstd".
+func (compression *Compression) Set(value string) error {
+	return compression.set(value)
 }
 
 type Config struct {
 	BaseDirectory     string
+	Compression       Compression
 	LockCheckInterval time.Duration
 	LockLogTimeout    time.Duration
 }
@@ -51,25 +76,38 @@ type ObjectServer struct {
 	gc          objectserver.GarbageCollector
 	lockWatcher *lockwatcher.LockWatcher
 	Params
-	rwLock                sync.RWMutex // Protect the following fields.
-	duplicatedBytes       uint64       // Sum of refcount*size for all objects.
-	lastGarbageCollection time.Time
-	lastMutationTime      time.Time
-	objects               map[hash.Hash]*objectType // Only set if object known.
-	newestUnreferenced    *objectType
-	numDuplicated         uint64 // Sum of refcount for all objects.
-	numReferenced         uint64
-	numUnreferenced       uint64
-	oldestUnreferenced    *objectType
-	referencedBytes       uint64
-	totalBytes            uint64
-	unreferencedBytes     uint64
+	rwLock                    sync.RWMutex // Protect the following fields.
+	duplicatedBytes           uint64       // Sum of refcount*size for all objects.
+	lastGarbageCollection     time.Time
+	lastMutationTime          time.Time
+	objects                   map[hash.Hash]*objectType // Only set if object known.
+	newestUnreferenced        *objectType
+	numDuplicated             uint64 // Sum of refcount for all objects.
+	numReferenced             uint64
+	numUnreferenced           uint64
+	oldestUnreferenced        *objectType
+	referencedBytes           uint64
+	totalBytes                uint64 // Sum of uncompressed sizes.
+	totalPhysicalBytes        uint64 // Sum of file sizes.
+	unreferencedBytes         uint64
+	unreferencedPhysicalBytes uint64
 }
 
 type Params struct {
 	Logger log.DebugLogger
 }
 
+// Stats contains storage statistics. Logical sizes are the sizes of the
+// uncompressed objects and physical sizes are the sizes of the files.
+type Stats struct {
+	NumObjects                uint64
+	NumUnreferenced           uint64
+	PhysicalBytes             uint64
+	TotalBytes                uint64
+	UnreferencedBytes         uint64
+	UnreferencedPhysicalBytes uint64
+}
+
 func NewObjectServer(baseDir string, logger log.Logger) (
 	*ObjectServer, error) {
 	return newObjectServer(
@@ -123,8 +161,9 @@ func (objSrv *ObjectServer) DeleteStashedObject(hashVal hash.Hash) error {
 
 // DeleteUnreferenced will delete some or all unreferenced objects.
 // The oldest unreferenced objects are deleted first, until both the percentage
-// and bytes thresholds are satisfied. The number of bytes and objects deleted
-// are returned.
+// and bytes thresholds are satisfied. The bytes threshold applies to the
+// physical (on-disk) sizes of objects. The number of physical bytes and objects
+// deleted are returned.
 func (objSrv *ObjectServer) DeleteUnreferenced(percentage uint8,
 	bytes uint64) (uint64, uint64, error) {
 	return objSrv.deleteUnreferenced(percentage, bytes)
@@ -154,12 +193,25 @@ func (objSrv *ObjectServer) GetObjects(hashes []hash.Hash) (
 	return objSrv.getObjects(hashes)
 }
 
+// GetStats returns storage statistics.
+func (objSrv *ObjectServer) GetStats() Stats {
+	return objSrv.getStats()
+}
+
 func (objSrv *ObjectServer) LastMutationTime() time.Time {
 	objSrv.rwLock.RLock()
 	defer objSrv.rwLock.RUnlock()
 	return objSrv.lastMutationTime
 }
 
+// LinkObject will create a hard link to the object file if the object is not
+// compressed, otherwise it will write the uncompressed object data to a new
+// file. It returns true if a hard link was created.
+func (objSrv *ObjectServer) LinkObject(filename string,
+	hashVal hash.Hash) (bool, error) {
+	return objSrv.linkObject(filename, hashVal)
+}
+
 func (objSrv *ObjectServer) ListObjectSizes() map[hash.Hash]uint64 {
 	return objSrv.listObjectSizes()
 }
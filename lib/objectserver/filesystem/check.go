@@ -2,11 +2,8 @@ package filesystem
 
 import (
 	"fmt"
-	"os"
-	"path"
 
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
-	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
 )
 
 func (objSrv *ObjectServer) checkObjects(hashes []hash.Hash) ([]uint64, error) {
@@ -28,9 +25,8 @@ func (objSrv *ObjectServer) checkObject(hashVal hash.Hash) (uint64, error) {
 	if ok {
 		return object.size, nil
 	}
-	filename := path.Join(objSrv.BaseDirectory,
-		objectcache.HashToFilename(hashVal))
-	fi, err := os.Lstat(filename)
+	filename, compression, fi, err := findObjectFile(
+		objSrv.objectFilename(hashVal, CompressionNone))
 	if err != nil {
 		return 0, nil
 	}
@@ -38,7 +34,7 @@ func (objSrv *ObjectServer) checkObject(hashVal hash.Hash) (uint64, error) {
 		if fi.Size() < 1 {
 			return 0, fmt.Errorf("zero length file: %s", filename)
 		}
-		return uint64(fi.Size()), nil
+		return readLogicalSize(filename, compression, uint64(fi.Size()))
 	}
 	return 0, nil
 }
@@ -0,0 +1,230 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressed objects are stored in files with a suffix indicating the
+// compression algorithm. The file starts with a header containing the logical
+// (uncompressed) size of the object, followed by the compressed data.
+const compressedHeaderLength = 8
+
+var (
+	compressionToText = map[Compression]string{
+		CompressionNone: "none",
+		CompressionGzip: "gzip",
+		CompressionZstd: "zstd",
+	}
+	compressionToSuffix = map[Compression]string{
+		CompressionGzip: ".gz",
+		CompressionZstd: ".zst",
+	}
+	textToCompression   map[string]Compression
+	suffixes            []string
+	suffixToCompression map[string]Compression
+)
+
+type decompressingReader struct {
+	closer io.Closer
+	io.Reader
+	decoder *zstd.Decoder
+}
+
+func init() {
+	textToCompression = make(map[string]Compression, len(compressionToText))
+	for compression, text := range compressionToText {
+		textToCompression[text] = compression
+	}
+	suffixToCompression = make(map[string]Compression,
+		len(compressionToSuffix))
+	for compression, suffix := range compressionToSuffix {
+		suffixes = append(suffixes, suffix)
+		suffixToCompression[suffix] = compression
+	}
+}
+
+func (compression Compression) string() string {
+	if text, ok := compressionToText[compression]; ok {
+		return text
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", compression)
+}
+
+func (compression *Compression) set(value string) error {
+	if val, ok := textToCompression[value]; !ok {
+		return errors.New("unknown compression: " + value)
+	} else {
+		*compression = val
+		return nil
+	}
+}
+
+// compressData returns the compressed object data, including the header.
+func compressData(data []byte, compression Compression) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	var header [compressedHeaderLength]byte
+	binary.BigEndian.PutUint64(header[:], uint64(len(data)))
+	buffer.Write(header[:])
+	switch compression {
+	case CompressionGzip:
+		writer := gzip.NewWriter(buffer)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	case CompressionZstd:
+		writer, err := zstd.NewWriter(buffer)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(data); err != nil {
+			writer.Close()
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("unsupported compression: " +
+			compression.String())
+	}
+	return buffer.Bytes(), nil
+}
+
+// findObjectFile will look for the file for an object, which may have been
+// written with any compression. It returns the filename, compression and file
+// information.
+func findObjectFile(filename string) (string, Compression, os.FileInfo,
+	error) {
+	fi, err := os.Lstat(filename)
+	if err == nil || !os.IsNotExist(err) {
+		return filename, CompressionNone, fi, err
+	}
+	for _, suffix := range suffixes {
+		if fi, err := os.Lstat(filename + suffix); err == nil {
+			return filename + suffix, suffixToCompression[suffix], fi, nil
+		} else if !os.IsNotExist(err) {
+			return "", CompressionNone, nil, err
+		}
+	}
+	return "", CompressionNone, nil, err
+}
+
+// statObjectFile returns the compression and physical size for an object.
+func statObjectFile(filename string) (Compression, uint64, error) {
+	_, compression, fi, err := findObjectFile(filename)
+	if err != nil {
+		return CompressionNone, 0, err
+	}
+	return compression, uint64(fi.Size()), nil
+}
+
+// openObjectFile will open the file for an object and return the logical size
+// and a reader which yields the uncompressed data.
+func openObjectFile(filename string, compression Compression) (
+	uint64, io.ReadCloser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, nil, err
+	}
+	if compression == CompressionNone {
+		fi, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return 0, nil, err
+		}
+		return uint64(fi.Size()), file, nil
+	}
+	reader := bufio.NewReader(file)
+	var header [compressedHeaderLength]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		file.Close()
+		return 0, nil, fmt.Errorf("error reading header: %s: %s",
+			filename, err)
+	}
+	size := binary.BigEndian.Uint64(header[:])
+	switch compression {
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			file.Close()
+			return 0, nil, err
+		}
+		return size, &decompressingReader{closer: file, Reader: gzipReader},
+			nil
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			file.Close()
+			return 0, nil, err
+		}
+		return size, &decompressingReader{
+			closer:  file,
+			Reader:  decoder,
+			decoder: decoder,
+		}, nil
+	}
+	file.Close()
+	return 0, nil, errors.New("unsupported compression: " +
+		compression.String())
+}
+
+// readLogicalSize returns the logical (uncompressed) size of an object file.
+func readLogicalSize(filename string, compression Compression,
+	physicalSize uint64) (uint64, error) {
+	if compression == CompressionNone {
+		return physicalSize, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	var header [compressedHeaderLength]byte
+	if _, err := io.ReadFull(file, header[:]); err != nil {
+		return 0, fmt.Errorf("error reading header: %s: %s", filename, err)
+	}
+	return binary.BigEndian.Uint64(header[:]), nil
+}
+
+// findObject returns the filename and compression for an object.
+func (objSrv *ObjectServer) findObject(hashVal hash.Hash) (
+	string, Compression, error) {
+	objSrv.rwLock.RLock()
+	object, ok := objSrv.objects[hashVal]
+	objSrv.rwLock.RUnlock()
+	if ok {
+		return objSrv.objectFilename(hashVal, object.compression),
+			object.compression, nil
+	}
+	filename, compression, _, err := findObjectFile(
+		objSrv.objectFilename(hashVal, CompressionNone))
+	return filename, compression, err
+}
+
+func (objSrv *ObjectServer) objectFilename(hashVal hash.Hash,
+	compression Compression) string {
+	return path.Join(objSrv.BaseDirectory,
+		objectcache.HashToFilename(hashVal)) + compressionToSuffix[compression]
+}
+
+func (r *decompressingReader) Close() error {
+	if r.decoder != nil {
+		r.decoder.Close()
+	}
+	return r.closer.Close()
+}
@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+)
+
+func testCompression(t *testing.T, compression Compression) {
+	config := Config{BaseDirectory: t.TempDir(), Compression: compression}
+	params := Params{Logger: testlogger.New(t)}
+	objSrv, err := NewObjectServerWithConfigAndParams(config, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("compressible text "), 10000)
+	hashVal, isNew, err := objSrv.AddObject(bytes.NewReader(data),
+		uint64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isNew {
+		t.Fatal("object not new")
+	}
+	if _, isNew, err := objSrv.AddObject(bytes.NewReader(data),
+		uint64(len(data)), nil); err != nil {
+		t.Fatal(err)
+	} else if isNew {
+		t.Fatal("duplicate object added")
+	}
+	stats := objSrv.GetStats()
+	if stats.TotalBytes != uint64(len(data)) {
+		t.Fatalf("TotalBytes: %d != %d", stats.TotalBytes, len(data))
+	}
+	if stats.PhysicalBytes >= stats.TotalBytes {
+		t.Fatalf("PhysicalBytes: %d not compressed", stats.PhysicalBytes)
+	}
+	// Rescan the directory to check that the metadata are recovered.
+	objSrv, err = NewObjectServerWithConfigAndParams(config, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newStats := objSrv.GetStats(); newStats != stats {
+		t.Fatalf("stats after scan: %v != %v", newStats, stats)
+	}
+	size, reader, err := objSrv.GetObject(hashVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readData, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != uint64(len(data)) || !bytes.Equal(readData, data) {
+		t.Fatal("object data mismatch")
+	}
+	filename := filepath.Join(t.TempDir(), "linked")
+	if linked, err := objSrv.LinkObject(filename, hashVal); err != nil {
+		t.Fatal(err)
+	} else if linked {
+		t.Fatal("compressed object was linked")
+	}
+	if linkedData, err := os.ReadFile(filename); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(linkedData, data) {
+		t.Fatal("linked data mismatch")
+	}
+	if _, numDeleted, err := objSrv.DeleteUnreferenced(100, 0); err != nil {
+		t.Fatal(err)
+	} else if numDeleted != 1 {
+		t.Fatalf("deleted: %d objects", numDeleted)
+	}
+	if stats := objSrv.GetStats(); stats.PhysicalBytes != 0 {
+		t.Fatalf("PhysicalBytes: %d after deletion", stats.PhysicalBytes)
+	}
+}
+
+func TestGzipCompression(t *testing.T) {
+	testCompression(t, CompressionGzip)
+}
+
+func TestZstdCompression(t *testing.T) {
+	testCompression(t, CompressionZstd)
+}
@@ -3,17 +3,16 @@ package filesystem
 import (
 	"fmt"
 	"os"
-	"path"
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
-	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
 )
 
 // deleteObject will delete the specified object. If haveLock is false, the
 // lock is grabbed. In either case, the lock will be released.
 func (objSrv *ObjectServer) deleteObject(hashVal hash.Hash,
 	haveLock bool) error {
+	var compression Compression
 	var refcount uint64
 	if !haveLock {
 		objSrv.rwLock.Lock()
@@ -21,6 +20,7 @@ func (objSrv *ObjectServer) deleteObject(hashVal hash.Hash,
 	if object := objSrv.objects[hashVal]; object == nil {
 		return fmt.Errorf("deleteObject(%x): object unknown", hashVal)
 	} else {
+		compression = object.compression
 		refcount = object.refcount
 		delete(objSrv.objects, hashVal)
 		objSrv.duplicatedBytes -= object.size * object.refcount
@@ -32,12 +32,11 @@ func (objSrv *ObjectServer) deleteObject(hashVal hash.Hash,
 		}
 		objSrv.removeUnreferenced(object)
 		objSrv.totalBytes -= object.size
+		objSrv.totalPhysicalBytes -= object.physicalSize
 	}
 	objSrv.rwLock.Unlock()
 	if refcount > 0 {
 		objSrv.Logger.Printf("deleteObject(%x): refcount: %d\n", refcount)
 	}
-	filename := path.Join(objSrv.BaseDirectory,
-		objectcache.HashToFilename(hashVal))
-	return os.Remove(filename)
+	return os.Remove(objSrv.objectFilename(hashVal, compression))
 }
@@ -27,8 +27,9 @@ func (objSrv *ObjectServer) garbageCollector(lastLog *string) (uint64, error) {
 	objSrv.lastGarbageCollection = time.Now()
 	var bytesToDelete uint64
 	if objectServerCleanupStopSize < objectServerCleanupStartSize &&
-		objSrv.unreferencedBytes > uint64(objectServerCleanupStartSize) {
-		bytesToDelete = objSrv.unreferencedBytes -
+		objSrv.unreferencedPhysicalBytes >
+			uint64(objectServerCleanupStartSize) {
+		bytesToDelete = objSrv.unreferencedPhysicalBytes -
 			uint64(objectServerCleanupStopSize)
 	}
 	objSrv.rwLock.Unlock()
@@ -3,11 +3,8 @@ package filesystem
 import (
 	"errors"
 	"io"
-	"os"
-	"path"
 
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
-	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
 )
 
 func (objSrv *ObjectServer) getObjects(hashes []hash.Hash) (
@@ -38,16 +35,10 @@ func (or *ObjectsReader) nextObject() (uint64, io.ReadCloser, error) {
 	if or.nextIndex >= int64(len(or.hashes)) {
 		return 0, nil, errors.New("all objects have been consumed")
 	}
-	filename := path.Join(or.objectServer.BaseDirectory,
-		objectcache.HashToFilename(or.hashes[or.nextIndex]))
-	file, err := os.Open(filename)
+	filename, compression, err := or.objectServer.findObject(
+		or.hashes[or.nextIndex])
 	if err != nil {
 		return 0, nil, err
 	}
-	fi, err := file.Stat()
-	if err != nil {
-		file.Close()
-		return 0, nil, err
-	}
-	return uint64(fi.Size()), file, nil
+	return openObjectFile(filename, compression)
 }
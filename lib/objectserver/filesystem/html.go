@@ -23,7 +23,9 @@ func (objSrv *ObjectServer) writeHtml(writer io.Writer) {
 	numUnreferenced := objSrv.numUnreferenced
 	referencedBytes := objSrv.referencedBytes
 	totalBytes := objSrv.totalBytes
+	totalPhysicalBytes := objSrv.totalPhysicalBytes
 	unreferencedBytes := objSrv.unreferencedBytes
+	unreferencedPhysicalBytes := objSrv.unreferencedPhysicalBytes
 	objSrv.rwLock.RUnlock()
 	referencedUtilisation := float64(referencedBytes) * 100 / float64(capacity)
 	totalUtilisation := float64(totalBytes) * 100 / float64(capacity)
@@ -43,6 +45,17 @@ func (objSrv *ObjectServer) writeHtml(writer io.Writer) {
 		"Number of objects: %d, consuming %s (%.1f%% of FS which is %.1f%% full)<br>\n",
 		numObjects, format.FormatBytes(totalBytes), totalUtilisation,
 		utilisation)
+	if objSrv.Compression != CompressionNone ||
+		totalPhysicalBytes != totalBytes {
+		var ratio float64
+		if totalPhysicalBytes > 0 {
+			ratio = float64(totalBytes) / float64(totalPhysicalBytes)
+		}
+		fmt.Fprintf(writer,
+			"Compression: %s, objects consume %s on disk (%.3g*), unreferenced objects consume %s on disk<br>\n",
+			objSrv.Compression, format.FormatBytes(totalPhysicalBytes), ratio,
+			format.FormatBytes(unreferencedPhysicalBytes))
+	}
 	if numDuplicated > 0 {
 		fmt.Fprintf(writer,
 			"Number of referenced objects: %d (%d duplicates, %.3g*), consuming %s (%.1f%% of FS, %s dups, %.3g*)<br>\n",
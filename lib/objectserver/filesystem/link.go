@@ -0,0 +1,28 @@
+package filesystem
+
+import (
+	"os"
+
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+func (objSrv *ObjectServer) linkObject(filename string,
+	hashVal hash.Hash) (bool, error) {
+	objectFilename, compression, err := objSrv.findObject(hashVal)
+	if err != nil {
+		return false, err
+	}
+	if compression == CompressionNone {
+		if err := os.Link(objectFilename, filename); err == nil {
+			return true, nil
+		}
+	}
+	size, reader, err := openObjectFile(objectFilename, compression)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+	return false, fsutil.CopyToFile(filename, fsutil.PrivateFilePerms, reader,
+		size)
+}
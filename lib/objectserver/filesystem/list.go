@@ -4,6 +4,19 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 )
 
+func (objSrv *ObjectServer) getStats() Stats {
+	objSrv.rwLock.RLock()
+	defer objSrv.rwLock.RUnlock()
+	return Stats{
+		NumObjects:                uint64(len(objSrv.objects)),
+		NumUnreferenced:           objSrv.numUnreferenced,
+		PhysicalBytes:             objSrv.totalPhysicalBytes,
+		TotalBytes:                objSrv.totalBytes,
+		UnreferencedBytes:         objSrv.unreferencedBytes,
+		UnreferencedPhysicalBytes: objSrv.unreferencedPhysicalBytes,
+	}
+}
+
 func (objSrv *ObjectServer) listObjectSizes() map[hash.Hash]uint64 {
 	objSrv.rwLock.RLock()
 	defer objSrv.rwLock.RUnlock()
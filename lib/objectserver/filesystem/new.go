@@ -20,12 +20,28 @@ func newObjectServer(config Config, params Params) (*ObjectServer, error) {
 	startTime := time.Now()
 	var rusageStart, rusageStop wsyscall.Rusage
 	wsyscall.Getrusage(wsyscall.RUSAGE_SELF, &rusageStart)
-	err := scan.ScanTree(config.BaseDirectory, func(hashVal hash.Hash,
-		size uint64) {
-		objSrv.rwLock.Lock()
-		objSrv.add(&objectType{hash: hashVal, size: size})
-		objSrv.rwLock.Unlock()
-	})
+	err := scan.ScanTreeWithSuffixes(config.BaseDirectory, suffixes,
+		func(hashVal hash.Hash, suffix string, physicalSize uint64) error {
+			compression := suffixToCompression[suffix]
+			size, err := readLogicalSize(
+				objSrv.objectFilename(hashVal, compression), compression,
+				physicalSize)
+			if err != nil {
+				return err
+			}
+			objSrv.rwLock.Lock()
+			defer objSrv.rwLock.Unlock()
+			if _, ok := objSrv.objects[hashVal]; ok {
+				return nil // Stored with different compressions.
+			}
+			objSrv.add(&objectType{
+				compression:  compression,
+				hash:         hashVal,
+				physicalSize: physicalSize,
+				size:         size,
+			})
+			return nil
+		})
 	if err != nil {
 		return nil, err
 	}
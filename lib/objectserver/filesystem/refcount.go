@@ -81,6 +81,7 @@ func (objSrv *ObjectServer) addUnreferenced(object *objectType) {
 	objSrv.numUnreferenced++
 	object.newerUnreferenced = nil
 	objSrv.unreferencedBytes += object.size
+	objSrv.unreferencedPhysicalBytes += object.physicalSize
 }
 
 // Decrement refcount and possibly add to list of unreferenced objects.
@@ -101,9 +102,10 @@ func (objSrv *ObjectServer) decrementRefcount(object *objectType) error {
 	return nil
 }
 
-// This must be called without the lock being held.
+// This must be called without the lock being held. The logical and physical
+// sizes of the deleted object are returned.
 func (objSrv *ObjectServer) deleteOldestUnreferenced(lastPauseTime *time.Time) (
-	uint64, error) {
+	uint64, uint64, error) {
 	lockWatcherOptions := objSrv.lockWatcher.GetOptions()
 	// Inject periodic pauses so that the write lockwatcher is not starved out.
 	if time.Since(*lastPauseTime) > lockWatcherOptions.LogTimeout>>1 {
@@ -114,35 +116,46 @@ func (objSrv *ObjectServer) deleteOldestUnreferenced(lastPauseTime *time.Time) (
 	object := objSrv.oldestUnreferenced
 	if object == nil {
 		objSrv.rwLock.Unlock()
-		return 0, fmt.Errorf("no more objects to delete")
+		return 0, 0, fmt.Errorf("no more objects to delete")
 	}
 	// deleteObject() will release the lock.
 	if err := objSrv.deleteObject(object.hash, true); err != nil {
-		return 0, err
+		return 0, 0, err
 	}
-	return object.size, nil
+	return object.size, object.physicalSize, nil
 }
 
 // This must be called without the lock being held.
 func (objSrv *ObjectServer) deleteUnreferenced(percentage uint8,
 	bytesToDelete uint64) (uint64, uint64, error) {
 	startTime := time.Now()
-	var bytesDeleted, objectsDeleted uint64
+	var bytesDeleted, logicalBytesDeleted, objectsDeleted uint64
 	objSrv.rwLock.RLock()
 	objectsToDelete := uint64(percentage) * objSrv.numUnreferenced / 100
 	objSrv.rwLock.RUnlock()
 	lastPauseTime := time.Now()
 	for bytesDeleted < bytesToDelete || objectsDeleted < objectsToDelete {
-		size, err := objSrv.deleteOldestUnreferenced(&lastPauseTime)
+		size, physicalSize, err := objSrv.deleteOldestUnreferenced(
+			&lastPauseTime)
 		if err != nil {
 			return bytesDeleted, objectsDeleted, err
 		}
-		bytesDeleted += size
+		bytesDeleted += physicalSize
+		logicalBytesDeleted += size
 		objectsDeleted++
 	}
-	objSrv.Logger.Printf("Garbage collector deleted: %s in: %d objects in %s\n",
-		format.FormatBytes(bytesDeleted), objectsDeleted,
-		format.Duration(time.Since(startTime)))
+	if logicalBytesDeleted == bytesDeleted {
+		objSrv.Logger.Printf(
+			"Garbage collector deleted: %s in: %d objects in %s\n",
+			format.FormatBytes(bytesDeleted), objectsDeleted,
+			format.Duration(time.Since(startTime)))
+	} else {
+		objSrv.Logger.Printf(
+			"Garbage collector deleted: %s (%s logical) in: %d objects in %s\n",
+			format.FormatBytes(bytesDeleted),
+			format.FormatBytes(logicalBytesDeleted), objectsDeleted,
+			format.Duration(time.Since(startTime)))
+	}
 	return bytesDeleted, objectsDeleted, nil
 }
 
@@ -194,6 +207,7 @@ func (objSrv *ObjectServer) removeUnreferenced(object *objectType) {
 	if removed {
 		objSrv.numUnreferenced--
 		objSrv.unreferencedBytes -= object.size
+		objSrv.unreferencedPhysicalBytes -= object.physicalSize
 	}
 	object.newerUnreferenced = nil
 }
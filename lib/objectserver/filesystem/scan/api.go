@@ -7,5 +7,18 @@ import (
 // ScanTree will scan a directory tree for objects and will call registerFunc
 // for each object. Multiple calls to registerFunc may be called concurrently.
 func ScanTree(baseDir string, registerFunc func(hash.Hash, uint64)) error {
-	return scanTree(baseDir, registerFunc)
+	return scanTree(baseDir, nil,
+		func(hashVal hash.Hash, suffix string, size uint64) error {
+			registerFunc(hashVal, size)
+			return nil
+		})
+}
+
+// ScanTreeWithSuffixes is similar to ScanTree, except that object filenames
+// may have one of the specified suffixes, which is passed to registerFunc.
+// If registerFunc returns an error, the scan is aborted.
+func ScanTreeWithSuffixes(baseDir string, suffixes []string,
+	registerFunc func(hashVal hash.Hash, suffix string,
+		size uint64) error) error {
+	return scanTree(baseDir, suffixes, registerFunc)
 }
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Cloud-Foundations/Dominator/lib/concurrent"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
 )
 
-func scanTree(baseDir string, registerFunc func(hash.Hash, uint64)) error {
+type registerFuncType func(hashVal hash.Hash, suffix string, size uint64) error
+
+func scanTree(baseDir string, suffixes []string,
+	registerFunc registerFuncType) error {
 	if fi, err := os.Stat(baseDir); err != nil {
 		return fmt.Errorf("cannot stat: %s: %s\n", baseDir, err)
 	} else {
@@ -19,7 +23,8 @@ func scanTree(baseDir string, registerFunc func(hash.Hash, uint64)) error {
 		}
 	}
 	state := concurrent.NewState(0)
-	if err := scanDirectory(baseDir, "", state, registerFunc); err != nil {
+	err := scanDirectory(baseDir, "", suffixes, state, registerFunc)
+	if err != nil {
 		return err
 	}
 	if err := state.Reap(); err != nil {
@@ -28,8 +33,8 @@ func scanTree(baseDir string, registerFunc func(hash.Hash, uint64)) error {
 	return nil
 }
 
-func scanDirectory(baseDir string, subpath string, state *concurrent.State,
-	registerFunc func(hash.Hash, uint64)) error {
+func scanDirectory(baseDir string, subpath string, suffixes []string,
+	state *concurrent.State, registerFunc registerFuncType) error {
 	myPathName := filepath.Join(baseDir, subpath)
 	file, err := os.Open(myPathName)
 	if err != nil {
@@ -52,7 +57,8 @@ func scanDirectory(baseDir string, subpath string, state *concurrent.State,
 		filename := filepath.Join(subpath, name)
 		if fi.IsDir() {
 			if state == nil {
-				err := scanDirectory(baseDir, filename, nil, registerFunc)
+				err := scanDirectory(baseDir, filename, suffixes, nil,
+					registerFunc)
 				if err != nil {
 					return err
 				}
@@ -60,7 +66,8 @@ func scanDirectory(baseDir string, subpath string, state *concurrent.State,
 				// GoRun() cannot be used recursively, so limit concurrency to
 				// the top level. It's also more efficient this way.
 				if err := state.GoRun(func() error {
-					return scanDirectory(baseDir, filename, nil, registerFunc)
+					return scanDirectory(baseDir, filename, suffixes, nil,
+						registerFunc)
 				}); err != nil {
 					return err
 				}
@@ -76,11 +83,22 @@ func scanDirectory(baseDir string, subpath string, state *concurrent.State,
 			if fi.Size() < 1 {
 				return fmt.Errorf("zero-length file: %s", fullPathName)
 			}
+			var suffix string
+			for _, possibleSuffix := range suffixes {
+				if strings.HasSuffix(filename, possibleSuffix) {
+					suffix = possibleSuffix
+					filename = filename[:len(filename)-len(suffix)]
+					break
+				}
+			}
 			hashVal, err := objectcache.FilenameToHash(filename)
 			if err != nil {
 				return err
 			}
-			registerFunc(hashVal, uint64(fi.Size()))
+			if err := registerFunc(hashVal, suffix,
+				uint64(fi.Size())); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
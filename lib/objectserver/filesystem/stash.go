@@ -15,9 +15,8 @@ var stashDirectory string = ".stash"
 
 func (objSrv *ObjectServer) commitObject(hashVal hash.Hash) error {
 	hashName := objectcache.HashToFilename(hashVal)
-	filename := path.Join(objSrv.BaseDirectory, hashName)
-	stashFilename := path.Join(objSrv.BaseDirectory, stashDirectory, hashName)
-	fi, err := os.Lstat(stashFilename)
+	stashFilename, compression, fi, err := findObjectFile(
+		path.Join(objSrv.BaseDirectory, stashDirectory, hashName))
 	if err != nil {
 		if length, _ := objSrv.checkObject(hashVal); length > 0 {
 			return nil // Previously committed: return success.
@@ -28,6 +27,11 @@ func (objSrv *ObjectServer) commitObject(hashVal hash.Hash) error {
 		fsutil.ForceRemove(stashFilename)
 		return errors.New("existing non-file: " + stashFilename)
 	}
+	size, err := readLogicalSize(stashFilename, compression, uint64(fi.Size()))
+	if err != nil {
+		return err
+	}
+	filename := objSrv.objectFilename(hashVal, compression)
 	err = os.MkdirAll(path.Dir(filename), fsutil.PrivateDirPerms)
 	if err != nil {
 		return err
@@ -37,21 +41,29 @@ func (objSrv *ObjectServer) commitObject(hashVal hash.Hash) error {
 	if _, ok := objSrv.objects[hashVal]; ok {
 		fsutil.ForceRemove(stashFilename)
 		// Run in a goroutine to keep outside of the lock.
-		go objSrv.addCallback(hashVal, uint64(fi.Size()), false)
+		go objSrv.addCallback(hashVal, size, false)
 		return nil
 	} else {
-		objSrv.add(&objectType{hash: hashVal, size: uint64(fi.Size())})
+		objSrv.add(&objectType{
+			compression:  compression,
+			hash:         hashVal,
+			physicalSize: uint64(fi.Size()),
+			size:         size,
+		})
 		if objSrv.addCallback != nil {
 			// Run in a goroutine to keep outside of the lock.
-			go objSrv.addCallback(hashVal, uint64(fi.Size()), true)
+			go objSrv.addCallback(hashVal, size, true)
 		}
 		return os.Rename(stashFilename, filename)
 	}
 }
 
 func (objSrv *ObjectServer) deleteStashedObject(hashVal hash.Hash) error {
-	filename := path.Join(objSrv.BaseDirectory, stashDirectory,
-		objectcache.HashToFilename(hashVal))
+	filename, _, _, err := findObjectFile(path.Join(objSrv.BaseDirectory,
+		stashDirectory, objectcache.HashToFilename(hashVal)))
+	if err != nil {
+		return err
+	}
 	return os.Remove(filename)
 }
 
@@ -62,12 +74,15 @@ func (objSrv *ObjectServer) stashOrVerifyObject(reader io.Reader,
 		return hashVal, nil, err
 	}
 	hashName := objectcache.HashToFilename(hashVal)
-	filename := path.Join(objSrv.BaseDirectory, hashName)
 	// Check for existing object and collision.
 	if length, err := objSrv.checkObject(hashVal); err != nil {
 		return hashVal, nil, err
 	} else if length > 0 {
-		if err := collisionCheck(data, filename, int64(length)); err != nil {
+		filename, compression, err := objSrv.findObject(hashVal)
+		if err != nil {
+			return hashVal, nil, err
+		}
+		if err := collisionCheck(data, filename, compression); err != nil {
 			return hashVal, nil, err
 		}
 		return hashVal, nil, nil
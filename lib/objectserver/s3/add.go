@@ -0,0 +1,55 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// add registers a new object as unreferenced. This must be called with the
+// lock held.
+func (objSrv *ObjectServer) add(object *objectType) {
+	objSrv.objects[object.hash] = object
+	objSrv.totalBytes += object.size
+	objSrv.addUnreferenced(object)
+	objSrv.lastMutationTime = time.Now()
+}
+
+func (objSrv *ObjectServer) addObject(reader io.Reader, length uint64,
+	expectedHash *hash.Hash) (hash.Hash, bool, error) {
+	hashVal, data, err := objectcache.ReadObject(reader, length, expectedHash)
+	if err != nil {
+		return hashVal, false, err
+	}
+	unlockHash := objSrv.lockHash(hashVal)
+	defer unlockHash()
+	if size, _ := objSrv.checkObject(hashVal); size > 0 {
+		return hashVal, false, nil
+	}
+	if err := objSrv.putObject(objectsDirectory, hashVal, data); err != nil {
+		return hashVal, false, err
+	}
+	objSrv.rwLock.Lock()
+	defer objSrv.rwLock.Unlock()
+	if _, ok := objSrv.objects[hashVal]; ok {
+		return hashVal, false, nil
+	}
+	objSrv.add(&objectType{hash: hashVal, size: uint64(len(data))})
+	return hashVal, true, nil
+}
+
+func (objSrv *ObjectServer) putObject(directory string, hashVal hash.Hash,
+	data []byte) error {
+	_, err := objSrv.client.PutObject(&awss3.PutObjectInput{
+		Body:          bytes.NewReader(data),
+		Bucket:        aws.String(objSrv.Bucket),
+		ContentLength: aws.Int64(int64(len(data))),
+		Key:           objSrv.key(directory, hashVal),
+	})
+	return err
+}
@@ -0,0 +1,215 @@
+package s3
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/cachingreader"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+var (
+	// Interface checks.
+	_ objectserver.FullObjectServer     = (*ObjectServer)(nil)
+	_ objectserver.StashingObjectServer = (*ObjectServer)(nil)
+)
+
+// Config specifies the bucket to use and the local cache. Objects are stored
+// under the "objects/" key prefix (after Prefix), stashed objects are stored
+// under "stash/" and mirrored image files are stored under "images/".
+// The S3 credentials are obtained from the environment or the shared AWS
+// configuration files.
+type Config struct {
+	Bucket         string
+	CacheDirectory string // Local read-through cache for objects.
+	CacheSize      uint64 // Maximum number of bytes to cache.
+	Endpoint       string // Optional: for S3-compatible services (MinIO).
+	Prefix         string // Optional: prepended to all keys.
+	Region         string
+	UsePathStyle   bool // Often required for S3-compatible services.
+}
+
+type Params struct {
+	Logger log.DebugLogger
+}
+
+type hashLockType struct {
+	mutex    sync.Mutex
+	numUsers uint
+}
+
+type objectType struct {
+	hash              hash.Hash
+	newerUnreferenced *objectType
+	olderUnreferenced *objectType
+	refcount          uint64
+	size              uint64
+}
+
+// ObjectServer stores objects in an S3-compatible bucket. Objects which are
+// read are cached locally. The bucket (and Prefix) must not be shared with
+// another ObjectServer, since the object refcounts are maintained in memory.
+type ObjectServer struct {
+	Config
+	Params
+	cache              *cachingreader.ObjectServer
+	client             s3iface.S3API
+	hashLocksMutex     sync.Mutex
+	hashLocks          map[hash.Hash]*hashLockType
+	rwLock             sync.RWMutex // Protect the following fields.
+	lastMutationTime   time.Time
+	newestUnreferenced *objectType
+	numReferenced      uint64
+	numUnreferenced    uint64
+	objects            map[hash.Hash]*objectType
+	oldestUnreferenced *objectType
+	referencedBytes    uint64
+	totalBytes         uint64
+	unreferencedBytes  uint64
+}
+
+func NewObjectServer(config Config, params Params) (*ObjectServer, error) {
+	client, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return newObjectServer(config, params, client)
+}
+
+// AddObject will add an object. Object data are read from reader (length bytes
+// are read). The object hash is computed and compared with expectedHash if not
+// nil. The following are returned:
+//
+//	computed hash value
+//	a boolean which is true if the object is new
+//	an error or nil if no error.
+func (objSrv *ObjectServer) AddObject(reader io.Reader, length uint64,
+	expectedHash *hash.Hash) (hash.Hash, bool, error) {
+	return objSrv.addObject(reader, length, expectedHash)
+}
+
+// AdjustRefcounts will increment or decrement the refcounts for each object
+// yielded by the specified objects iterator. If there are missing objects or
+// the iterator returns an error, the adjustments are reverted and an error is
+// returned.
+func (objSrv *ObjectServer) AdjustRefcounts(increment bool,
+	iterator objectserver.ObjectsIterator) error {
+	return objSrv.adjustRefcounts(increment, iterator)
+}
+
+func (objSrv *ObjectServer) CheckObjects(hashes []hash.Hash) ([]uint64, error) {
+	return objSrv.checkObjects(hashes)
+}
+
+// CommitObject will commit (add) a previously stashed object.
+func (objSrv *ObjectServer) CommitObject(hashVal hash.Hash) error {
+	return objSrv.commitObject(hashVal)
+}
+
+func (objSrv *ObjectServer) DeleteObject(hashVal hash.Hash) error {
+	return objSrv.deleteObject(hashVal)
+}
+
+func (objSrv *ObjectServer) DeleteStashedObject(hashVal hash.Hash) error {
+	return objSrv.deleteStashedObject(hashVal)
+}
+
+// DeleteUnreferenced will delete some or all unreferenced objects from the
+// bucket. The oldest unreferenced objects are deleted first, until both the
+// percentage and bytes thresholds are satisfied. The number of bytes and
+// objects deleted are returned.
+func (objSrv *ObjectServer) DeleteUnreferenced(percentage uint8,
+	bytes uint64) (uint64, uint64, error) {
+	return objSrv.deleteUnreferenced(percentage, bytes)
+}
+
+func (objSrv *ObjectServer) GetObject(hashVal hash.Hash) (
+	uint64, io.ReadCloser, error) {
+	return objectserver.GetObject(objSrv, hashVal)
+}
+
+// GetObjects will read objects through the local cache.
+func (objSrv *ObjectServer) GetObjects(hashes []hash.Hash) (
+	objectserver.ObjectsReader, error) {
+	return objSrv.getObjects(hashes)
+}
+
+func (objSrv *ObjectServer) LastMutationTime() time.Time {
+	objSrv.rwLock.RLock()
+	defer objSrv.rwLock.RUnlock()
+	return objSrv.lastMutationTime
+}
+
+func (objSrv *ObjectServer) ListObjectSizes() map[hash.Hash]uint64 {
+	return objSrv.listObjectSizes()
+}
+
+func (objSrv *ObjectServer) ListObjects() []hash.Hash {
+	return objSrv.listObjects()
+}
+
+func (objSrv *ObjectServer) ListUnreferenced() map[hash.Hash]uint64 {
+	return objSrv.listUnreferenced()
+}
+
+func (objSrv *ObjectServer) NumObjects() uint64 {
+	objSrv.rwLock.RLock()
+	defer objSrv.rwLock.RUnlock()
+	return uint64(len(objSrv.objects))
+}
+
+// StashOrVerifyObject will stash an object if it is new or it will verify if it
+// already exists. Object data are read from reader (length bytes are read). The
+// object hash is computed and compared with expectedHash if not nil.
+// The following are returned:
+//
+//	computed hash value
+//	the object data if the object is new, otherwise nil
+//	an error or nil if no error.
+func (objSrv *ObjectServer) StashOrVerifyObject(reader io.Reader,
+	length uint64, expectedHash *hash.Hash) (hash.Hash, []byte, error) {
+	return objSrv.stashOrVerifyObject(reader, length, expectedHash)
+}
+
+func (objSrv *ObjectServer) WriteHtml(writer io.Writer) {
+	objSrv.writeHtml(writer)
+}
+
+// FileMirror implements the imageserver/scanner.FileMirror interface, storing
+// image database files in the bucket.
+type FileMirror struct {
+	Config
+	Params
+	client s3iface.S3API
+}
+
+func NewFileMirror(config Config, params Params) (*FileMirror, error) {
+	client, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &FileMirror{Config: config, Params: params, client: client}, nil
+}
+
+func (mirror *FileMirror) MakeDirectory(name string) error {
+	return mirror.makeDirectory(name)
+}
+
+func (mirror *FileMirror) RemoveFile(name string) error {
+	return mirror.removeFile(name)
+}
+
+// RestoreFiles will copy files and directories from the bucket which are
+// missing from baseDir.
+func (mirror *FileMirror) RestoreFiles(baseDir string) error {
+	return mirror.restoreFiles(baseDir)
+}
+
+// SaveFile will copy the file named filename into the bucket, as name.
+func (mirror *FileMirror) SaveFile(name, filename string) error {
+	return mirror.saveFile(name, filename)
+}
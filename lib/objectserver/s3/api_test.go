@@ -0,0 +1,364 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+type fakeBucket struct {
+	s3iface.S3API
+	deleteHook func() // If not nil, called before deleting.
+	mutex      sync.Mutex
+	objects    map[string][]byte
+	numGets    int
+}
+
+type hashListIterator []hash.Hash
+
+func newFakeBucket() *fakeBucket {
+	return &fakeBucket{objects: make(map[string][]byte)}
+}
+
+func (b *fakeBucket) get(key string) ([]byte, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, awserr.New(awss3.ErrCodeNoSuchKey, key, nil)
+	}
+	return data, nil
+}
+
+func (b *fakeBucket) CopyObject(input *awss3.CopyObjectInput) (
+	*awss3.CopyObjectOutput, error) {
+	source, err := url.PathUnescape(*input.CopySource)
+	if err != nil {
+		return nil, err
+	}
+	source = strings.TrimPrefix(source, *input.Bucket+"/")
+	data, err := b.get(source)
+	if err != nil {
+		return nil, err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.objects[*input.Key] = data
+	return &awss3.CopyObjectOutput{}, nil
+}
+
+func (b *fakeBucket) DeleteObject(input *awss3.DeleteObjectInput) (
+	*awss3.DeleteObjectOutput, error) {
+	if b.deleteHook != nil {
+		b.deleteHook()
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.objects, *input.Key)
+	return &awss3.DeleteObjectOutput{}, nil
+}
+
+func (b *fakeBucket) GetObject(input *awss3.GetObjectInput) (
+	*awss3.GetObjectOutput, error) {
+	data, err := b.get(*input.Key)
+	if err != nil {
+		return nil, err
+	}
+	b.mutex.Lock()
+	b.numGets++
+	b.mutex.Unlock()
+	return &awss3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+	}, nil
+}
+
+func (b *fakeBucket) HeadObject(input *awss3.HeadObjectInput) (
+	*awss3.HeadObjectOutput, error) {
+	data, err := b.get(*input.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &awss3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(data))),
+	}, nil
+}
+
+func (b *fakeBucket) ListObjectsV2Pages(input *awss3.ListObjectsV2Input,
+	fn func(*awss3.ListObjectsV2Output, bool) bool) error {
+	b.mutex.Lock()
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, *input.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	output := &awss3.ListObjectsV2Output{}
+	for _, key := range keys {
+		output.Contents = append(output.Contents, &awss3.Object{
+			Key:  aws.String(key),
+			Size: aws.Int64(int64(len(b.objects[key]))),
+		})
+	}
+	b.mutex.Unlock()
+	fn(output, true)
+	return nil
+}
+
+func (b *fakeBucket) PutObject(input *awss3.PutObjectInput) (
+	*awss3.PutObjectOutput, error) {
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.objects[*input.Key] = data
+	return &awss3.PutObjectOutput{}, nil
+}
+
+func (list hashListIterator) ForEachObject(fn func(hash.Hash) error) error {
+	for _, hashVal := range list {
+		if err := fn(hashVal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addObject(t *testing.T, objSrv *ObjectServer, data string) hash.Hash {
+	hashVal, isNew, err := objSrv.AddObject(strings.NewReader(data),
+		uint64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isNew {
+		t.Fatalf("object: %s not new", data)
+	}
+	return hashVal
+}
+
+func makeObjectServer(t *testing.T, bucket *fakeBucket) *ObjectServer {
+	return makeObjectServerWithPrefix(t, bucket, "imageserver")
+}
+
+func makeObjectServerWithPrefix(t *testing.T, bucket *fakeBucket,
+	prefix string) *ObjectServer {
+	objSrv, err := newObjectServer(
+		Config{
+			Bucket:         "bucket",
+			CacheDirectory: filepath.Join(t.TempDir(), "cache"),
+			CacheSize:      1 << 20,
+			Prefix:         prefix,
+		},
+		Params{Logger: testlogger.New(t)},
+		bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objSrv
+}
+
+func readObject(t *testing.T, objSrv *ObjectServer, hashVal hash.Hash) string {
+	_, reader, err := objSrv.GetObject(hashVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestAddGetAndRestart(t *testing.T) {
+	bucket := newFakeBucket()
+	objSrv := makeObjectServer(t, bucket)
+	hashVal := addObject(t, objSrv, "hello, world")
+	if _, isNew, err := objSrv.AddObject(strings.NewReader("hello, world"),
+		12, nil); err != nil {
+		t.Fatal(err)
+	} else if isNew {
+		t.Fatal("duplicate object is new")
+	}
+	for count := 0; count < 2; count++ {
+		if data := readObject(t, objSrv, hashVal); data != "hello, world" {
+			t.Fatalf("read: %s", data)
+		}
+	}
+	if bucket.numGets != 1 {
+		t.Fatalf("expected 1 read from bucket, got: %d", bucket.numGets)
+	}
+	objSrv = makeObjectServer(t, bucket)
+	if sizes, err := objSrv.CheckObjects([]hash.Hash{hashVal}); err != nil {
+		t.Fatal(err)
+	} else if sizes[0] != 12 {
+		t.Fatalf("size after restart: %d", sizes[0])
+	}
+	if _, _, err := objSrv.GetObject(hash.Hash{}); err == nil {
+		t.Fatal("no error getting missing object")
+	}
+}
+
+func TestRefcounts(t *testing.T) {
+	bucket := newFakeBucket()
+	objSrv := makeObjectServer(t, bucket)
+	hash0 := addObject(t, objSrv, "object0")
+	hash1 := addObject(t, objSrv, "object1")
+	err := objSrv.AdjustRefcounts(true, hashListIterator{hash0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = objSrv.AdjustRefcounts(true, hashListIterator{hash0, hash.Hash{}})
+	if err == nil {
+		t.Fatal("no error adjusting refcount for missing object")
+	}
+	unreferenced := objSrv.ListUnreferenced()
+	if len(unreferenced) != 1 || unreferenced[hash1] != 7 {
+		t.Fatalf("unexpected unreferenced objects: %v", unreferenced)
+	}
+	bytesDeleted, objectsDeleted, err := objSrv.DeleteUnreferenced(100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytesDeleted != 7 || objectsDeleted != 1 {
+		t.Fatalf("deleted: %d bytes in %d objects", bytesDeleted,
+			objectsDeleted)
+	}
+	if len(bucket.objects) != 1 {
+		t.Fatalf("%d objects left in bucket", len(bucket.objects))
+	}
+	if objSrv.NumObjects() != 1 {
+		t.Fatalf("%d objects left", objSrv.NumObjects())
+	}
+}
+
+func TestStash(t *testing.T) {
+	// The second prefix requires the copy source to be escaped.
+	for _, prefix := range []string{"imageserver", "image server/100%"} {
+		bucket := newFakeBucket()
+		objSrv := makeObjectServerWithPrefix(t, bucket, prefix)
+		hashVal, data, err := objSrv.StashOrVerifyObject(
+			strings.NewReader("stashed"), 7, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "stashed" {
+			t.Fatalf("stashed data: %s", string(data))
+		}
+		if objSrv.NumObjects() != 0 {
+			t.Fatal("stashed object is visible")
+		}
+		if err := objSrv.CommitObject(hashVal); err != nil {
+			t.Fatalf("%s: %s", prefix, err)
+		}
+		if data := readObject(t, objSrv, hashVal); data != "stashed" {
+			t.Fatalf("read: %s", data)
+		}
+		if len(bucket.objects) != 1 {
+			t.Fatalf("%d objects in bucket", len(bucket.objects))
+		}
+	}
+}
+
+func TestDeleteRacingAdd(t *testing.T) {
+	bucket := newFakeBucket()
+	objSrv := makeObjectServer(t, bucket)
+	hashVal := addObject(t, objSrv, "object")
+	deleting := make(chan struct{})
+	release := make(chan struct{})
+	bucket.deleteHook = func() {
+		close(deleting)
+		<-release
+	}
+	deleteResult := make(chan error, 1)
+	go func() { deleteResult <- objSrv.DeleteObject(hashVal) }()
+	<-deleting
+	addResult := make(chan error, 1)
+	go func() {
+		_, _, err := objSrv.AddObject(strings.NewReader("object"), 6, nil)
+		addResult <- err
+	}()
+	select {
+	case err := <-addResult:
+		t.Fatalf("add completed during delete: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	if err := <-deleteResult; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-addResult; err != nil {
+		t.Fatal(err)
+	}
+	if sizes, err := objSrv.CheckObjects([]hash.Hash{hashVal}); err != nil {
+		t.Fatal(err)
+	} else if sizes[0] != 6 {
+		t.Fatalf("size after re-add: %d", sizes[0])
+	}
+	if len(bucket.objects) != 1 {
+		t.Fatalf("%d objects in bucket", len(bucket.objects))
+	}
+}
+
+func TestFileMirror(t *testing.T) {
+	bucket := newFakeBucket()
+	mirror := &FileMirror{
+		Config: Config{Bucket: "bucket", Prefix: "imageserver"},
+		Params: Params{Logger: testlogger.New(t)},
+		client: bucket,
+	}
+	sourceDir := t.TempDir()
+	filename := filepath.Join(sourceDir, "image")
+	if err := os.WriteFile(filename, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.SaveFile("dir/image", filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.MakeDirectory("empty"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.SaveFile("dir/deleted", filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.RemoveFile("dir/deleted"); err != nil {
+		t.Fatal(err)
+	}
+	restoreDir := t.TempDir()
+	if err := mirror.RestoreFiles(restoreDir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(restoreDir, "dir", "image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "image" {
+		t.Fatalf("restored: %s", string(data))
+	}
+	if fi, err := os.Stat(filepath.Join(restoreDir, "empty")); err != nil {
+		t.Fatal(err)
+	} else if !fi.IsDir() {
+		t.Fatal("empty directory not restored")
+	}
+	_, err = os.Stat(filepath.Join(restoreDir, "dir", "deleted"))
+	if !os.IsNotExist(err) {
+		t.Fatal("deleted file restored")
+	}
+}
@@ -0,0 +1,24 @@
+package s3
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+func (objSrv *ObjectServer) checkObjects(hashes []hash.Hash) ([]uint64, error) {
+	sizesList := make([]uint64, len(hashes))
+	for index, hashVal := range hashes {
+		sizesList[index], _ = objSrv.checkObject(hashVal)
+	}
+	return sizesList, nil
+}
+
+// checkObject returns the size of the object and true if known, else 0 and
+// false.
+func (objSrv *ObjectServer) checkObject(hashVal hash.Hash) (uint64, bool) {
+	objSrv.rwLock.RLock()
+	defer objSrv.rwLock.RUnlock()
+	if object, ok := objSrv.objects[hashVal]; ok {
+		return object.size, true
+	}
+	return 0, false
+}
@@ -0,0 +1,99 @@
+package s3
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+func (objSrv *ObjectServer) deleteKey(directory string,
+	hashVal hash.Hash) error {
+	_, err := objSrv.client.DeleteObject(&awss3.DeleteObjectInput{
+		Bucket: aws.String(objSrv.Bucket),
+		Key:    objSrv.key(directory, hashVal),
+	})
+	return err
+}
+
+func (objSrv *ObjectServer) deleteObject(hashVal hash.Hash) error {
+	unlockHash := objSrv.lockHash(hashVal)
+	defer unlockHash()
+	objSrv.rwLock.Lock()
+	object := objSrv.objects[hashVal]
+	if object == nil {
+		objSrv.rwLock.Unlock()
+		return fmt.Errorf("deleteObject(%x): object unknown", hashVal)
+	}
+	objSrv.removeWithLock(object)
+	objSrv.rwLock.Unlock()
+	if object.refcount > 0 {
+		objSrv.Logger.Printf("deleteObject(%x): refcount: %d\n",
+			hashVal, object.refcount)
+	}
+	return objSrv.deleteKey(objectsDirectory, hashVal)
+}
+
+// deleteOldestUnreferenced deletes the oldest unreferenced object and returns
+// its size. This must be called without the lock being held.
+func (objSrv *ObjectServer) deleteOldestUnreferenced() (uint64, error) {
+	for {
+		objSrv.rwLock.RLock()
+		object := objSrv.oldestUnreferenced
+		objSrv.rwLock.RUnlock()
+		if object == nil {
+			return 0, fmt.Errorf("no more objects to delete")
+		}
+		unlockHash := objSrv.lockHash(object.hash)
+		objSrv.rwLock.Lock()
+		if objSrv.objects[object.hash] != object || object.refcount > 0 {
+			// Raced with another mutation: try again.
+			objSrv.rwLock.Unlock()
+			unlockHash()
+			continue
+		}
+		objSrv.removeWithLock(object)
+		objSrv.rwLock.Unlock()
+		err := objSrv.deleteKey(objectsDirectory, object.hash)
+		unlockHash()
+		return object.size, err
+	}
+}
+
+// This must be called without the lock being held.
+func (objSrv *ObjectServer) deleteUnreferenced(percentage uint8,
+	bytesToDelete uint64) (uint64, uint64, error) {
+	startTime := time.Now()
+	var bytesDeleted, objectsDeleted uint64
+	objSrv.rwLock.RLock()
+	objectsToDelete := uint64(percentage) * objSrv.numUnreferenced / 100
+	objSrv.rwLock.RUnlock()
+	for bytesDeleted < bytesToDelete || objectsDeleted < objectsToDelete {
+		size, err := objSrv.deleteOldestUnreferenced()
+		if err != nil {
+			return bytesDeleted, objectsDeleted, err
+		}
+		bytesDeleted += size
+		objectsDeleted++
+	}
+	objSrv.Logger.Printf("Deleted: %s in: %d objects from bucket in %s\n",
+		format.FormatBytes(bytesDeleted), objectsDeleted,
+		format.Duration(time.Since(startTime)))
+	return bytesDeleted, objectsDeleted, nil
+}
+
+// removeWithLock removes the object from the map and the accounting. This must
+// be called with the lock held.
+func (objSrv *ObjectServer) removeWithLock(object *objectType) {
+	delete(objSrv.objects, object.hash)
+	objSrv.lastMutationTime = time.Now()
+	if object.refcount > 0 {
+		objSrv.numReferenced--
+		objSrv.referencedBytes -= object.size
+	}
+	objSrv.removeUnreferenced(object)
+	objSrv.totalBytes -= object.size
+}
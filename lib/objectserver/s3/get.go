@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver"
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// bucketGetter is the upstream for the read-through cache.
+type bucketGetter struct {
+	objSrv *ObjectServer
+}
+
+// bucketReader reads objects directly from the bucket.
+type bucketReader struct {
+	hashes    []hash.Hash
+	nextIndex int
+	objSrv    *ObjectServer
+	sizes     []uint64
+}
+
+func (objSrv *ObjectServer) getObjects(hashes []hash.Hash) (
+	objectserver.ObjectsReader, error) {
+	if _, err := objSrv.getSizes(hashes); err != nil {
+		return nil, err
+	}
+	return objSrv.cache.GetObjects(hashes)
+}
+
+func (objSrv *ObjectServer) getSizes(hashes []hash.Hash) ([]uint64, error) {
+	sizes := make([]uint64, 0, len(hashes))
+	for _, hashVal := range hashes {
+		size, ok := objSrv.checkObject(hashVal)
+		if !ok {
+			hashStr, _ := hashVal.MarshalText()
+			return nil, errors.New("missing object: " + string(hashStr))
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+func (bg *bucketGetter) GetObjects(hashes []hash.Hash) (
+	objectserver.ObjectsReader, error) {
+	sizes, err := bg.objSrv.getSizes(hashes)
+	if err != nil {
+		return nil, err
+	}
+	return &bucketReader{hashes: hashes, objSrv: bg.objSrv, sizes: sizes}, nil
+}
+
+func (br *bucketReader) Close() error {
+	return nil
+}
+
+func (br *bucketReader) NextObject() (uint64, io.ReadCloser, error) {
+	if br.nextIndex >= len(br.hashes) {
+		return 0, nil, errors.New("all objects have been consumed")
+	}
+	hashVal := br.hashes[br.nextIndex]
+	size := br.sizes[br.nextIndex]
+	br.nextIndex++
+	output, err := br.objSrv.client.GetObject(&awss3.GetObjectInput{
+		Bucket: aws.String(br.objSrv.Bucket),
+		Key:    br.objSrv.key(objectsDirectory, hashVal),
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return size, output.Body, nil
+}
+
+func (br *bucketReader) ObjectSizes() []uint64 {
+	return br.sizes
+}
@@ -0,0 +1,30 @@
+package s3
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+)
+
+// lockHash serialises bucket mutations for an object, so that an object which
+// is being deleted is not concurrently added (or vice versa), which could
+// leave the object tracked but missing from the bucket. The returned function
+// must be called to release the lock. This must be called without the lock
+// being held.
+func (objSrv *ObjectServer) lockHash(hashVal hash.Hash) func() {
+	objSrv.hashLocksMutex.Lock()
+	hashLock := objSrv.hashLocks[hashVal]
+	if hashLock == nil {
+		hashLock = &hashLockType{}
+		objSrv.hashLocks[hashVal] = hashLock
+	}
+	hashLock.numUsers++
+	objSrv.hashLocksMutex.Unlock()
+	hashLock.mutex.Lock()
+	return func() {
+		hashLock.mutex.Unlock()
+		objSrv.hashLocksMutex.Lock()
+		defer objSrv.hashLocksMutex.Unlock()
+		if hashLock.numUsers--; hashLock.numUsers < 1 {
+			delete(objSrv.hashLocks, hashVal)
+		}
+	}
+}
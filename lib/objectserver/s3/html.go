@@ -0,0 +1,33 @@
+package s3
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+)
+
+func (objSrv *ObjectServer) writeHtml(writer io.Writer) {
+	objSrv.rwLock.RLock()
+	numObjects := len(objSrv.objects)
+	numReferenced := objSrv.numReferenced
+	numUnreferenced := objSrv.numUnreferenced
+	referencedBytes := objSrv.referencedBytes
+	totalBytes := objSrv.totalBytes
+	unreferencedBytes := objSrv.unreferencedBytes
+	objSrv.rwLock.RUnlock()
+	fmt.Fprintf(writer, "Object storage bucket: %s", objSrv.Bucket)
+	if objSrv.Endpoint != "" {
+		fmt.Fprintf(writer, " at: %s", objSrv.Endpoint)
+	}
+	fmt.Fprintln(writer, "<br>")
+	fmt.Fprintf(writer, "Number of objects: %d, consuming %s<br>\n",
+		numObjects, format.FormatBytes(totalBytes))
+	fmt.Fprintf(writer,
+		"Number of referenced objects: %d, consuming %s<br>\n",
+		numReferenced, format.FormatBytes(referencedBytes))
+	fmt.Fprintf(writer,
+		"Number of unreferenced objects: %d, consuming %s<br>\n",
+		numUnreferenced, format.FormatBytes(unreferencedBytes))
+	objSrv.cache.WriteHtml(writer)
+}
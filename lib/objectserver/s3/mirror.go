@@ -0,0 +1,107 @@
+package s3
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+func (mirror *FileMirror) key(name string) string {
+	return path.Join(mirror.Prefix, imagesDirectory, name)
+}
+
+// makeDirectory writes an empty marker object for the directory, so that empty
+// directories are restored.
+func (mirror *FileMirror) makeDirectory(name string) error {
+	_, err := mirror.client.PutObject(&awss3.PutObjectInput{
+		Body:   strings.NewReader(""),
+		Bucket: aws.String(mirror.Bucket),
+		Key:    aws.String(mirror.key(name) + "/"),
+	})
+	return err
+}
+
+func (mirror *FileMirror) removeFile(name string) error {
+	_, err := mirror.client.DeleteObject(&awss3.DeleteObjectInput{
+		Bucket: aws.String(mirror.Bucket),
+		Key:    aws.String(mirror.key(name)),
+	})
+	return err
+}
+
+func (mirror *FileMirror) restoreFile(baseDir, name string,
+	size uint64) error {
+	if strings.HasSuffix(name, "/") {
+		return os.MkdirAll(filepath.Join(baseDir, name), fsutil.DirPerms)
+	}
+	filename := filepath.Join(baseDir, name)
+	if _, err := os.Lstat(filename); err == nil {
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(filename), fsutil.DirPerms)
+	if err != nil {
+		return err
+	}
+	output, err := mirror.client.GetObject(&awss3.GetObjectInput{
+		Bucket: aws.String(mirror.Bucket),
+		Key:    aws.String(mirror.key(name)),
+	})
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+	var perms os.FileMode = fsutil.PublicFilePerms
+	if path.Base(name) == ".secret" {
+		perms = fsutil.PrivateFilePerms
+	}
+	mirror.Logger.Debugf(0, "Restoring: %s\n", name)
+	return fsutil.CopyToFile(filename, perms, output.Body, size)
+}
+
+func (mirror *FileMirror) restoreFiles(baseDir string) error {
+	prefix := mirror.key("") + "/"
+	var err error
+	e := mirror.client.ListObjectsV2Pages(
+		&awss3.ListObjectsV2Input{
+			Bucket: aws.String(mirror.Bucket),
+			Prefix: aws.String(prefix),
+		},
+		func(page *awss3.ListObjectsV2Output, lastPage bool) bool {
+			for _, s3Object := range page.Contents {
+				name := strings.TrimPrefix(aws.StringValue(s3Object.Key),
+					prefix)
+				if name == "" {
+					continue
+				}
+				err = mirror.restoreFile(baseDir, name,
+					uint64(aws.Int64Value(s3Object.Size)))
+				if err != nil {
+					return false
+				}
+			}
+			return true
+		})
+	if e != nil {
+		return e
+	}
+	return err
+}
+
+func (mirror *FileMirror) saveFile(name, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = mirror.client.PutObject(&awss3.PutObjectInput{
+		Body:   file,
+		Bucket: aws.String(mirror.Bucket),
+		Key:    aws.String(mirror.key(name)),
+	})
+	return err
+}
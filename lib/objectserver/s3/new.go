@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/cachingreader"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+const (
+	imagesDirectory  = "images/"
+	objectsDirectory = "objects/"
+	stashDirectory   = "stash/"
+)
+
+func newClient(config Config) (s3iface.S3API, error) {
+	awsConfig := aws.NewConfig().WithS3ForcePathStyle(config.UsePathStyle)
+	if config.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(config.Endpoint)
+	}
+	if config.Region != "" {
+		awsConfig = awsConfig.WithRegion(config.Region)
+	}
+	awsSession, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return awss3.New(awsSession), nil
+}
+
+func newObjectServer(config Config, params Params, client s3iface.S3API) (
+	*ObjectServer, error) {
+	err := os.MkdirAll(config.CacheDirectory, fsutil.PrivateDirPerms)
+	if err != nil {
+		return nil, err
+	}
+	objSrv := &ObjectServer{
+		Config:    config,
+		Params:    params,
+		client:    client,
+		hashLocks: make(map[hash.Hash]*hashLockType),
+		objects:   make(map[hash.Hash]*objectType),
+	}
+	startTime := time.Now()
+	if err := objSrv.listBucket(); err != nil {
+		return nil, err
+	}
+	plural := ""
+	if len(objSrv.objects) != 1 {
+		plural = "s"
+	}
+	params.Logger.Printf("Listed %d object%s (%s) in bucket: %s in %s\n",
+		len(objSrv.objects), plural, format.FormatBytes(objSrv.totalBytes),
+		config.Bucket, format.Duration(time.Since(startTime)))
+	objSrv.cache, err = cachingreader.NewObjectServerWithUpstream(
+		config.CacheDirectory, config.CacheSize, &bucketGetter{objSrv},
+		params.Logger)
+	if err != nil {
+		return nil, err
+	}
+	return objSrv, nil
+}
+
+func (objSrv *ObjectServer) key(directory string, hashVal hash.Hash) *string {
+	return aws.String(
+		path.Join(objSrv.Prefix, directory, objectcache.HashToFilename(hashVal)))
+}
+
+// listBucket registers all the objects in the bucket. All objects start out
+// as unreferenced.
+func (objSrv *ObjectServer) listBucket() error {
+	prefix := path.Join(objSrv.Prefix, objectsDirectory) + "/"
+	var err error
+	e := objSrv.client.ListObjectsV2Pages(
+		&awss3.ListObjectsV2Input{
+			Bucket: aws.String(objSrv.Bucket),
+			Prefix: aws.String(prefix),
+		},
+		func(page *awss3.ListObjectsV2Output, lastPage bool) bool {
+			for _, s3Object := range page.Contents {
+				name := strings.TrimPrefix(aws.StringValue(s3Object.Key),
+					prefix)
+				var hashVal hash.Hash
+				hashVal, err = objectcache.FilenameToHash(name)
+				if err != nil {
+					return false
+				}
+				objSrv.add(&objectType{
+					hash: hashVal,
+					size: uint64(aws.Int64Value(s3Object.Size)),
+				})
+			}
+			return true
+		})
+	if e != nil {
+		return e
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package s3
+
+import (
+	"io"
+	"net/url"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
+	"github.com/aws/aws-sdk-go/aws"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+func (objSrv *ObjectServer) commitObject(hashVal hash.Hash) error {
+	unlockHash := objSrv.lockHash(hashVal)
+	defer unlockHash()
+	if size, _ := objSrv.checkObject(hashVal); size > 0 {
+		objSrv.deleteKey(stashDirectory, hashVal)
+		return nil // Previously committed: return success.
+	}
+	stashKey := objSrv.key(stashDirectory, hashVal)
+	headOutput, err := objSrv.client.HeadObject(&awss3.HeadObjectInput{
+		Bucket: aws.String(objSrv.Bucket),
+		Key:    stashKey,
+	})
+	if err != nil {
+		return err
+	}
+	// The copy source must be URL-encoded, since the prefix may contain any
+	// characters.
+	copySource := &url.URL{Path: objSrv.Bucket + "/" + *stashKey}
+	_, err = objSrv.client.CopyObject(&awss3.CopyObjectInput{
+		Bucket:     aws.String(objSrv.Bucket),
+		CopySource: aws.String(copySource.EscapedPath()),
+		Key:        objSrv.key(objectsDirectory, hashVal),
+	})
+	if err != nil {
+		return err
+	}
+	if err := objSrv.deleteKey(stashDirectory, hashVal); err != nil {
+		objSrv.Logger.Printf("Error deleting stashed object: %x: %s\n",
+			hashVal, err)
+	}
+	objSrv.rwLock.Lock()
+	defer objSrv.rwLock.Unlock()
+	if _, ok := objSrv.objects[hashVal]; !ok {
+		objSrv.add(&objectType{
+			hash: hashVal,
+			size: uint64(aws.Int64Value(headOutput.ContentLength)),
+		})
+	}
+	return nil
+}
+
+func (objSrv *ObjectServer) deleteStashedObject(hashVal hash.Hash) error {
+	return objSrv.deleteKey(stashDirectory, hashVal)
+}
+
+func (objSrv *ObjectServer) stashOrVerifyObject(reader io.Reader,
+	length uint64, expectedHash *hash.Hash) (hash.Hash, []byte, error) {
+	hashVal, data, err := objectcache.ReadObject(reader, length, expectedHash)
+	if err != nil {
+		return hashVal, nil, err
+	}
+	if size, _ := objSrv.checkObject(hashVal); size > 0 {
+		return hashVal, nil, nil
+	}
+	if err := objSrv.putObject(stashDirectory, hashVal, data); err != nil {
+		return hashVal, nil, err
+	}
+	return hashVal, data, nil
+}
@@ -0,0 +1,34 @@
+/*
+Package openmetrics exports tricorder metrics in the OpenMetrics text format,
+so that they may be scraped by Prometheus.
+
+Metric names are derived from the tricorder paths, with characters which are
+not valid in metric names replaced with underscores. Units are converted to
+base units (i.e. milliseconds are converted to seconds), times are converted
+to seconds since the Unix epoch and durations are converted to seconds.
+Numeric and boolean metrics are exported as gauges, strings are exported as
+info metrics with a "value" label, distributions are exported as histograms
+and list metrics are exported with an "index" label for each entry.
+
+Importing this package registers a handler for the /metrics path with the
+default HTTP mux. Requests from browsers (which accept text/html) are
+redirected to the tricorder metrics pages.
+*/
+package openmetrics
+
+import (
+	"io"
+	"net/http"
+)
+
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Handler returns a HTTP handler which writes all the tricorder metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(handler)
+}
+
+// WriteMetrics will write the tricorder metrics at or under path to writer.
+func WriteMetrics(writer io.Writer, path string) error {
+	return writeMetrics(writer, path)
+}
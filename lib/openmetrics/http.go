@@ -0,0 +1,27 @@
+package openmetrics
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+const metricsPath = "/metrics"
+
+func init() {
+	http.HandleFunc(metricsPath, handler)
+}
+
+func handler(w http.ResponseWriter, req *http.Request) {
+	if strings.Contains(req.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, req, metricsPath+"/", http.StatusFound)
+		return
+	}
+	buffer := &bytes.Buffer{}
+	if err := writeMetrics(buffer, "/"); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	buffer.WriteTo(w)
+}
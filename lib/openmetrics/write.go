@@ -0,0 +1,237 @@
+package openmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Cloud-Foundations/tricorder/go/tricorder"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder/messages"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder/types"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder/units"
+)
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+type metricWriter struct {
+	*bufio.Writer
+	metric *messages.Metric
+	name   string
+	scale  float64 // Multiply values by this to convert to base units.
+	unit   string
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// metricName converts a tricorder path into a valid metric name.
+func metricName(path string) string {
+	path = strings.Trim(path, "/")
+	buffer := make([]byte, 0, len(path)+1)
+	for index := 0; index < len(path); index++ {
+		ch := path[index]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch == '_',
+			ch == ':':
+		case ch >= '0' && ch <= '9':
+			if index == 0 {
+				buffer = append(buffer, '_')
+			}
+		default:
+			ch = '_'
+		}
+		buffer = append(buffer, ch)
+	}
+	return string(buffer)
+}
+
+// unitInfo returns the OpenMetrics unit and the scale factor to convert values
+// to that unit.
+func unitInfo(metric *messages.Metric) (string, float64) {
+	if metric.Kind == types.GoTime || metric.Kind == types.GoDuration ||
+		metric.SubType == types.GoTime || metric.SubType == types.GoDuration {
+		return "seconds", 1 // Converted by types.Type.ToFloat().
+	}
+	switch metric.Unit {
+	case units.Millisecond:
+		return "seconds", 1e-3
+	case units.Second:
+		return "seconds", 1
+	case units.Byte:
+		return "bytes", 1
+	case units.BytePerSecond:
+		return "bytes_per_second", 1
+	case units.Celsius:
+		return "celsius", 1
+	}
+	return "", 1
+}
+
+func writeMetrics(writer io.Writer, path string) error {
+	w := bufio.NewWriter(writer)
+	for _, metric := range tricorder.ReadMyMetrics(path) {
+		writeMetric(w, metric)
+	}
+	w.WriteString("# EOF\n")
+	return w.Flush()
+}
+
+func writeMetric(writer *bufio.Writer, metric *messages.Metric) {
+	name := metricName(metric.Path)
+	if name == "" {
+		return
+	}
+	var unit string
+	scale := 1.0
+	if metric.Kind != types.String && metric.SubType != types.String {
+		unit, scale = unitInfo(metric)
+	}
+	if unit != "" && !strings.HasSuffix(name, "_"+unit) {
+		name += "_" + unit
+	}
+	mw := &metricWriter{
+		Writer: writer,
+		metric: metric,
+		name:   name,
+		scale:  scale,
+		unit:   unit,
+	}
+	switch metric.Kind {
+	case types.Dist:
+		mw.writeDistribution()
+	case types.List:
+		mw.writeList()
+	case types.String:
+		mw.writeHeader("info")
+		mw.writeInfo("", metric.Value)
+	default:
+		if value, ok := mw.toFloat(metric.Kind, metric.Value); ok {
+			mw.writeHeader("gauge")
+			mw.writeSample("", "", value)
+		}
+	}
+}
+
+func (mw *metricWriter) toFloat(kind types.Type, value interface{}) (
+	float64, bool) {
+	if kind == types.Bool {
+		if value.(bool) {
+			return 1, true
+		}
+		return 0, true
+	}
+	if !kind.CanToFromFloat() {
+		return 0, false
+	}
+	result := kind.ToFloat(value)
+	if kind != types.GoTime && kind != types.GoDuration {
+		result *= mw.scale
+	}
+	return result, true
+}
+
+func (mw *metricWriter) writeDistribution() {
+	dist, ok := mw.metric.Value.(*messages.Distribution)
+	if !ok || dist == nil {
+		return
+	}
+	if dist.IsNotCumulative {
+		mw.writeHeader("gaugehistogram")
+	} else {
+		mw.writeHeader("histogram")
+	}
+	var count uint64
+	for index, bucket := range dist.Ranges {
+		count += bucket.Count
+		upper := math.Inf(1)
+		if index < len(dist.Ranges)-1 {
+			upper = bucket.Upper * mw.scale
+		}
+		mw.writeSample("_bucket",
+			fmt.Sprintf(`le="%s"`, formatFloat(upper)), float64(count))
+	}
+	if len(dist.Ranges) < 1 {
+		mw.writeSample("_bucket", `le="+Inf"`, float64(dist.Count))
+	}
+	if dist.IsNotCumulative {
+		mw.writeSample("_gcount", "", float64(dist.Count))
+		mw.writeSample("_gsum", "", dist.Sum*mw.scale)
+	} else {
+		mw.writeSample("_count", "", float64(dist.Count))
+		mw.writeSample("_sum", "", dist.Sum*mw.scale)
+	}
+}
+
+func (mw *metricWriter) writeHeader(metricType string) {
+	name := mw.name
+	if metricType == "info" {
+		name = strings.TrimSuffix(name, "_info")
+		mw.name = name + "_info"
+	}
+	fmt.Fprintf(mw, "# TYPE %s %s\n", name, metricType)
+	if mw.unit != "" && metricType != "info" {
+		fmt.Fprintf(mw, "# UNIT %s %s\n", name, mw.unit)
+	}
+	if mw.metric.Description != "" {
+		fmt.Fprintf(mw, "# HELP %s %s\n",
+			name, helpEscaper.Replace(mw.metric.Description))
+	}
+}
+
+func (mw *metricWriter) writeInfo(labels string, value interface{}) {
+	if labels != "" {
+		labels += ","
+	}
+	labels += `value="` + labelEscaper.Replace(fmt.Sprint(value)) + `"`
+	fmt.Fprintf(mw, "%s{%s} 1\n", mw.name, labels)
+}
+
+// writeList writes one sample per entry in the list, with an index label.
+func (mw *metricWriter) writeList() {
+	list := reflect.ValueOf(mw.metric.Value)
+	if list.Kind() != reflect.Slice {
+		return
+	}
+	subType := mw.metric.SubType
+	if subType == types.String {
+		mw.writeHeader("info")
+	} else if subType == types.Bool || subType.CanToFromFloat() {
+		mw.writeHeader("gauge")
+	} else {
+		return
+	}
+	for index := 0; index < list.Len(); index++ {
+		labels := fmt.Sprintf(`index="%d"`, index)
+		entry := list.Index(index).Interface()
+		if subType == types.String {
+			mw.writeInfo(labels, entry)
+		} else if value, ok := mw.toFloat(subType, entry); ok {
+			mw.writeSample("", labels, value)
+		}
+	}
+}
+
+func (mw *metricWriter) writeSample(suffix, labels string, value float64) {
+	if labels == "" {
+		fmt.Fprintf(mw, "%s%s %s\n", mw.name, suffix, formatFloat(value))
+	} else {
+		fmt.Fprintf(mw, "%s%s{%s} %s\n",
+			mw.name, suffix, labels, formatFloat(value))
+	}
+}
@@ -0,0 +1,98 @@
+package openmetrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/tricorder/go/tricorder"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder/units"
+)
+
+func mustRegister(t *testing.T, path string, metric interface{},
+	unit units.Unit, description string) {
+	if err := tricorder.RegisterMetric(path, metric, unit,
+		description); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	tests := map[string]string{
+		"/proc/cpu/user":    "proc_cpu_user",
+		"/image-count":      "image_count",
+		"/1st/metric":       "_1st_metric",
+		"/a.b/c d":          "a_b_c_d",
+		"/already_snake/ok": "already_snake_ok",
+	}
+	for path, expected := range tests {
+		if name := metricName(path); name != expected {
+			t.Errorf("metricName(%s): %s != %s", path, name, expected)
+		}
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	count := uint64(42)
+	latency := 1500 * time.Millisecond
+	name := "my \"server\""
+	enabled := true
+	milliseconds := 250.0
+	mustRegister(t, "/openmetrics-test/count", &count, units.None,
+		"number of things")
+	mustRegister(t, "/openmetrics-test/enabled", &enabled, units.None, "")
+	mustRegister(t, "/openmetrics-test/latency", &latency, units.Second,
+		"latency\nof things")
+	mustRegister(t, "/openmetrics-test/delay", &milliseconds,
+		units.Millisecond, "")
+	mustRegister(t, "/openmetrics-test/name", &name, units.None, "")
+	mustRegister(t, "/openmetrics-test/sizes",
+		tricorder.NewList([]int64{10, 20}, tricorder.ImmutableSlice),
+		units.Byte, "")
+	dist := tricorder.NewArbitraryBucketer(10, 100).
+		NewCumulativeDistribution()
+	mustRegister(t, "/openmetrics-test/dist", dist, units.Millisecond, "")
+	dist.Add(5.0)
+	dist.Add(50.0)
+	dist.Add(500.0)
+	buffer := &bytes.Buffer{}
+	if err := WriteMetrics(buffer, "/openmetrics-test"); err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	expectedLines := []string{
+		"# TYPE openmetrics_test_count gauge",
+		"# HELP openmetrics_test_count number of things",
+		"openmetrics_test_count 42",
+		"openmetrics_test_enabled 1",
+		"# UNIT openmetrics_test_latency_seconds seconds",
+		`# HELP openmetrics_test_latency_seconds latency\nof things`,
+		"openmetrics_test_latency_seconds 1.5",
+		"openmetrics_test_delay_seconds 0.25",
+		"# TYPE openmetrics_test_name info",
+		`openmetrics_test_name_info{value="my \"server\""} 1`,
+		`openmetrics_test_sizes_bytes{index="1"} 20`,
+		"# TYPE openmetrics_test_dist_seconds histogram",
+		`openmetrics_test_dist_seconds_bucket{le="0.01"} 1`,
+		`openmetrics_test_dist_seconds_bucket{le="0.1"} 2`,
+		`openmetrics_test_dist_seconds_bucket{le="+Inf"} 3`,
+		"openmetrics_test_dist_seconds_count 3",
+		"openmetrics_test_dist_seconds_sum 0.555",
+	}
+	lines := make(map[string]struct{})
+	for _, line := range strings.Split(output, "\n") {
+		lines[line] = struct{}{}
+	}
+	for _, line := range expectedLines {
+		if _, ok := lines[line]; !ok {
+			t.Errorf("missing line: %s", line)
+		}
+	}
+	if !strings.HasSuffix(output, "# EOF\n") {
+		t.Error("missing EOF marker")
+	}
+	if t.Failed() {
+		t.Log(output)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Package power defines a common interface to the power controls of physical
+machines. The controls are typically provided by a Baseboard Management
+Controller (BMC), which may be accessed with IPMI or Redfish.
+*/
+package power
+
+const (
+	StateUnknown State = iota
+	StateOff
+	StateOn
+
+	HealthCritical = "Critical"
+	HealthOK       = "OK"
+	HealthUnknown  = ""
+	HealthWarning  = "Warning"
+)
+
+type Driver interface {
+	GetHealth() (*Health, error)
+	GetPowerState() (State, error)
+	GetSerialNumber() (string, error)
+	PowerCycle() error // Powers on if the machine is off.
+	PowerOff() error   // Immediate, the OS is not shut down.
+	PowerOn() error
+	SetBootToPXE() error // Applies to the next boot only.
+}
+
+type Health struct {
+	Sensors []Sensor `json:",omitempty"`
+	Status  string   `json:",omitempty"` // One of the Health* constants.
+}
+
+type Sensor struct {
+	Name    string
+	Reading float64 `json:",omitempty"`
+	Status  string  `json:",omitempty"` // One of the Health* constants.
+	Units   string  `json:",omitempty"`
+}
+
+type State uint
+
+func (state State) String() string {
+	return state.string()
+}
+
+// TrimSerialNumber returns the serial number with surrounding whitespace
+// removed. Common bogus serial numbers are replaced with an empty string.
+func TrimSerialNumber(serialNumber string) string {
+	return trimSerialNumber(serialNumber)
+}
+
+// WorstHealth returns the more severe of the two health values.
+func WorstHealth(left, right string) string {
+	return worstHealth(left, right)
+}
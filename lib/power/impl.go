@@ -0,0 +1,46 @@
+package power
+
+import (
+	"strings"
+)
+
+var healthSeverity = map[string]uint{
+	HealthUnknown:  0,
+	HealthOK:       1,
+	HealthWarning:  2,
+	HealthCritical: 3,
+}
+
+func (state State) string() string {
+	switch state {
+	case StateUnknown:
+		return "unknown"
+	case StateOff:
+		return "off"
+	case StateOn:
+		return "on"
+	default:
+		return "BAD STATE"
+	}
+}
+
+func trimSerialNumber(serialNumber string) string {
+	serialNumber = strings.TrimSpace(serialNumber)
+	// Ignore some common bogus serial numbers.
+	switch serialNumber {
+	case "0123456789":
+		return ""
+	case "System Serial Number":
+		return ""
+	case "To be filled by O.E.M.":
+		return ""
+	}
+	return serialNumber
+}
+
+func worstHealth(left, right string) string {
+	if healthSeverity[right] > healthSeverity[left] {
+		return right
+	}
+	return left
+}
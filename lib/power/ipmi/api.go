@@ -0,0 +1,53 @@
+/*
+Package ipmi implements a power.Driver which uses the ipmitool utility to
+control a machine via its IPMI interface.
+*/
+package ipmi
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/power"
+)
+
+type Driver struct {
+	params Params
+}
+
+type Params struct {
+	Hostname     string // Hostname or IP address of the BMC.
+	PasswordFile string
+	Username     string
+}
+
+var _ power.Driver = (*Driver)(nil)
+
+func New(params Params) *Driver {
+	return &Driver{params: params}
+}
+
+func (d *Driver) GetHealth() (*power.Health, error) {
+	return d.getHealth()
+}
+
+func (d *Driver) GetPowerState() (power.State, error) {
+	return d.getPowerState()
+}
+
+func (d *Driver) GetSerialNumber() (string, error) {
+	return d.getSerialNumber()
+}
+
+func (d *Driver) PowerCycle() error {
+	return d.powerCycle()
+}
+
+func (d *Driver) PowerOff() error {
+	return d.run("chassis", "power", "off")
+}
+
+func (d *Driver) PowerOn() error {
+	return d.run("chassis", "power", "on")
+}
+
+func (d *Driver) SetBootToPXE() error {
+	return d.run("chassis", "bootdev", "pxe")
+}
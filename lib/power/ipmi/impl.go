@@ -0,0 +1,124 @@
+package ipmi
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/Cloud-Foundations/Dominator/lib/power"
+)
+
+func parseSensor(line string) (power.Sensor, bool) {
+	fields := strings.Split(line, "|")
+	if len(fields) != 5 {
+		return power.Sensor{}, false
+	}
+	sensor := power.Sensor{Name: strings.TrimSpace(fields[0])}
+	switch strings.TrimSpace(fields[2]) {
+	case "ok":
+		sensor.Status = power.HealthOK
+	case "nc":
+		sensor.Status = power.HealthWarning
+	case "cr", "nr":
+		sensor.Status = power.HealthCritical
+	default: // Not present or no reading.
+		return power.Sensor{}, false
+	}
+	readingFields := strings.Fields(fields[4])
+	if len(readingFields) > 0 {
+		if value, err := strconv.ParseFloat(readingFields[0], 64); err == nil {
+			sensor.Reading = value
+			sensor.Units = strings.Join(readingFields[1:], " ")
+		}
+	}
+	return sensor, true
+}
+
+func (d *Driver) command(args ...string) *exec.Cmd {
+	return exec.Command("ipmitool", append([]string{
+		"-f", d.params.PasswordFile,
+		"-H", d.params.Hostname,
+		"-I", "lanplus",
+		"-U", d.params.Username}, args...)...)
+}
+
+func (d *Driver) getHealth() (*power.Health, error) {
+	output, err := d.output("sdr", "elist")
+	if err != nil {
+		return nil, err
+	}
+	health := &power.Health{}
+	for _, line := range strings.Split(output, "\n") {
+		if sensor, ok := parseSensor(line); ok {
+			health.Sensors = append(health.Sensors, sensor)
+			health.Status = power.WorstHealth(health.Status, sensor.Status)
+		}
+	}
+	return health, nil
+}
+
+func (d *Driver) getPowerState() (power.State, error) {
+	output, err := d.output("chassis", "power", "status")
+	if err != nil {
+		return power.StateUnknown, err
+	}
+	if strings.Contains(output, "Power is off") {
+		return power.StateOff, nil
+	}
+	if strings.Contains(output, "Power is on") {
+		return power.StateOn, nil
+	}
+	return power.StateUnknown, nil
+}
+
+func (d *Driver) getSerialNumber() (string, error) {
+	output, err := d.output("fru", "print")
+	if err != nil {
+		return "", err
+	}
+	var boardSerial, productSerial string
+	for _, line := range strings.Split(output, "\n") {
+		splitLine := strings.Split(line, ":")
+		if len(splitLine) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(splitLine[0]) {
+		case "Board Serial":
+			boardSerial = power.TrimSerialNumber(splitLine[1])
+		case "Product Serial":
+			productSerial = power.TrimSerialNumber(splitLine[1])
+		}
+	}
+	if productSerial != "" {
+		return productSerial, nil
+	}
+	return boardSerial, nil
+}
+
+func (d *Driver) output(args ...string) (string, error) {
+	output, err := d.command(args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// powerCycle will cycle the power, or power on the machine if it is off, since
+// many BMCs refuse to cycle the power of a machine which is off.
+func (d *Driver) powerCycle() error {
+	if state, err := d.getPowerState(); err != nil {
+		return err
+	} else if state == power.StateOff {
+		return d.run("chassis", "power", "on")
+	}
+	return d.run("chassis", "power", "cycle")
+}
+
+func (d *Driver) run(args ...string) error {
+	output, err := d.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Package redfish implements a power.Driver which uses the DMTF Redfish REST API
+to control a machine via its BMC.
+*/
+package redfish
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/Cloud-Foundations/Dominator/lib/power"
+)
+
+type Driver struct {
+	baseUrl     string
+	httpClient  *http.Client
+	params      Params
+	mutex       sync.Mutex // Protect everything below.
+	chassisPath string
+	systemPath  string
+}
+
+type Params struct {
+	Address            string       // Hostname or URL of the BMC.
+	HttpClient         *http.Client // Default: constructed from parameters.
+	InsecureSkipVerify bool         // Do not verify the BMC certificate.
+	Password           string
+	Username           string
+}
+
+var _ power.Driver = (*Driver)(nil)
+
+func New(params Params) *Driver {
+	return newDriver(params)
+}
+
+func (d *Driver) GetHealth() (*power.Health, error) {
+	return d.getHealth()
+}
+
+func (d *Driver) GetPowerState() (power.State, error) {
+	return d.getPowerState()
+}
+
+func (d *Driver) GetSerialNumber() (string, error) {
+	return d.getSerialNumber()
+}
+
+func (d *Driver) PowerCycle() error {
+	return d.powerCycle()
+}
+
+func (d *Driver) PowerOff() error {
+	return d.reset("ForceOff")
+}
+
+func (d *Driver) PowerOn() error {
+	return d.reset("On")
+}
+
+func (d *Driver) SetBootToPXE() error {
+	return d.setBootToPXE()
+}
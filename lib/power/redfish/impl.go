@@ -0,0 +1,375 @@
+package redfish
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/power"
+)
+
+const (
+	chassisCollectionPath = "/redfish/v1/Chassis"
+	resetActionName       = "#ComputerSystem.Reset"
+	systemCollectionPath  = "/redfish/v1/Systems"
+)
+
+// Redfish Base message registry IDs for unsupported parameter values.
+var unsupportedMessageIds = []string{
+	"ActionParameterNotSupported",
+	"ActionParameterValueNotInList",
+	"PropertyValueNotInList",
+}
+
+type actionType struct {
+	AllowableValues []string `json:"ResetType@Redfish.AllowableValues"`
+	Target          string   `json:"target"`
+}
+
+type bootType struct {
+	BootSourceOverrideEnabled string
+	BootSourceOverrideTarget  string
+}
+
+type collectionType struct {
+	Members []linkType
+}
+
+type fanType struct {
+	Name         string
+	Reading      *float64
+	ReadingUnits string
+	Status       statusType
+}
+
+type linkType struct {
+	Id string `json:"@odata.id"`
+}
+
+type powerControlType struct {
+	Name               string
+	PowerConsumedWatts *float64
+	Status             statusType
+}
+
+type powerSupplyType struct {
+	Name   string
+	Status statusType
+}
+
+type powerType struct {
+	PowerControl  []powerControlType
+	PowerSupplies []powerSupplyType
+}
+
+// responseError is returned when the BMC responds with an error status.
+type responseError struct {
+	message    string
+	method     string
+	path       string
+	status     string
+	statusCode int
+}
+
+type resetRequestType struct {
+	ResetType string
+}
+
+type setBootRequestType struct {
+	Boot bootType
+}
+
+type statusType struct {
+	Health string
+	State  string
+}
+
+type systemType struct {
+	Actions      map[string]actionType
+	PowerState   string
+	SerialNumber string
+	Status       statusType
+}
+
+type temperatureType struct {
+	Name           string
+	ReadingCelsius *float64
+	Status         statusType
+}
+
+type thermalType struct {
+	Fans         []fanType
+	Temperatures []temperatureType
+}
+
+func addSensor(health *power.Health, name string, reading *float64,
+	units string, status statusType) {
+	if status.State == "Absent" {
+		return
+	}
+	sensor := power.Sensor{Name: name, Status: status.Health}
+	if reading != nil {
+		sensor.Reading = *reading
+		sensor.Units = units
+	}
+	health.Sensors = append(health.Sensors, sensor)
+	health.Status = power.WorstHealth(health.Status, sensor.Status)
+}
+
+func newDriver(params Params) *Driver {
+	baseUrl := strings.TrimSuffix(params.Address, "/")
+	if !strings.Contains(baseUrl, "://") {
+		baseUrl = "https://" + baseUrl
+	}
+	httpClient := params.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: time.Minute,
+			Transport: &http.Transport{
+				IdleConnTimeout: time.Minute,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: params.InsecureSkipVerify,
+				},
+			},
+		}
+	}
+	return &Driver{
+		baseUrl:    baseUrl,
+		httpClient: httpClient,
+		params:     params,
+	}
+}
+
+func (d *Driver) getChassisPath() (string, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.chassisPath != "" {
+		return d.chassisPath, nil
+	}
+	path, err := d.getFirstMember(chassisCollectionPath)
+	if err != nil {
+		return "", err
+	}
+	d.chassisPath = path
+	return path, nil
+}
+
+// getFirstMember returns the path of the first member of a collection.
+func (d *Driver) getFirstMember(collectionPath string) (string, error) {
+	var collection collectionType
+	if err := d.request("GET", collectionPath, nil, &collection); err != nil {
+		return "", err
+	}
+	if len(collection.Members) < 1 || collection.Members[0].Id == "" {
+		return "", fmt.Errorf("no members in: %s", collectionPath)
+	}
+	return collection.Members[0].Id, nil
+}
+
+func (d *Driver) getHealth() (*power.Health, error) {
+	system, _, err := d.getSystem()
+	if err != nil {
+		return nil, err
+	}
+	health := &power.Health{Status: system.Status.Health}
+	chassisPath, err := d.getChassisPath()
+	if err != nil {
+		return nil, err
+	}
+	var thermal thermalType
+	if err := d.request("GET", chassisPath+"/Thermal", nil,
+		&thermal); err != nil {
+		return nil, err
+	}
+	for _, temperature := range thermal.Temperatures {
+		addSensor(health, temperature.Name, temperature.ReadingCelsius,
+			"degrees C", temperature.Status)
+	}
+	for _, fan := range thermal.Fans {
+		addSensor(health, fan.Name, fan.Reading, fan.ReadingUnits, fan.Status)
+	}
+	var powerInfo powerType
+	if err := d.request("GET", chassisPath+"/Power", nil,
+		&powerInfo); err != nil {
+		return nil, err
+	}
+	for _, control := range powerInfo.PowerControl {
+		addSensor(health, control.Name, control.PowerConsumedWatts, "Watts",
+			control.Status)
+	}
+	for _, supply := range powerInfo.PowerSupplies {
+		addSensor(health, supply.Name, nil, "", supply.Status)
+	}
+	return health, nil
+}
+
+func (d *Driver) getPowerState() (power.State, error) {
+	system, _, err := d.getSystem()
+	if err != nil {
+		return power.StateUnknown, err
+	}
+	switch system.PowerState {
+	case "Off", "PoweringOff":
+		return power.StateOff, nil
+	case "On", "PoweringOn":
+		return power.StateOn, nil
+	}
+	return power.StateUnknown, nil
+}
+
+func (d *Driver) getSerialNumber() (string, error) {
+	system, _, err := d.getSystem()
+	if err != nil {
+		return "", err
+	}
+	return power.TrimSerialNumber(system.SerialNumber), nil
+}
+
+func (d *Driver) getSystem() (*systemType, string, error) {
+	systemPath, err := d.getSystemPath()
+	if err != nil {
+		return nil, "", err
+	}
+	var system systemType
+	if err := d.request("GET", systemPath, nil, &system); err != nil {
+		return nil, "", err
+	}
+	return &system, systemPath, nil
+}
+
+func (d *Driver) getSystemPath() (string, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.systemPath != "" {
+		return d.systemPath, nil
+	}
+	path, err := d.getFirstMember(systemCollectionPath)
+	if err != nil {
+		return "", err
+	}
+	d.systemPath = path
+	return path, nil
+}
+
+// isUnsupportedError returns true if the error is a response from the BMC
+// which indicates that the request is not supported.
+func isUnsupportedError(err error) bool {
+	rErr, ok := err.(*responseError)
+	if !ok {
+		return false
+	}
+	switch rErr.statusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusBadRequest:
+		for _, messageId := range unsupportedMessageIds {
+			if strings.Contains(rErr.message, messageId) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.path, e.status, e.message)
+}
+
+// powerCycle will cycle the power, or power on the machine if it is off. Not
+// all BMCs support the PowerCycle reset type, so fall back to ForceRestart if
+// the BMC does not list PowerCycle as an allowable value or if it reports that
+// PowerCycle is not supported.
+func (d *Driver) powerCycle() error {
+	system, _, err := d.getSystem()
+	if err != nil {
+		return err
+	}
+	switch system.PowerState {
+	case "Off", "PoweringOff":
+		return d.reset("On")
+	}
+	allowableValues := system.Actions[resetActionName].AllowableValues
+	if len(allowableValues) > 0 {
+		for _, resetType := range []string{"PowerCycle", "ForceRestart"} {
+			for _, value := range allowableValues {
+				if value == resetType {
+					return d.reset(resetType)
+				}
+			}
+		}
+		return fmt.Errorf("no power cycle reset type in: %v", allowableValues)
+	}
+	if err := d.reset("PowerCycle"); !isUnsupportedError(err) {
+		return err
+	}
+	return d.reset("ForceRestart")
+}
+
+func (d *Driver) request(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, d.baseUrl+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(d.params.Username, d.params.Password)
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &responseError{
+			message:    strings.TrimSpace(string(message)),
+			method:     method,
+			path:       path,
+			status:     resp.Status,
+			statusCode: resp.StatusCode,
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (d *Driver) reset(resetType string) error {
+	system, systemPath, err := d.getSystem()
+	if err != nil {
+		return err
+	}
+	target := systemPath + "/Actions/ComputerSystem.Reset"
+	if action, ok := system.Actions[resetActionName]; ok &&
+		action.Target != "" {
+		target = action.Target
+	}
+	return d.request("POST", target, resetRequestType{resetType}, nil)
+}
+
+func (d *Driver) setBootToPXE() error {
+	systemPath, err := d.getSystemPath()
+	if err != nil {
+		return err
+	}
+	return d.request("PATCH", systemPath, setBootRequestType{
+		bootType{
+			BootSourceOverrideEnabled: "Once",
+			BootSourceOverrideTarget:  "Pxe",
+		}}, nil)
+}
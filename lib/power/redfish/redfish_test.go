@@ -0,0 +1,260 @@
+package redfish
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/power"
+)
+
+const (
+	testChassisPath = "/redfish/v1/Chassis/1"
+	testPassword    = "secret"
+	testSystemPath  = "/redfish/v1/Systems/1"
+	testUsername    = "admin"
+)
+
+// mockBmcType is a minimal Redfish service for a single system.
+type mockBmcType struct {
+	mutex           sync.Mutex
+	allowableResets []string // Advertised reset types.
+	bootOverride    bootType
+	failResets      bool // If true, resets fail with an internal error.
+	powerState      string
+	resets          []string
+}
+
+func newMockBmc(powerState string) (*mockBmcType, *httptest.Server) {
+	bmc := &mockBmcType{powerState: powerState}
+	return bmc, httptest.NewServer(bmc)
+}
+
+func (bmc *mockBmcType) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if username, password, ok := req.BasicAuth(); !ok ||
+		username != testUsername || password != testPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	bmc.mutex.Lock()
+	defer bmc.mutex.Unlock()
+	switch req.Method + " " + req.URL.Path {
+	case "GET " + chassisCollectionPath:
+		writeJson(w, collectionType{[]linkType{{testChassisPath}}})
+	case "GET " + systemCollectionPath:
+		writeJson(w, collectionType{[]linkType{{testSystemPath}}})
+	case "GET " + testSystemPath:
+		writeJson(w, systemType{
+			Actions: map[string]actionType{resetActionName: {
+				AllowableValues: bmc.allowableResets,
+				Target: testSystemPath +
+					"/Actions/ComputerSystem.Reset",
+			}},
+			PowerState:   bmc.powerState,
+			SerialNumber: " SN1234 ",
+			Status:       statusType{Health: power.HealthOK},
+		})
+	case "PATCH " + testSystemPath:
+		var request setBootRequestType
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bmc.bootOverride = request.Boot
+		w.WriteHeader(http.StatusNoContent)
+	case "POST " + testSystemPath + "/Actions/ComputerSystem.Reset":
+		var request resetRequestType
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if bmc.failResets {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch request.ResetType {
+		case "On":
+			bmc.powerState = "On"
+		case "ForceOff":
+			bmc.powerState = "Off"
+		case "ForceRestart":
+		default: // Like many BMCs, PowerCycle is not supported.
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"@Message.ExtendedInfo":[{` +
+				`"MessageId":"Base.1.8.ActionParameterNotSupported"}]}}`))
+			return
+		}
+		bmc.resets = append(bmc.resets, request.ResetType)
+		w.WriteHeader(http.StatusNoContent)
+	case "GET " + testChassisPath + "/Power":
+		watts := 180.0
+		writeJson(w, powerType{
+			PowerControl: []powerControlType{{
+				Name:               "System Power",
+				PowerConsumedWatts: &watts,
+				Status:             statusType{power.HealthOK, "Enabled"},
+			}},
+			PowerSupplies: []powerSupplyType{
+				{"PSU1", statusType{power.HealthOK, "Enabled"}},
+				{"PSU2", statusType{"", "Absent"}},
+			},
+		})
+	case "GET " + testChassisPath + "/Thermal":
+		celsius := 41.0
+		writeJson(w, thermalType{
+			Temperatures: []temperatureType{{
+				Name:           "CPU1 Temp",
+				ReadingCelsius: &celsius,
+				Status:         statusType{power.HealthWarning, "Enabled"},
+			}},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeJson(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func newTestDriver(server *httptest.Server) *Driver {
+	return New(Params{
+		Address:  server.URL,
+		Password: testPassword,
+		Username: testUsername,
+	})
+}
+
+func TestPowerControl(t *testing.T) {
+	bmc, server := newMockBmc("Off")
+	defer server.Close()
+	driver := newTestDriver(server)
+	if state, err := driver.GetPowerState(); err != nil {
+		t.Fatal(err)
+	} else if state != power.StateOff {
+		t.Fatalf("expected state: off, got: %s", state)
+	}
+	if err := driver.SetBootToPXE(); err != nil {
+		t.Fatal(err)
+	}
+	if bmc.bootOverride.BootSourceOverrideTarget != "Pxe" ||
+		bmc.bootOverride.BootSourceOverrideEnabled != "Once" {
+		t.Fatalf("PXE boot not set: %v", bmc.bootOverride)
+	}
+	if err := driver.PowerCycle(); err != nil { // Off: should power on.
+		t.Fatal(err)
+	}
+	if err := driver.PowerCycle(); err != nil { // Falls back to ForceRestart.
+		t.Fatal(err)
+	}
+	if err := driver.PowerOff(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"On", "ForceRestart", "ForceOff"}
+	if len(bmc.resets) != len(expected) {
+		t.Fatalf("expected resets: %v, got: %v", expected, bmc.resets)
+	}
+	for index, resetType := range expected {
+		if bmc.resets[index] != resetType {
+			t.Fatalf("expected resets: %v, got: %v", expected, bmc.resets)
+		}
+	}
+	if state, err := driver.GetPowerState(); err != nil {
+		t.Fatal(err)
+	} else if state != power.StateOff {
+		t.Fatalf("expected state: off, got: %s", state)
+	}
+}
+
+func TestPowerCycle(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowableResets []string
+		failResets      bool
+		expectError     bool
+		expectedResets  []string
+	}{
+		{
+			name:           "PowerCycle unsupported",
+			expectedResets: []string{"ForceRestart"},
+		},
+		{
+			name:            "ForceRestart allowed",
+			allowableResets: []string{"On", "ForceOff", "ForceRestart"},
+			expectedResets:  []string{"ForceRestart"},
+		},
+		{
+			name:            "no restart allowed",
+			allowableResets: []string{"On", "ForceOff"},
+			expectError:     true,
+		},
+		{
+			name:        "reset error",
+			failResets:  true,
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		bmc, server := newMockBmc("On")
+		bmc.allowableResets = test.allowableResets
+		bmc.failResets = test.failResets
+		err := newTestDriver(server).PowerCycle()
+		server.Close()
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if len(bmc.resets) != len(test.expectedResets) {
+			t.Errorf("%s: expected resets: %v, got: %v",
+				test.name, test.expectedResets, bmc.resets)
+			continue
+		}
+		for index, resetType := range test.expectedResets {
+			if bmc.resets[index] != resetType {
+				t.Errorf("%s: expected resets: %v, got: %v",
+					test.name, test.expectedResets, bmc.resets)
+			}
+		}
+	}
+}
+
+func TestReadouts(t *testing.T) {
+	_, server := newMockBmc("On")
+	defer server.Close()
+	driver := newTestDriver(server)
+	if serial, err := driver.GetSerialNumber(); err != nil {
+		t.Fatal(err)
+	} else if serial != "SN1234" {
+		t.Fatalf("expected serial: SN1234, got: \"%s\"", serial)
+	}
+	health, err := driver.GetHealth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != power.HealthWarning {
+		t.Fatalf("expected health: %s, got: %s",
+			power.HealthWarning, health.Status)
+	}
+	if len(health.Sensors) != 3 {
+		t.Fatalf("expected 3 sensors, got: %v", health.Sensors)
+	}
+	if sensor := health.Sensors[0]; sensor.Name != "CPU1 Temp" ||
+		sensor.Reading != 41 || sensor.Units != "degrees C" {
+		t.Fatalf("bad temperature sensor: %v", sensor)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	_, server := newMockBmc("On")
+	defer server.Close()
+	driver := New(Params{Address: server.URL, Username: testUsername})
+	if _, err := driver.GetPowerState(); err == nil {
+		t.Fatal("no error for bad credentials")
+	}
+}
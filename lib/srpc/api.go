@@ -61,6 +61,8 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/log/debuglogger"
 	libnet "github.com/Cloud-Foundations/Dominator/lib/net"
 	"github.com/Cloud-Foundations/Dominator/lib/resourcepool"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+	"github.com/Cloud-Foundations/Dominator/proto/auditlog"
 )
 
 var (
@@ -144,10 +146,41 @@ func LoadCertificatesFromMetadata(timeout time.Duration, errorIfMissing bool,
 	return loadCertificatesFromMetadata(timeout, errorIfMissing, errorIfExpired)
 }
 
+// AuditLogger defines an interface for recording calls to methods which may
+// mutate state.
+type AuditLogger interface {
+	// LogCall is called after each call to a method which may mutate state.
+	LogCall(record auditlog.AuditRecord)
+}
+
+const (
+	PolicyActionNone  PolicyAction = iota // No rule matched.
+	PolicyActionGrant                     // Access granted.
+	PolicyActionDeny                      // Access denied.
+)
+
 type AuthInformation struct {
 	GroupList        map[string]struct{}
 	HaveMethodAccess bool
 	Username         string
+	remoteAddress    string
+	serviceMethod    string
+}
+
+// CheckPolicyForTarget returns true if the authorisation policy (see
+// SetAuthorisationPolicy) grants access to the target of the method call, which
+// has the specified tags. Method handlers may call this if the built-in
+// ownership checks do not grant access to a resource such as a VM.
+func (authInfo *AuthInformation) CheckPolicyForTarget(
+	targetTags tags.Tags) bool {
+	return authInfo.checkPolicyForTarget(targetTags)
+}
+
+// AuthorisationPolicy defines an interface for a declarative policy which is
+// evaluated for each method call.
+type AuthorisationPolicy interface {
+	// Evaluate is called to check if access should be granted or denied.
+	Evaluate(request PolicyRequest) PolicyDecision
 }
 
 type ClientI interface {
@@ -175,6 +208,24 @@ type Encoder interface {
 
 type FakeClientOptions struct{}
 
+type PolicyAction uint
+
+type PolicyDecision struct {
+	Action PolicyAction
+	Rule   string // Name of the matching rule.
+}
+
+// PolicyRequest contains the information about a method call which is used to
+// evaluate an AuthorisationPolicy.
+type PolicyRequest struct {
+	GroupList     map[string]struct{}
+	RemoteAddress string
+	ServiceMethod string
+	TargetTags    tags.Tags // nil: no target is being checked.
+	Time          time.Time
+	Username      string
+}
+
 // MethodBlocker defines an interface to block method calls (after possible
 // authorisation) for a receiver (passed to RegisterName). This may be used to
 // attach rate limiting polcies for method calls.
@@ -255,6 +306,25 @@ type ClientResource struct {
 	closeError            error
 }
 
+// SetAuditLogger registers auditLogger which will be called after each call to
+// a method which may mutate state. Methods with names starting with a read-only
+// prefix (such as Get, List, Poll and Watch) are not audited. Request messages
+// for request-reply methods are summarised, with fields whose names suggest
+// sensitive data (such as Password, Token and UserData) redacted.
+func SetAuditLogger(auditLogger AuditLogger) {
+	setAuditLogger(auditLogger)
+}
+
+// SetAuthorisationPolicy registers policy which will be evaluated for each
+// method call. If the policy denies access, the call is rejected, even if
+// access would be granted by the built-in authorisation mechanism or the method
+// is public. If the policy grants access, the method is called with method
+// access. Otherwise, the built-in authorisation mechanism is used. Decisions
+// are logged. If policy is nil, no policy is evaluated.
+func SetAuthorisationPolicy(policy AuthorisationPolicy) {
+	setAuthorisationPolicy(policy)
+}
+
 // SetDefaultGrantMethod registers the grantMethod function which will be
 // called to grant access to methods (if access is not granted by the built-in
 // authorisation mechanism) for all receivers. This is overridden by receivers
@@ -489,6 +559,7 @@ type Conn struct {
 	permittedMethods  map[string]struct{} // nil: all, empty: none permitted.
 	releaseNotifier   func()
 	remoteAddr        string
+	serviceMethod     string // The method being called (server-side).
 	username          string // Empty string for unauthenticated.
 }
 
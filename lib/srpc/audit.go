@@ -0,0 +1,200 @@
+package srpc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	proto "github.com/Cloud-Foundations/Dominator/proto/auditlog"
+)
+
+const (
+	maxAuditDepth          = 8
+	maxAuditSliceLength    = 32
+	maxAuditStringLength   = 256
+	maxAuditRequestSummary = 4096
+)
+
+type callInfo struct {
+	methodError    error
+	requestSummary string
+}
+
+var (
+	auditLogger AuditLogger
+
+	readOnlyMethodPrefixes = []string{
+		"Check",
+		"Find",
+		"Get",
+		"List",
+		"Ping",
+		"Poll",
+		"Probe",
+		"Test",
+		"Watch",
+	}
+
+	sensitiveFieldNames = []string{
+		"certificate",
+		"credential",
+		"key",
+		"password",
+		"secret",
+		"token",
+		"userdata",
+	}
+)
+
+// isMutatingMethod returns true if the method may mutate state, based on the
+// name of the method.
+func isMutatingMethod(methodName string) bool {
+	for _, prefix := range readOnlyMethodPrefixes {
+		if strings.HasPrefix(methodName, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSensitiveFieldName(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitiveName := range sensitiveFieldNames {
+		if strings.Contains(name, sensitiveName) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue returns a representation of value suitable for encoding as JSON,
+// with sensitive fields removed and large values summarised.
+func redactValue(value reflect.Value, depth int) interface{} {
+	if depth > maxAuditDepth {
+		return "..."
+	}
+	if !value.IsValid() {
+		return nil
+	}
+	if value.CanInterface() {
+		if _, ok := value.Interface().(encoding.TextMarshaler); ok {
+			return value.Interface()
+		}
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return redactValue(value.Elem(), depth+1)
+	case reflect.Struct:
+		result := make(map[string]interface{})
+		valueType := value.Type()
+		for index := 0; index < value.NumField(); index++ {
+			field := valueType.Field(index)
+			if !field.IsExported() {
+				continue
+			}
+			fieldValue := value.Field(index)
+			if fieldValue.IsZero() {
+				continue
+			}
+			if isSensitiveFieldName(field.Name) {
+				result[field.Name] = "<redacted>"
+				continue
+			}
+			if field.Anonymous {
+				if embedded, ok := redactValue(fieldValue,
+					depth+1).(map[string]interface{}); ok {
+					for key, value := range embedded {
+						result[key] = value
+					}
+					continue
+				}
+			}
+			result[field.Name] = redactValue(fieldValue, depth+1)
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("<%d bytes>", value.Len())
+		}
+		if value.Len() > maxAuditSliceLength {
+			return fmt.Sprintf("<%d entries>", value.Len())
+		}
+		result := make([]interface{}, 0, value.Len())
+		for index := 0; index < value.Len(); index++ {
+			result = append(result, redactValue(value.Index(index), depth+1))
+		}
+		return result
+	case reflect.Map:
+		if value.Len() > maxAuditSliceLength {
+			return fmt.Sprintf("<%d entries>", value.Len())
+		}
+		result := make(map[string]interface{}, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if isSensitiveFieldName(key) {
+				result[key] = "<redacted>"
+			} else {
+				result[key] = redactValue(iter.Value(), depth+1)
+			}
+		}
+		return result
+	case reflect.String:
+		if value.Len() > maxAuditStringLength {
+			return fmt.Sprintf("%s...<%d bytes>",
+				value.String()[:maxAuditStringLength], value.Len())
+		}
+		return value.String()
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return nil
+	}
+	if value.CanInterface() {
+		return value.Interface()
+	}
+	return nil
+}
+
+// summariseRequest returns a redacted summary of a request message.
+func summariseRequest(request reflect.Value) string {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactValue(request, 0)); err != nil {
+		return "<" + err.Error() + ">"
+	}
+	data := bytes.TrimSpace(buffer.Bytes())
+	if len(data) > maxAuditRequestSummary {
+		return string(data[:maxAuditRequestSummary]) + "..."
+	}
+	return string(data)
+}
+
+func setAuditLogger(logger AuditLogger) {
+	auditLogger = logger
+}
+
+func (m *methodWrapper) audit(conn *Conn, startTime time.Time,
+	info callInfo) {
+	if auditLogger == nil || !m.mutating {
+		return
+	}
+	record := proto.AuditRecord{
+		Duration:   time.Since(startTime),
+		Method:     m.serviceMethod,
+		RemoteAddr: conn.remoteAddr,
+		Request:    info.requestSummary,
+		StartTime:  startTime,
+		Username:   conn.username,
+	}
+	if info.methodError != nil && info.methodError != ErrorCloseClient {
+		record.Error = info.methodError.Error()
+	}
+	auditLogger.LogCall(record)
+}
@@ -0,0 +1,57 @@
+package srpc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type auditTestRequest struct {
+	Hostname   string
+	Password   string
+	Data       []byte
+	Names      []string
+	UserData   map[string]string
+	unexported string
+}
+
+func TestIsMutatingMethod(t *testing.T) {
+	for _, name := range []string{"GetImage", "ListVMs", "Poll"} {
+		if isMutatingMethod(name) {
+			t.Errorf("%s reported as mutating", name)
+		}
+	}
+	for _, name := range []string{"AddImage", "DestroyVm", "Update"} {
+		if !isMutatingMethod(name) {
+			t.Errorf("%s not reported as mutating", name)
+		}
+	}
+}
+
+func TestSummariseRequest(t *testing.T) {
+	request := auditTestRequest{
+		Hostname:   "host.example.com",
+		Password:   "hunter2",
+		Data:       make([]byte, 100),
+		Names:      make([]string, maxAuditSliceLength+1),
+		UserData:   map[string]string{"a": "b"},
+		unexported: "hidden",
+	}
+	summary := summariseRequest(reflect.ValueOf(&request))
+	for _, wanted := range []string{
+		`"Hostname":"host.example.com"`,
+		`"Password":"<redacted>"`,
+		`"Data":"<100 bytes>"`,
+		`"Names":"<33 entries>"`,
+		`"UserData":"<redacted>"`,
+	} {
+		if !strings.Contains(summary, wanted) {
+			t.Errorf("summary: %s does not contain: %s", summary, wanted)
+		}
+	}
+	for _, unwanted := range []string{"hunter2", "hidden"} {
+		if strings.Contains(summary, unwanted) {
+			t.Errorf("summary: %s contains: %s", summary, unwanted)
+		}
+	}
+}
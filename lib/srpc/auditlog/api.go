@@ -0,0 +1,92 @@
+/*
+Package auditlog records calls to SRPC methods which may mutate state.
+
+Package auditlog implements the srpc.AuditLogger interface, writing a record for
+each call to a method which may mutate state to a rotating, append-only file of
+JSON records, one per line. Records may be retrieved with the
+AuditLog.GetAuditLog SRPC method and viewed at the /showAuditLog HTTP path.
+*/
+package auditlog
+
+import (
+	"flag"
+	"os"
+	"sync"
+
+	"github.com/Cloud-Foundations/Dominator/lib/flagutil"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	proto "github.com/Cloud-Foundations/Dominator/proto/auditlog"
+)
+
+var (
+	auditLogDirectory = flag.String("auditLogDirectory", "",
+		"Directory to write audit log of mutating SRPC calls to. If empty, no audit log is written")
+	auditLogMaxFileSize = flagutil.Size(10 << 20)
+	auditLogQuota       = flagutil.Size(1 << 30)
+
+	setupOnce sync.Once
+)
+
+func init() {
+	flag.Var(&auditLogMaxFileSize, "auditLogMaxFileSize",
+		"Maximum size for an audit log file. If exceeded, new file is created")
+	flag.Var(&auditLogQuota, "auditLogQuota",
+		"Audit log quota. If exceeded, old audit logs are deleted")
+}
+
+type AuditLog struct {
+	options  Options
+	mutex    sync.Mutex // Protect everything below.
+	file     *os.File
+	fileSize flagutil.Size
+	usage    flagutil.Size
+}
+
+type Options struct {
+	Directory   string
+	Logger      log.DebugLogger
+	MaxFileSize flagutil.Size // Minimum: 16 KiB.
+	Quota       flagutil.Size // Minimum: 64 KiB.
+}
+
+// New will create an *AuditLog which writes records to the directory specified
+// in options.
+func New(options Options) (*AuditLog, error) {
+	return newAuditLog(options)
+}
+
+// SetupFromFlags will create an *AuditLog using the command-line flags, register
+// it with the srpc package and register the AuditLog SRPC receiver and HTTP
+// handlers. If the -auditLogDirectory flag is empty, nothing is done. This
+// should be called once by servers.
+// The following command-line flags are registered and used:
+//
+//	-auditLogDirectory:   Directory to write audit logs to
+//	-auditLogMaxFileSize: Maximum size for each audit log file
+//	-auditLogQuota:       Audit log quota. If exceeded, old files are deleted
+func SetupFromFlags(logger log.DebugLogger) error {
+	return setupFromFlags(logger)
+}
+
+// GetRecords will call fn for each record which matches the request, oldest
+// first. If request.MaxRecords is non-zero, only the most recent matching
+// records are passed. If fn returns an error, processing stops and the error
+// is returned.
+func (al *AuditLog) GetRecords(request proto.GetAuditLogRequest,
+	fn func(proto.AuditRecord) error) error {
+	return al.getRecords(request, fn)
+}
+
+// LogCall will write record to the audit log. It implements the
+// srpc.AuditLogger interface.
+func (al *AuditLog) LogCall(record proto.AuditRecord) {
+	al.logCall(record)
+}
+
+// GetAuditLog will call the AuditLog.GetAuditLog SRPC method on the server and
+// call fn for each record received.
+func GetAuditLog(client srpc.ClientI, request proto.GetAuditLogRequest,
+	fn func(proto.AuditRecord) error) error {
+	return getAuditLog(client, request, fn)
+}
@@ -0,0 +1,39 @@
+package auditlog
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	proto "github.com/Cloud-Foundations/Dominator/proto/auditlog"
+)
+
+func getAuditLog(client srpc.ClientI, request proto.GetAuditLogRequest,
+	fn func(proto.AuditRecord) error) error {
+	conn, err := client.Call("AuditLog.GetAuditLog")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.Encode(request); err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	for {
+		var reply proto.GetAuditLogResponse
+		if err := conn.Decode(&reply); err != nil {
+			return err
+		}
+		if err := errors.New(reply.Error); err != nil {
+			return err
+		}
+		if len(reply.Records) < 1 {
+			return nil
+		}
+		for _, record := range reply.Records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+}
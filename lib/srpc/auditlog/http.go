@@ -0,0 +1,93 @@
+package auditlog
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	libhtml "github.com/Cloud-Foundations/Dominator/lib/html"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/url"
+	proto "github.com/Cloud-Foundations/Dominator/proto/auditlog"
+)
+
+const defaultMaxRecords = 1000
+
+func (al *AuditLog) registerHttpHandlers() {
+	libhtml.HandleFunc("/showAuditLog", al.showAuditLogHandler)
+}
+
+func (al *AuditLog) showAuditLogHandler(w http.ResponseWriter,
+	req *http.Request) {
+	parsedQuery := url.ParseQuery(req.URL)
+	request := proto.GetAuditLogRequest{
+		MaxRecords: defaultMaxRecords,
+		Method:     req.URL.Query().Get("method"),
+		Username:   req.URL.Query().Get("user"),
+	}
+	if value := req.URL.Query().Get("count"); value != "" {
+		if val, err := strconv.ParseUint(value, 10, 64); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, err)
+			return
+		} else {
+			request.MaxRecords = uint(val)
+		}
+	}
+	if _, ok := parsedQuery.Table["last"]; ok {
+		if duration, err := parsedQuery.Last(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, err)
+			return
+		} else {
+			request.Since = time.Now().Add(-duration)
+		}
+	}
+	var records []proto.AuditRecord
+	err := al.GetRecords(request, func(record proto.AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, err)
+		return
+	}
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	if parsedQuery.OutputType() == url.OutputTypeJson {
+		json.WriteWithIndent(writer, "    ", records)
+		return
+	}
+	fmt.Fprintln(writer, "<title>Audit log</title>")
+	fmt.Fprintln(writer, "<body>")
+	fmt.Fprintln(writer, "<center>")
+	fmt.Fprintln(writer, "<h2>Audit log</h2>")
+	fmt.Fprintln(writer, "</center>")
+	fmt.Fprintf(writer, "Showing %d most recent matching records", len(records))
+	fmt.Fprintln(writer, ` (<a href="showAuditLog?output=json">JSON</a>)<br>`)
+	fmt.Fprintln(writer, `<table border="1" style="width:100%">`)
+	tw, _ := libhtml.NewTableWriter(writer, true, "Start Time", "User",
+		"Method", "Remote Address", "Duration", "Error", "Request")
+	for index := len(records) - 1; index >= 0; index-- {
+		record := records[index]
+		var foreground string
+		if record.Error != "" {
+			foreground = "red"
+		}
+		tw.WriteRow(foreground, "",
+			record.StartTime.Local().Format(format.TimeFormatSeconds),
+			html.EscapeString(record.Username),
+			html.EscapeString(record.Method),
+			html.EscapeString(record.RemoteAddr),
+			format.Duration(record.Duration),
+			html.EscapeString(record.Error),
+			html.EscapeString(record.Request))
+	}
+	tw.Close()
+	fmt.Fprintln(writer, "</body>")
+}
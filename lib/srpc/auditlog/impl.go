@@ -0,0 +1,265 @@
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/flagutil"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/log/nulllogger"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	proto "github.com/Cloud-Foundations/Dominator/proto/auditlog"
+)
+
+const (
+	dirPerms    = 0700
+	filePerms   = 0600
+	fileSuffix  = ".jsonl"
+	timeLayout  = "2006-01-02:15:04:05.000000"
+	maxLineSize = 1 << 20
+)
+
+func setupFromFlags(logger log.DebugLogger) error {
+	if *auditLogDirectory == "" {
+		return nil
+	}
+	var err error
+	setupOnce.Do(func() {
+		var auditLog *AuditLog
+		auditLog, err = newAuditLog(Options{
+			Directory:   *auditLogDirectory,
+			Logger:      logger,
+			MaxFileSize: auditLogMaxFileSize,
+			Quota:       auditLogQuota,
+		})
+		if err != nil {
+			return
+		}
+		srpc.SetAuditLogger(auditLog)
+		if err = auditLog.registerServer(); err != nil {
+			return
+		}
+		auditLog.registerHttpHandlers()
+	})
+	return err
+}
+
+func newAuditLog(options Options) (*AuditLog, error) {
+	if options.Logger == nil {
+		options.Logger = nulllogger.New()
+	}
+	if options.MaxFileSize < 16<<10 {
+		options.MaxFileSize = 16 << 10
+	}
+	if options.Quota < 64<<10 {
+		options.Quota = 64 << 10
+	}
+	if err := os.MkdirAll(options.Directory, dirPerms); err != nil {
+		return nil, err
+	}
+	al := &AuditLog{options: options}
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+	if err := al.enforceQuota(); err != nil {
+		return nil, err
+	}
+	if err := al.openNewFile(); err != nil {
+		return nil, err
+	}
+	return al, nil
+}
+
+// listFiles returns the names of the audit log files, oldest first.
+func (al *AuditLog) listFiles() ([]string, error) {
+	file, err := os.Open(al.options.Directory)
+	if err != nil {
+		return nil, err
+	}
+	names, err := file.Readdirnames(-1)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	filenames := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasSuffix(name, fileSuffix) {
+			filenames = append(filenames, name)
+		}
+	}
+	sort.Strings(filenames)
+	return filenames, nil
+}
+
+// This should be called with the lock held.
+func (al *AuditLog) enforceQuota() error {
+	names, err := al.listFiles()
+	if err != nil {
+		return err
+	}
+	var usage flagutil.Size
+	for index := len(names) - 1; index >= 0; index-- {
+		filename := filepath.Join(al.options.Directory, names[index])
+		fi, err := os.Stat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		size := flagutil.Size(fi.Size())
+		if al.file != nil && index == len(names)-1 {
+			usage += size // Never delete the current file.
+			continue
+		}
+		if usage+size > al.options.Quota {
+			if err := os.Remove(filename); err != nil {
+				return err
+			}
+			al.options.Logger.Printf("deleted old audit log: %s\n", filename)
+			continue
+		}
+		usage += size
+	}
+	al.usage = usage
+	return nil
+}
+
+// This should be called with the lock held.
+func (al *AuditLog) openNewFile() error {
+	if al.file != nil {
+		al.file.Close()
+		al.file = nil
+	}
+	filename := filepath.Join(al.options.Directory,
+		time.Now().UTC().Format(timeLayout)+fileSuffix)
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		filePerms)
+	if err != nil {
+		return err
+	}
+	al.file = file
+	al.fileSize = 0
+	return nil
+}
+
+func (al *AuditLog) logCall(record proto.AuditRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		al.options.Logger.Printf("error encoding audit record: %s\n", err)
+		return
+	}
+	data = append(data, '\n')
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+	if al.file == nil {
+		if err := al.openNewFile(); err != nil {
+			al.options.Logger.Printf("error opening audit log: %s\n", err)
+			return
+		}
+	}
+	if _, err := al.file.Write(data); err != nil {
+		al.options.Logger.Printf("error writing audit log: %s\n", err)
+		return
+	}
+	al.fileSize += flagutil.Size(len(data))
+	al.usage += flagutil.Size(len(data))
+	if al.fileSize > al.options.MaxFileSize {
+		if err := al.openNewFile(); err != nil {
+			al.options.Logger.Printf("error opening audit log: %s\n", err)
+		}
+	}
+	if al.usage > al.options.Quota {
+		if err := al.enforceQuota(); err != nil {
+			al.options.Logger.Printf("error enforcing audit log quota: %s\n",
+				err)
+		}
+	}
+}
+
+func (al *AuditLog) getRecords(request proto.GetAuditLogRequest,
+	fn func(proto.AuditRecord) error) error {
+	if request.Method != "" {
+		if _, err := filepath.Match(request.Method, ""); err != nil {
+			return fmt.Errorf("bad Method pattern: %s", err)
+		}
+	}
+	al.mutex.Lock()
+	names, err := al.listFiles()
+	al.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	var records []proto.AuditRecord
+	for _, name := range names {
+		err := al.readFile(filepath.Join(al.options.Directory, name),
+			func(record proto.AuditRecord) error {
+				if !matchRecord(request, record) {
+					return nil
+				}
+				if request.MaxRecords < 1 {
+					return fn(record)
+				}
+				records = append(records, record)
+				if uint(len(records)) > request.MaxRecords {
+					records = records[1:]
+				}
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (al *AuditLog) readFile(filename string,
+	fn func(proto.AuditRecord) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) { // Deleted by quota enforcement.
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	for scanner.Scan() {
+		var record proto.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Ignore partial/corrupt records.
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func matchRecord(request proto.GetAuditLogRequest,
+	record proto.AuditRecord) bool {
+	if !request.Since.IsZero() && record.StartTime.Before(request.Since) {
+		return false
+	}
+	if request.Username != "" && record.Username != request.Username {
+		return false
+	}
+	if request.Method != "" {
+		if matched, _ := filepath.Match(request.Method,
+			record.Method); !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package auditlog
+
+import (
+	"github.com/Cloud-Foundations/Dominator/lib/errors"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/serverutil"
+	proto "github.com/Cloud-Foundations/Dominator/proto/auditlog"
+)
+
+const recordsPerResponse = 256
+
+type srpcType struct {
+	auditLog *AuditLog
+	*serverutil.PerUserMethodLimiter
+}
+
+func (al *AuditLog) registerServer() error {
+	return srpc.RegisterName("AuditLog", &srpcType{
+		auditLog: al,
+		PerUserMethodLimiter: serverutil.NewPerUserMethodLimiter(
+			map[string]uint{
+				"GetAuditLog": 1,
+			}),
+	})
+}
+
+func (t *srpcType) GetAuditLog(conn *srpc.Conn) error {
+	var request proto.GetAuditLogRequest
+	if err := conn.Decode(&request); err != nil {
+		return err
+	}
+	records := make([]proto.AuditRecord, 0, recordsPerResponse)
+	err := t.auditLog.GetRecords(request, func(record proto.AuditRecord) error {
+		records = append(records, record)
+		if len(records) < recordsPerResponse {
+			return nil
+		}
+		err := conn.Encode(proto.GetAuditLogResponse{Records: records})
+		records = records[:0]
+		return err
+	})
+	if err == nil && len(records) > 0 {
+		err = conn.Encode(proto.GetAuditLogResponse{Records: records})
+	}
+	return conn.Encode(proto.GetAuditLogResponse{
+		Error: errors.ErrorToString(err),
+	})
+}
@@ -24,6 +24,8 @@ func (conn *Conn) getAuthInformation() *AuthInformation {
 		GroupList:        conn.groupList,
 		HaveMethodAccess: conn.haveMethodAccess,
 		Username:         conn.username,
+		remoteAddress:    conn.remoteAddr,
+		serviceMethod:    conn.serviceMethod,
 	}
 }
 
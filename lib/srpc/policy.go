@@ -0,0 +1,98 @@
+package srpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+)
+
+var (
+	authorisationPolicy      AuthorisationPolicy
+	authorisationPolicyMutex sync.RWMutex
+)
+
+func getAuthorisationPolicy() AuthorisationPolicy {
+	authorisationPolicyMutex.RLock()
+	defer authorisationPolicyMutex.RUnlock()
+	return authorisationPolicy
+}
+
+func setAuthorisationPolicy(policy AuthorisationPolicy) {
+	authorisationPolicyMutex.Lock()
+	defer authorisationPolicyMutex.Unlock()
+	authorisationPolicy = policy
+}
+
+func (action PolicyAction) String() string {
+	switch action {
+	case PolicyActionNone:
+		return "none"
+	case PolicyActionGrant:
+		return "grant"
+	case PolicyActionDeny:
+		return "deny"
+	}
+	return "UNKNOWN"
+}
+
+func (authInfo *AuthInformation) checkPolicyForTarget(
+	targetTags tags.Tags) bool {
+	if authInfo == nil || authInfo.serviceMethod == "" {
+		return false
+	}
+	if targetTags == nil {
+		targetTags = make(tags.Tags)
+	}
+	decision := evaluatePolicy(PolicyRequest{
+		GroupList:     authInfo.GroupList,
+		RemoteAddress: authInfo.remoteAddress,
+		ServiceMethod: authInfo.serviceMethod,
+		TargetTags:    targetTags,
+		Time:          time.Now(),
+		Username:      authInfo.Username,
+	})
+	return decision.Action == PolicyActionGrant
+}
+
+// evaluatePolicy evaluates the registered policy (if any) and logs the
+// decision.
+func evaluatePolicy(request PolicyRequest) PolicyDecision {
+	policy := getAuthorisationPolicy()
+	if policy == nil {
+		return PolicyDecision{}
+	}
+	decision := policy.Evaluate(request)
+	username := request.Username
+	if username == "" {
+		username = "unauthenticated user"
+	}
+	switch decision.Action {
+	case PolicyActionGrant:
+		if request.TargetTags == nil {
+			logger.Debugf(0, "policy rule: \"%s\" granted %s to %s at %s\n",
+				decision.Rule, request.ServiceMethod, username,
+				request.RemoteAddress)
+		} else {
+			logger.Debugf(0,
+				"policy rule: \"%s\" granted %s target %v to %s at %s\n",
+				decision.Rule, request.ServiceMethod, request.TargetTags,
+				username, request.RemoteAddress)
+		}
+	case PolicyActionDeny:
+		logger.Printf("policy rule: \"%s\" denied %s to %s at %s\n",
+			decision.Rule, request.ServiceMethod, username,
+			request.RemoteAddress)
+	}
+	return decision
+}
+
+func (conn *Conn) evaluatePolicy() PolicyDecision {
+	return evaluatePolicy(PolicyRequest{
+		GroupList:     conn.groupList,
+		RemoteAddress: conn.remoteAddr,
+		ServiceMethod: conn.serviceMethod,
+		Time:          time.Now(),
+		Username:      conn.username,
+	})
+}
@@ -0,0 +1,100 @@
+/*
+Package policy implements a declarative authorisation policy for SRPC methods.
+
+Package policy implements the srpc.AuthorisationPolicy interface. A policy is
+a list of rules, read from a JSON file. Each rule grants (or denies) access to
+a list of methods (Service.Method glob patterns) if all of the conditions in
+the rule are met. The conditions are:
+
+	Users:      the caller is one of the listed users
+	Groups:     the caller is a member of one of the listed groups
+	Networks:   the caller is connecting from one of the listed networks (CIDR)
+	Days:       the day of the week is one of the listed days (Mon, Tue...)
+	Hours:      the time of day is within the range (such as 09:00-17:00)
+	TargetTags: the target (such as a VM) has tags which match the values
+	            (glob patterns)
+
+Empty conditions always match. Rules with TargetTags are only evaluated when a
+method handler checks access to a target (see
+srpc.AuthInformation.CheckPolicyForTarget), and cannot deny access. If any
+rule denies access, access is denied, otherwise the first rule which grants
+access is used.
+*/
+package policy
+
+import (
+	"flag"
+	"io"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+)
+
+var (
+	srpcAuthorisationPolicy = flag.String("srpcAuthorisationPolicy", "",
+		"URL or filename of SRPC authorisation policy. If empty, no policy is used")
+	srpcAuthorisationPolicyCheckInterval = flag.Duration(
+		"srpcAuthorisationPolicyCheckInterval", time.Minute,
+		"Interval between checks for SRPC authorisation policy changes")
+)
+
+type Config struct {
+	Rules []Rule
+}
+
+// Policy is an immutable, compiled policy.
+type Policy struct {
+	rules []*ruleType
+}
+
+type Rule struct {
+	Action     string            `json:",omitempty"` // Default: "grant".
+	Days       []string          `json:",omitempty"` // Empty: every day.
+	Groups     []string          `json:",omitempty"` // Empty: any.
+	Hours      string            `json:",omitempty"` // Empty: all day.
+	Methods    []string          // Service.Method glob patterns.
+	Name       string            `json:",omitempty"`
+	Networks   []string          `json:",omitempty"` // Empty: any.
+	TargetTags map[string]string `json:",omitempty"` // Glob patterns.
+	TimeZone   string            `json:",omitempty"` // Empty: local.
+	Users      []string          `json:",omitempty"` // Empty: any.
+}
+
+// Compile will check and compile a policy configuration.
+func Compile(config Config) (*Policy, error) {
+	return compile(config)
+}
+
+// Decode will decode and compile a JSON-encoded policy configuration.
+func Decode(reader io.Reader) (*Policy, error) {
+	return decode(reader)
+}
+
+// Load will load a policy from a JSON file.
+func Load(filename string) (*Policy, error) {
+	return load(filename)
+}
+
+// SetupFromFlags will load the policy specified by the command-line flags and
+// register it with the srpc package. The policy is watched for changes and
+// reloaded. If the -srpcAuthorisationPolicy flag is empty, nothing is done.
+// This should be called once by servers.
+// The following command-line flags are registered and used:
+//
+//	-srpcAuthorisationPolicy:              URL or filename of policy
+//	-srpcAuthorisationPolicyCheckInterval: interval between checks for changes
+func SetupFromFlags(logger log.DebugLogger) error {
+	return setupFromFlags(logger)
+}
+
+// Evaluate will evaluate the policy for a request. It implements the
+// srpc.AuthorisationPolicy interface.
+func (p *Policy) Evaluate(request srpc.PolicyRequest) srpc.PolicyDecision {
+	return p.evaluate(request)
+}
+
+// NumRules returns the number of rules in the policy.
+func (p *Policy) NumRules() uint {
+	return uint(len(p.rules))
+}
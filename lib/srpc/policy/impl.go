@@ -0,0 +1,280 @@
+package policy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+)
+
+var weekdays = map[string]time.Weekday{
+	"Fri": time.Friday,
+	"Mon": time.Monday,
+	"Sat": time.Saturday,
+	"Sun": time.Sunday,
+	"Thu": time.Thursday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+}
+
+type ruleType struct {
+	action      srpc.PolicyAction
+	days        map[time.Weekday]struct{}
+	endMinute   int // Exclusive.
+	groups      []string
+	haveHours   bool
+	location    *time.Location
+	methods     []string
+	name        string
+	networks    []*net.IPNet
+	startMinute int
+	targetTags  map[string]string
+	users       map[string]struct{}
+}
+
+func compile(config Config) (*Policy, error) {
+	policy := &Policy{}
+	for index, rule := range config.Rules {
+		compiledRule, err := compileRule(rule)
+		if err != nil {
+			if rule.Name == "" {
+				return nil, fmt.Errorf("rule[%d]: %s", index, err)
+			}
+			return nil, fmt.Errorf("rule: \"%s\": %s", rule.Name, err)
+		}
+		if compiledRule.name == "" {
+			compiledRule.name = fmt.Sprintf("rule[%d]", index)
+		}
+		policy.rules = append(policy.rules, compiledRule)
+	}
+	return policy, nil
+}
+
+func compileRule(rule Rule) (*ruleType, error) {
+	compiledRule := &ruleType{
+		groups:     rule.Groups,
+		location:   time.Local,
+		methods:    rule.Methods,
+		name:       rule.Name,
+		targetTags: rule.TargetTags,
+	}
+	switch rule.Action {
+	case "", "grant":
+		compiledRule.action = srpc.PolicyActionGrant
+	case "deny":
+		if len(rule.TargetTags) > 0 {
+			return nil, fmt.Errorf("deny rules cannot have TargetTags")
+		}
+		compiledRule.action = srpc.PolicyActionDeny
+	default:
+		return nil, fmt.Errorf("unknown action: %s", rule.Action)
+	}
+	if len(rule.Methods) < 1 {
+		return nil, fmt.Errorf("no Methods")
+	}
+	for _, pattern := range rule.Methods {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad method pattern: %s: %s", pattern, err)
+		}
+	}
+	for _, pattern := range rule.TargetTags {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad tag pattern: %s: %s", pattern, err)
+		}
+	}
+	if len(rule.Days) > 0 {
+		compiledRule.days = make(map[time.Weekday]struct{}, len(rule.Days))
+		for _, day := range rule.Days {
+			weekday, ok := weekdays[day]
+			if !ok {
+				return nil, fmt.Errorf("unknown day: %s", day)
+			}
+			compiledRule.days[weekday] = struct{}{}
+		}
+	}
+	if rule.Hours != "" {
+		start, end, ok := strings.Cut(rule.Hours, "-")
+		if !ok {
+			return nil, fmt.Errorf("bad Hours: %s", rule.Hours)
+		}
+		var err error
+		compiledRule.startMinute, err = parseTimeOfDay(start)
+		if err != nil {
+			return nil, err
+		}
+		compiledRule.endMinute, err = parseTimeOfDay(end)
+		if err != nil {
+			return nil, err
+		}
+		compiledRule.haveHours = true
+	}
+	for _, network := range rule.Networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, err
+		}
+		compiledRule.networks = append(compiledRule.networks, ipNet)
+	}
+	if rule.TimeZone != "" {
+		location, err := time.LoadLocation(rule.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		compiledRule.location = location
+	}
+	if len(rule.Users) > 0 {
+		compiledRule.users = make(map[string]struct{}, len(rule.Users))
+		for _, user := range rule.Users {
+			compiledRule.users[user] = struct{}{}
+		}
+	}
+	return compiledRule, nil
+}
+
+func decode(reader io.Reader) (*Policy, error) {
+	var config Config
+	if err := json.Read(reader, &config); err != nil {
+		return nil, err
+	}
+	return compile(config)
+}
+
+func load(filename string) (*Policy, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	policy, err := decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("error loading: %s: %s", filename, err)
+	}
+	return policy, nil
+}
+
+// parseTimeOfDay parses HH:MM and returns the number of minutes since
+// midnight.
+func parseTimeOfDay(value string) (int, error) {
+	timeOfDay, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("bad time of day: %s", value)
+	}
+	return timeOfDay.Hour()*60 + timeOfDay.Minute(), nil
+}
+
+func (p *Policy) evaluate(request srpc.PolicyRequest) srpc.PolicyDecision {
+	var decision srpc.PolicyDecision
+	for _, rule := range p.rules {
+		if !rule.match(request) {
+			continue
+		}
+		if rule.action == srpc.PolicyActionDeny {
+			return srpc.PolicyDecision{
+				Action: srpc.PolicyActionDeny,
+				Rule:   rule.name,
+			}
+		}
+		if decision.Action == srpc.PolicyActionNone {
+			decision = srpc.PolicyDecision{Action: rule.action, Rule: rule.name}
+		}
+	}
+	return decision
+}
+
+func (rule *ruleType) match(request srpc.PolicyRequest) bool {
+	if (len(rule.targetTags) > 0) != (request.TargetTags != nil) {
+		return false
+	}
+	if !rule.matchMethod(request.ServiceMethod) {
+		return false
+	}
+	if rule.users != nil {
+		if _, ok := rule.users[request.Username]; !ok {
+			return false
+		}
+	}
+	if len(rule.groups) > 0 && !rule.matchGroups(request.GroupList) {
+		return false
+	}
+	if len(rule.networks) > 0 && !rule.matchNetwork(request.RemoteAddress) {
+		return false
+	}
+	if !rule.matchTime(request.Time) {
+		return false
+	}
+	for key, pattern := range rule.targetTags {
+		value, ok := request.TargetTags[key]
+		if !ok {
+			return false
+		}
+		if matched, _ := path.Match(pattern, value); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule *ruleType) matchGroups(groupList map[string]struct{}) bool {
+	for _, group := range rule.groups {
+		if _, ok := groupList[group]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *ruleType) matchMethod(serviceMethod string) bool {
+	for _, pattern := range rule.methods {
+		if matched, _ := path.Match(pattern, serviceMethod); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *ruleType) matchNetwork(remoteAddress string) bool {
+	host, _, err := net.SplitHostPort(remoteAddress)
+	if err != nil {
+		host = remoteAddress
+	}
+	ipAddr := net.ParseIP(host)
+	if ipAddr == nil {
+		return false
+	}
+	for _, network := range rule.networks {
+		if network.Contains(ipAddr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *ruleType) matchTime(now time.Time) bool {
+	if rule.days == nil && !rule.haveHours {
+		return true
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.In(rule.location)
+	if rule.days != nil {
+		if _, ok := rule.days[now.Weekday()]; !ok {
+			return false
+		}
+	}
+	if !rule.haveHours {
+		return true
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if rule.startMinute <= rule.endMinute {
+		return minute >= rule.startMinute && minute < rule.endMinute
+	}
+	// The range wraps around midnight.
+	return minute >= rule.startMinute || minute < rule.endMinute
+}
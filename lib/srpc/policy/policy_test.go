@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/tags"
+)
+
+const testPolicy = `{
+	"Rules": [
+		{
+			"Name": "no guests",
+			"Action": "deny",
+			"Methods": ["Hypervisor.*"],
+			"Networks": ["192.168.0.0/16"]
+		},
+		{
+			"Name": "operators",
+			"Methods": ["Hypervisor.*"],
+			"Groups": ["operators"],
+			"Days": ["Mon", "Tue", "Wed", "Thu", "Fri"],
+			"Hours": "09:00-17:00",
+			"TimeZone": "UTC"
+		},
+		{
+			"Name": "night shift",
+			"Methods": ["Hypervisor.Get*"],
+			"Users": ["bob"],
+			"Hours": "22:00-06:00",
+			"TimeZone": "UTC"
+		},
+		{
+			"Name": "dev VMs",
+			"Methods": ["Hypervisor.DestroyVm"],
+			"Groups": ["dev-team"],
+			"TargetTags": {"Environment": "dev*"}
+		}
+	]
+}`
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		`{"Rules": [{"Methods": []}]}`,
+		`{"Rules": [{"Action": "allow", "Methods": ["*"]}]}`,
+		`{"Rules": [{"Action": "deny", "Methods": ["*"],
+			"TargetTags": {"a": "b"}}]}`,
+		`{"Rules": [{"Methods": ["*"], "Days": ["Monday"]}]}`,
+		`{"Rules": [{"Methods": ["*"], "Hours": "9-17"}]}`,
+		`{"Rules": [{"Methods": ["*"], "Networks": ["10.0.0.0"]}]}`,
+	}
+	for _, test := range tests {
+		if _, err := Decode(strings.NewReader(test)); err == nil {
+			t.Errorf("no error for: %s", test)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy, err := Decode(strings.NewReader(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	saturday := time.Date(2024, 1, 6, 10, 0, 0, 0, time.UTC)
+	night := time.Date(2024, 1, 6, 23, 0, 0, 0, time.UTC)
+	operators := map[string]struct{}{"operators": {}}
+	developers := map[string]struct{}{"dev-team": {}}
+	tests := []struct {
+		request srpc.PolicyRequest
+		action  srpc.PolicyAction
+		rule    string
+	}{
+		{srpc.PolicyRequest{GroupList: operators, RemoteAddress: "10.0.0.1:1",
+			ServiceMethod: "Hypervisor.StopVm", Time: monday},
+			srpc.PolicyActionGrant, "operators"},
+		{srpc.PolicyRequest{GroupList: operators, RemoteAddress: "10.0.0.1:1",
+			ServiceMethod: "Hypervisor.StopVm", Time: saturday},
+			srpc.PolicyActionNone, ""},
+		{srpc.PolicyRequest{GroupList: operators,
+			RemoteAddress: "192.168.1.1:1",
+			ServiceMethod: "Hypervisor.StopVm", Time: monday},
+			srpc.PolicyActionDeny, "no guests"},
+		{srpc.PolicyRequest{ServiceMethod: "Hypervisor.GetVmInfo",
+			Time: night, Username: "bob"},
+			srpc.PolicyActionGrant, "night shift"},
+		{srpc.PolicyRequest{ServiceMethod: "Hypervisor.GetVmInfo",
+			Time: monday, Username: "bob"},
+			srpc.PolicyActionNone, ""},
+		{srpc.PolicyRequest{GroupList: developers,
+			ServiceMethod: "Hypervisor.DestroyVm", Time: monday},
+			srpc.PolicyActionNone, ""},
+		{srpc.PolicyRequest{GroupList: developers,
+			ServiceMethod: "Hypervisor.DestroyVm",
+			TargetTags:    tags.Tags{"Environment": "development"},
+			Time:          monday},
+			srpc.PolicyActionGrant, "dev VMs"},
+		{srpc.PolicyRequest{GroupList: developers,
+			ServiceMethod: "Hypervisor.DestroyVm",
+			TargetTags:    tags.Tags{"Environment": "production"},
+			Time:          monday},
+			srpc.PolicyActionNone, ""},
+	}
+	for index, test := range tests {
+		decision := policy.Evaluate(test.request)
+		if decision.Action != test.action || decision.Rule != test.rule {
+			t.Errorf("test[%d]: expected: %s (%s), got: %s (%s)",
+				index, test.action, test.rule, decision.Action, decision.Rule)
+		}
+	}
+}
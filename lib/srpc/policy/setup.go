@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/configwatch"
+	"github.com/Cloud-Foundations/Dominator/lib/log"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+)
+
+const initialLoadTimeout = 10 * time.Second
+
+func setupFromFlags(logger log.DebugLogger) error {
+	if *srpcAuthorisationPolicy == "" {
+		return nil
+	}
+	policyChannel, err := configwatch.Watch(*srpcAuthorisationPolicy,
+		*srpcAuthorisationPolicyCheckInterval,
+		func(reader io.Reader) (interface{}, error) {
+			return decode(reader)
+		},
+		logger)
+	if err != nil {
+		return err
+	}
+	// Wait for the first policy, so that no calls are permitted which the
+	// policy would deny.
+	timer := time.NewTimer(initialLoadTimeout)
+	defer timer.Stop()
+	select {
+	case config := <-policyChannel:
+		setPolicy(config.(*Policy), logger)
+	case <-timer.C:
+		return errors.New("timed out loading SRPC authorisation policy: " +
+			*srpcAuthorisationPolicy)
+	}
+	go func() {
+		for config := range policyChannel {
+			setPolicy(config.(*Policy), logger)
+		}
+	}()
+	return nil
+}
+
+func setPolicy(policy *Policy, logger log.DebugLogger) {
+	srpc.SetAuthorisationPolicy(policy)
+	logger.Printf("Loaded SRPC authorisation policy with %d rules\n",
+		policy.NumRules())
+}
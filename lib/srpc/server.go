@@ -49,7 +49,9 @@ type builtinReceiver struct{} // NOTE: GrantMethod allows all access.
 
 type methodWrapper struct {
 	methodType                    int
+	mutating                      bool // If true, calls are audited.
 	public                        bool
+	serviceMethod                 string
 	fn                            reflect.Value
 	requestType                   reflect.Type
 	responseType                  reflect.Type
@@ -205,6 +207,10 @@ func _registerName(name string, rcvr interface{},
 		if _, ok := publicMethods[method.Name]; ok {
 			mVal.public = true
 		}
+		if name != "" {
+			mVal.mutating = isMutatingMethod(method.Name)
+			mVal.serviceMethod = name + "." + method.Name
+		}
 		dir, err := receiverMetricsDir.RegisterDirectory(method.Name)
 		if err != nil {
 			return err
@@ -603,9 +609,21 @@ func (conn *Conn) findMethod(serviceMethod string) (*methodWrapper, error) {
 	if !ok {
 		return nil, errors.New(serviceName + ": unknown method: " + methodName)
 	}
+	conn.serviceMethod = serviceMethod
+	decision := conn.evaluatePolicy()
+	if decision.Action == PolicyActionDeny {
+		conn.haveMethodAccess = false
+		method.numDeniedCalls++
+		method.audit(conn, time.Now(),
+			callInfo{methodError: ErrorAccessToMethodDenied})
+		return nil, ErrorAccessToMethodDenied
+	}
 	if conn.allowMethodPowers &&
 		conn.checkMethodAccess(serviceMethod) {
 		conn.haveMethodAccess = true
+	} else if conn.allowMethodPowers &&
+		decision.Action == PolicyActionGrant {
+		conn.haveMethodAccess = true
 	} else if conn.allowMethodPowers &&
 		receiver.grantMethod(serviceName, conn.GetAuthInformation()) {
 		conn.haveMethodAccess = true
@@ -613,11 +631,14 @@ func (conn *Conn) findMethod(serviceMethod string) (*methodWrapper, error) {
 		conn.haveMethodAccess = false
 		if !method.public {
 			method.numDeniedCalls++
+			method.audit(conn, time.Now(),
+				callInfo{methodError: ErrorAccessToMethodDenied})
 			return nil, ErrorAccessToMethodDenied
 		}
 	}
 	authInfo := conn.GetAuthInformation()
 	if rn, err := receiver.blockMethod(methodName, authInfo); err != nil {
+		method.audit(conn, time.Now(), callInfo{methodError: err})
 		return nil, err
 	} else {
 		conn.releaseNotifier = rn
@@ -698,17 +719,20 @@ func listPublicMethodsHttpHandler(w http.ResponseWriter, req *http.Request) {
 func (m *methodWrapper) call(conn *Conn, makeCoder coderMaker) error {
 	m.numPermittedCalls++
 	startTime := time.Now()
-	err := m._call(conn, makeCoder)
+	var info callInfo
+	err := m._call(conn, makeCoder, &info)
 	timeTaken := time.Since(startTime)
 	if err == nil {
 		m.successfulCallsDistribution.Add(timeTaken)
 	} else {
 		m.failedCallsDistribution.Add(timeTaken)
 	}
+	m.audit(conn, startTime, info)
 	return err
 }
 
-func (m *methodWrapper) _call(conn *Conn, makeCoder coderMaker) error {
+func (m *methodWrapper) _call(conn *Conn, makeCoder coderMaker,
+	info *callInfo) error {
 	serverMetricsMutex.Lock()
 	numRunningMethods++
 	serverMetricsMutex.Unlock()
@@ -733,7 +757,8 @@ func (m *methodWrapper) _call(conn *Conn, makeCoder coderMaker) error {
 		returnValues := m.fn.Call([]reflect.Value{connValue})
 		errInter := returnValues[0].Interface()
 		if errInter != nil {
-			return errInter.(error)
+			info.methodError = errInter.(error)
+			return info.methodError
 		}
 		return nil
 	case methodTypeCoder:
@@ -744,16 +769,21 @@ func (m *methodWrapper) _call(conn *Conn, makeCoder coderMaker) error {
 		})
 		errInter := returnValues[0].Interface()
 		if errInter != nil {
-			return errInter.(error)
+			info.methodError = errInter.(error)
+			return info.methodError
 		}
 		return nil
 	case methodTypeRequestReply:
 		request := reflect.New(m.requestType)
 		response := reflect.New(m.responseType)
 		if err := conn.Decode(request.Interface()); err != nil {
+			info.methodError = err
 			_, err = conn.WriteString(err.Error() + "\n")
 			return err
 		}
+		if m.mutating && auditLogger != nil {
+			info.requestSummary = summariseRequest(request.Elem())
+		}
 		startTime := time.Now()
 		returnValues := m.fn.Call([]reflect.Value{connValue, request.Elem(),
 			response})
@@ -762,6 +792,7 @@ func (m *methodWrapper) _call(conn *Conn, makeCoder coderMaker) error {
 		if errInter != nil {
 			m.failedRRCallsDistribution.Add(timeTaken)
 			err := errInter.(error)
+			info.methodError = err
 			_, err = conn.WriteString(err.Error() + "\n")
 			return err
 		}
@@ -2,6 +2,7 @@ package serverutil
 
 import (
 	"sync"
+	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 )
@@ -12,6 +13,40 @@ type PerUserMethodLimiter struct {
 	perUserMethodLimits map[string]uint
 }
 
+// RateLimit specifies a token-bucket rate limit and a daily quota for a
+// method. The cost of each call is 1, unless the limit is Weighted, in which
+// case the method handler charges the cost (such as the number of bytes) with
+// RateLimiter.Charge. A zero value means no limit.
+type RateLimit struct {
+	Burst      float64 `json:",omitempty"` // Default: 1 second at Rate.
+	DailyQuota float64 `json:",omitempty"` // 0: no quota.
+	Rate       float64 `json:",omitempty"` // Cost per second. 0: no limit.
+	Weighted   bool    `json:",omitempty"`
+}
+
+// RateLimiter implements per-user, per-method rate limits and daily quotas.
+// It implements the srpc.MethodBlocker interface. A nil *RateLimiter does not
+// limit anything.
+type RateLimiter struct {
+	config     RateLimiterConfig
+	metrics    map[string]*rateLimitMetrics // Key: method.
+	now        func() time.Time
+	mutex      sync.Mutex // Protect everything below.
+	buckets    map[userMethodType]*bucketType
+	lastSweep  time.Time
+	numBuckets uint64
+}
+
+// RateLimiterConfig specifies the limits for methods. Limits for users take
+// precedence over limits for groups, which take precedence over the default
+// limits for methods. If a user is a member of multiple groups with limits
+// for a method, the most generous limits are used.
+type RateLimiterConfig struct {
+	Groups  map[string]map[string]RateLimit `json:",omitempty"` // Group,method.
+	Methods map[string]RateLimit            `json:",omitempty"` // Key: method.
+	Users   map[string]map[string]RateLimit `json:",omitempty"` // User,method.
+}
+
 type userMethodType struct {
 	method   string
 	username string
@@ -26,3 +61,40 @@ func (limiter *PerUserMethodLimiter) BlockMethod(methodName string,
 	authInfo *srpc.AuthInformation) (func(), error) {
 	return limiter.blockMethod(methodName, authInfo)
 }
+
+// LoadRateLimiterConfig will load a JSON-encoded RateLimiterConfig from a file.
+func LoadRateLimiterConfig(filename string) (RateLimiterConfig, error) {
+	return loadRateLimiterConfig(filename)
+}
+
+// NewRateLimiter will create a RateLimiter. Metrics are registered in the
+// srpc/rate-limiter/name tricorder directory.
+func NewRateLimiter(name string, config RateLimiterConfig) (
+	*RateLimiter, error) {
+	return newRateLimiter(name, config)
+}
+
+// BlockMethod will return an error if the user has exceeded the rate limit or
+// daily quota for the method. It implements the srpc.MethodBlocker interface.
+func (limiter *RateLimiter) BlockMethod(methodName string,
+	authInfo *srpc.AuthInformation) (func(), error) {
+	return limiter.blockMethod(methodName, authInfo)
+}
+
+// Charge will charge the cost of a call to a method with a Weighted limit. If
+// the limit is not Weighted, nothing is charged, since BlockMethod has already
+// charged for the call. If the user has exceeded the rate limit or the cost
+// would exceed the daily quota, an error is returned and nothing is charged. The rate limit may be
+// exceeded by a single call, in which case later calls are blocked until the
+// bucket is refilled.
+func (limiter *RateLimiter) Charge(methodName string,
+	authInfo *srpc.AuthInformation, cost float64) error {
+	return limiter.charge(methodName, authInfo, cost)
+}
+
+// Refund will return the cost previously charged for a call to a method with
+// Charge, such as when the call subsequently failed.
+func (limiter *RateLimiter) Refund(methodName string,
+	authInfo *srpc.AuthInformation, cost float64) {
+	limiter.refund(methodName, authInfo, cost)
+}
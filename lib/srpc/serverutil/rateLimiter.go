@@ -0,0 +1,323 @@
+package serverutil
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/format"
+	"github.com/Cloud-Foundations/Dominator/lib/json"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder"
+	"github.com/Cloud-Foundations/tricorder/go/tricorder/units"
+)
+
+const sweepInterval = time.Minute
+
+type bucketType struct {
+	day       int // Year*1000 + day of year of quota usage.
+	limit     RateLimit
+	tokens    float64
+	updatedAt time.Time
+	used      float64 // Quota used on day.
+}
+
+type rateLimitMetrics struct {
+	numQuotaRejections uint64
+	numRateRejections  uint64
+	totalCost          float64
+}
+
+func computeDay(now time.Time) int {
+	return now.Year()*1000 + now.YearDay()
+}
+
+func loadRateLimiterConfig(filename string) (RateLimiterConfig, error) {
+	var config RateLimiterConfig
+	if err := json.ReadFromFile(filename, &config); err != nil {
+		return RateLimiterConfig{}, err
+	}
+	return config, nil
+}
+
+// mergeLimits returns the more generous of two limits.
+func mergeLimits(left, right RateLimit) RateLimit {
+	maxLimit := func(left, right float64) float64 {
+		if left <= 0 || right <= 0 {
+			return 0
+		}
+		if left > right {
+			return left
+		}
+		return right
+	}
+	return RateLimit{
+		Burst:      maxLimit(left.getBurst(), right.getBurst()),
+		DailyQuota: maxLimit(left.DailyQuota, right.DailyQuota),
+		Rate:       maxLimit(left.Rate, right.Rate),
+		Weighted:   left.Weighted || right.Weighted,
+	}
+}
+
+func newRateLimiter(name string, config RateLimiterConfig) (
+	*RateLimiter, error) {
+	limiter := &RateLimiter{
+		buckets: make(map[userMethodType]*bucketType),
+		config:  config,
+		metrics: make(map[string]*rateLimitMetrics),
+		now:     time.Now,
+	}
+	var methodLimitsList []map[string]RateLimit
+	methodLimitsList = append(methodLimitsList, config.Methods)
+	for _, methodLimits := range config.Groups {
+		methodLimitsList = append(methodLimitsList, methodLimits)
+	}
+	for _, methodLimits := range config.Users {
+		methodLimitsList = append(methodLimitsList, methodLimits)
+	}
+	for _, methodLimits := range methodLimitsList {
+		for method, limit := range methodLimits {
+			if limit.Burst < 0 || limit.DailyQuota < 0 || limit.Rate < 0 {
+				return nil, fmt.Errorf("negative limit for: %s", method)
+			}
+			limiter.metrics[method] = &rateLimitMetrics{}
+		}
+	}
+	dir, err := tricorder.RegisterDirectory("srpc/rate-limiter/" + name)
+	if err != nil {
+		return nil, err
+	}
+	err = dir.RegisterMetric("num-buckets", &limiter.numBuckets, units.None,
+		"number of user and method buckets")
+	if err != nil {
+		return nil, err
+	}
+	for method, metrics := range limiter.metrics {
+		methodDir, err := dir.RegisterDirectory(method)
+		if err != nil {
+			return nil, err
+		}
+		err = methodDir.RegisterMetric("num-quota-rejections",
+			&metrics.numQuotaRejections, units.None,
+			"number of calls rejected due to exhausted daily quota")
+		if err != nil {
+			return nil, err
+		}
+		err = methodDir.RegisterMetric("num-rate-rejections",
+			&metrics.numRateRejections, units.None,
+			"number of calls rejected due to rate limit")
+		if err != nil {
+			return nil, err
+		}
+		err = methodDir.RegisterMetric("total-cost", &metrics.totalCost,
+			units.None, "total cost charged")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return limiter, nil
+}
+
+func (limit RateLimit) getBurst() float64 {
+	if limit.Burst > 0 {
+		return limit.Burst
+	}
+	if limit.Weighted || limit.Rate > 1 {
+		return limit.Rate
+	}
+	return 1
+}
+
+func (limit RateLimit) isLimited() bool {
+	return limit.DailyQuota > 0 || limit.Rate > 0
+}
+
+func (limiter *RateLimiter) blockMethod(methodName string,
+	authInfo *srpc.AuthInformation) (func(), error) {
+	if limiter == nil {
+		return nil, nil
+	}
+	limit := limiter.getLimit(methodName, authInfo)
+	if !limit.isLimited() {
+		return nil, nil
+	}
+	var cost float64
+	if !limit.Weighted {
+		cost = 1
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return nil, limiter.take(methodName, authInfo.Username, limit, cost)
+}
+
+func (limiter *RateLimiter) charge(methodName string,
+	authInfo *srpc.AuthInformation, cost float64) error {
+	if limiter == nil || cost <= 0 {
+		return nil
+	}
+	limit := limiter.getLimit(methodName, authInfo)
+	if !limit.isLimited() || !limit.Weighted { // Unweighted: charged by call.
+		return nil
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return limiter.take(methodName, authInfo.Username, limit, cost)
+}
+
+// getBucket returns the refilled bucket for the user and method, creating it
+// if needed. This must be called with the lock held.
+func (limiter *RateLimiter) getBucket(key userMethodType, limit RateLimit,
+	now time.Time) *bucketType {
+	if now.Sub(limiter.lastSweep) >= sweepInterval {
+		limiter.sweep(now)
+	}
+	bucket := limiter.buckets[key]
+	if bucket == nil {
+		bucket = &bucketType{
+			day:       computeDay(now),
+			tokens:    limit.getBurst(),
+			updatedAt: now,
+		}
+		limiter.buckets[key] = bucket
+		limiter.numBuckets = uint64(len(limiter.buckets))
+	}
+	bucket.limit = limit
+	bucket.refill(now)
+	return bucket
+}
+
+func (limiter *RateLimiter) getLimit(methodName string,
+	authInfo *srpc.AuthInformation) RateLimit {
+	if limit, ok := limiter.config.Users[authInfo.Username][methodName]; ok {
+		return limit
+	}
+	var limit RateLimit
+	var found bool
+	for group := range authInfo.GroupList {
+		if groupLimit, ok := limiter.config.Groups[group][methodName]; ok {
+			if found {
+				limit = mergeLimits(limit, groupLimit)
+			} else {
+				limit = groupLimit
+				found = true
+			}
+		}
+	}
+	if found {
+		return limit
+	}
+	return limiter.config.Methods[methodName]
+}
+
+func (limiter *RateLimiter) refund(methodName string,
+	authInfo *srpc.AuthInformation, cost float64) {
+	if limiter == nil || cost <= 0 {
+		return
+	}
+	limit := limiter.getLimit(methodName, authInfo)
+	if !limit.isLimited() || !limit.Weighted {
+		return
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	bucket := limiter.getBucket(
+		userMethodType{method: methodName, username: authInfo.Username},
+		limit, limiter.now())
+	if limit.Rate > 0 {
+		bucket.tokens += cost
+		if burst := limit.getBurst(); bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+	}
+	bucket.used -= cost
+	if bucket.used < 0 {
+		bucket.used = 0
+	}
+	if metrics := limiter.metrics[methodName]; metrics != nil {
+		metrics.totalCost -= cost
+	}
+}
+
+// sweep removes buckets which are full and have no quota usage today. This
+// must be called with the lock held.
+func (limiter *RateLimiter) sweep(now time.Time) {
+	today := computeDay(now)
+	for key, bucket := range limiter.buckets {
+		bucket.refill(now)
+		if bucket.limit.Rate > 0 && bucket.tokens < bucket.limit.getBurst() {
+			continue
+		}
+		if bucket.limit.DailyQuota > 0 && bucket.day == today &&
+			bucket.used > 0 {
+			continue
+		}
+		delete(limiter.buckets, key)
+	}
+	limiter.lastSweep = now
+	limiter.numBuckets = uint64(len(limiter.buckets))
+}
+
+// take will take cost from the bucket for the user and method, if permitted.
+// A cost of zero checks that the rate limit and quota are not exhausted. This
+// must be called with the lock held.
+func (limiter *RateLimiter) take(methodName, username string, limit RateLimit,
+	cost float64) error {
+	now := limiter.now()
+	bucket := limiter.getBucket(
+		userMethodType{method: methodName, username: username}, limit, now)
+	metrics := limiter.metrics[methodName]
+	if metrics == nil {
+		metrics = &rateLimitMetrics{} // Unregistered, should not happen.
+	}
+	if username == "" {
+		username = "unauthenticated user"
+	}
+	if limit.Rate > 0 {
+		needed := cost
+		if limit.Weighted {
+			// Permit a single call to overdraw the bucket.
+			needed = math.SmallestNonzeroFloat64
+		}
+		if bucket.tokens < needed {
+			metrics.numRateRejections++
+			retryIn := time.Duration((needed - bucket.tokens) / limit.Rate *
+				float64(time.Second))
+			if retryIn < time.Millisecond {
+				retryIn = time.Millisecond
+			}
+			return fmt.Errorf(
+				"%s exceeded rate limit of %g per second for %s, retry in %s",
+				username, limit.Rate, methodName, format.Duration(retryIn))
+		}
+	}
+	if limit.DailyQuota > 0 && (bucket.used >= limit.DailyQuota ||
+		bucket.used+cost > limit.DailyQuota) {
+		metrics.numQuotaRejections++
+		year, month, day := now.Date()
+		tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+		return fmt.Errorf(
+			"%s exhausted daily quota of %g for %s, resets in %s",
+			username, limit.DailyQuota, methodName,
+			format.Duration(tomorrow.Sub(now)))
+	}
+	if limit.Rate > 0 {
+		bucket.tokens -= cost
+	}
+	bucket.used += cost
+	metrics.totalCost += cost
+	return nil
+}
+
+func (bucket *bucketType) refill(now time.Time) {
+	if day := computeDay(now); day != bucket.day {
+		bucket.day = day
+		bucket.used = 0
+	}
+	if bucket.limit.Rate > 0 {
+		bucket.tokens += now.Sub(bucket.updatedAt).Seconds() * bucket.limit.Rate
+		if burst := bucket.limit.getBurst(); bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+	}
+	bucket.updatedAt = now
+}
@@ -0,0 +1,158 @@
+package serverutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter, err := NewRateLimiter("test", RateLimiterConfig{
+		Groups: map[string]map[string]RateLimit{
+			"builders": {"GetImage": {Rate: 10}},
+		},
+		Methods: map[string]RateLimit{
+			"AddImage": {DailyQuota: 1000, Rate: 100, Weighted: true},
+			"GetImage": {Rate: 1},
+		},
+		Users: map[string]map[string]RateLimit{
+			"replicator": {"GetImage": {}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	limiter.now = func() time.Time { return now }
+	alice := &srpc.AuthInformation{Username: "alice"}
+	builder := &srpc.AuthInformation{
+		GroupList: map[string]struct{}{"builders": {}},
+		Username:  "bob",
+	}
+	replicator := &srpc.AuthInformation{Username: "replicator"}
+	if _, err := limiter.BlockMethod("GetImage", alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.BlockMethod("GetImage", alice); err == nil {
+		t.Fatal("rate limit not enforced")
+	}
+	for count := 0; count < 10; count++ {
+		if _, err := limiter.BlockMethod("GetImage", builder); err != nil {
+			t.Fatalf("call: %d: %s", count, err)
+		}
+		if _, err := limiter.BlockMethod("GetImage", replicator); err != nil {
+			t.Fatalf("call: %d: %s", count, err)
+		}
+	}
+	now = now.Add(time.Second)
+	if _, err := limiter.BlockMethod("GetImage", alice); err != nil {
+		t.Fatal(err)
+	}
+	// Weighted limit: a single charge may overdraw the bucket.
+	if _, err := limiter.BlockMethod("AddImage", alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Charge("AddImage", alice, 300); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.BlockMethod("AddImage", alice); err == nil {
+		t.Fatal("weighted rate limit not enforced")
+	}
+	now = now.Add(3 * time.Second)
+	if err := limiter.Charge("AddImage", alice, 800); err == nil {
+		t.Fatal("daily quota not enforced")
+	}
+	if err := limiter.Charge("AddImage", alice, 500); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(24 * time.Hour)
+	if err := limiter.Charge("AddImage", alice, 800); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.metrics["AddImage"].numQuotaRejections != 1 {
+		t.Errorf("expected 1 quota rejection, got: %d",
+			limiter.metrics["AddImage"].numQuotaRejections)
+	}
+	var nilLimiter *RateLimiter
+	if _, err := nilLimiter.BlockMethod("GetImage", alice); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	limiter, err := NewRateLimiter("test-refund", RateLimiterConfig{
+		Methods: map[string]RateLimit{
+			"CreateVm": {DailyQuota: 10, Rate: 1, Weighted: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	limiter.now = func() time.Time { return now }
+	alice := &srpc.AuthInformation{Username: "alice"}
+	if err := limiter.Charge("CreateVm", alice, 8); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.BlockMethod("CreateVm", alice); err == nil {
+		t.Fatal("weighted rate limit not enforced")
+	}
+	limiter.Refund("CreateVm", alice, 8)
+	if _, err := limiter.BlockMethod("CreateVm", alice); err != nil {
+		t.Fatalf("refund not applied to rate limit: %s", err)
+	}
+	if err := limiter.Charge("CreateVm", alice, 10); err != nil {
+		t.Fatalf("refund not applied to daily quota: %s", err)
+	}
+	if total := limiter.metrics["CreateVm"].totalCost; total != 10 {
+		t.Errorf("expected total cost: 10, got: %g", total)
+	}
+	limiter.Refund("CreateVm", alice, 20)
+	bucket := limiter.buckets[userMethodType{"CreateVm", "alice"}]
+	if bucket.used != 0 || bucket.tokens != 1 {
+		t.Errorf("refund not bounded: used: %g, tokens: %g",
+			bucket.used, bucket.tokens)
+	}
+}
+
+func TestChargeUnweighted(t *testing.T) {
+	limiter, err := NewRateLimiter("test-unweighted", RateLimiterConfig{
+		Methods: map[string]RateLimit{
+			"AddImage": {DailyQuota: 2},
+			"CreateVm": {Rate: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	limiter.now = func() time.Time { return now }
+	alice := &srpc.AuthInformation{Username: "alice"}
+	// Each call costs 1, regardless of the cost passed to Charge.
+	for count := 0; count < 2; count++ {
+		if _, err := limiter.BlockMethod("AddImage", alice); err != nil {
+			t.Fatalf("call: %d: %s", count, err)
+		}
+		if err := limiter.Charge("AddImage", alice, 1e9); err != nil {
+			t.Fatalf("call: %d: %s", count, err)
+		}
+	}
+	if _, err := limiter.BlockMethod("AddImage", alice); err == nil {
+		t.Fatal("daily quota not enforced")
+	}
+	if _, err := limiter.BlockMethod("CreateVm", alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Charge("CreateVm", alice, 4); err != nil {
+		t.Fatal(err)
+	}
+	limiter.Refund("CreateVm", alice, 4)
+	if _, err := limiter.BlockMethod("CreateVm", alice); err == nil {
+		t.Fatal("rate limit not enforced after refund")
+	}
+	now = now.Add(time.Second)
+	if _, err := limiter.BlockMethod("CreateVm", alice); err != nil {
+		t.Fatal(err)
+	}
+}
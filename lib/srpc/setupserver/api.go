@@ -10,6 +10,10 @@
 	  -caFile:   Name of file containing the root of trust
 	  -certFile: Name of file containing the SSL certificate
 	  -keyFile:  Name of file containing the SSL key
+
+	Servers also have an audit log of mutating method calls set up (see the
+	lib/srpc/auditlog package) and an optional authorisation policy (see the
+	lib/srpc/policy package).
 */
 package setupserver
 
@@ -13,7 +13,10 @@ import (
 
 	"github.com/Cloud-Foundations/Dominator/lib/format"
 	"github.com/Cloud-Foundations/Dominator/lib/log/nulllogger"
+	_ "github.com/Cloud-Foundations/Dominator/lib/openmetrics" // Register /metrics.
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/auditlog"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc/policy"
 	"github.com/Cloud-Foundations/tricorder/go/tricorder"
 	"github.com/Cloud-Foundations/tricorder/go/tricorder/units"
 )
@@ -163,6 +166,15 @@ func setupTls(params Params) error {
 	if err != nil {
 		return err
 	}
+	if !params.ClientOnly {
+		if err := auditlog.SetupFromFlags(params.Logger); err != nil {
+			return fmt.Errorf("unable to setup audit log: %s", err)
+		}
+		if err := policy.SetupFromFlags(params.Logger); err != nil {
+			return fmt.Errorf("unable to setup authorisation policy: %s",
+				err)
+		}
+	}
 	go loadLoop(params, cert)
 	return nil
 }
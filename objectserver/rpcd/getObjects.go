@@ -6,6 +6,8 @@ import (
 	"io"
 	"sync"
 
+	"github.com/Cloud-Foundations/Dominator/lib/chunks"
+	"github.com/Cloud-Foundations/Dominator/lib/format"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/srpc"
 	"github.com/Cloud-Foundations/Dominator/proto/objectserver"
@@ -62,18 +64,36 @@ func (objSrv *srpcType) GetObjects(conn *srpc.Conn) error {
 		return conn.Encode(response)
 	}
 	defer objectsReader.Close()
+	var availableChunks map[hash.Hash]struct{}
+	if request.Chunked {
+		response.Chunked = true
+		availableChunks = make(map[hash.Hash]struct{},
+			len(request.AvailableChunks))
+		for _, hashVal := range request.AvailableChunks {
+			availableChunks[hashVal] = struct{}{}
+		}
+	}
 	if err := conn.Encode(response); err != nil {
 		return err
 	}
 	conn.Flush()
 	buffer := make([]byte, 32<<10)
+	var numReferencedBytes uint64
 	for _, hashVal := range request.Hashes {
 		length, reader, err := objectsReader.NextObject()
 		if err != nil {
 			objSrv.logger.Println(err)
 			return err
 		}
-		nCopied, err := io.CopyBuffer(conn, reader, buffer)
+		var nCopied int64
+		if request.Chunked {
+			var numReferenced uint64
+			nCopied, numReferenced, err = sendChunks(conn, reader, length,
+				availableChunks)
+			numReferencedBytes += numReferenced
+		} else {
+			nCopied, err = io.CopyBuffer(conn, reader, buffer)
+		}
 		reader.Close()
 		if err != nil {
 			objSrv.logger.Printf("Error copying: %s\n", err)
@@ -86,11 +106,74 @@ func (objSrv *srpcType) GetObjects(conn *srpc.Conn) error {
 			return errors.New(txt)
 		}
 	}
-	objSrv.logger.Debugf(0, "GetObjects() sent: %d objects\n",
-		len(request.Hashes))
+	if request.Chunked {
+		objSrv.logger.Debugf(0,
+			"GetObjects() sent: %d objects, %s in chunks already present\n",
+			len(request.Hashes), format.FormatBytes(numReferencedBytes))
+	} else {
+		objSrv.logger.Debugf(0, "GetObjects() sent: %d objects\n",
+			len(request.Hashes))
+	}
 	return nil
 }
 
+// sendChunks will send an object as a stream of chunks, sending references for
+// chunks which the receiver has. It returns the number of bytes in the object
+// and the number of bytes which were sent as references.
+func sendChunks(conn *srpc.Conn, reader io.Reader, length uint64,
+	availableChunks map[hash.Hash]struct{}) (int64, uint64, error) {
+	var nCopied int64
+	var numReferenced uint64
+	if length < 1 {
+		// A zero length chunk marks the end of the object, so an empty object
+		// is just the end marker.
+		return 0, 0, conn.Encode(objectserver.ObjectChunk{})
+	}
+	if length < chunks.MinimumObjectSize || len(availableChunks) < 1 {
+		chunk := objectserver.ObjectChunk{Length: length}
+		if err := conn.Encode(chunk); err != nil {
+			return 0, 0, err
+		}
+		nCopied, err := io.Copy(conn, reader)
+		if err != nil {
+			return nCopied, 0, err
+		}
+		return nCopied, 0, conn.Encode(objectserver.ObjectChunk{})
+	}
+	chunker := chunks.NewChunker(reader)
+	for {
+		data, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nCopied, numReferenced, err
+		}
+		nCopied += int64(len(data))
+		hashVal := chunks.HashChunk(data)
+		if _, ok := availableChunks[hashVal]; ok {
+			chunk := objectserver.ObjectChunk{
+				Hash:      hashVal,
+				Length:    uint64(len(data)),
+				Reference: true,
+			}
+			if err := conn.Encode(chunk); err != nil {
+				return nCopied, numReferenced, err
+			}
+			numReferenced += uint64(len(data))
+			continue
+		}
+		chunk := objectserver.ObjectChunk{Length: uint64(len(data))}
+		if err := conn.Encode(chunk); err != nil {
+			return nCopied, numReferenced, err
+		}
+		if _, err := conn.Write(data); err != nil {
+			return nCopied, numReferenced, err
+		}
+	}
+	return nCopied, numReferenced, conn.Encode(objectserver.ObjectChunk{})
+}
+
 func releaseSemaphore(semaphore <-chan bool) {
 	<-semaphore
 }
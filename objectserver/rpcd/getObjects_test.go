@@ -0,0 +1,221 @@
+package rpcd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/chunks"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/log/testlogger"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/client"
+	"github.com/Cloud-Foundations/Dominator/lib/objectserver/memory"
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/objectserver"
+)
+
+type chunkSenderType struct {
+	availableChunks map[hash.Hash]struct{}
+	objects         [][]byte
+}
+
+type stashingObjectServer struct {
+	*memory.ObjectServer
+}
+
+func (objSrv *stashingObjectServer) CommitObject(hash.Hash) error {
+	return errors.New("not implemented")
+}
+
+func (objSrv *stashingObjectServer) DeleteStashedObject(hash.Hash) error {
+	return errors.New("not implemented")
+}
+
+func (objSrv *stashingObjectServer) StashOrVerifyObject(io.Reader, uint64,
+	*hash.Hash) (hash.Hash, []byte, error) {
+	return hash.Hash{}, nil, errors.New("not implemented")
+}
+
+func (t *chunkSenderType) SendChunks(conn *srpc.Conn) error {
+	for _, data := range t.objects {
+		_, _, err := sendChunks(conn, bytes.NewReader(data), uint64(len(data)),
+			t.availableChunks)
+		if err != nil {
+			return err
+		}
+	}
+	// Send a marker so that the receiver can check it is still in sync.
+	return conn.Encode(objectserver.ObjectChunk{Error: "done"})
+}
+
+func dialTestServer(t *testing.T) *srpc.Client {
+	listener, err := net.Listen("tcp", "localhost:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go http.Serve(listener, nil)
+	srpcClient, err := srpc.DialHTTP("tcp", listener.Addr().String(),
+		time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srpcClient.Close() })
+	return srpcClient
+}
+
+func TestSendChunksEmptyObjects(t *testing.T) {
+	largeObject := make([]byte, 4<<20)
+	rand.New(rand.NewSource(2)).Read(largeObject)
+	chunkSender := &chunkSenderType{
+		// A chunk which is not in any object, so that objects are chunked.
+		availableChunks: map[hash.Hash]struct{}{{1}: {}},
+		objects: [][]byte{
+			nil, []byte("small object"), nil, nil, largeObject, nil,
+		},
+	}
+	srpc.RegisterName("ChunkSender", chunkSender)
+	conn, err := dialTestServer(t).Call("ChunkSender.SendChunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for index, expected := range chunkSender.objects {
+		var data []byte
+		for {
+			var chunk objectserver.ObjectChunk
+			if err := conn.Decode(&chunk); err != nil {
+				t.Fatal(err)
+			}
+			if chunk.Error != "" {
+				t.Fatalf("object: %d: out of sync: %s", index, chunk.Error)
+			}
+			if chunk.Length < 1 {
+				break
+			}
+			if chunk.Reference {
+				t.Fatalf("object: %d: unexpected reference", index)
+			}
+			buffer := make([]byte, chunk.Length)
+			if _, err := io.ReadFull(conn, buffer); err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, buffer...)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("object: %d data mismatch", index)
+		}
+	}
+	var chunk objectserver.ObjectChunk
+	if err := conn.Decode(&chunk); err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Error != "done" {
+		t.Fatalf("out of sync after last object: %v", chunk)
+	}
+}
+
+func TestGetObjectsChunked(t *testing.T) {
+	objSrv := memory.NewObjectServer()
+	smallObject := []byte("small object")
+	largeObject := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(largeObject)
+	var hashes []hash.Hash
+	for _, data := range [][]byte{smallObject, largeObject} {
+		hashVal, _, err := objSrv.AddObject(bytes.NewReader(data),
+			uint64(len(data)), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hashVal)
+	}
+	srpc.RegisterName("ObjectServer", &srpcType{
+		objectServer: &stashingObjectServer{objSrv},
+		getSemaphore: make(chan bool, 1),
+		logger:       testlogger.New(t),
+	})
+	srpcClient := dialTestServer(t)
+	// Make a base file which differs from the large object in the middle.
+	baseData := append([]byte(nil), largeObject...)
+	copy(baseData[len(baseData)/2:], "modified")
+	baseFilename := filepath.Join(t.TempDir(), "base")
+	if err := os.WriteFile(baseFilename, baseData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	chunkIndex := chunks.NewIndex()
+	defer chunkIndex.Close()
+	if err := chunkIndex.AddFile(baseFilename); err != nil {
+		t.Fatal(err)
+	}
+	objClient := client.AttachObjectClient(srpcClient)
+	objClient.SetChunkIndex(chunkIndex)
+	objectsReader, err := objClient.GetObjects(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, expected := range [][]byte{smallObject, largeObject} {
+		length, reader, err := objectsReader.NextObject()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if length != uint64(len(expected)) {
+			t.Fatalf("object: %d length: %d, expected: %d",
+				index, length, len(expected))
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			t.Fatal(err)
+		}
+		reader.Close()
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("object: %d data mismatch", index)
+		}
+	}
+	reusedBytes := objectsReader.(*client.ObjectsReader).ReusedBytes()
+	if reusedBytes < uint64(len(largeObject))/2 {
+		t.Fatalf("only reused: %d bytes", reusedBytes)
+	}
+	objectsReader.Close()
+	// Change the base file. The large object cannot be made, but the reader
+	// should skip it and remain in sync.
+	rand.New(rand.NewSource(3)).Read(baseData)
+	if err := os.WriteFile(baseFilename, baseData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	objectsReader, err = objClient.GetObjects(
+		[]hash.Hash{hashes[1], hashes[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer objectsReader.Close()
+	_, reader, err := objectsReader.NextObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, reader); err == nil {
+		t.Fatal("changed base file not detected")
+	} else if !errors.Is(err, client.ErrLocalChunk) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, reader, err = objectsReader.NextObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, smallObject) {
+		t.Fatal("object after skipped object: data mismatch")
+	}
+}
@@ -0,0 +1,27 @@
+package auditlog
+
+import (
+	"time"
+)
+
+type AuditRecord struct {
+	Duration   time.Duration
+	Error      string `json:",omitempty"`
+	Method     string // Service.Method
+	RemoteAddr string
+	Request    string `json:",omitempty"` // Redacted summary of request.
+	StartTime  time.Time
+	Username   string `json:",omitempty"` // Empty if unauthenticated.
+}
+
+type GetAuditLogRequest struct {
+	MaxRecords uint      // Zero: no limit. Most recent records are sent.
+	Method     string    // Empty: match all methods. Glob patterns permitted.
+	Since      time.Time // Zero: match all times.
+	Username   string    // Empty: match all users.
+}
+
+type GetAuditLogResponse struct { // Multiple responses are sent.
+	Error   string
+	Records []AuditRecord // Empty: this is the final response.
+}
@@ -5,9 +5,22 @@ import (
 
 	"github.com/Cloud-Foundations/Dominator/lib/mdb"
 	"github.com/Cloud-Foundations/Dominator/lib/tags"
+	"github.com/Cloud-Foundations/Dominator/lib/triggers"
 	"github.com/Cloud-Foundations/Dominator/proto/sub"
 )
 
+const (
+	RolloutStateRunning    = RolloutState(0)
+	RolloutStatePaused     = RolloutState(1)
+	RolloutStateCompleted  = RolloutState(2)
+	RolloutStateRolledBack = RolloutState(3)
+	RolloutStateAborted    = RolloutState(4)
+)
+
+type AbortDefaultImageRolloutRequest struct{}
+
+type AbortDefaultImageRolloutResponse struct{}
+
 type ClearSafetyShutoffRequest struct {
 	Hostname string
 }
@@ -18,6 +31,41 @@ type ConfigureSubsRequest sub.Configuration
 
 type ConfigureSubsResponse struct{}
 
+type DefaultImageRolloutPlan struct {
+	ImageName            string
+	MaximumFailures      uint // Pause/roll back if exceeded.
+	MinimumSyncedPercent uint // Default: 100.
+	RollBackOnFailure    bool // If false, pause on failure.
+	Stages               []DefaultImageRolloutStage
+}
+
+// DefaultImageRolloutStage describes a cohort of subs which do not have a
+// RequiredImage. A sub is in the cohort if it matches the tags and falls within
+// the percentage. Cohorts are cumulative: each stage includes the subs from all
+// previous stages. After the last stage, the image becomes the default image.
+// A stage with no tags and a Percent of zero (or 100) selects all subs, so only
+// the last stage may do so.
+type DefaultImageRolloutStage struct {
+	Percent     uint           // Zero: 100 percent.
+	SoakTime    time.Duration  // Time to wait after the cohort is synced.
+	TagsToMatch tags.MatchTags // Empty: match all tags.
+}
+
+type DefaultImageRolloutStatus struct {
+	DefaultImageRolloutPlan
+	CurrentStage      uint
+	Message           string `json:",omitempty"`
+	NumFailed         uint
+	NumSubs           uint
+	NumSynced         uint
+	PreviousImageName string    `json:",omitempty"`
+	StageStartTime    time.Time `json:",omitempty"`
+	StageSyncedTime   time.Time `json:",omitempty"`
+	StartedBy         string    `json:",omitempty"`
+	StartTime         time.Time
+	State             RolloutState
+}
+
 type DisableUpdatesRequest struct {
 	Reason string
 }
@@ -54,6 +102,25 @@ type GetDefaultImageResponse struct {
 	ImageName string
 }
 
+type GetDefaultImageRolloutRequest struct{}
+
+type GetDefaultImageRolloutResponse struct {
+	Rollout *DefaultImageRolloutStatus // nil: no rollout.
+}
+
+// GetPlannedUpdateRequest is used to compute the update the Dominator would
+// send to a sub, without sending it. If ImageName is empty, the image the
+// Dominator would push (the RequiredImage or the default image) is used.
+type GetPlannedUpdateRequest struct {
+	Hostname  string
+	ImageName string
+}
+
+type GetPlannedUpdateResponse struct {
+	Error  string
+	Update *PlannedUpdate
+}
+
 type GetSubsConfigurationRequest struct{}
 
 type GetSubsConfigurationResponse sub.Configuration
@@ -82,12 +149,50 @@ type ListSubsResponse struct {
 	Hostnames []string
 }
 
+type PauseDefaultImageRolloutRequest struct {
+	Reason string
+}
+
+type PauseDefaultImageRolloutResponse struct{}
+
+type ResumeDefaultImageRolloutRequest struct{}
+
+type ResumeDefaultImageRolloutResponse struct{}
+
+type PlannedFileChange struct {
+	Name     string
+	Data     bool `json:",omitempty"` // Contents or type will be replaced.
+	Metadata bool `json:",omitempty"` // Mode, ownership or times will change.
+}
+
+// PlannedUpdate describes the changes an update would make to a sub.
+type PlannedUpdate struct {
+	Hostname                string
+	ImageName               string
+	LastSuccessfulImageName string              `json:",omitempty"`
+	Disruptive              bool                `json:",omitempty"`
+	Reboot                  bool                `json:",omitempty"`
+	FilesAdded              []string            `json:",omitempty"`
+	FilesChanged            []PlannedFileChange `json:",omitempty"`
+	FilesRemoved            []string            `json:",omitempty"`
+	MissingComputedFiles    []string            `json:",omitempty"`
+	TriggersToStop          []triggers.Trigger  `json:",omitempty"`
+	TriggersToStart         []triggers.Trigger  `json:",omitempty"`
+	UpdatesDisabled         bool                `json:",omitempty"`
+}
+
+type RolloutState uint
+
 type SetDefaultImageRequest struct {
 	ImageName string
 }
 
 type SetDefaultImageResponse struct{}
 
+type StartDefaultImageRolloutRequest DefaultImageRolloutPlan
+
+type StartDefaultImageRolloutResponse struct{}
+
 type SubInfo struct {
 	mdb.Machine
 	LastAddress         string              `json:",omitempty"`
@@ -0,0 +1,59 @@
+package dominator
+
+import (
+	"fmt"
+)
+
+const (
+	rolloutStateUnknown = "UNKNOWN RolloutState"
+)
+
+var (
+	rolloutStateToText = map[RolloutState]string{
+		RolloutStateRunning:    "running",
+		RolloutStatePaused:     "paused",
+		RolloutStateCompleted:  "completed",
+		RolloutStateRolledBack: "rolled back",
+		RolloutStateAborted:    "aborted",
+	}
+	textToRolloutState map[string]RolloutState
+)
+
+func init() {
+	textToRolloutState = make(map[string]RolloutState,
+		len(rolloutStateToText))
+	for state, text := range rolloutStateToText {
+		textToRolloutState[text] = state
+	}
+}
+
+// IsActive returns true if the rollout has not finished.
+func (state RolloutState) IsActive() bool {
+	return state == RolloutStateRunning || state == RolloutStatePaused
+}
+
+func (state RolloutState) MarshalText() (text []byte, err error) {
+	if text, ok := rolloutStateToText[state]; ok {
+		return []byte(text), nil
+	} else {
+		return nil, fmt.Errorf("invalid RolloutState: %d", state)
+	}
+}
+
+func (state RolloutState) String() string {
+	if text, ok := rolloutStateToText[state]; ok {
+		return text
+	} else {
+		return rolloutStateUnknown
+	}
+}
+
+func (state *RolloutState) UnmarshalText(text []byte) error {
+	txt := string(text)
+	if val, ok := textToRolloutState[txt]; ok {
+		*state = val
+		return nil
+	} else {
+		return fmt.Errorf("unknown RolloutState: %s", txt)
+	}
+}
@@ -1,6 +1,8 @@
 package filegenerator
 
 import (
+	"time"
+
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/mdb"
 )
@@ -33,9 +35,20 @@ type FileInfo struct {
 	Length   uint64
 }
 
+// GeneratorAlert describes a generator which is failing to generate valid data
+// for some machines. The last valid data (if any) are served for those
+// machines.
+type GeneratorAlert struct {
+	LastError          string
+	LastFailure        time.Time
+	NumFailingMachines uint
+	Pathname           string
+}
+
 type ListGeneratorsRequest struct{}
 
 type ListGeneratorsResponse struct {
+	Alerts    []GeneratorAlert `json:",omitempty"`
 	Pathnames []string
 }
 
@@ -2,9 +2,21 @@ package fleetmanager
 
 import (
 	"net"
+	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/power"
 	"github.com/Cloud-Foundations/Dominator/lib/tags"
 	proto "github.com/Cloud-Foundations/Dominator/proto/hypervisor"
+	"github.com/Cloud-Foundations/Dominator/proto/installer"
+)
+
+const (
+	// VMs with the same value for this tag are preferentially placed on the
+	// same Hypervisor.
+	AffinityGroupTag = "AffinityGroup"
+	// VMs with the same value for this tag are never placed on the same
+	// Hypervisor.
+	AntiAffinityGroupTag = "AntiAffinityGroup"
 )
 
 type ChangeMachineTagsRequest struct {
@@ -16,6 +28,28 @@ type ChangeMachineTagsResponse struct {
 	Error string
 }
 
+type DrainHypervisorRequest struct {
+	Hostname       string
+	MaxConcurrency uint // Zero means default (2).
+	MaxRetries     uint // Number of retries per VM.
+}
+
+type DrainHypervisorResponse struct {
+	Error string
+}
+
+type DrainStatus struct {
+	Error        string            `json:",omitempty"`
+	FailedVMs    map[string]string `json:",omitempty"` // IPaddr:error
+	Finished     bool              `json:",omitempty"`
+	FinishedTime time.Time         `json:",omitempty"`
+	Hostname     string
+	MigratedVMs  map[string]string `json:",omitempty"` // IPaddr:hostname
+	MigratingVMs []string          `json:",omitempty"` // IPaddr
+	NumVMs       uint
+	StartTime    time.Time
+}
+
 type GetHypervisorForVMRequest struct {
 	IpAddress net.IP
 }
@@ -46,6 +80,15 @@ type Hypervisor struct {
 	VMs []proto.VmInfo `json:",omitempty"`
 }
 
+type GetMachineHealthRequest struct {
+	Hostname string
+}
+
+type GetMachineHealthResponse struct {
+	Error  string
+	Health power.Health
+}
+
 type GetMachineInfoRequest struct {
 	Hostname               string
 	IgnoreMissingLocalTags bool
@@ -73,6 +116,7 @@ type Update struct {
 	ChangedVMs      map[string]*proto.VmInfo `json:",omitempty"` // Key: IPaddr
 	DeletedMachines []string                 `json:",omitempty"` // Hostname
 	DeletedVMs      []string                 `json:",omitempty"` // IPaddr
+	DrainStatuses   []*DrainStatus           `json:",omitempty"`
 	Error           string                   `json:",omitempty"`
 	VmToHypervisor  map[string]string        `json:",omitempty"` // IP:hostname
 }
@@ -123,12 +167,14 @@ type Machine struct {
 	Location                string       `json:",omitempty"`
 	MemoryInMiB             uint64       `json:",omitempty"`
 	NetworkEntry            `json:",omitempty"`
-	NumCPUs                 uint           `json:",omitempty"`
-	OwnerGroups             []string       `json:",omitempty"`
-	OwnerUsers              []string       `json:",omitempty"`
-	SecondaryNetworkEntries []NetworkEntry `json:",omitempty"`
-	Tags                    tags.Tags      `json:",omitempty"`
-	TotalVolumeBytes        uint64         `json:",omitempty"`
+	NumCPUs                 uint                     `json:",omitempty"`
+	OwnerGroups             []string                 `json:",omitempty"`
+	OwnerUsers              []string                 `json:",omitempty"`
+	PowerDriver             string                   `json:",omitempty"`
+	SecondaryNetworkEntries []NetworkEntry           `json:",omitempty"`
+	StorageLayout           *installer.StorageLayout `json:",omitempty"`
+	Tags                    tags.Tags                `json:",omitempty"`
+	TotalVolumeBytes        uint64                   `json:",omitempty"`
 }
 
 type MoveIpAddressesRequest struct {
@@ -148,6 +194,39 @@ type NetworkEntry struct {
 	VlanTrunk      bool         `json:",omitempty"`
 }
 
+type PlaceVmRequest struct {
+	ExcludeHypervisors    []string       `json:",omitempty"` // Hostnames.
+	HypervisorTagsToMatch tags.MatchTags `json:",omitempty"` // Empty: match all.
+	Location              string         `json:",omitempty"`
+	PackVMs               bool           `json:",omitempty"` // Prefer fullest.
+	SubnetId              string         `json:",omitempty"`
+	VmInfo                proto.VmInfo
+}
+
+type PlaceVmResponse struct {
+	Error             string `json:",omitempty"`
+	HypervisorAddress string // host:port
+}
+
+type PowerCycleMachineRequest struct {
+	BootToPXE bool // Boot from the network once.
+	Force     bool // Power cycle even if VM state is unknown.
+	Hostname  string
+}
+
+type PowerCycleMachineResponse struct {
+	Error string
+}
+
+type PowerOffMachineRequest struct {
+	Force    bool // Power off even if VM state is unknown.
+	Hostname string
+}
+
+type PowerOffMachineResponse struct {
+	Error string
+}
+
 type PowerOnMachineRequest struct {
 	Hostname string
 }
@@ -72,6 +72,9 @@ func (left *Machine) Equal(right *Machine) bool {
 	if !listsEqual(left.OwnerUsers, right.OwnerUsers) {
 		return false
 	}
+	if left.PowerDriver != right.PowerDriver {
+		return false
+	}
 	if len(left.SecondaryNetworkEntries) != len(right.SecondaryNetworkEntries) {
 		return false
 	}
@@ -80,6 +83,9 @@ func (left *Machine) Equal(right *Machine) bool {
 			return false
 		}
 	}
+	if !left.StorageLayout.Equal(right.StorageLayout) {
+		return false
+	}
 	if !left.Tags.Equal(right.Tags) {
 		return false
 	}
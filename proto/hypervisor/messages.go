@@ -27,6 +27,8 @@ const (
 	StateExporting     = 7
 	StateCrashed       = 8
 	StateDebugging     = 9
+	StateSuspending    = 10
+	StateSuspended     = 11
 
 	VolumeFormatRaw   = 0
 	VolumeFormatQCOW2 = 1
@@ -57,8 +59,9 @@ type AcknowledgeVmResponse struct {
 }
 
 type Address struct {
-	IpAddress  net.IP `json:",omitempty"`
-	MacAddress string
+	IpAddress   net.IP `json:",omitempty"`
+	Ipv6Address net.IP `json:",omitempty"`
+	MacAddress  string
 }
 
 type AddressList []Address
@@ -772,6 +775,15 @@ type SetDisabledStateResponse struct {
 	Error string
 }
 
+type ResumeVmRequest struct {
+	AccessToken []byte
+	IpAddress   net.IP
+}
+
+type ResumeVmResponse struct {
+	Error string
+}
+
 type SnapshotVmRequest struct {
 	IpAddress         net.IP
 	ForceIfNotStopped bool
@@ -818,6 +830,17 @@ type Subnet struct {
 	AllowedUsers      []string `json:",omitempty"`
 	FirstDynamicIP    net.IP   `json:",omitempty"`
 	LastDynamicIP     net.IP   `json:",omitempty"`
+	Ipv6Gateway       net.IP   `json:",omitempty"`
+	Ipv6Prefix        net.IP   `json:",omitempty"` // Must be a /64.
+}
+
+type SuspendVmRequest struct {
+	AccessToken []byte
+	IpAddress   net.IP
+}
+
+type SuspendVmResponse struct {
+	Error string
 }
 
 type TraceVmMetadataRequest struct {
@@ -43,6 +43,8 @@ var (
 		StateExporting:     "exporting",
 		StateCrashed:       "crashed",
 		StateDebugging:     "debugging",
+		StateSuspending:    "suspending",
+		StateSuspended:     "suspended",
 	}
 	textToState map[string]State
 
@@ -151,6 +153,9 @@ func (left *Address) Equal(right *Address) bool {
 	if !CompareIPs(left.IpAddress, right.IpAddress) {
 		return false
 	}
+	if !CompareIPs(left.Ipv6Address, right.Ipv6Address) {
+		return false
+	}
 	if left.MacAddress != right.MacAddress {
 		return false
 	}
@@ -365,6 +370,12 @@ func (left *Subnet) Equal(right *Subnet) bool {
 	if !CompareIPs(left.FirstDynamicIP, right.FirstDynamicIP) {
 		return false
 	}
+	if !CompareIPs(left.Ipv6Gateway, right.Ipv6Gateway) {
+		return false
+	}
+	if !CompareIPs(left.Ipv6Prefix, right.Ipv6Prefix) {
+		return false
+	}
 	return true
 }
 
@@ -1,6 +1,7 @@
 package hypervisor
 
 import (
+	"net"
 	"reflect"
 	"strings"
 	"testing"
@@ -71,6 +72,7 @@ func makeVmInfo(t *testing.T, nonZeroFill bool, invertIndex int,
 			case "SecondaryAddresses":
 				addresses := []Address{{
 					[]byte{1, 2, 3, 4},
+					net.ParseIP("2001:db8::1"),
 					"01:02:03",
 				}}
 				fieldValue.Set(reflect.ValueOf(addresses))
@@ -94,6 +96,7 @@ func makeVmInfo(t *testing.T, nonZeroFill bool, invertIndex int,
 			case "Address":
 				address := Address{
 					[]byte{1, 2, 3, 4},
+					net.ParseIP("2001:db8::1"),
 					"01:02:03",
 				}
 				fieldValue.Set(reflect.ValueOf(address))
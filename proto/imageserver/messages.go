@@ -6,6 +6,7 @@ import (
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/image"
+	"github.com/Cloud-Foundations/Dominator/lib/image/vulnerability"
 	"github.com/Cloud-Foundations/Dominator/lib/tags"
 )
 
@@ -112,6 +113,16 @@ type GetImageResponse struct {
 	Image *image.Image
 }
 
+type GetImageSbomRequest struct {
+	Format    string // "cyclonedx" (default) or "spdx".
+	ImageName string
+}
+
+type GetImageSbomResponse struct {
+	Error string
+	Sbom  []byte // JSON encoding.
+}
+
 const (
 	OperationAddImage      = 0
 	OperationDeleteImage   = 1
@@ -153,6 +164,17 @@ type ImageArchive struct {
 // The server sends a stream of image.Directory values with an empty string
 // for the Name field signifying the end of the list.
 
+type ListImageVulnerabilitiesRequest struct {
+	ImageName string
+}
+
+type ListImageVulnerabilitiesResponse struct {
+	Error           string
+	ImageExists     bool
+	NoDatabase      bool                    // No advisory database configured.
+	Vulnerabilities []vulnerability.Finding // Most severe first.
+}
+
 // The ListImages() RPC is fully streamed.
 // The client sends no information to the server.
 // The server sends a stream of strings (image names) with an empty string
@@ -3,19 +3,55 @@ package installer
 const (
 	FileSystemTypeExt4 = 0
 	FileSystemTypeVfat = 1
+	FileSystemTypeXfs  = 2
 )
 
 type FileSystemType uint
 
+type LogicalVolume struct {
+	FileSystemType FileSystemType `json:",omitempty"`
+	MountPoint     string         `json:",omitempty"`
+	Name           string
+	SizeBytes      uint64 `json:",omitempty"` // Zero: use remaining space.
+}
+
 type Partition struct {
 	FileSystemType   FileSystemType `json:",omitempty"`
 	MountPoint       string         `json:",omitempty"`
 	MinimumFreeBytes uint64         `json:",omitempty"`
 }
 
+// RaidArray describes a software RAID array built from whole drives. Drives
+// are indices into the list of selected drives, where 0 is the boot drive. If
+// MountPoint is empty, the array must be used by a VolumeGroup.
+type RaidArray struct {
+	Drives         []uint
+	FileSystemType FileSystemType `json:",omitempty"`
+	Level          uint           // Supported: 0, 1, 10.
+	MountPoint     string         `json:",omitempty"`
+	Name           string         // Device name under /dev/md.
+}
+
+// StorageLayout describes how to configure the storage for a machine. If
+// MirrorBootDrive is true, the first two selected drives are partitioned
+// identically and each partition is mirrored (RAID 1). Drives which are not
+// used for the boot drive, a RaidArray or a VolumeGroup are used for data
+// file-systems mounted under ExtraMountPointsBasename.
 type StorageLayout struct {
-	BootDriveLayout          []Partition `json:",omitempty"`
-	ExtraMountPointsBasename string      `json:",omitempty"`
-	Encrypt                  bool        `json:",omitempty"`
-	UseKexec                 bool        `json:",omitempty"`
+	BootDriveLayout          []Partition   `json:",omitempty"`
+	ExtraMountPointsBasename string        `json:",omitempty"`
+	Encrypt                  bool          `json:",omitempty"`
+	MirrorBootDrive          bool          `json:",omitempty"`
+	RaidArrays               []RaidArray   `json:",omitempty"`
+	UseKexec                 bool          `json:",omitempty"`
+	VolumeGroups             []VolumeGroup `json:",omitempty"`
+}
+
+// VolumeGroup describes an LVM volume group built from whole drives (indices
+// into the list of selected drives) and RAID arrays (by name).
+type VolumeGroup struct {
+	Drives         []uint `json:",omitempty"`
+	LogicalVolumes []LogicalVolume
+	Name           string
+	RaidArrays     []string `json:",omitempty"`
 }
@@ -12,6 +12,7 @@ var (
 	fileSystemTypeToText = map[FileSystemType]string{
 		FileSystemTypeExt4: "ext4",
 		FileSystemTypeVfat: "vfat",
+		FileSystemTypeXfs:  "xfs",
 	}
 	textToFileSystemType map[string]FileSystemType
 )
@@ -52,3 +53,104 @@ func (fileSystemType FileSystemType) String() string {
 func (fileSystemType *FileSystemType) UnmarshalText(text []byte) error {
 	return fileSystemType.Set(string(text))
 }
+
+func uintListsEqual(left, right []uint) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for index, leftValue := range left {
+		if leftValue != right[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func (left *RaidArray) Equal(right *RaidArray) bool {
+	if !uintListsEqual(left.Drives, right.Drives) {
+		return false
+	}
+	if left.FileSystemType != right.FileSystemType {
+		return false
+	}
+	if left.Level != right.Level {
+		return false
+	}
+	if left.MountPoint != right.MountPoint {
+		return false
+	}
+	if left.Name != right.Name {
+		return false
+	}
+	return true
+}
+
+// Equal returns true if the layouts are the same. Either may be nil.
+func (left *StorageLayout) Equal(right *StorageLayout) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	if len(left.BootDriveLayout) != len(right.BootDriveLayout) {
+		return false
+	}
+	for index, leftPartition := range left.BootDriveLayout {
+		if leftPartition != right.BootDriveLayout[index] {
+			return false
+		}
+	}
+	if left.ExtraMountPointsBasename != right.ExtraMountPointsBasename {
+		return false
+	}
+	if left.Encrypt != right.Encrypt {
+		return false
+	}
+	if left.MirrorBootDrive != right.MirrorBootDrive {
+		return false
+	}
+	if len(left.RaidArrays) != len(right.RaidArrays) {
+		return false
+	}
+	for index, leftArray := range left.RaidArrays {
+		if !leftArray.Equal(&right.RaidArrays[index]) {
+			return false
+		}
+	}
+	if left.UseKexec != right.UseKexec {
+		return false
+	}
+	if len(left.VolumeGroups) != len(right.VolumeGroups) {
+		return false
+	}
+	for index, leftGroup := range left.VolumeGroups {
+		if !leftGroup.Equal(&right.VolumeGroups[index]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (left *VolumeGroup) Equal(right *VolumeGroup) bool {
+	if !uintListsEqual(left.Drives, right.Drives) {
+		return false
+	}
+	if len(left.LogicalVolumes) != len(right.LogicalVolumes) {
+		return false
+	}
+	for index, leftVolume := range left.LogicalVolumes {
+		if leftVolume != right.LogicalVolumes[index] {
+			return false
+		}
+	}
+	if left.Name != right.Name {
+		return false
+	}
+	if len(left.RaidArrays) != len(right.RaidArrays) {
+		return false
+	}
+	for index, leftName := range left.RaidArrays {
+		if leftName != right.RaidArrays[index] {
+			return false
+		}
+	}
+	return true
+}
@@ -30,16 +30,31 @@ type CheckObjectsResponse struct {
 }
 
 // This is used in the special GetObjects streaming HTTP/RPC protocol.
+// If Chunked is true and the server supports chunked transfers, each object is
+// sent as a stream of ObjectChunk messages, each optionally followed by the
+// chunk data. Chunks listed in AvailableChunks are not sent, instead a
+// reference to the chunk is sent. Older servers ignore Chunked and send the
+// object data.
 type GetObjectsRequest struct {
-	Exclusive bool // For initial performance benchmarking only.
-	Hashes    []hash.Hash
+	AvailableChunks []hash.Hash // Chunks which the receiver already has.
+	Chunked         bool
+	Exclusive       bool // For initial performance benchmarking only.
+	Hashes          []hash.Hash
 }
 
 type GetObjectsResponse struct {
+	Chunked        bool // If true, objects are sent as streams of chunks.
 	ResponseString string
 	ObjectSizes    []uint64
 } // Object datas are streamed afterwards.
 
+type ObjectChunk struct {
+	Error     string
+	Hash      hash.Hash // Only sent if Reference is true.
+	Length    uint64    // Length == 0: end of object.
+	Reference bool      // If false, chunk data are streamed afterwards.
+}
+
 type TestBandwidthRequest struct {
 	Duration     time.Duration // Ignored when sending to server.
 	ChunkSize    uint          // Maximum permitted: 65535.
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/filter"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
 	"github.com/Cloud-Foundations/Dominator/lib/objectcache"
 	"github.com/Cloud-Foundations/Dominator/lib/triggers"
@@ -21,6 +22,14 @@ const (
 
 	ErrorDisruptionPending = "disruption pending"
 	ErrorDisruptionDenied  = "disruption denied"
+
+	DriftFlagAdded        = DriftFlags(1 << 0)
+	DriftFlagDeleted      = DriftFlags(1 << 1)
+	DriftFlagTypeChanged  = DriftFlags(1 << 2)
+	DriftFlagHashChanged  = DriftFlags(1 << 3)
+	DriftFlagModeChanged  = DriftFlags(1 << 4)
+	DriftFlagOwnerChanged = DriftFlags(1 << 5)
+	DriftFlagNewSetuid    = DriftFlags(1 << 6) // Setuid or setgid bit added.
 )
 
 type BoostCpuLimitRequest struct{}
@@ -41,7 +50,39 @@ type DisruptionRequest uint
 
 type DisruptionState uint
 
+// DriftChange describes how a file differs from the baseline which was
+// recorded after the last successful Update().
+type DriftChange struct {
+	Flags   DriftFlags
+	Name    string
+	OldGid  uint32
+	NewGid  uint32
+	OldHash hash.Hash
+	NewHash hash.Hash
+	OldMode filesystem.FileMode
+	NewMode filesystem.FileMode
+	OldUid  uint32
+	NewUid  uint32
+}
+
+type DriftFlags uint
+
+type DriftReport struct {
+	BaselineImageName string    // Image name of the last successful Update().
+	BaselineTime      time.Time // Time the baseline scan was recorded.
+	Changes           []DriftChange
+	Hostname          string
+	NumChanges        uint // May exceed len(Changes) if truncated.
+	ReportTime        time.Time
+	ScanCount         uint64 // Scan used to compute the changes.
+	UpdatesDisabled   bool
+}
+
+// If ChunkBases is not empty, the sub will index the chunks in those files
+// (pathnames are relative to the root of the sub file-system) and will fetch
+// only the chunks of objects which are not already present.
 type FetchRequest struct {
+	ChunkBases    []string
 	LockFor       time.Duration // Duration to lock other clients from mutating.
 	ServerAddress string
 	SpeedPercent  byte
@@ -55,6 +96,21 @@ type FetchResponse struct {
 
 type GetConfigurationRequest struct{}
 
+// GetDriftReportRequest is used to request a report of the files which have
+// changed since the last successful Update(). If MaxChanges is non-zero, at
+// most MaxChanges changes are included in the report.
+type GetDriftReportRequest struct {
+	MaxChanges uint
+}
+
+// The report is gob-encoded so that the signature covers exactly the bytes
+// which were sent.
+type GetDriftReportResponse struct {
+	Certificate []byte // DER-encoded certificate of the signing key.
+	Report      []byte // gob-encoded DriftReport.
+	Signature   []byte // Signature of Report (SHA-256 for RSA and ECDSA).
+}
+
 type GetConfigurationResponse Configuration
 
 // The GetFiles() RPC is fully streamed.
@@ -108,6 +164,7 @@ type SetConfigurationResponse struct{}
 type UpdateRequest struct {
 	ForceDisruption bool
 	ImageName       string
+	ImageSignature  []byte // Signature of image. See lib/image/signature.
 	Wait            bool
 	// The ordering here reflects the ordering that the sub is expected to use.
 	FilesToCopyToCache  []FileToCopyToCache
@@ -118,6 +175,7 @@ type UpdateRequest struct {
 	InodesToChange      []Inode
 	MultiplyUsedObjects map[hash.Hash]uint64
 	Triggers            *triggers.Triggers
+	ImageFilter         *filter.Filter // Used when reporting drift.
 }
 
 type UpdateResponse struct{}
@@ -3,6 +3,7 @@ package sub
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -25,6 +26,16 @@ var (
 		DisruptionStateDenied:    "denied",
 	}
 	textToDisruptionState map[string]DisruptionState
+
+	driftFlagNames = []string{ // Indexed by bit number.
+		"added",
+		"deleted",
+		"type",
+		"hash",
+		"mode",
+		"owner",
+		"setuid",
+	}
 )
 
 func init() {
@@ -113,3 +124,13 @@ func (state *DisruptionState) UnmarshalText(text []byte) error {
 		return fmt.Errorf("unknown DisruptionState: %s", txt)
 	}
 }
+
+func (flags DriftFlags) String() string {
+	var names []string
+	for index, name := range driftFlagNames {
+		if flags&(1<<uint(index)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
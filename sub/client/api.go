@@ -1,6 +1,7 @@
 package client
 
 import (
+	"crypto/x509"
 	"io"
 
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
@@ -44,6 +45,17 @@ func GetConfiguration(client *srpc.Client) (sub.Configuration, error) {
 	return getConfiguration(client)
 }
 
+// GetDriftReport will get a report of the files on the sub which have changed
+// since the last successful Update(). The signature of the report is verified
+// and the certificate of the signer is verified using options. If
+// options.Roots is nil, the RootCAs of the client TLS configuration are used.
+// If options.DNSName is specified, the certificate must be for that host (the
+// sub which was dialled). The certificate of the signer is returned.
+func GetDriftReport(client *srpc.Client, request sub.GetDriftReportRequest,
+	options x509.VerifyOptions) (*sub.DriftReport, *x509.Certificate, error) {
+	return getDriftReport(client, request, options)
+}
+
 func GetFiles(client *srpc.Client, filenames []string,
 	readerFunc func(reader io.Reader, size uint64) error) error {
 	return getFiles(client, filenames, readerFunc)
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/gob"
+	"errors"
+	"fmt"
+
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/sub"
+)
+
+func getDriftReport(client *srpc.Client, request sub.GetDriftReportRequest,
+	options x509.VerifyOptions) (*sub.DriftReport, *x509.Certificate, error) {
+	var reply sub.GetDriftReportResponse
+	err := client.RequestReply("Subd.GetDriftReport", request, &reply)
+	if err != nil {
+		return nil, nil, err
+	}
+	if options.Roots == nil {
+		if tlsConfig := srpc.GetClientTlsConfig(); tlsConfig != nil {
+			options.Roots = tlsConfig.RootCAs
+		}
+	}
+	return verifyDriftReport(reply, options)
+}
+
+// verifyCertificate checks that the certificate is issued by one of the
+// trusted roots and that it belongs to the host in options.DNSName (if
+// specified). Certificates which only contain a Common Name are accepted.
+func verifyCertificate(cert *x509.Certificate,
+	options x509.VerifyOptions) error {
+	if options.Roots == nil {
+		return errors.New("no trusted CAs to verify drift report signer")
+	}
+	hostname := options.DNSName
+	options.DNSName = ""
+	if len(options.KeyUsages) < 1 {
+		options.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	if _, err := cert.Verify(options); err != nil {
+		return fmt.Errorf("untrusted drift report signer: %s: %s",
+			cert.Subject.CommonName, err)
+	}
+	if hostname == "" || cert.Subject.CommonName == hostname {
+		return nil
+	}
+	if err := cert.VerifyHostname(hostname); err != nil {
+		return fmt.Errorf("drift report signed by: %s, not by dialled sub: %s",
+			cert.Subject.CommonName, hostname)
+	}
+	return nil
+}
+
+func verifyDriftReport(response sub.GetDriftReportResponse,
+	options x509.VerifyOptions) (*sub.DriftReport, *x509.Certificate, error) {
+	if len(response.Signature) < 1 {
+		return nil, nil, errors.New("drift report is not signed")
+	}
+	cert, err := x509.ParseCertificate(response.Certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := verifyCertificate(cert, options); err != nil {
+		return nil, nil, err
+	}
+	var algorithm x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.ECDSA:
+		algorithm = x509.ECDSAWithSHA256
+	case x509.Ed25519:
+		algorithm = x509.PureEd25519
+	case x509.RSA:
+		algorithm = x509.SHA256WithRSA
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key algorithm: %s",
+			cert.PublicKeyAlgorithm)
+	}
+	err = cert.CheckSignature(algorithm, response.Report, response.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	var report sub.DriftReport
+	decoder := gob.NewDecoder(bytes.NewReader(response.Report))
+	if err := decoder.Decode(&report); err != nil {
+		return nil, nil, err
+	}
+	return &report, cert, nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/gob"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/proto/sub"
+)
+
+func makeTestCertificate(t *testing.T, commonName string,
+	parent *x509.Certificate, parentKey ed25519.PrivateKey) (
+	*x509.Certificate, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		NotAfter:     time.Now().Add(time.Hour),
+		NotBefore:    time.Now().Add(-time.Hour),
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+	}
+	if parent == nil {
+		template.BasicConstraintsValid = true
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent = template
+		parentKey = privateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent,
+		publicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, privateKey
+}
+
+func makeTestDriftResponse(t *testing.T, cert *x509.Certificate,
+	key ed25519.PrivateKey) sub.GetDriftReportResponse {
+	buffer := &bytes.Buffer{}
+	err := gob.NewEncoder(buffer).Encode(sub.DriftReport{Hostname: "sub0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sub.GetDriftReportResponse{
+		Certificate: cert.Raw,
+		Report:      buffer.Bytes(),
+		Signature:   ed25519.Sign(key, buffer.Bytes()),
+	}
+}
+
+func TestVerifyDriftReport(t *testing.T) {
+	caCert, caKey := makeTestCertificate(t, "CA", nil, nil)
+	subCert, subKey := makeTestCertificate(t, "sub0.example.com", caCert,
+		caKey)
+	selfCert, selfKey := makeTestCertificate(t, "sub0.example.com", nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	unsigned := makeTestDriftResponse(t, subCert, subKey)
+	unsigned.Signature = nil
+	tampered := makeTestDriftResponse(t, subCert, subKey)
+	tampered.Signature = makeTestDriftResponse(t, subCert, selfKey).Signature
+	tests := []struct {
+		name        string
+		response    sub.GetDriftReportResponse
+		options     x509.VerifyOptions
+		expectError bool
+	}{
+		{
+			name:     "trusted",
+			response: makeTestDriftResponse(t, subCert, subKey),
+			options: x509.VerifyOptions{
+				DNSName: "sub0.example.com",
+				Roots:   roots,
+			},
+		},
+		{
+			name:     "trusted without hostname",
+			response: makeTestDriftResponse(t, subCert, subKey),
+			options:  x509.VerifyOptions{Roots: roots},
+		},
+		{
+			name:        "no roots",
+			response:    makeTestDriftResponse(t, subCert, subKey),
+			expectError: true,
+		},
+		{
+			name:     "self-signed",
+			response: makeTestDriftResponse(t, selfCert, selfKey),
+			options: x509.VerifyOptions{
+				DNSName: "sub0.example.com",
+				Roots:   roots,
+			},
+			expectError: true,
+		},
+		{
+			name:     "other sub",
+			response: makeTestDriftResponse(t, subCert, subKey),
+			options: x509.VerifyOptions{
+				DNSName: "sub1.example.com",
+				Roots:   roots,
+			},
+			expectError: true,
+		},
+		{
+			name:        "unsigned",
+			response:    unsigned,
+			options:     x509.VerifyOptions{Roots: roots},
+			expectError: true,
+		},
+		{
+			name:        "bad signature",
+			response:    tampered,
+			options:     x509.VerifyOptions{Roots: roots},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		report, cert, err := verifyDriftReport(test.response, test.options)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if report.Hostname != "sub0" {
+			t.Errorf("%s: bad report hostname: %s", test.name, report.Hostname)
+		}
+		if !cert.Equal(subCert) {
+			t.Errorf("%s: wrong certificate returned", test.name)
+		}
+	}
+}
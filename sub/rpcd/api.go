@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/dsse"
 	"github.com/Cloud-Foundations/Dominator/lib/goroutine"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/rateio"
@@ -36,6 +37,7 @@ type Config struct {
 type Params struct {
 	DisableScannerFunction    func(disableScanner bool)
 	FileSystemHistory         *scanner.FileSystemHistory
+	ImageSignatureVerifier    *dsse.Verifier // nil: do not verify.
 	Logger                    log.DebugLogger
 	NetworkReaderContext      *rateio.ReaderContext
 	RescanObjectCacheFunction func()
@@ -50,6 +52,8 @@ type rpcType struct {
 	systemGoroutine *goroutine.Goroutine
 	*serverutil.PerUserMethodLimiter
 	disruptionManagerControl     chan<- bool // True: request; false: cancel.
+	driftLock                    sync.Mutex  // Protect drift.
+	drift                        driftStateType
 	ownerUsers                   map[string]struct{}
 	rwLock                       sync.RWMutex // Protect everything below.
 	disruptionState              proto.DisruptionState
@@ -77,6 +81,7 @@ type addObjectsHandlerType struct {
 }
 
 type HtmlWriter struct {
+	rpcObj                  *rpcType
 	lastNote                *string
 	lastSuccessfulImageName *string
 }
@@ -116,8 +121,12 @@ func Setup(config Config, params Params) *HtmlWriter {
 	} else if note != "" {
 		rpcObj.lastNote = note
 	}
+	tricorder.RegisterMetric("/drift/num-changes", rpcObj.getNumDriftChanges,
+		units.None, "number of files changed since the last update")
 	go rpcObj.startWriteProber()
+	go rpcObj.startDriftDetector()
 	return &HtmlWriter{
+		rpcObj:                  rpcObj,
 		lastNote:                &rpcObj.lastNote,
 		lastSuccessfulImageName: &rpcObj.lastSuccessfulImageName,
 	}
@@ -0,0 +1,292 @@
+package rpcd
+
+import (
+	"bufio"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/filesystem"
+	"github.com/Cloud-Foundations/Dominator/lib/filter"
+	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/lib/wsyscall"
+	"github.com/Cloud-Foundations/Dominator/proto/sub"
+)
+
+const (
+	driftBaselineFile  = "drift-baseline.gob"
+	driftCheckInterval = 10 * time.Second
+	setidBits          = wsyscall.S_ISUID | wsyscall.S_ISGID
+)
+
+type driftEntryType struct {
+	Gid     uint32
+	Hash    hash.Hash
+	Mode    filesystem.FileMode
+	Symlink string
+	Uid     uint32
+}
+
+type driftBaselineType struct {
+	Entries     map[string]driftEntryType
+	ImageFilter *filter.Filter
+	ImageName   string
+	Time        time.Time
+}
+
+type driftPendingBaselineType struct {
+	imageFilter  *filter.Filter
+	imageName    string
+	minScanCount uint64 // Baseline must be taken from a later scan.
+}
+
+type driftStateType struct {
+	baseline           *driftBaselineType
+	changes            []sub.DriftChange
+	comparedBaseline   *driftBaselineType
+	comparedGeneration uint64
+	comparedScanCount  uint64
+	pendingBaseline    *driftPendingBaselineType
+}
+
+// compareDrift returns the changes between the baseline and current entries,
+// sorted by name. Names for which ignore returns true are skipped.
+func compareDrift(baseline, current map[string]driftEntryType,
+	ignore func(name string) bool) []sub.DriftChange {
+	var changes []sub.DriftChange
+	for name, oldEntry := range baseline {
+		if ignore(name) {
+			continue
+		}
+		if newEntry, ok := current[name]; !ok {
+			changes = append(changes, sub.DriftChange{
+				Flags:   sub.DriftFlagDeleted,
+				Name:    name,
+				OldGid:  oldEntry.Gid,
+				OldHash: oldEntry.Hash,
+				OldMode: oldEntry.Mode,
+				OldUid:  oldEntry.Uid,
+			})
+		} else if flags := compareDriftEntries(oldEntry, newEntry); flags != 0 {
+			changes = append(changes, sub.DriftChange{
+				Flags:   flags,
+				Name:    name,
+				OldGid:  oldEntry.Gid,
+				NewGid:  newEntry.Gid,
+				OldHash: oldEntry.Hash,
+				NewHash: newEntry.Hash,
+				OldMode: oldEntry.Mode,
+				NewMode: newEntry.Mode,
+				OldUid:  oldEntry.Uid,
+				NewUid:  newEntry.Uid,
+			})
+		}
+	}
+	for name, newEntry := range current {
+		if _, ok := baseline[name]; ok || ignore(name) {
+			continue
+		}
+		flags := sub.DriftFlagAdded
+		if newEntry.Mode&setidBits != 0 {
+			flags |= sub.DriftFlagNewSetuid
+		}
+		changes = append(changes, sub.DriftChange{
+			Flags:   flags,
+			Name:    name,
+			NewGid:  newEntry.Gid,
+			NewHash: newEntry.Hash,
+			NewMode: newEntry.Mode,
+			NewUid:  newEntry.Uid,
+		})
+	}
+	sort.Slice(changes, func(left, right int) bool {
+		return changes[left].Name < changes[right].Name
+	})
+	return changes
+}
+
+func compareDriftEntries(oldEntry, newEntry driftEntryType) sub.DriftFlags {
+	var flags sub.DriftFlags
+	if oldEntry.Mode&wsyscall.S_IFMT != newEntry.Mode&wsyscall.S_IFMT {
+		return sub.DriftFlagTypeChanged
+	}
+	if oldEntry.Hash != newEntry.Hash || oldEntry.Symlink != newEntry.Symlink {
+		flags |= sub.DriftFlagHashChanged
+	}
+	if oldEntry.Mode != newEntry.Mode {
+		flags |= sub.DriftFlagModeChanged
+	}
+	if oldEntry.Uid != newEntry.Uid || oldEntry.Gid != newEntry.Gid {
+		flags |= sub.DriftFlagOwnerChanged
+	}
+	if newEntry.Mode&^oldEntry.Mode&setidBits != 0 {
+		flags |= sub.DriftFlagNewSetuid
+	}
+	return flags
+}
+
+// makeDriftEntries records the metadata for each file in the file-system.
+// Names matching imageFilter are skipped.
+func makeDriftEntries(fs *filesystem.FileSystem,
+	imageFilter *filter.Filter) map[string]driftEntryType {
+	entries := make(map[string]driftEntryType, len(fs.InodeTable))
+	fs.ForEachFile(func(name string, inodeNumber uint64,
+		genericInode filesystem.GenericInode) error {
+		if imageFilter != nil && imageFilter.Match(name) {
+			return nil
+		}
+		entry := driftEntryType{
+			Gid: genericInode.GetGid(),
+			Uid: genericInode.GetUid(),
+		}
+		switch inode := genericInode.(type) {
+		case *filesystem.DirectoryInode:
+			entry.Mode = inode.Mode
+		case *filesystem.RegularInode:
+			entry.Hash = inode.Hash
+			entry.Mode = inode.Mode
+		case *filesystem.SpecialInode:
+			entry.Mode = inode.Mode
+		case *filesystem.SymlinkInode:
+			entry.Mode = wsyscall.S_IFLNK
+			entry.Symlink = inode.Symlink
+		}
+		entries[name] = entry
+		return nil
+	})
+	return entries
+}
+
+func (t *rpcType) checkDrift() {
+	fsh := t.params.FileSystemHistory
+	scanCount := fsh.ScanCount()
+	generationCount := fsh.GenerationCount()
+	fs := fsh.FileSystem()
+	if fs == nil {
+		return
+	}
+	t.driftLock.Lock()
+	defer t.driftLock.Unlock()
+	state := &t.drift
+	if pending := state.pendingBaseline; pending != nil {
+		if scanCount <= pending.minScanCount {
+			return // Do not report changes made by the update.
+		}
+		state.pendingBaseline = nil
+		t.makeDriftBaseline(&fs.FileSystem.FileSystem, pending.imageName,
+			pending.imageFilter)
+	}
+	if state.baseline == nil {
+		t.params.Logger.Println(
+			"No drift baseline, recording current file-system")
+		t.rwLock.RLock()
+		imageName := t.lastSuccessfulImageName
+		t.rwLock.RUnlock()
+		t.makeDriftBaseline(&fs.FileSystem.FileSystem, imageName, nil)
+	}
+	if state.comparedBaseline == state.baseline &&
+		state.comparedGeneration == generationCount {
+		return
+	}
+	baseline := state.baseline
+	scanFilter := t.params.ScannerConfiguration.ScanFilter
+	changes := compareDrift(baseline.Entries,
+		makeDriftEntries(&fs.FileSystem.FileSystem, baseline.ImageFilter),
+		func(name string) bool {
+			// Files which are now excluded from scanning look deleted.
+			return scanFilter != nil && scanFilter.Match(name)
+		})
+	if len(changes) != len(state.changes) {
+		t.params.Logger.Printf("Drift from image: %s: %d changes\n",
+			baseline.ImageName, len(changes))
+	}
+	state.changes = changes
+	state.comparedBaseline = baseline
+	state.comparedGeneration = generationCount
+	state.comparedScanCount = scanCount
+}
+
+func (t *rpcType) loadDriftBaseline() {
+	if t.params.SubdDirectory == "" {
+		return
+	}
+	filename := filepath.Join(t.params.SubdDirectory, driftBaselineFile)
+	file, err := os.Open(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.params.Logger.Println(err)
+		}
+		return
+	}
+	defer file.Close()
+	var baseline driftBaselineType
+	decoder := gob.NewDecoder(bufio.NewReader(file))
+	if err := decoder.Decode(&baseline); err != nil {
+		t.params.Logger.Printf("Error decoding drift baseline: %s\n", err)
+		return
+	}
+	t.driftLock.Lock()
+	t.drift.baseline = &baseline
+	t.driftLock.Unlock()
+}
+
+// makeDriftBaseline records a new baseline. The driftLock must be held.
+func (t *rpcType) makeDriftBaseline(fs *filesystem.FileSystem,
+	imageName string, imageFilter *filter.Filter) {
+	baseline := &driftBaselineType{
+		Entries:     makeDriftEntries(fs, imageFilter),
+		ImageFilter: imageFilter,
+		ImageName:   imageName,
+		Time:        time.Now(),
+	}
+	t.drift.baseline = baseline
+	t.drift.changes = nil
+	t.params.Logger.Printf(
+		"Recorded drift baseline for image: %s (%d files)\n",
+		imageName, len(baseline.Entries))
+	if t.params.SubdDirectory == "" {
+		return
+	}
+	filename := filepath.Join(t.params.SubdDirectory, driftBaselineFile)
+	writer, err := fsutil.CreateRenamingWriter(filename,
+		fsutil.PrivateFilePerms)
+	if err != nil {
+		t.params.Logger.Println(err)
+		return
+	}
+	defer writer.Close()
+	bufferedWriter := bufio.NewWriter(writer)
+	if err := gob.NewEncoder(bufferedWriter).Encode(baseline); err != nil {
+		writer.Abort()
+		t.params.Logger.Printf("Error encoding drift baseline: %s\n", err)
+		return
+	}
+	if err := bufferedWriter.Flush(); err != nil {
+		writer.Abort()
+		t.params.Logger.Println(err)
+	}
+}
+
+// requestDriftBaseline is called after a successful Update() to record a new
+// baseline from a subsequent scan. A scan which was in progress when the update
+// completed may contain a mix of old and new files, so it is skipped.
+func (t *rpcType) requestDriftBaseline(imageName string,
+	imageFilter *filter.Filter) {
+	t.driftLock.Lock()
+	defer t.driftLock.Unlock()
+	t.drift.pendingBaseline = &driftPendingBaselineType{
+		imageFilter:  imageFilter,
+		imageName:    imageName,
+		minScanCount: t.params.FileSystemHistory.ScanCount() + 1,
+	}
+}
+
+func (t *rpcType) startDriftDetector() {
+	t.loadDriftBaseline()
+	for ; ; time.Sleep(driftCheckInterval) {
+		t.checkDrift()
+	}
+}
@@ -0,0 +1,49 @@
+package rpcd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cloud-Foundations/Dominator/lib/hash"
+	"github.com/Cloud-Foundations/Dominator/proto/sub"
+)
+
+func TestCompareDrift(t *testing.T) {
+	baseline := map[string]driftEntryType{
+		"/bin/ls":      {Mode: 0100755, Hash: hash.Hash{1}},
+		"/bin/su":      {Mode: 0100755, Hash: hash.Hash{2}},
+		"/etc/passwd":  {Mode: 0100644, Hash: hash.Hash{3}},
+		"/etc/shadow":  {Mode: 0100600, Hash: hash.Hash{4}},
+		"/var/log/old": {Mode: 0100644, Hash: hash.Hash{5}},
+	}
+	current := map[string]driftEntryType{
+		"/bin/ls":      {Mode: 0100755, Hash: hash.Hash{1}},
+		"/bin/su":      {Mode: 0104755, Hash: hash.Hash{2}},
+		"/etc/passwd":  {Mode: 0100644, Hash: hash.Hash{6}, Uid: 1},
+		"/tmp/evil":    {Mode: 0102755, Hash: hash.Hash{7}},
+		"/var/log/new": {Mode: 0100644, Hash: hash.Hash{8}},
+	}
+	changes := compareDrift(baseline, current, func(name string) bool {
+		return strings.HasPrefix(name, "/var/log/")
+	})
+	expected := []struct {
+		name  string
+		flags sub.DriftFlags
+	}{
+		{"/bin/su", sub.DriftFlagModeChanged | sub.DriftFlagNewSetuid},
+		{"/etc/passwd", sub.DriftFlagHashChanged | sub.DriftFlagOwnerChanged},
+		{"/etc/shadow", sub.DriftFlagDeleted},
+		{"/tmp/evil", sub.DriftFlagAdded | sub.DriftFlagNewSetuid},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got: %v", len(expected), changes)
+	}
+	for index, change := range changes {
+		if change.Name != expected[index].name ||
+			change.Flags != expected[index].flags {
+			t.Errorf("expected %s: %s, got %s: %s",
+				expected[index].name, expected[index].flags,
+				change.Name, change.Flags)
+		}
+	}
+}
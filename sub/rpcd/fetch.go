@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/chunks"
 	"github.com/Cloud-Foundations/Dominator/lib/format"
 	"github.com/Cloud-Foundations/Dominator/lib/fsutil"
 	"github.com/Cloud-Foundations/Dominator/lib/hash"
@@ -116,38 +117,40 @@ func (t *rpcType) doFetch(request sub.FetchRequest, username string) error {
 				speedPercent, username)
 		}
 	}
-	objectsReader, err := objectServer.GetObjects(request.Hashes)
-	if err != nil {
-		t.params.Logger.Printf("Error getting object reader: %s\n", err.Error())
-		return err
+	if len(request.ChunkBases) > 0 {
+		chunkIndex := t.makeChunkIndex(request.ChunkBases)
+		defer chunkIndex.Close()
+		objectServer.SetChunkIndex(chunkIndex)
 	}
-	defer objectsReader.Close()
-	var totalLength uint64
-	defer t.params.WorkdirGoroutine.Run(t.params.RescanObjectCacheFunction)
-	timeStart := time.Now()
-	for _, hash := range request.Hashes {
-		length, reader, err := objectsReader.NextObject()
-		if err != nil {
-			t.params.Logger.Println(err)
-			return err
+	newReader := func(reader io.Reader) io.Reader {
+		if speedPercent >= 100 {
+			return reader
 		}
-		r := io.Reader(reader)
-		if speedPercent < 100 {
-			if haveLinkSpeed {
-				if linkSpeed > 0 {
-					r = rateio.NewReaderContext(linkSpeed, speedPercent,
-						&rateio.ReadMeasurer{}).NewReader(reader)
-				}
-			} else if !benchmark {
-				r = t.params.NetworkReaderContext.NewReader(reader)
+		if haveLinkSpeed {
+			if linkSpeed > 0 {
+				return rateio.NewReaderContext(linkSpeed, speedPercent,
+					&rateio.ReadMeasurer{}).NewReader(reader)
 			}
+		} else if !benchmark {
+			return t.params.NetworkReaderContext.NewReader(reader)
 		}
-		t.params.WorkdirGoroutine.Run(func() {
-			err = readOne(t.config.ObjectsDirectoryName, hash, length, r)
-		})
-		reader.Close()
+		return reader
+	}
+	defer t.params.WorkdirGoroutine.Run(t.params.RescanObjectCacheFunction)
+	timeStart := time.Now()
+	totalLength, reusedBytes, retryHashes, err := t.fetchObjects(objectServer,
+		request.Hashes, newReader)
+	if err != nil {
+		return err
+	}
+	if len(retryHashes) > 0 {
+		t.params.Logger.Printf(
+			"Fetching %d objects again without local chunks\n",
+			len(retryHashes))
+		objectServer.SetChunkIndex(nil)
+		length, _, _, err := t.fetchObjects(objectServer, retryHashes,
+			newReader)
 		if err != nil {
-			t.params.Logger.Println(err)
 			return err
 		}
 		totalLength += length
@@ -162,12 +165,92 @@ func (t *rpcType) doFetch(request sub.FetchRequest, username string) error {
 		}
 		t.params.NetworkReaderContext.InitialiseMaximumSpeed(speed)
 	}
+	if reusedBytes > 0 {
+		t.params.Logger.Printf(
+			"Fetch() complete. Read: %s (%s from local chunks) in %s (%s/s)\n",
+			format.FormatBytes(totalLength), format.FormatBytes(reusedBytes),
+			format.Duration(duration), format.FormatBytes(speed))
+		return nil
+	}
 	t.params.Logger.Printf("Fetch() complete. Read: %s in %s (%s/s)\n",
 		format.FormatBytes(totalLength), format.Duration(duration),
 		format.FormatBytes(speed))
 	return nil
 }
 
+// fetchObjects will fetch the specified objects and write them to the object
+// cache. It returns the number of bytes fetched, the number of bytes read from
+// local chunks and the objects which could not be made because a local chunk
+// could not be read. Those should be fetched again without local chunks.
+func (t *rpcType) fetchObjects(objectServer *objectclient.ObjectClient,
+	hashes []hash.Hash, newReader func(io.Reader) io.Reader) (
+	uint64, uint64, []hash.Hash, error) {
+	objectsReader, err := objectServer.GetObjects(hashes)
+	if err != nil {
+		t.params.Logger.Printf("Error getting object reader: %s\n", err.Error())
+		return 0, 0, nil, err
+	}
+	defer objectsReader.Close()
+	var retryHashes []hash.Hash
+	var totalLength uint64
+	for _, hash := range hashes {
+		length, reader, err := objectsReader.NextObject()
+		if err != nil {
+			t.params.Logger.Println(err)
+			return 0, 0, nil, err
+		}
+		r := newReader(reader)
+		t.params.WorkdirGoroutine.Run(func() {
+			err = readOne(t.config.ObjectsDirectoryName, hash, length, r)
+		})
+		retry := errors.Is(err, objectclient.ErrLocalChunk)
+		if retry {
+			t.params.Logger.Debugf(0, "Error making: %x: %s\n", hash, err)
+			retryHashes = append(retryHashes, hash)
+			err = nil
+		}
+		if e := reader.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			t.params.Logger.Println(err)
+			return 0, 0, nil, err
+		}
+		if !retry {
+			totalLength += length
+		}
+	}
+	var reusedBytes uint64
+	if reader, ok := objectsReader.(*objectclient.ObjectsReader); ok {
+		reusedBytes = reader.ReusedBytes()
+	}
+	return totalLength, reusedBytes, retryHashes, nil
+}
+
+// makeChunkIndex will index the chunks in the specified files, which may share
+// content with the objects to be fetched. Files which cannot be read are
+// skipped. Files are read at the rate permitted for scanning.
+func (t *rpcType) makeChunkIndex(chunkBases []string) *chunks.Index {
+	rootDirectoryName := t.params.FileSystemHistory.FileSystem().
+		RootDirectoryName()
+	chunkIndex := chunks.NewIndex()
+	startTime := time.Now()
+	t.params.WorkdirGoroutine.Run(func() {
+		for _, pathname := range chunkBases {
+			filename := path.Join(rootDirectoryName, pathname)
+			err := chunkIndex.AddRateLimitedFile(filename,
+				t.params.ScannerConfiguration.FsScanContext)
+			if err != nil {
+				t.params.Logger.Debugf(0, "Error indexing chunks: %s\n", err)
+			}
+		}
+	})
+	t.params.Logger.Debugf(0, "Indexed %s of chunks in %d files in %s\n",
+		format.FormatBytes(chunkIndex.NumBytes()), len(chunkBases),
+		format.Duration(time.Since(startTime)))
+	return chunkIndex
+}
+
 func (t *rpcType) logFetch(request sub.FetchRequest, speed, speedPercent uint64,
 	username string) {
 	speedString := "unlimited speed"
@@ -0,0 +1,95 @@
+package rpcd
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/Cloud-Foundations/Dominator/lib/srpc"
+	"github.com/Cloud-Foundations/Dominator/proto/sub"
+)
+
+func (t *rpcType) GetDriftReport(conn *srpc.Conn,
+	request sub.GetDriftReportRequest,
+	reply *sub.GetDriftReportResponse) error {
+	report, err := t.getDriftReport(request.MaxChanges)
+	if err != nil {
+		return err
+	}
+	buffer := &bytes.Buffer{}
+	if err := gob.NewEncoder(buffer).Encode(report); err != nil {
+		return err
+	}
+	certificate, signature, err := signData(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	*reply = sub.GetDriftReportResponse{
+		Certificate: certificate,
+		Report:      buffer.Bytes(),
+		Signature:   signature,
+	}
+	return nil
+}
+
+func (t *rpcType) getDriftReport(maxChanges uint) (*sub.DriftReport, error) {
+	t.driftLock.Lock()
+	defer t.driftLock.Unlock()
+	if t.drift.comparedBaseline == nil {
+		return nil, errors.New("no drift baseline yet")
+	}
+	report := &sub.DriftReport{
+		BaselineImageName: t.drift.comparedBaseline.ImageName,
+		BaselineTime:      t.drift.comparedBaseline.Time,
+		Changes:           t.drift.changes,
+		NumChanges:        uint(len(t.drift.changes)),
+		ReportTime:        time.Now(),
+		ScanCount:         t.drift.comparedScanCount,
+		UpdatesDisabled:   *readOnly || *disableUpdates,
+	}
+	if maxChanges > 0 && uint(len(report.Changes)) > maxChanges {
+		report.Changes = report.Changes[:maxChanges]
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		report.Hostname = hostname
+	}
+	return report, nil
+}
+
+func (t *rpcType) getNumDriftChanges() uint {
+	t.driftLock.Lock()
+	defer t.driftLock.Unlock()
+	return uint(len(t.drift.changes))
+}
+
+// signData signs data using the key for the first client certificate. The
+// DER-encoded certificate and the signature are returned.
+func signData(data []byte) ([]byte, []byte, error) {
+	tlsConfig := srpc.GetClientTlsConfig()
+	if tlsConfig == nil || len(tlsConfig.Certificates) < 1 {
+		return nil, nil, errors.New("no certificate available for signing")
+	}
+	tlsCert := tlsConfig.Certificates[0]
+	signer, ok := tlsCert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("private key cannot sign")
+	}
+	var signature []byte
+	var err error
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		signature, err = signer.Sign(rand.Reader, data, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(data)
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return tlsCert.Certificate[0], signature, nil
+}
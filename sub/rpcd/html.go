@@ -12,4 +12,9 @@ func (hw *HtmlWriter) writeHtml(writer io.Writer) {
 		fmt.Fprintf(writer, "Note at last successful update: \"%s\"<br>\n",
 			*hw.lastNote)
 	}
+	if numChanges := hw.rpcObj.getNumDriftChanges(); numChanges > 0 {
+		fmt.Fprintf(writer,
+			"<font color=\"red\">Files changed since last update: %d</font><br>\n",
+			numChanges)
+	}
 }
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/Cloud-Foundations/Dominator/lib/image/signature"
 	jsonlib "github.com/Cloud-Foundations/Dominator/lib/json"
 	"github.com/Cloud-Foundations/Dominator/lib/log"
 	"github.com/Cloud-Foundations/Dominator/lib/osutil"
@@ -34,6 +36,10 @@ type flusher interface {
 
 func (t *rpcType) Update(conn *srpc.Conn, request sub.UpdateRequest,
 	reply *sub.UpdateResponse) error {
+	if err := t.checkImageSignature(request); err != nil {
+		t.params.Logger.Println(err)
+		return err
+	}
 	if err := t.getUpdateLock(conn); err != nil {
 		t.params.Logger.Println(err)
 		return err
@@ -47,6 +53,22 @@ func (t *rpcType) Update(conn *srpc.Conn, request sub.UpdateRequest,
 	return nil
 }
 
+// checkImageSignature will check that the image name, filter and triggers in
+// the request match the signature of the image, if signatures are required.
+// The file changes in the request are not covered by the signature.
+func (t *rpcType) checkImageSignature(request sub.UpdateRequest) error {
+	verifier := t.params.ImageSignatureVerifier
+	if verifier == nil {
+		return nil
+	}
+	_, err := signature.VerifyFilterAndTriggers(request.ImageSignature,
+		verifier, request.ImageName, request.ImageFilter, request.Triggers)
+	if err != nil {
+		return fmt.Errorf("Update(%s) rejected: %s", request.ImageName, err)
+	}
+	return nil
+}
+
 func (t *rpcType) getUpdateLock(conn *srpc.Conn) error {
 	if *readOnly || *disableUpdates {
 		return errors.New("Update() rejected due to read-only mode")
@@ -143,6 +165,7 @@ func (t *rpcType) updateAndUnlock(request sub.UpdateRequest,
 			t.lastNote = note
 		}
 		t.rwLock.Unlock()
+		t.requestDriftBaseline(request.ImageName, request.ImageFilter)
 	}
 	t.params.Logger.Printf("Update() completed in %s (change window: %s)\n",
 		timeTaken, fsChangeDuration)